@@ -0,0 +1,205 @@
+package cache
+
+import "container/list"
+
+type arcSegment int
+
+const (
+	arcT1 arcSegment = iota
+	arcT2
+	arcB1
+	arcB2
+)
+
+type arcEntry struct {
+	element *list.Element
+	segment arcSegment
+}
+
+// arcList implements the Adaptive Replacement Cache policy. T1 and T2 hold
+// resident keys seen once and at least twice, B1 and B2 remember keys recently
+// evicted from them. Hits in B1 grow the recency target p, hits in B2 shrink it.
+type arcList struct {
+	capacity int
+	target   int
+	segments [4]*list.List
+	entries  map[string]*arcEntry
+}
+
+func newARCList(capacity int) *arcList {
+	l := &arcList{
+		capacity: capacity,
+		entries:  make(map[string]*arcEntry),
+	}
+	for i := range l.segments {
+		l.segments[i] = list.New()
+	}
+
+	return l
+}
+
+func (l *arcList) add(key string) []string {
+	var evicted []string
+
+	entry, found := l.entries[key]
+	switch {
+	case found && (entry.segment == arcT1 || entry.segment == arcT2):
+		l.moveToFront(key, entry, arcT2)
+		return nil
+	case found && entry.segment == arcB1:
+		l.target = minInt(l.capacity, l.target+maxInt(l.size(arcB2)/l.size(arcB1), 1))
+		evicted = l.replace(false)
+		l.moveToFront(key, entry, arcT2)
+		return evicted
+	case found && entry.segment == arcB2:
+		l.target = maxInt(0, l.target-maxInt(l.size(arcB1)/l.size(arcB2), 1))
+		evicted = l.replace(true)
+		l.moveToFront(key, entry, arcT2)
+		return evicted
+	}
+
+	t1AndB1 := l.size(arcT1) + l.size(arcB1)
+	total := t1AndB1 + l.size(arcT2) + l.size(arcB2)
+	if t1AndB1 >= l.capacity {
+		if l.size(arcT1) < l.capacity {
+			l.dropOldest(arcB1)
+			evicted = l.replace(false)
+		} else {
+			oldestKey, _ := l.dropOldest(arcT1)
+			evicted = append(evicted, oldestKey)
+		}
+	} else if total >= l.capacity {
+		if total >= 2*l.capacity {
+			l.dropOldest(arcB2)
+		}
+		evicted = l.replace(false)
+	}
+
+	l.entries[key] = &arcEntry{element: l.segments[arcT1].PushFront(key), segment: arcT1}
+
+	return evicted
+}
+
+func (l *arcList) touch(key string) {
+	entry, found := l.entries[key]
+	if !found || (entry.segment != arcT1 && entry.segment != arcT2) {
+		return
+	}
+
+	l.moveToFront(key, entry, arcT2)
+}
+
+func (l *arcList) remove(key string) {
+	entry, found := l.entries[key]
+	if !found {
+		return
+	}
+
+	l.segments[entry.segment].Remove(entry.element)
+	delete(l.entries, key)
+}
+
+func (l *arcList) contains(key string) bool {
+	entry, found := l.entries[key]
+	return found && (entry.segment == arcT1 || entry.segment == arcT2)
+}
+
+func (l *arcList) victim() (string, bool) {
+	if l.len() == 0 {
+		return "", false
+	}
+
+	element := l.segments[l.demotionSegment(false)].Back()
+
+	return element.Value.(string), true
+}
+
+func (l *arcList) evict() (string, bool) {
+	if l.len() == 0 {
+		return "", false
+	}
+
+	evicted := l.demote(false)
+
+	return evicted, true
+}
+
+func (l *arcList) clear() {
+	*l = *newARCList(l.capacity)
+}
+
+func (l *arcList) len() int {
+	return l.size(arcT1) + l.size(arcT2)
+}
+
+func (l *arcList) replace(inB2 bool) []string {
+	if l.len() < l.capacity {
+		return nil
+	}
+
+	return []string{l.demote(inB2)}
+}
+
+func (l *arcList) demote(inB2 bool) string {
+	if l.demotionSegment(inB2) == arcT1 {
+		return l.moveOldest(arcT1, arcB1)
+	}
+
+	return l.moveOldest(arcT2, arcB2)
+}
+
+func (l *arcList) demotionSegment(inB2 bool) arcSegment {
+	t1Size := l.size(arcT1)
+	if t1Size > 0 && (t1Size > l.target || (inB2 && t1Size == l.target) || l.size(arcT2) == 0) {
+		return arcT1
+	}
+
+	return arcT2
+}
+
+func (l *arcList) moveOldest(from, to arcSegment) string {
+	element := l.segments[from].Back()
+	key := element.Value.(string)
+	l.moveToFront(key, l.entries[key], to)
+
+	return key
+}
+
+func (l *arcList) moveToFront(key string, entry *arcEntry, segment arcSegment) {
+	l.segments[entry.segment].Remove(entry.element)
+	entry.element = l.segments[segment].PushFront(key)
+	entry.segment = segment
+}
+
+func (l *arcList) dropOldest(segment arcSegment) (string, bool) {
+	element := l.segments[segment].Back()
+	if element == nil {
+		return "", false
+	}
+
+	key := element.Value.(string)
+	l.segments[segment].Remove(element)
+	delete(l.entries, key)
+
+	return key, true
+}
+
+func (l *arcList) size(segment arcSegment) int {
+	return l.segments[segment].Len()
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func Test_arcList_add(t *testing.T) {
+	arc := newARCList(3)
+	for _, key := range []string{"test1", "test2", "test3"} {
+		if evicted := arc.add(key); len(evicted) != 0 {
+			t.Errorf("add(%s) evicted %v before reaching capacity", key, evicted)
+		}
+	}
+	arc.touch("test1")
+
+	evicted := arc.add("test4")
+	if len(evicted) != 1 || evicted[0] != "test2" {
+		t.Errorf("add() evicted %v, want [test2]", evicted)
+	}
+	if arc.len() != 3 {
+		t.Errorf("len() = %d, want %d", arc.len(), 3)
+	}
+	if entry := arc.entries["test2"]; entry == nil || entry.segment != arcB1 {
+		t.Errorf("evicted key is expected to be remembered in B1")
+	}
+
+	arc.add("test2")
+	if arc.target == 0 {
+		t.Errorf("a hit in B1 is expected to grow the recency target")
+	}
+	if entry := arc.entries["test2"]; entry == nil || entry.segment != arcT2 {
+		t.Errorf("a key re-added from B1 is expected to move to T2")
+	}
+}
+
+func Test_arcList_scanResistance(t *testing.T) {
+	arc := newARCList(4)
+	for _, key := range []string{"hot1", "hot2"} {
+		arc.add(key)
+		arc.touch(key)
+	}
+
+	for i := 0; i < 100; i++ {
+		arc.add(fmt.Sprintf("scan%d", i))
+	}
+
+	for _, key := range []string{"hot1", "hot2"} {
+		if entry := arc.entries[key]; entry == nil || entry.segment != arcT2 {
+			t.Errorf("frequently used key %s is expected to survive a scan", key)
+		}
+	}
+	if arc.len() > 4 {
+		t.Errorf("len() = %d, exceeds capacity %d", arc.len(), 4)
+	}
+}
+
+func Test_arcList_remove(t *testing.T) {
+	arc := newARCList(2)
+	arc.add("test1")
+	arc.add("test2")
+	arc.remove("test1")
+
+	if arc.len() != 1 {
+		t.Errorf("len() = %d, want %d", arc.len(), 1)
+	}
+	if evicted := arc.add("test3"); len(evicted) != 0 {
+		t.Errorf("add() evicted %v while below capacity", evicted)
+	}
+
+	key, ok := arc.evict()
+	if !ok || key != "test2" {
+		t.Errorf("evict() = %s, want test2", key)
+	}
+}
+
+func TestWithEvictionPolicy_ARC(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithEvictionPolicy(ARC)(cache)
+	WithMaxEntries(2)(cache)
+
+	cache.Set("test1", 1, time.Second*10)
+	cache.Get("test1")
+	cache.Set("test2", 2, time.Second*10)
+	cache.Set("test3", 3, time.Second*10)
+
+	if _, ok := cache.Get("test1"); !ok {
+		t.Errorf("Get() expected frequently used key test1 to be cached")
+	}
+	if _, ok := cache.Get("test2"); ok {
+		t.Errorf("Get() expected key test2 to be evicted")
+	}
+}
@@ -0,0 +1,354 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrNotInteger = errors.New("cache: value is not an integer")
+	ErrOverflow   = errors.New("cache: integer overflow")
+)
+
+type Counter interface {
+	Increment(key string, delta int64, expiredInterval time.Duration) (int64, error)
+	Decrement(key string, delta int64, expiredInterval time.Duration) (int64, error)
+}
+
+// Increment atomically adds delta to the integer stored under key and returns
+// the result. A missing or expired key starts from zero and is stored with
+// expiredInterval; an existing counter keeps its expiration. Values of any
+// integer type are accepted and stored back as int64, as are the integral
+// float64 and json.Number values JSON codecs decode numbers into.
+// ErrNotInteger is returned, and the entry left unchanged, for other values,
+// and ErrOverflow for values outside the int64 range and results that would
+// wrap around.
+func (c *inMemoryCache) Increment(key string, delta int64, expiredInterval time.Duration) (int64, error) {
+	var result int64
+	err := c.update(key, func(existing *cacheItem) (*cacheItem, error) {
+		if existing == nil {
+			result = delta
+			return c.newCacheItem(result, expiredInterval), nil
+		}
+
+		current, err := toInt64(c.decoded(existing))
+		if err != nil {
+			return nil, err
+		}
+		if delta > 0 && current > math.MaxInt64-delta || delta < 0 && current < math.MinInt64-delta {
+			return nil, ErrOverflow
+		}
+		result = current + delta
+		updated := existing.clone()
+		updated.value = c.storedValue(result)
+		updated.size = c.sizeOf(updated.value)
+
+		return updated, nil
+	})
+
+	return result, err
+}
+
+// Decrement is Increment with -delta.
+func (c *inMemoryCache) Decrement(key string, delta int64, expiredInterval time.Duration) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, ErrOverflow
+	}
+
+	return c.Increment(key, -delta, expiredInterval)
+}
+
+type CompareAndSwapper interface {
+	CompareAndSwap(key string, old, new interface{}, expiredInterval time.Duration) bool
+}
+
+// CompareAndSwap stores new with expiredInterval if the live value under key
+// equals old and reports whether it did. Values are compared with ==, so a
+// value that is not comparable, including a struct holding a slice in an
+// interface field, never matches. With WithCodec, old is passed through the
+// codec first and compared with the decoded value, so that for example an int
+// matches the float64 a JSON codec decodes it into.
+func (c *inMemoryCache) CompareAndSwap(key string, old, new interface{}, expiredInterval time.Duration) bool {
+	old = c.normalized(old)
+	swapped := false
+	_ = c.update(key, func(existing *cacheItem) (*cacheItem, error) {
+		swapped = existing != nil && equalValues(c.decoded(existing), old)
+		if !swapped {
+			return nil, nil
+		}
+
+		return c.newCacheItem(new, expiredInterval), nil
+	})
+
+	return swapped
+}
+
+type ConditionalSetter interface {
+	SetIfAbsent(key string, value interface{}, expiredInterval time.Duration) bool
+	SetIfPresent(key string, value interface{}, expiredInterval time.Duration) bool
+}
+
+// SetIfAbsent stores value only if key has no live entry and reports whether
+// it did, like Redis SETNX.
+func (c *inMemoryCache) SetIfAbsent(key string, value interface{}, expiredInterval time.Duration) bool {
+	return c.setIf(key, value, expiredInterval, false)
+}
+
+// SetIfPresent replaces the value of a live entry and reports whether there
+// was one.
+func (c *inMemoryCache) SetIfPresent(key string, value interface{}, expiredInterval time.Duration) bool {
+	return c.setIf(key, value, expiredInterval, true)
+}
+
+func (c *inMemoryCache) setIf(key string, value interface{}, expiredInterval time.Duration, present bool) bool {
+	stored := false
+	_ = c.update(key, func(existing *cacheItem) (*cacheItem, error) {
+		stored = (existing != nil) == present
+		if !stored {
+			return nil, nil
+		}
+
+		return c.newCacheItem(value, expiredInterval), nil
+	})
+
+	return stored
+}
+
+type Swapper interface {
+	Swap(key string, value interface{}, expiredInterval time.Duration) (interface{}, bool)
+}
+
+// Swap stores value and returns the live value it replaced, with false if
+// there was none.
+func (c *inMemoryCache) Swap(key string, value interface{}, expiredInterval time.Duration) (interface{}, bool) {
+	var previous interface{}
+	existed := false
+	_ = c.update(key, func(existing *cacheItem) (*cacheItem, error) {
+		previous, existed = nil, existing != nil
+		if existed {
+			previous = c.decoded(existing)
+		}
+
+		return c.newCacheItem(value, expiredInterval), nil
+	})
+
+	return previous, existed
+}
+
+type GetAndDeleter interface {
+	GetAndDelete(key string) (interface{}, bool)
+}
+
+// GetAndDelete removes the live entry under key and returns its value. When
+// several callers race for the same entry, exactly one of them gets it.
+func (c *inMemoryCache) GetAndDelete(key string) (interface{}, bool) {
+	for {
+		item, found := c.storage.Load(key)
+		if !found || !item.isLive(c.now()) {
+			c.stats.misses.Add(1)
+			return nil, false
+		}
+
+		if c.deleteUnchanged(key, item, nil) {
+			c.stats.hits.Add(1)
+			return c.decoded(item), true
+		}
+	}
+}
+
+type Updater interface {
+	Update(key string, expiredInterval time.Duration, fn func(old interface{}, exists bool) (new interface{}, keep bool))
+}
+
+// Update replaces the value under key with the result of fn, which gets the
+// live value or false. The result is stored with expiredInterval if keep is
+// true; otherwise the entry is deleted. Calls to Update for the same key run
+// one at a time, but fn is called again if a concurrent Set or Delete changes
+// the entry before the result is stored. fn must not call Update itself.
+// Eviction callbacks run once the key is released, so they may update it.
+func (c *inMemoryCache) Update(key string, expiredInterval time.Duration, fn func(old interface{}, exists bool) (new interface{}, keep bool)) {
+	if err := c.validateKey(key); err != nil {
+		c.stats.rejected.Add(1)
+		return
+	}
+	var notices []evictionNotice
+	unlock, _ := c.updateLocks.lock(context.Background(), key)
+	defer func() {
+		unlock()
+		c.deliver(notices)
+	}()
+
+	for {
+		current, _ := c.storage.Load(key)
+		var existing *cacheItem
+		if current != nil && current.isLive(c.now()) {
+			existing = current
+		}
+
+		var old interface{}
+		if existing != nil {
+			old = c.valueOf(existing)
+		}
+		value, keep := fn(old, existing != nil)
+		if !keep {
+			if existing == nil || c.deleteUnchanged(key, existing, &notices) {
+				return
+			}
+			continue
+		}
+		if c.replaceUnchanged(key, current, c.newCacheItem(value, expiredInterval), &notices) {
+			c.stats.sets.Add(1)
+			return
+		}
+	}
+}
+
+// update replaces the entry under key with the item returned by fn, which is
+// given the live item or nil. fn returning a nil item or an error leaves the
+// entry unchanged. The result is stored only if the entry did not change in
+// the meantime; otherwise fn is called again, so it must not have side effects
+// beyond its result.
+func (c *inMemoryCache) update(key string, fn func(existing *cacheItem) (*cacheItem, error)) error {
+	if err := c.validateKey(key); err != nil {
+		c.stats.rejected.Add(1)
+		return err
+	}
+	for {
+		current, _ := c.storage.Load(key)
+		var existing *cacheItem
+		if current != nil && current.isLive(c.now()) {
+			existing = current
+		}
+		item, err := fn(existing)
+		if err != nil || item == nil {
+			return err
+		}
+		if c.replaceUnchanged(key, current, item, nil) {
+			c.stats.sets.Add(1)
+			return nil
+		}
+	}
+}
+
+// replaceUnchanged stores item under key if the storage still holds previous,
+// or nothing for a nil previous. Eviction notices go to notices as described
+// for notifyEvicted.
+func (c *inMemoryCache) replaceUnchanged(key string, previous, item *cacheItem, notices *[]evictionNotice) bool {
+	if c.itemError(item) != nil {
+		c.stats.rejected.Add(1)
+		return previous == nil || c.deleteUnchanged(key, previous, notices)
+	}
+	if c.evictor == nil {
+		return c.swapItem(key, previous, item, notices)
+	}
+
+	c.mu.Lock()
+	defer c.unlockInto(notices)
+
+	if current, _ := c.storage.Load(key); current != previous {
+		return false
+	}
+	c.setLocked(key, item, defaultItemCost)
+
+	return true
+}
+
+// swapItem stores item under key if the storage still holds previous, or
+// nothing for a nil previous, with the side effects of storeItem.
+func (c *inMemoryCache) swapItem(key string, previous, item *cacheItem, notices *[]evictionNotice) bool {
+	if previous == nil {
+		if _, loaded := c.storage.LoadOrStore(key, item); loaded {
+			return false
+		}
+		c.stats.entries.Add(1)
+	} else {
+		if !c.storage.CompareAndSwap(key, previous, item) {
+			return false
+		}
+		c.untag(key, previous)
+		if previous.isExpired(c.now()) {
+			c.notifyEvicted(key, previous, Expired, notices)
+		} else {
+			c.notifyEvicted(key, previous, Replaced, notices)
+		}
+	}
+	c.resize(key, previous, item)
+	c.scheduleExpiration(key, item)
+	c.publishEvent(EventSet, key, item)
+	c.journalSet(key, item)
+
+	return true
+}
+
+func equalValues(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	// The dynamic values decide: an interface field holding a slice makes a
+	// struct of a comparable type panic on ==.
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() || !va.Comparable() {
+		return false
+	}
+
+	return va.Equal(vb)
+}
+
+// toInt64 returns the integer held by value, or ErrNotInteger if it holds
+// none and ErrOverflow if it is outside the int64 range.
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint:
+		return uintToInt64(uint64(v))
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return uintToInt64(v)
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, ErrNotInteger
+		}
+		if v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, ErrOverflow
+		}
+		return int64(v), nil
+	case json.Number:
+		n, err := v.Int64()
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, ErrOverflow
+		}
+		if err != nil {
+			return 0, ErrNotInteger
+		}
+		return n, nil
+	default:
+		return 0, ErrNotInteger
+	}
+}
+
+func uintToInt64(v uint64) (int64, error) {
+	if v > math.MaxInt64 {
+		return 0, ErrOverflow
+	}
+
+	return int64(v), nil
+}
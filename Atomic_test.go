@@ -0,0 +1,557 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// atomicCaches covers the lock-free, sharded and evictor code paths.
+var atomicCaches = []struct {
+	name  string
+	cache func() *inMemoryCache
+}{
+	{
+		name: "In memory cache",
+		cache: func() *inMemoryCache {
+			return &inMemoryCache{}
+		},
+	},
+	{
+		name: "In memory cache with sharded storage",
+		cache: func() *inMemoryCache {
+			cache := &inMemoryCache{}
+			WithShardedStorage(4)(cache)
+			return cache
+		},
+	},
+	{
+		name: "In memory cache with eviction",
+		cache: func() *inMemoryCache {
+			cache := &inMemoryCache{}
+			WithMaxEntries(10)(cache)
+			return cache
+		},
+	},
+}
+
+func Test_inMemoryCache_Increment(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache()
+			clock := newFakeClock()
+			WithClock(clock)(cache)
+			cache.Set("int", 40, time.Minute)
+			cache.Set("string", "value", time.Minute)
+			cache.Set("expired", 40, time.Nanosecond)
+			clock.Advance(time.Millisecond)
+
+			tests := []struct {
+				name    string
+				key     string
+				delta   int64
+				want    int64
+				wantErr error
+			}{
+				{name: "Existing counter", key: "int", delta: 2, want: 42},
+				{name: "Missing counter", key: "missing", delta: 5, want: 5},
+				{name: "Expired counter", key: "expired", delta: 1, want: 1},
+				{name: "Not an integer", key: "string", delta: 1, wantErr: ErrNotInteger},
+			}
+			for _, tt := range tests {
+				got, err := cache.Increment(tt.key, tt.delta, time.Minute)
+				if !errors.Is(err, tt.wantErr) || got != tt.want {
+					t.Errorf("%s: Increment() = %v, %v, want %v, %v", tt.name, got, err, tt.want, tt.wantErr)
+				}
+			}
+			if got, err := cache.Decrement("int", 50, time.Minute); err != nil || got != -8 {
+				t.Errorf("Decrement() = %v, %v, want %v, <nil>", got, err, -8)
+			}
+			if value, _ := cache.Get("string"); value != "value" {
+				t.Errorf("Increment() changed a non-integer value to %v", value)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_Increment_JSONCodec(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithCodec(JSONCodec{})(cache)
+	cache.Set("float", 1.5, time.Minute)
+
+	for want := int64(2); want <= 6; want += 2 {
+		if got, err := cache.Increment("counter", 2, time.Minute); err != nil || got != want {
+			t.Fatalf("Increment() = %v, %v, want %v, <nil>", got, err, want)
+		}
+	}
+	if _, err := cache.Increment("float", 1, time.Minute); !errors.Is(err, ErrNotInteger) {
+		t.Errorf("Increment() of a fractional number error = %v, want %v", err, ErrNotInteger)
+	}
+}
+
+func Test_inMemoryCache_Increment_Overflow(t *testing.T) {
+	tests := []struct {
+		name    string
+		stored  interface{}
+		delta   int64
+		want    int64
+		wantErr error
+	}{
+		{name: "Up to the maximum", stored: int64(math.MaxInt64 - 1), delta: 1, want: math.MaxInt64},
+		{name: "Past the maximum", stored: int64(math.MaxInt64), delta: 1, wantErr: ErrOverflow},
+		{name: "Down to the minimum", stored: int64(math.MinInt64 + 1), delta: -1, want: math.MinInt64},
+		{name: "Past the minimum", stored: int64(math.MinInt64), delta: -1, wantErr: ErrOverflow},
+		{name: "Unsigned value in range", stored: uint64(math.MaxInt64), delta: -1, want: math.MaxInt64 - 1},
+		{name: "Unsigned value out of range", stored: uint64(math.MaxInt64 + 1), delta: 1, wantErr: ErrOverflow},
+		{name: "Float out of range", stored: float64(math.MaxInt64), delta: 1, wantErr: ErrOverflow},
+		{name: "JSON number out of range", stored: json.Number("9223372036854775808"), delta: 1, wantErr: ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			cache.Set("counter", tt.stored, time.Minute)
+
+			got, err := cache.Increment("counter", tt.delta, time.Minute)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("Increment() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+			if value, _ := cache.Get("counter"); err != nil && value != tt.stored {
+				t.Errorf("Increment() changed the value to %v on overflow", value)
+			}
+		})
+	}
+
+	cache := &inMemoryCache{}
+	if _, err := cache.Decrement("counter", math.MinInt64, time.Minute); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Decrement() by the minimum error = %v, want %v", err, ErrOverflow)
+	}
+}
+
+func Test_inMemoryCache_Increment_Concurrent(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache()
+
+			var wg sync.WaitGroup
+			for i := 0; i < 100; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					cache.Increment("test", 1, time.Minute)
+				}()
+			}
+			wg.Wait()
+
+			if value, _ := cache.Get("test"); value != int64(100) {
+				t.Errorf("Get() after concurrent increments = %v, want %v", value, 100)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_Increment_KeepsExpiration(t *testing.T) {
+	cache := &inMemoryCache{}
+	clock := newFakeClock()
+	WithClock(clock)(cache)
+	cache.Increment("test", 1, time.Millisecond*10)
+	cache.Increment("test", 1, time.Minute)
+
+	clock.Advance(time.Millisecond * 20)
+	if _, found := cache.Get("test"); found {
+		t.Errorf("Increment() extended the expiration of an existing counter")
+	}
+}
+
+// holder is comparable by type, but not when Value holds a slice.
+type holder struct {
+	Value interface{}
+}
+
+func Test_inMemoryCache_CompareAndSwap(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			tests := []struct {
+				name      string
+				key       string
+				old       interface{}
+				want      bool
+				wantValue interface{}
+			}{
+				{name: "Matching value", key: "int", old: 1, want: true, wantValue: 2},
+				{name: "Different value", key: "int", old: 3, wantValue: 1},
+				{name: "Different type", key: "int", old: int64(1), wantValue: 1},
+				{name: "Missing entry", key: "missing", old: nil},
+				{name: "Expired entry", key: "expired", old: 1},
+				{name: "Not comparable", key: "slice", old: []int{1}, wantValue: []int{1}},
+				{name: "Struct holding a slice", key: "struct", old: holder{Value: []int{1}}, wantValue: holder{Value: []int{1}}},
+			}
+			for _, tt := range tests {
+				cache := tc.cache()
+				clock := newFakeClock()
+				WithClock(clock)(cache)
+				cache.Set("int", 1, time.Minute)
+				cache.Set("slice", []int{1}, time.Minute)
+				cache.Set("struct", holder{Value: []int{1}}, time.Minute)
+				cache.Set("expired", 1, time.Nanosecond)
+				clock.Advance(time.Millisecond)
+
+				if got := cache.CompareAndSwap(tt.key, tt.old, 2, time.Minute); got != tt.want {
+					t.Errorf("%s: CompareAndSwap() = %v, want %v", tt.name, got, tt.want)
+				}
+				if value, _ := cache.Get(tt.key); !reflect.DeepEqual(value, tt.wantValue) {
+					t.Errorf("%s: Get() after CompareAndSwap() = %v, want %v", tt.name, value, tt.wantValue)
+				}
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_CompareAndSwap_JSONCodec(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithCodec(JSONCodec{})(cache)
+	cache.Set("test", 1, time.Minute)
+
+	if !cache.CompareAndSwap("test", 1, 2, time.Minute) {
+		t.Errorf("CompareAndSwap() did not match the value decoded as float64")
+	}
+	if value, _ := cache.Get("test"); value != float64(2) {
+		t.Errorf("Get() after CompareAndSwap() = %v, want %v", value, 2)
+	}
+}
+
+func Test_inMemoryCache_CompareAndSwap_Concurrent(t *testing.T) {
+	cache := &inMemoryCache{}
+	cache.Set("test", 0, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				value, _ := cache.Get("test")
+				if cache.CompareAndSwap("test", value, value.(int)+1, time.Minute) {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if value, _ := cache.Get("test"); value != 50 {
+		t.Errorf("Get() after concurrent swaps = %v, want %v", value, 50)
+	}
+}
+
+func Test_inMemoryCache_SetIfAbsent(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			tests := []struct {
+				name      string
+				key       string
+				want      bool
+				wantValue interface{}
+			}{
+				{name: "Missing entry", key: "missing", want: true, wantValue: 2},
+				{name: "Expired entry", key: "expired", want: true, wantValue: 2},
+				{name: "Live entry", key: "live", wantValue: 1},
+			}
+			for _, tt := range tests {
+				cache := tc.cache()
+				clock := newFakeClock()
+				WithClock(clock)(cache)
+				cache.Set("live", 1, time.Minute)
+				cache.Set("expired", 1, time.Nanosecond)
+				clock.Advance(time.Millisecond)
+
+				if got := cache.SetIfAbsent(tt.key, 2, time.Minute); got != tt.want {
+					t.Errorf("%s: SetIfAbsent() = %v, want %v", tt.name, got, tt.want)
+				}
+				if value, _ := cache.Get(tt.key); value != tt.wantValue {
+					t.Errorf("%s: Get() after SetIfAbsent() = %v, want %v", tt.name, value, tt.wantValue)
+				}
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_SetIfAbsent_Concurrent(t *testing.T) {
+	cache := &inMemoryCache{}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if cache.SetIfAbsent("lock", struct{}{}, time.Minute) {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if winners != 1 {
+		t.Errorf("SetIfAbsent() succeeded %d times, want once", winners)
+	}
+}
+
+func Test_inMemoryCache_SetIfPresent(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			tests := []struct {
+				name      string
+				key       string
+				want      bool
+				wantValue interface{}
+			}{
+				{name: "Live entry", key: "live", want: true, wantValue: 2},
+				{name: "Missing entry", key: "missing"},
+				{name: "Expired entry", key: "expired"},
+			}
+			for _, tt := range tests {
+				cache := tc.cache()
+				clock := newFakeClock()
+				WithClock(clock)(cache)
+				cache.Set("live", 1, time.Minute)
+				cache.Set("expired", 1, time.Nanosecond)
+				clock.Advance(time.Millisecond)
+
+				if got := cache.SetIfPresent(tt.key, 2, time.Minute); got != tt.want {
+					t.Errorf("%s: SetIfPresent() = %v, want %v", tt.name, got, tt.want)
+				}
+				if value, _ := cache.Get(tt.key); value != tt.wantValue {
+					t.Errorf("%s: Get() after SetIfPresent() = %v, want %v", tt.name, value, tt.wantValue)
+				}
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_GetAndDelete(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache()
+			clock := newFakeClock()
+			WithClock(clock)(cache)
+			cache.Set("live", 1, time.Minute)
+			cache.Set("expired", 1, time.Nanosecond)
+			clock.Advance(time.Millisecond)
+
+			tests := []struct {
+				name      string
+				key       string
+				wantValue interface{}
+				wantFound bool
+			}{
+				{name: "Live entry", key: "live", wantValue: 1, wantFound: true},
+				{name: "Already deleted entry", key: "live"},
+				{name: "Expired entry", key: "expired"},
+				{name: "Missing entry", key: "missing"},
+			}
+			for _, tt := range tests {
+				value, found := cache.GetAndDelete(tt.key)
+				if value != tt.wantValue || found != tt.wantFound {
+					t.Errorf("%s: GetAndDelete() = %v, %v, want %v, %v", tt.name, value, found, tt.wantValue, tt.wantFound)
+				}
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_GetAndDelete_Concurrent(t *testing.T) {
+	cache := &inMemoryCache{}
+	cache.Set("token", "secret", time.Minute)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	redeemed := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, found := cache.GetAndDelete("token"); found {
+				mu.Lock()
+				redeemed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if redeemed != 1 {
+		t.Errorf("GetAndDelete() returned the entry %d times, want once", redeemed)
+	}
+}
+
+func Test_inMemoryCache_Swap(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache()
+			clock := newFakeClock()
+			WithClock(clock)(cache)
+			cache.Set("expired", 1, time.Nanosecond)
+			clock.Advance(time.Millisecond)
+
+			tests := []struct {
+				name         string
+				key          string
+				value        interface{}
+				wantPrevious interface{}
+				wantExisted  bool
+			}{
+				{name: "Missing entry", key: "test", value: 1},
+				{name: "Live entry", key: "test", value: 2, wantPrevious: 1, wantExisted: true},
+				{name: "Expired entry", key: "expired", value: 2},
+			}
+			for _, tt := range tests {
+				previous, existed := cache.Swap(tt.key, tt.value, time.Minute)
+				if previous != tt.wantPrevious || existed != tt.wantExisted {
+					t.Errorf("%s: Swap() = %v, %v, want %v, %v", tt.name, previous, existed, tt.wantPrevious, tt.wantExisted)
+				}
+				if value, _ := cache.Get(tt.key); value != tt.value {
+					t.Errorf("%s: Get() after Swap() = %v, want %v", tt.name, value, tt.value)
+				}
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_Swap_Concurrent(t *testing.T) {
+	cache := &inMemoryCache{}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[interface{}]int)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if previous, existed := cache.Swap("test", i, time.Minute); existed {
+				mu.Lock()
+				seen[previous]++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	last, _ := cache.Get("test")
+	seen[last]++
+	if len(seen) != 50 {
+		t.Errorf("Swap() lost or duplicated values: %v", seen)
+	}
+}
+
+func Test_inMemoryCache_Update(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			tests := []struct {
+				name       string
+				key        string
+				keep       bool
+				wantOld    interface{}
+				wantExists bool
+				wantValue  interface{}
+				wantFound  bool
+			}{
+				{name: "Transform live entry", key: "live", keep: true, wantOld: 1, wantExists: true, wantValue: 2, wantFound: true},
+				{name: "Create missing entry", key: "missing", keep: true, wantValue: 2, wantFound: true},
+				{name: "Create over expired entry", key: "expired", keep: true, wantValue: 2, wantFound: true},
+				{name: "Delete live entry", key: "live", wantOld: 1, wantExists: true},
+				{name: "Leave missing entry", key: "missing"},
+			}
+			for _, tt := range tests {
+				cache := tc.cache()
+				clock := newFakeClock()
+				WithClock(clock)(cache)
+				cache.Set("live", 1, time.Minute)
+				cache.Set("expired", 1, time.Nanosecond)
+				clock.Advance(time.Millisecond)
+
+				cache.Update(tt.key, time.Minute, func(old interface{}, exists bool) (interface{}, bool) {
+					if old != tt.wantOld || exists != tt.wantExists {
+						t.Errorf("%s: fn() got %v, %v, want %v, %v", tt.name, old, exists, tt.wantOld, tt.wantExists)
+					}
+					return 2, tt.keep
+				})
+				if value, found := cache.Get(tt.key); value != tt.wantValue || found != tt.wantFound {
+					t.Errorf("%s: Get() after Update() = %v, %v, want %v, %v", tt.name, value, found, tt.wantValue, tt.wantFound)
+				}
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_Update_Concurrent(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache()
+
+			var wg sync.WaitGroup
+			calls := 0
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					cache.Update("test", time.Minute, func(old interface{}, exists bool) (interface{}, bool) {
+						calls++
+						values, _ := old.([]int)
+						return append(append([]int(nil), values...), i), true
+					})
+				}(i)
+			}
+			wg.Wait()
+
+			if value, _ := cache.Get("test"); len(value.([]int)) != 50 {
+				t.Errorf("Get() after concurrent updates has %d values, want %d", len(value.([]int)), 50)
+			}
+			if calls != 50 {
+				t.Errorf("fn() was called %d times, want once per Update()", calls)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_Update_OnEvictedUpdatesKey(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache()
+			var called atomic.Bool
+			WithOnEvicted(func(key string, value interface{}, reason EvictionReason) {
+				if called.Swap(true) {
+					return
+				}
+				cache.Update(key, time.Minute, func(old interface{}, exists bool) (interface{}, bool) {
+					return "from callback", true
+				})
+			})(cache)
+			cache.Set("test", "stored", time.Minute)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				cache.Update("test", time.Minute, func(old interface{}, exists bool) (interface{}, bool) {
+					return "updated", true
+				})
+			}()
+			select {
+			case <-done:
+			case <-time.After(time.Second * 2):
+				t.Fatalf("Update() deadlocked when the eviction callback updated the key")
+			}
+
+			if value, _ := cache.Get("test"); value != "from callback" {
+				t.Errorf("Get() = %v, want the value stored by the callback", value)
+			}
+		})
+	}
+}
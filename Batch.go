@@ -0,0 +1,101 @@
+package cache
+
+import "time"
+
+type MultiCache interface {
+	GetMulti(keys []string) map[string]interface{}
+	SetMulti(items map[string]interface{}, expiredInterval time.Duration)
+	DeleteMulti(keys []string)
+}
+
+// GetMulti uses the batch implementation of c when available and falls back
+// to single-key calls otherwise. Missing keys are absent from the result.
+func GetMulti(c Cache, keys []string) map[string]interface{} {
+	if multiCache, ok := c.(MultiCache); ok {
+		return multiCache.GetMulti(keys)
+	}
+
+	values := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if value, found := c.Get(key); found {
+			values[key] = value
+		}
+	}
+
+	return values
+}
+
+func SetMulti(c Cache, items map[string]interface{}, expiredInterval time.Duration) {
+	if multiCache, ok := c.(MultiCache); ok {
+		multiCache.SetMulti(items, expiredInterval)
+		return
+	}
+
+	for key, value := range items {
+		c.Set(key, value, expiredInterval)
+	}
+}
+
+func DeleteMulti(c Cache, keys []string) {
+	if multiCache, ok := c.(MultiCache); ok {
+		multiCache.DeleteMulti(keys)
+		return
+	}
+
+	for _, key := range keys {
+		c.Delete(key)
+	}
+}
+
+func (c *inMemoryCache) GetMulti(keys []string) map[string]interface{} {
+	values := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if value, found := c.Get(key); found {
+			values[key] = value
+		}
+	}
+
+	return values
+}
+
+func (c *inMemoryCache) SetMulti(items map[string]interface{}, expiredInterval time.Duration) {
+	c.stats.sets.Add(uint64(len(items)))
+	cacheItems := make(map[string]*cacheItem, len(items))
+	for key, value := range items {
+		item := c.newCacheItem(value, expiredInterval)
+		if c.checkWrite(key, item) != nil {
+			continue
+		}
+		cacheItems[key] = item
+	}
+	if c.evictor == nil {
+		for key, item := range cacheItems {
+			c.storeItem(key, item)
+		}
+		return
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	for key, item := range cacheItems {
+		c.setLocked(key, item, defaultItemCost)
+	}
+}
+
+func (c *inMemoryCache) DeleteMulti(keys []string) {
+	c.stats.deletes.Add(uint64(len(keys)))
+	if c.evictor == nil {
+		for _, key := range keys {
+			c.deleteItem(key, Deleted)
+		}
+		return
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	for _, key := range keys {
+		c.deleteLocked(key)
+	}
+}
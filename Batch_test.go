@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type singleKeyCache struct {
+	values map[string]interface{}
+}
+
+func (c *singleKeyCache) Get(key string) (interface{}, bool) {
+	value, found := c.values[key]
+	return value, found
+}
+
+func (c *singleKeyCache) Set(key string, value interface{}, expiredInterval time.Duration) {
+	c.values[key] = value
+}
+
+func (c *singleKeyCache) Delete(key string) {
+	delete(c.values, key)
+}
+
+func TestMultiOperations(t *testing.T) {
+	tests := []struct {
+		name  string
+		cache func() Cache
+	}{
+		{
+			name: "In memory cache",
+			cache: func() Cache {
+				return &inMemoryCache{}
+			},
+		},
+		{
+			name: "In memory cache with eviction",
+			cache: func() Cache {
+				cache := &inMemoryCache{}
+				WithMaxEntries(10)(cache)
+				return cache
+			},
+		},
+		{
+			name: "Cache without batch support",
+			cache: func() Cache {
+				return &singleKeyCache{values: map[string]interface{}{}}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := tt.cache()
+
+			SetMulti(cache, map[string]interface{}{"test1": 1, "test2": 2, "test3": 3}, time.Second*10)
+			got := GetMulti(cache, []string{"test1", "test2", "test4"})
+			want := map[string]interface{}{"test1": 1, "test2": 2}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GetMulti() = %v, want %v", got, want)
+			}
+
+			DeleteMulti(cache, []string{"test1", "test3"})
+			got = GetMulti(cache, []string{"test1", "test2", "test3"})
+			want = map[string]interface{}{"test2": 2}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GetMulti() after DeleteMulti() = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -2,12 +2,16 @@ package cache
 
 import (
 	"context"
+	"crypto/cipher"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	defaultCleanUpInterval = time.Second * 30
+	defaultItemCost        = 1
 )
 
 type Cache interface {
@@ -16,24 +20,149 @@ type Cache interface {
 	Delete(key string)
 }
 
+type GetOrSetter interface {
+	GetOrSet(key string, value interface{}, expiredInterval time.Duration) (interface{}, bool)
+}
+
+type Cleaner interface {
+	CleanupNow() int
+}
+
+type CostSetter interface {
+	SetWithCost(key string, value interface{}, expiredInterval time.Duration, cost int64)
+}
+
 type inMemoryCache struct {
-	storage       sync.Map
-	cleanUpTicker *time.Ticker
+	storage             storageEngine
+	cleanUpTicker       Ticker
+	clock               Clock
+	defaultTTL          time.Duration
+	ttlJitter           float64
+	manualCleanUp       bool
+	cleanUpBatchSize    int
+	maxCleanUpDuration  time.Duration
+	minCleanUpInterval  time.Duration
+	maxCleanUpInterval  time.Duration
+	cleanUpInterval     time.Duration
+	earlyExpirationBeta float64
+	staleGrace          time.Duration
+	staleLoader         func(ctx context.Context, key string) (interface{}, error)
+	refreshLoader       func(ctx context.Context, key string) (interface{}, error)
+	refreshAfter        time.Duration
+	maxEntries          int
+	evictionPolicy      EvictionPolicy
+	admissionPolicy     AdmissionPolicy
+	maxCost             int64
+	maxMemory           int64
+	maxItemSize         int64
+	keyValidators       []func(key string) error
+	copier              func(value interface{}) interface{}
+	codec               Codec
+	compression         Compression
+	compressMinSize     int
+	aead                cipher.AEAD
+	logger              *slog.Logger
+	sizer               func(value interface{}) int64
+	mu                  sync.Mutex
+	evictor             evictor
+	admission           *tinyLFU
+	costs               map[string]int64
+	totalCost           int64
+	loads               loadGroup
+	refreshes           loadGroup
+	updateLocks         keyLocks
+	keyLocks            keyLocks
+	commitMu            sync.Mutex
+	commitSeq           atomic.Uint64
+	stats               cacheStats
+	onEvicted           func(key string, value interface{}, reason EvictionReason)
+	pendingNotices      []evictionNotice
+	expirations         chan ExpiredItem
+	expirationsMu       sync.RWMutex
+	expirationsClosed   bool
+	expiryQueue         expirationQueue
+	watchMu             sync.RWMutex
+	watchers            map[string]map[chan Event]struct{}
+	snapshot            *snapshotConfig
+	journal             *journal
+	tagMu               sync.Mutex
+	tags                tagIndex
+	namespaces          sync.Map
+	staleGenerations    atomic.Bool
+	negativeTTL         time.Duration
+	hotKeys             *hotKeys
 }
 
 type cacheItem struct {
 	validThrough time.Time
 	value        interface{}
+	tags         []string
+	interval     time.Duration
+	sliding      bool
+	delta        time.Duration
+	size         int64
+	negative     bool
+	createdAt    time.Time
+	access       entryAccess
+}
+
+// newCacheItem returns an item holding value, encoded or copied as configured,
+// that expires interval from now.
+func (c *inMemoryCache) newCacheItem(value interface{}, interval time.Duration) *cacheItem {
+	value = c.storedValue(value)
+	item := &cacheItem{value: value, size: c.sizeOf(value), createdAt: c.now()}
+	c.setExpiration(item, interval)
+
+	return item
+}
+
+// setExpiration makes item expire interval, jittered by WithTTLJitter, from
+// now. The interval is kept so that Touch and sliding reads can restart it.
+func (c *inMemoryCache) setExpiration(item *cacheItem, interval time.Duration) {
+	interval = c.jitter(interval)
+	item.validThrough = c.expiresAt(interval)
+	item.interval = 0
+	if interval > 0 {
+		item.interval = interval
+	}
+}
+
+// isExpired reports false for items stored without expiration, whose
+// validThrough is zero.
+func (i *cacheItem) isExpired(now time.Time) bool {
+	return !i.validThrough.IsZero() && now.UnixNano() > i.validThrough.UnixNano()
+}
+
+// isLive reports whether item holds a value that has not expired. Negative
+// entries hold none.
+func (i *cacheItem) isLive(now time.Time) bool {
+	return !i.negative && !i.isExpired(now)
 }
 
 func NewInMemoryCache(ctx context.Context, options ...func(cache *inMemoryCache)) Cache {
-	cleanUpTicker := time.NewTicker(defaultCleanUpInterval)
-	cache := &inMemoryCache{cleanUpTicker: cleanUpTicker}
+	cache := &inMemoryCache{
+		cleanUpTicker:   systemClock{}.NewTicker(defaultCleanUpInterval),
+		cleanUpInterval: defaultCleanUpInterval,
+	}
 
 	for _, optionFn := range options {
 		optionFn(cache)
 	}
+	if cache.manualCleanUp {
+		cache.cleanUpTicker.Stop()
+	}
 
+	if cache.snapshot != nil {
+		if err := cache.restoreSnapshot(); err != nil {
+			cache.log(slog.LevelError, "cache snapshot restore failed", "path", cache.snapshot.path, "error", err)
+		}
+		go cache.snapshotPeriodically(ctx, cache.newTicker(cache.snapshot.interval))
+	}
+	if cache.journal != nil {
+		if err := cache.openJournal(ctx); err != nil {
+			cache.log(slog.LevelError, "cache journal open failed", "path", cache.journal.path, "error", err)
+		}
+	}
 	go cache.cleanUpCache(ctx)
 
 	return cache
@@ -41,57 +170,460 @@ func NewInMemoryCache(ctx context.Context, options ...func(cache *inMemoryCache)
 
 func WithCleanUpInterval(duration time.Duration) func(*inMemoryCache) {
 	return func(cache *inMemoryCache) {
+		cache.cleanUpInterval = duration
 		cache.cleanUpTicker.Reset(duration)
 	}
 }
 
+// WithoutBackgroundCleanup stops the periodic removal of expired entries, for
+// embedders that call CleanupNow from their own scheduler. Expired entries
+// are never returned either way, but they hold memory until removed.
+func WithoutBackgroundCleanup() func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.manualCleanUp = true
+	}
+}
+
+// WithCleanUpBatchSize limits a cleanup pass to deleting n expired entries.
+// The rest are deleted by the following passes, which keeps a large backlog
+// from stalling foreground traffic.
+func WithCleanUpBatchSize(n int) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.cleanUpBatchSize = n
+	}
+}
+
+// WithMaxCleanUpDuration stops a cleanup pass after d and leaves the
+// remaining expired entries to the following passes. Every pass deletes at
+// least one entry, so cleanup keeps making progress.
+func WithMaxCleanUpDuration(d time.Duration) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.maxCleanUpDuration = d
+	}
+}
+
+// WithAdaptiveCleanUp lets the cleanup interval follow the expiration rate
+// between minInterval and maxInterval, starting at minInterval. The interval
+// doubles after a pass that found nothing to delete and halves after a pass
+// that left expired entries behind, because of the limits of
+// WithCleanUpBatchSize and WithMaxCleanUpDuration or because entries expired
+// during the pass. It replaces the interval of WithCleanUpInterval.
+func WithAdaptiveCleanUp(minInterval, maxInterval time.Duration) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		if maxInterval < minInterval {
+			maxInterval = minInterval
+		}
+		cache.minCleanUpInterval = minInterval
+		cache.maxCleanUpInterval = maxInterval
+		cache.cleanUpInterval = minInterval
+		cache.cleanUpTicker.Reset(minInterval)
+	}
+}
+
+// CleanupNow removes expired entries immediately and returns how many were
+// removed.
+func (c *inMemoryCache) CleanupNow() int {
+	return c.cleanUp()
+}
+
 func (c *inMemoryCache) Get(key string) (interface{}, bool) {
-	storageValue, found := c.storage.Load(key)
+	item, found := c.get(key)
 	if !found {
 		return nil, false
 	}
 
-	item := storageValue.(cacheItem)
-	if time.Now().UnixNano() > item.validThrough.UnixNano() {
+	return c.valueOf(item), true
+}
+
+// load returns the item stored under key. It waits for a transaction commit
+// in progress, so that a read sees all of the commit's writes or none.
+func (c *inMemoryCache) load(key string) (*cacheItem, bool) {
+	for {
+		seq := c.commitSeq.Load()
+		if seq%2 == 0 {
+			item, found := c.storage.Load(key)
+			if c.commitSeq.Load() == seq {
+				return item, found
+			}
+		}
+		// Wait for the commit to finish.
+		c.commitMu.Lock()
+		c.commitMu.Unlock()
+	}
+}
+
+// get returns the item a read of key hits, with the side effects of the read.
+func (c *inMemoryCache) get(key string) (*cacheItem, bool) {
+	item, found := c.load(key)
+	if !found || item.negative {
+		c.recordAccess(key, false)
 		return nil, false
 	}
 
-	return item.value, true
+	now := c.now()
+	if item.isExpired(now) && c.serveStale(key, item, now) {
+		c.recordAccess(key, true)
+		item.recordHit(now)
+		return item, true
+	}
+	if item.isExpired(now) || c.expiresEarly(item, now) {
+		c.recordAccess(key, false)
+		return nil, false
+	}
+
+	c.recordAccess(key, true)
+	item.recordHit(now)
+	c.refreshAhead(key, item, now)
+	c.slide(key, item)
+
+	return item, true
 }
 
 func (c *inMemoryCache) Set(key string, value interface{}, expiredInterval time.Duration) {
-	item := cacheItem{value: value, validThrough: time.Now().Add(expiredInterval)}
-	c.storage.Store(key, item)
+	c.SetWithCost(key, value, expiredInterval, defaultItemCost)
+}
+
+func (c *inMemoryCache) SetWithCost(key string, value interface{}, expiredInterval time.Duration, cost int64) {
+	c.set(key, c.newCacheItem(value, expiredInterval), cost)
+}
+
+func (c *inMemoryCache) set(key string, item *cacheItem, cost int64) error {
+	c.stats.sets.Add(1)
+	if err := c.checkWrite(key, item); err != nil {
+		return err
+	}
+	if c.evictor == nil {
+		c.storeItem(key, item)
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	c.setLocked(key, item, cost)
+
+	return nil
+}
+
+// GetOrSet returns the live value stored under key and true, or stores value
+// and returns it with false.
+func (c *inMemoryCache) GetOrSet(key string, value interface{}, expiredInterval time.Duration) (interface{}, bool) {
+	item := c.newCacheItem(value, expiredInterval)
+	if err := c.validateKey(key); err != nil {
+		c.stats.rejected.Add(1)
+		return value, false
+	}
+	if c.itemError(item) != nil {
+		if current, found := c.Get(key); found {
+			return current, true
+		}
+		c.reject(key)
+		return value, false
+	}
+	if c.evictor != nil {
+		c.mu.Lock()
+		defer c.unlock()
+
+		if existing, found := c.storage.Load(key); found {
+			if existing.isLive(c.now()) {
+				c.stats.hits.Add(1)
+				existing.recordHit(c.now())
+				c.evictor.touch(key)
+				c.slide(key, existing)
+				return c.valueOf(existing), true
+			}
+		}
+		c.stats.misses.Add(1)
+		c.stats.sets.Add(1)
+		c.setLocked(key, item, defaultItemCost)
+
+		return value, false
+	}
+
+	for {
+		existing, loaded := c.storage.LoadOrStore(key, item)
+		if !loaded {
+			c.stats.entries.Add(1)
+			c.resize(key, nil, item)
+			c.scheduleExpiration(key, item)
+			c.publishEvent(EventSet, key, item)
+			c.journalSet(key, item)
+			break
+		}
+
+		if existing.isLive(c.now()) {
+			c.stats.hits.Add(1)
+			existing.recordHit(c.now())
+			c.slide(key, existing)
+			return c.valueOf(existing), true
+		}
+		if c.storage.CompareAndSwap(key, existing, item) {
+			c.resize(key, existing, item)
+			c.scheduleExpiration(key, item)
+			c.untag(key, existing)
+			c.notifyEvicted(key, existing, Expired, nil)
+			c.publishEvent(EventSet, key, item)
+			c.journalSet(key, item)
+			break
+		}
+	}
+	c.stats.misses.Add(1)
+	c.stats.sets.Add(1)
+
+	return value, false
+}
+
+func (c *inMemoryCache) setLocked(key string, item *cacheItem, cost int64) {
+	if !c.fits(key, item, cost) {
+		return
+	}
+	if c.admission != nil {
+		c.admission.increment(key)
+		if c.needsEviction(key, item, cost) {
+			victimKey, ok := c.evictor.victim()
+			if ok && victimKey != key && !c.admission.admit(key, victimKey) {
+				return
+			}
+		}
+	}
+	c.makeRoom(key, item, cost)
+	c.storeItem(key, item)
+}
+
+// fits reports whether item with cost can be stored under key at all.
+func (c *inMemoryCache) fits(key string, item *cacheItem, cost int64) bool {
+	if c.maxCost > 0 && cost > c.maxCost {
+		return false
+	}
+
+	return c.maxMemory <= 0 || int64(len(key))+item.size <= c.maxMemory
+}
+
+// makeRoom evicts entries until item with cost fits under key and records key
+// with the eviction policy.
+func (c *inMemoryCache) makeRoom(key string, item *cacheItem, cost int64) {
+	if c.maxCost > 0 {
+		c.removeCost(key)
+		c.evictor.remove(key)
+		for c.totalCost+cost > c.maxCost {
+			evictedKey, ok := c.evictor.evict()
+			if !ok {
+				break
+			}
+			c.evictLocked(evictedKey)
+		}
+		c.costs[key] = cost
+		c.totalCost += cost
+	}
+	if c.maxMemory > 0 {
+		c.evictor.remove(key)
+		for c.exceedsMemory(key, item) {
+			evictedKey, ok := c.evictor.evict()
+			if !ok {
+				break
+			}
+			c.evictLocked(evictedKey)
+		}
+	}
+
+	for _, evictedKey := range c.evictor.add(key) {
+		c.evictLocked(evictedKey)
+	}
 }
 
 func (c *inMemoryCache) Delete(key string) {
-	c.storage.Delete(key)
+	c.stats.deletes.Add(1)
+	if c.evictor == nil {
+		c.deleteItem(key, Deleted)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	c.deleteLocked(key)
+}
+
+func (c *inMemoryCache) deleteLocked(key string) {
+	c.deleteItem(key, Deleted)
+	c.evictor.remove(key)
+	c.removeCost(key)
+}
+
+func (c *inMemoryCache) storeItem(key string, item *cacheItem) {
+	previous, loaded := c.storage.Swap(key, item)
+	c.stored(key, item, previous, loaded, nil)
+}
+
+// stored does the bookkeeping for item having replaced previous under key,
+// or having been added if not loaded. Eviction notices go to notices as
+// described for notifyEvicted.
+func (c *inMemoryCache) stored(key string, item, previous *cacheItem, loaded bool, notices *[]evictionNotice) {
+	c.resize(key, previous, item)
+	c.scheduleExpiration(key, item)
+	c.publishEvent(EventSet, key, item)
+	c.journalSet(key, item)
+	if !loaded {
+		c.stats.entries.Add(1)
+		return
+	}
+
+	c.untag(key, previous)
+	if previous.isExpired(c.now()) {
+		c.notifyEvicted(key, previous, Expired, notices)
+	} else {
+		c.notifyEvicted(key, previous, Replaced, notices)
+	}
+}
+
+func (c *inMemoryCache) deleteItem(key string, reason EvictionReason) bool {
+	item, loaded := c.storage.LoadAndDelete(key)
+	if !loaded {
+		return false
+	}
+	c.deleted(key, item, reason, nil)
+
+	return true
+}
+
+// deleted does the bookkeeping for item having been removed from key.
+func (c *inMemoryCache) deleted(key string, item *cacheItem, reason EvictionReason, notices *[]evictionNotice) {
+	c.stats.entries.Add(-1)
+	c.resize(key, item, nil)
+	c.untag(key, item)
+	c.notifyEvicted(key, item, reason, notices)
+	if reason == Deleted {
+		c.journalDelete(key)
+	}
+}
+
+func (c *inMemoryCache) deleteExpired(key string) bool {
+	if c.evictor != nil {
+		c.mu.Lock()
+		defer c.unlock()
+	}
+
+	item, found := c.storage.Load(key)
+	if !found || !c.isRemovable(item, c.now()) {
+		return false
+	}
+	if !c.storage.CompareAndDelete(key, item) {
+		return false
+	}
+
+	c.stats.entries.Add(-1)
+	c.stats.expired.Add(1)
+	c.resize(key, item, nil)
+	c.untag(key, item)
+	c.notifyEvicted(key, item, Expired, nil)
+	c.publishExpiration(key, item)
+	if c.evictor != nil {
+		c.evictor.remove(key)
+		c.removeCost(key)
+	}
+
+	return true
+}
+
+func (c *inMemoryCache) recordAccess(key string, hit bool) {
+	if hit {
+		c.stats.hits.Add(1)
+		c.hotKeys.hit(key)
+	} else {
+		c.stats.misses.Add(1)
+	}
+	if c.evictor == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if hit {
+		c.evictor.touch(key)
+	}
+	if c.admission != nil {
+		c.admission.increment(key)
+	}
 }
 
 func (c *inMemoryCache) cleanUpCache(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
+			c.closeExpirations()
 			return
-		case <-c.cleanUpTicker.C:
-			itemsToDelete := c.getCacheItemsToDelete()
-			for _, itemKey := range itemsToDelete {
-				c.storage.Delete(itemKey)
+		case <-c.cleanUpTicker.C():
+			deleted := c.cleanUp()
+			if c.maxCleanUpInterval > 0 {
+				c.cleanUpInterval = c.nextCleanUpInterval(deleted, c.expiryQueue.hasDue(c.now()))
+				c.cleanUpTicker.Reset(c.cleanUpInterval)
 			}
 		}
 	}
 }
 
-func (c *inMemoryCache) getCacheItemsToDelete() []interface{} {
-	var itemsToDelete []interface{}
-	c.storage.Range(func(key, value interface{}) bool {
-		item := value.(cacheItem)
-		if time.Now().UnixNano() > item.validThrough.UnixNano() {
-			itemsToDelete = append(itemsToDelete, key)
+// cleanUp deletes expired entries and returns how many it deleted. It stops
+// early at the limits set by WithCleanUpBatchSize and WithMaxCleanUpDuration;
+// the keys it did not get to stay queued for the next pass.
+func (c *inMemoryCache) cleanUp() int {
+	startedAt := time.Now()
+	deleted := 0
+	itemsToDelete := c.getCacheItemsToDelete()
+	for i, itemKey := range itemsToDelete {
+		if i > 0 && c.maxCleanUpDuration > 0 && time.Since(startedAt) > c.maxCleanUpDuration {
+			c.requeue(itemsToDelete[i:])
+			break
+		}
+		if c.deleteExpired(itemKey) {
+			deleted++
 		}
+	}
+	c.sweepGenerations()
+	duration := time.Since(startedAt)
+	c.stats.cleanUps.Add(1)
+	c.stats.cleanUpNanos.Add(uint64(duration))
+	c.log(slog.LevelDebug, "cache cleanup", "due", len(itemsToDelete), "removed", deleted, "queued", c.expiryQueue.len(), "duration", duration)
+
+	return deleted
+}
+
+func (c *inMemoryCache) nextCleanUpInterval(deleted int, backlog bool) time.Duration {
+	interval := c.cleanUpInterval
+	switch {
+	case backlog:
+		interval /= 2
+	case deleted == 0:
+		interval *= 2
+	}
 
-		return true
-	})
+	if interval < c.minCleanUpInterval {
+		return c.minCleanUpInterval
+	}
+	if interval > c.maxCleanUpInterval {
+		return c.maxCleanUpInterval
+	}
+
+	return interval
+}
+
+// getCacheItemsToDelete returns the keys whose entries are removable now.
+// Keys whose entry was extended after it was queued, such as sliding entries,
+// are queued again at their new time.
+func (c *inMemoryCache) getCacheItemsToDelete() []string {
+	var itemsToDelete []string
+	now := c.now()
+	for _, key := range c.expiryQueue.due(now, c.cleanUpBatchSize) {
+		item, found := c.storage.Load(key)
+		if !found {
+			continue
+		}
+		if c.isRemovable(item, now) {
+			itemsToDelete = append(itemsToDelete, key)
+		} else {
+			c.scheduleExpiration(key, item)
+		}
+	}
 
 	return itemsToDelete
 }
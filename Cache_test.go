@@ -4,6 +4,8 @@ import (
 	"context"
 	"math"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -38,13 +40,13 @@ func TestNewInMemoryCache(t *testing.T) {
 
 func TestWithCleanUpInterval(t *testing.T) {
 	expectedDuration := time.Millisecond * 100
-	cache := &inMemoryCache{cleanUpTicker: time.NewTicker(time.Second * 5)}
+	cache := &inMemoryCache{cleanUpTicker: systemClock{}.NewTicker(time.Second * 5)}
 
 	fn := WithCleanUpInterval(expectedDuration)
 	fn(cache)
 
 	beginTime := time.Now()
-	<-cache.cleanUpTicker.C
+	<-cache.cleanUpTicker.C()
 	endTime := time.Now()
 
 	actualDuration := endTime.Sub(beginTime)
@@ -60,6 +62,48 @@ func TestWithCleanUpInterval(t *testing.T) {
 	}
 }
 
+func TestWithoutBackgroundCleanup(t *testing.T) {
+	clock := newFakeClock()
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	cache := NewInMemoryCache(ctx, WithCleanUpInterval(time.Millisecond), WithClock(clock), WithoutBackgroundCleanup()).(*inMemoryCache)
+	cache.Set("test", 1, time.Nanosecond)
+
+	clock.Advance(time.Millisecond * 10)
+	if _, found := cache.storage.Load("test"); !found {
+		t.Fatalf("expired entry removed without a manual cleanup")
+	}
+	if deleted := cache.CleanupNow(); deleted != 1 {
+		t.Errorf("CleanupNow() = %d, want %d", deleted, 1)
+	}
+	if _, found := cache.storage.Load("test"); found {
+		t.Errorf("CleanupNow() left an expired entry behind")
+	}
+}
+
+func Test_inMemoryCache_CleanupNow(t *testing.T) {
+	cache := &inMemoryCache{}
+	clock := newFakeClock()
+	WithClock(clock)(cache)
+	cache.Set("expired1", 1, time.Nanosecond)
+	cache.Set("expired2", 2, time.Nanosecond)
+	cache.Set("live", 3, time.Minute)
+	clock.Advance(time.Millisecond)
+
+	if deleted := cache.CleanupNow(); deleted != 2 {
+		t.Errorf("CleanupNow() = %d, want %d", deleted, 2)
+	}
+	if deleted := cache.CleanupNow(); deleted != 0 {
+		t.Errorf("second CleanupNow() = %d, want %d", deleted, 0)
+	}
+	if _, found := cache.Get("live"); !found {
+		t.Errorf("CleanupNow() removed a live entry")
+	}
+	if cleanUps := cache.Stats().CleanUps; cleanUps != 2 {
+		t.Errorf("Stats().CleanUps = %d, want %d", cleanUps, 2)
+	}
+}
+
 func Test_inMemoryCache_Delete(t *testing.T) {
 	type args struct {
 		key             string
@@ -148,11 +192,22 @@ func Test_inMemoryCache_Get(t *testing.T) {
 				key:             "test",
 				isValueExisting: true,
 				value:           42,
-				expiredInterval: 0,
+				expiredInterval: time.Nanosecond,
 			},
 			expectedValue:     nil,
 			expectedExistence: false,
 		},
+		{
+			name: "Get value without expiration",
+			args: args{
+				key:             "test",
+				isValueExisting: true,
+				value:           42,
+				expiredInterval: NoExpiration,
+			},
+			expectedValue:     42,
+			expectedExistence: true,
+		},
 	}
 
 	var cache Cache
@@ -276,23 +331,29 @@ func Test_inMemoryCache_cleanCache(t *testing.T) {
 				{
 					key:      "test3",
 					value:    44,
-					interval: 0,
+					interval: time.Nanosecond,
 				},
 				{
 					key:      "test4",
 					value:    45,
 					interval: time.Millisecond * 30,
 				},
+				{
+					key:      "test5",
+					value:    46,
+					interval: 0,
+				},
 			},
 			want: []interface{}{
 				"test1",
 				"test4",
+				"test5",
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ticker := time.NewTicker(time.Millisecond * 20)
+			ticker := systemClock{}.NewTicker(time.Millisecond * 20)
 			cache := &inMemoryCache{
 				cleanUpTicker: ticker,
 			}
@@ -304,7 +365,7 @@ func Test_inMemoryCache_cleanCache(t *testing.T) {
 			cache.cleanUpCache(ctx)
 			cancelFn()
 
-			cache.storage.Range(func(key, value interface{}) bool {
+			cache.storage.Range(func(key string, _ *cacheItem) bool {
 				result := false
 				for _, wantKey := range tt.want {
 					if wantKey == key {
@@ -330,7 +391,7 @@ func Test_inMemoryCache_getCacheItemsToDelete(t *testing.T) {
 			value    interface{}
 			interval time.Duration
 		}
-		want []interface{}
+		want []string
 	}{
 		{
 			name: "Find cache items to delete",
@@ -352,10 +413,15 @@ func Test_inMemoryCache_getCacheItemsToDelete(t *testing.T) {
 				{
 					key:      "test3",
 					value:    44,
-					interval: 0,
+					interval: time.Nanosecond,
+				},
+				{
+					key:      "test4",
+					value:    45,
+					interval: NoExpiration,
 				},
 			},
-			want: []interface{}{
+			want: []string{
 				"test2",
 				"test3",
 			},
@@ -364,10 +430,12 @@ func Test_inMemoryCache_getCacheItemsToDelete(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cache := &inMemoryCache{}
+			clock := newFakeClock()
+			WithClock(clock)(cache)
 			for _, item := range tt.items {
 				cache.Set(item.key, item.value, item.interval)
 			}
-			time.Sleep(time.Millisecond)
+			clock.Advance(time.Millisecond * 2)
 			got := cache.getCacheItemsToDelete()
 
 			if len(got) != len(tt.want) {
@@ -390,3 +458,114 @@ func Test_inMemoryCache_getCacheItemsToDelete(t *testing.T) {
 		})
 	}
 }
+
+func Test_inMemoryCache_GetOrSet(t *testing.T) {
+	type args struct {
+		key             string
+		isValueExisting bool
+		existingValue   interface{}
+		existingTTL     time.Duration
+		value           interface{}
+	}
+	tests := []struct {
+		name          string
+		options       []func(cache *inMemoryCache)
+		args          args
+		expectedValue interface{}
+		expectedFound bool
+	}{
+		{
+			name: "Store a non-existent value",
+			args: args{
+				key:   "test",
+				value: 42,
+			},
+			expectedValue: 42,
+			expectedFound: false,
+		},
+		{
+			name: "Return an existing value",
+			args: args{
+				key:             "test",
+				isValueExisting: true,
+				existingValue:   1,
+				existingTTL:     time.Second * 10,
+				value:           42,
+			},
+			expectedValue: 1,
+			expectedFound: true,
+		},
+		{
+			name: "Replace an expired value",
+			args: args{
+				key:             "test",
+				isValueExisting: true,
+				existingValue:   1,
+				existingTTL:     time.Nanosecond,
+				value:           42,
+			},
+			expectedValue: 42,
+			expectedFound: false,
+		},
+		{
+			name:    "Return an existing value with eviction enabled",
+			options: []func(cache *inMemoryCache){WithMaxEntries(10)},
+			args: args{
+				key:             "test",
+				isValueExisting: true,
+				existingValue:   []int{1},
+				existingTTL:     time.Second * 10,
+				value:           42,
+			},
+			expectedValue: []int{1},
+			expectedFound: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			clock := newFakeClock()
+			WithClock(clock)(cache)
+			for _, optionFn := range tt.options {
+				optionFn(cache)
+			}
+			if tt.args.isValueExisting {
+				cache.Set(tt.args.key, tt.args.existingValue, tt.args.existingTTL)
+				clock.Advance(time.Millisecond)
+			}
+
+			actualValue, actualFound := cache.GetOrSet(tt.args.key, tt.args.value, time.Second*10)
+			if !reflect.DeepEqual(actualValue, tt.expectedValue) {
+				t.Errorf("GetOrSet() actualValue = %v, want %v", actualValue, tt.expectedValue)
+			}
+			if actualFound != tt.expectedFound {
+				t.Errorf("GetOrSet() actualFound = %v, want %v", actualFound, tt.expectedFound)
+			}
+
+			storedValue, _ := cache.Get(tt.args.key)
+			if !reflect.DeepEqual(storedValue, tt.expectedValue) {
+				t.Errorf("Get() after GetOrSet() = %v, want %v", storedValue, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_GetOrSetConcurrent(t *testing.T) {
+	cache := &inMemoryCache{}
+	var wg sync.WaitGroup
+	var stored int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(value int) {
+			defer wg.Done()
+			if _, found := cache.GetOrSet("test", value, time.Second*10); !found {
+				atomic.AddInt32(&stored, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if stored != 1 {
+		t.Errorf("GetOrSet() stored a value %d times, want exactly once", stored)
+	}
+}
@@ -0,0 +1,65 @@
+package cache
+
+import "time"
+
+// Clock is the source of time for expiration and background cleanup. Tests
+// can pass a fake Clock, such as cachetest.Clock, to WithClock to move time
+// forward without sleeping.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks like time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// WithClock makes the cache read the time from clock and schedule cleanup
+// passes with its tickers. Durations of work, such as loader and cleanup
+// timings, are still measured on the system clock.
+func WithClock(clock Clock) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.clock = clock
+		if cache.cleanUpTicker != nil {
+			cache.cleanUpTicker.Stop()
+			cache.cleanUpTicker = clock.NewTicker(cache.cleanUpInterval)
+		}
+	}
+}
+
+func (c *inMemoryCache) newTicker(d time.Duration) Ticker {
+	if c.clock == nil {
+		return systemClock{}.NewTicker(d)
+	}
+
+	return c.clock.NewTicker(d)
+}
+
+func (c *inMemoryCache) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+
+	return c.clock.Now()
+}
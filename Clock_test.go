@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock mirrors cachetest.Clock, which tests of this package cannot
+// import. It only moves when advanced, and its tickers fire at most once per
+// Advance, like time.Ticker drops ticks for slow receivers. If resets is set,
+// the period of every ticker Reset is sent to it, so tests can wait for the
+// cleanup loop to finish a pass.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+	resets  chan time.Duration
+}
+
+type fakeTicker struct {
+	clock  *fakeClock
+	c      chan time.Time
+	period time.Duration
+	next   time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *fakeClock) NewTicker(d time.Duration) Ticker {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ticker := &fakeTicker{clock: f, c: make(chan time.Time, 1), period: d, next: f.now.Add(d)}
+	f.tickers = append(f.tickers, ticker)
+
+	return ticker
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	for _, ticker := range f.tickers {
+		if ticker.period <= 0 || f.now.Before(ticker.next) {
+			continue
+		}
+		for !ticker.next.After(f.now) {
+			ticker.next = ticker.next.Add(ticker.period)
+		}
+		select {
+		case ticker.c <- f.now:
+		default:
+		}
+	}
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.period = d
+	t.next = t.clock.now.Add(d)
+	if t.clock.resets != nil {
+		t.clock.resets <- d
+	}
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.period = 0
+}
+
+func TestWithClock_Expiration(t *testing.T) {
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		advance   time.Duration
+		wantFound bool
+		wantTTL   time.Duration
+	}{
+		{
+			name:      "Before expiration",
+			interval:  time.Hour,
+			advance:   time.Minute * 59,
+			wantFound: true,
+			wantTTL:   time.Minute,
+		},
+		{
+			name:      "At expiration",
+			interval:  time.Hour,
+			advance:   time.Hour,
+			wantFound: true,
+		},
+		{
+			name:     "After expiration",
+			interval: time.Hour,
+			advance:  time.Hour + time.Nanosecond,
+		},
+		{
+			name:      "No expiration",
+			interval:  NoExpiration,
+			advance:   time.Hour * 24 * 365,
+			wantFound: true,
+			wantTTL:   NoExpiration,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			cache := &inMemoryCache{}
+			WithClock(clock)(cache)
+
+			cache.Set("test", 42, tt.interval)
+			clock.Advance(tt.advance)
+
+			if _, found := cache.Get("test"); found != tt.wantFound {
+				t.Errorf("Get() found = %v, want %v", found, tt.wantFound)
+			}
+			if ttl, _ := cache.TTL("test"); ttl != tt.wantTTL {
+				t.Errorf("TTL() = %v, want %v", ttl, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestWithClock_CleanUp(t *testing.T) {
+	clock := newFakeClock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	removed := make(chan string, 1)
+	cache := NewInMemoryCache(ctx,
+		WithCleanUpInterval(time.Minute),
+		WithClock(clock),
+		WithOnEvicted(func(key string, value interface{}, reason EvictionReason) {
+			removed <- key
+		}),
+	)
+	cache.Set("test", 42, time.Second)
+
+	clock.Advance(time.Second * 30)
+	select {
+	case key := <-removed:
+		t.Fatalf("%q removed before the cleanup interval passed", key)
+	case <-time.After(time.Millisecond * 20):
+	}
+
+	clock.Advance(time.Second * 30)
+	select {
+	case key := <-removed:
+		if key != "test" {
+			t.Errorf("removed %q, want %q", key, "test")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expired entry not removed after the cleanup interval passed")
+	}
+}
@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec converts cache values to and from bytes.
+type Codec interface {
+	Marshal(value interface{}) ([]byte, error)
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// GobCodec encodes values with encoding/gob. Concrete types other than the gob
+// builtins must be registered with gob.Register.
+type GobCodec struct{}
+
+type gobEnvelope struct {
+	Value interface{}
+}
+
+func (GobCodec) Marshal(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(gobEnvelope{Value: value}); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte) (interface{}, error) {
+	var envelope gobEnvelope
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&envelope); err != nil {
+		return nil, err
+	}
+
+	return envelope.Value, nil
+}
+
+// JSONCodec encodes values with encoding/json. Values decode into the generic
+// JSON types: objects become map[string]interface{} and numbers float64.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec) Unmarshal(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// WithCodec stores values encoded by codec instead of as live values, which
+// isolates callers from each other like WithValueCopying and makes the
+// default size estimate the encoded length. Writes of values codec fails to
+// encode are dropped like those over WithMaxItemSize, and TrySet returns the
+// error. A value that fails to decode reads as nil. WithCodec takes
+// precedence over WithValueCopying.
+func WithCodec(codec Codec) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.codec = codec
+	}
+}
+
+// encodingFailure stands in for a value the codec could not encode until the
+// write is rejected.
+type encodingFailure struct {
+	err error
+}
+
+// storedValue returns the representation of value kept in cache items.
+func (c *inMemoryCache) storedValue(value interface{}) interface{} {
+	if c.codec == nil {
+		return c.copyValue(value)
+	}
+
+	data, err := c.codec.Marshal(value)
+	if err == nil {
+		data, err = c.compress(data)
+	}
+	if err == nil {
+		data, err = c.seal(data)
+	}
+	if err != nil {
+		return encodingFailure{err: err}
+	}
+
+	return data
+}
+
+// decoded returns the value held by item. Unless a codec is set, it is the
+// stored value itself.
+func (c *inMemoryCache) decoded(item *cacheItem) interface{} {
+	if c.codec == nil {
+		return item.value
+	}
+
+	data, ok := item.value.([]byte)
+	if !ok {
+		return nil
+	}
+	data, err := c.open(data)
+	if err == nil {
+		data, err = c.decompress(data)
+	}
+	if err != nil {
+		return nil
+	}
+	value, err := c.codec.Unmarshal(data)
+	if err != nil {
+		return nil
+	}
+
+	return value
+}
+
+// normalized returns value as the codec decodes it, or value itself without a
+// codec or when it fails to encode.
+func (c *inMemoryCache) normalized(value interface{}) interface{} {
+	if c.codec == nil {
+		return value
+	}
+
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return value
+	}
+	decoded, err := c.codec.Unmarshal(data)
+	if err != nil {
+		return value
+	}
+
+	return decoded
+}
+
+// valueOf returns the value held by item for handing out to callers, which
+// never shares memory with the cache when WithCodec or WithValueCopying is
+// set.
+func (c *inMemoryCache) valueOf(item *cacheItem) interface{} {
+	if c.codec == nil {
+		return c.copyValue(item.value)
+	}
+
+	return c.decoded(item)
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCodecs(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+		value interface{}
+		want  interface{}
+	}{
+		{name: "Gob string", codec: GobCodec{}, value: "abc", want: "abc"},
+		{name: "Gob slice", codec: GobCodec{}, value: []int{1, 2}, want: []int{1, 2}},
+		{name: "Gob nil", codec: GobCodec{}, value: nil, want: nil},
+		{name: "JSON string", codec: JSONCodec{}, value: "abc", want: "abc"},
+		{name: "JSON number", codec: JSONCodec{}, value: 42, want: float64(42)},
+		{name: "JSON object", codec: JSONCodec{}, value: map[string]int{"a": 1}, want: map[string]interface{}{"a": float64(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.codec.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			got, err := tt.codec.Unmarshal(data)
+			if err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCodec(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithCodec(GobCodec{})(cache)
+
+	value := []int{1, 2, 3}
+	cache.Set("test", value, time.Minute)
+	value[0] = 42
+	got, found := cache.Get("test")
+	if !found || !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("Get() = %v, %v, want the value as it was stored", got, found)
+	}
+	if stored, _ := cache.storage.Load("test"); reflect.TypeOf(stored.value) != reflect.TypeOf([]byte(nil)) {
+		t.Errorf("stored value is a %T, want encoded bytes", stored.value)
+	}
+
+	if _, err := cache.Increment("counter", 2, time.Minute); err != nil {
+		t.Fatalf("Increment() error = %v", err)
+	}
+	if count, err := cache.Increment("counter", 3, time.Minute); err != nil || count != 5 {
+		t.Errorf("Increment() = %d, %v, want %d, <nil>", count, err, 5)
+	}
+	cache.Set("name", "abc", time.Minute)
+	if !cache.CompareAndSwap("name", "abc", "def", time.Minute) {
+		t.Errorf("CompareAndSwap() did not match the decoded value")
+	}
+
+	cache.Set("func", "abc", time.Minute)
+	if err := cache.TrySet("func", func() {}, time.Minute); err == nil {
+		t.Errorf("TrySet() of a value gob cannot encode returned no error")
+	}
+	if _, found := cache.Get("func"); found {
+		t.Errorf("Get() found the entry replaced by a failed write")
+	}
+}
+
+func TestWithCodec_DecodesOnlyForSubscribers(t *testing.T) {
+	codec := &countingCodec{}
+	cache := &inMemoryCache{}
+	WithCodec(codec)(cache)
+
+	cache.Set("test", 1, time.Minute)
+	cache.Set("test", 2, time.Minute)
+	cache.Delete("test")
+	if codec.unmarshals != 0 {
+		t.Errorf("writes without watchers or OnEvicted decoded %d values, want 0", codec.unmarshals)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := cache.Watch(ctx, "test")
+	cache.Set("test", 3, time.Minute)
+	if event := <-events; event.Value != 3 {
+		t.Errorf("Watch() event value = %v, want %v", event.Value, 3)
+	}
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+
+	"github.com/klauspost/compress/s2"
+)
+
+type Compression int
+
+const (
+	NoCompression Compression = iota
+	Gzip
+	Snappy
+)
+
+var ErrCorruptValue = errors.New("cache: corrupt stored value")
+
+// WithCompression compresses encoded values of at least minSize bytes with
+// algorithm before they are stored; smaller values are stored as they are.
+// Values are encoded with the WithCodec codec, or GobCodec if none is set.
+func WithCompression(algorithm Compression, minSize int) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.compression = algorithm
+		cache.compressMinSize = minSize
+		if cache.codec == nil {
+			cache.codec = GobCodec{}
+		}
+	}
+}
+
+// compress prefixes data with the algorithm it was compressed with, which is
+// NoCompression below the size threshold. Without WithCompression data is
+// returned as is.
+func (c *inMemoryCache) compress(data []byte) ([]byte, error) {
+	if c.compression == NoCompression {
+		return data, nil
+	}
+
+	algorithm := c.compression
+	if len(data) < c.compressMinSize {
+		algorithm = NoCompression
+	}
+
+	switch algorithm {
+	case Gzip:
+		var buffer bytes.Buffer
+		buffer.WriteByte(byte(Gzip))
+		writer := gzip.NewWriter(&buffer)
+		if _, err := writer.Write(data); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	case Snappy:
+		return append([]byte{byte(Snappy)}, s2.EncodeSnappy(nil, data)...), nil
+	default:
+		return append([]byte{byte(NoCompression)}, data...), nil
+	}
+}
+
+func (c *inMemoryCache) decompress(data []byte) ([]byte, error) {
+	if c.compression == NoCompression {
+		return data, nil
+	}
+	if len(data) == 0 {
+		return nil, ErrCorruptValue
+	}
+
+	switch Compression(data[0]) {
+	case NoCompression:
+		return data[1:], nil
+	case Gzip:
+		reader, err := gzip.NewReader(bytes.NewReader(data[1:]))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(reader)
+	case Snappy:
+		return s2.Decode(nil, data[1:])
+	default:
+		return nil, ErrCorruptValue
+	}
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithCompression(t *testing.T) {
+	large := strings.Repeat("compressible ", 100)
+	tests := []struct {
+		name      string
+		algorithm Compression
+		value     string
+		wantFlag  Compression
+	}{
+		{name: "Gzip", algorithm: Gzip, value: large, wantFlag: Gzip},
+		{name: "Snappy", algorithm: Snappy, value: large, wantFlag: Snappy},
+		{name: "Below the threshold", algorithm: Gzip, value: "small", wantFlag: NoCompression},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			WithCompression(tt.algorithm, 64)(cache)
+
+			cache.Set("test", tt.value, time.Minute)
+			if got, found := cache.Get("test"); !found || got != tt.value {
+				t.Errorf("Get() = %v, %v, want the stored value", got, found)
+			}
+
+			stored, _ := cache.storage.Load("test")
+			data := stored.value.([]byte)
+			if Compression(data[0]) != tt.wantFlag {
+				t.Errorf("stored value compressed with %d, want %d", data[0], tt.wantFlag)
+			}
+			if tt.wantFlag != NoCompression && len(data) >= len(tt.value) {
+				t.Errorf("stored %d bytes for a %d byte value", len(data), len(tt.value))
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_decompress(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithCompression(Snappy, 0)(cache)
+
+	for _, data := range [][]byte{nil, {42}, {byte(Snappy), 0xff}} {
+		if _, err := cache.decompress(data); err == nil {
+			t.Errorf("decompress(%v) returned no error", data)
+		}
+	}
+}
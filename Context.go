@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("cache: key not found")
+
+// CacheCtx is a Cache whose calls take a context and report failures, for
+// backends that may time out or fail. Get returns ErrNotFound on a miss.
+type CacheCtx interface {
+	Get(ctx context.Context, key string) (interface{}, error)
+	Set(ctx context.Context, key string, value interface{}, expiredInterval time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// ToCacheCtx adapts c to CacheCtx. Calls fail with the context error once ctx
+// is done and otherwise run to completion; Set reports the errors of c's
+// TrySet when c is a TrySetter.
+func ToCacheCtx(c Cache) CacheCtx {
+	if adapter, ok := c.(*ctxlessCache); ok {
+		return adapter.next
+	}
+
+	return &ctxCache{next: c}
+}
+
+// FromCacheCtx adapts c to Cache. Calls run with context.Background. Failed
+// Gets read as misses, failed writes are dropped, and both are passed to
+// onError if it is not nil; misses are not failures.
+func FromCacheCtx(c CacheCtx, onError func(operation, key string, err error)) Cache {
+	if adapter, ok := c.(*ctxCache); ok && onError == nil {
+		return adapter.next
+	}
+
+	return &ctxlessCache{next: c, onError: onError}
+}
+
+type ctxCache struct {
+	next Cache
+}
+
+func (c *ctxCache) Get(ctx context.Context, key string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	value, found := c.next.Get(key)
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	return value, nil
+}
+
+func (c *ctxCache) Set(ctx context.Context, key string, value interface{}, expiredInterval time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if setter, ok := c.next.(TrySetter); ok {
+		return setter.TrySet(key, value, expiredInterval)
+	}
+	c.next.Set(key, value, expiredInterval)
+
+	return nil
+}
+
+func (c *ctxCache) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.next.Delete(key)
+
+	return nil
+}
+
+type ctxlessCache struct {
+	next    CacheCtx
+	onError func(operation, key string, err error)
+}
+
+func (c *ctxlessCache) Get(key string) (interface{}, bool) {
+	value, err := c.next.Get(context.Background(), key)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			c.report("get", key, err)
+		}
+		return nil, false
+	}
+
+	return value, true
+}
+
+func (c *ctxlessCache) Set(key string, value interface{}, expiredInterval time.Duration) {
+	c.report("set", key, c.next.Set(context.Background(), key, value, expiredInterval))
+}
+
+func (c *ctxlessCache) Delete(key string) {
+	c.report("delete", key, c.next.Delete(context.Background(), key))
+}
+
+func (c *ctxlessCache) report(operation, key string, err error) {
+	if err != nil && c.onError != nil {
+		c.onError(operation, key, err)
+	}
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestToCacheCtx(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		options   []func(*inMemoryCache)
+		key       string
+		wantSet   error
+		wantGet   error
+		wantValue interface{}
+	}{
+		{
+			name:      "Set and Get",
+			ctx:       context.Background(),
+			key:       "test",
+			wantValue: 42,
+		},
+		{
+			name:    "Rejected write",
+			ctx:     context.Background(),
+			options: []func(*inMemoryCache){WithKeyValidator(NonEmptyKey)},
+			wantSet: ErrInvalidKey,
+			wantGet: ErrNotFound,
+		},
+		{
+			name:    "Done context",
+			ctx:     canceled,
+			key:     "test",
+			wantSet: context.Canceled,
+			wantGet: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &inMemoryCache{}
+			for _, optionFn := range tt.options {
+				optionFn(c)
+			}
+			ctxCache := ToCacheCtx(c)
+
+			if err := ctxCache.Set(tt.ctx, tt.key, 42, time.Minute); !errors.Is(err, tt.wantSet) {
+				t.Errorf("Set() error = %v, want %v", err, tt.wantSet)
+			}
+			value, err := ctxCache.Get(tt.ctx, tt.key)
+			if !errors.Is(err, tt.wantGet) || value != tt.wantValue {
+				t.Errorf("Get() = %v, %v, want %v, %v", value, err, tt.wantValue, tt.wantGet)
+			}
+		})
+	}
+}
+
+func TestToCacheCtx_Delete(t *testing.T) {
+	ctxCache := ToCacheCtx(&inMemoryCache{})
+	ctxCache.Set(context.Background(), "test", 42, time.Minute)
+
+	if err := ctxCache.Delete(context.Background(), "test"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := ctxCache.Get(context.Background(), "test"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+type failingCacheCtx struct {
+	err error
+}
+
+func (f failingCacheCtx) Get(ctx context.Context, key string) (interface{}, error) {
+	return nil, f.err
+}
+
+func (f failingCacheCtx) Set(ctx context.Context, key string, value interface{}, expiredInterval time.Duration) error {
+	return f.err
+}
+
+func (f failingCacheCtx) Delete(ctx context.Context, key string) error {
+	return f.err
+}
+
+func TestFromCacheCtx(t *testing.T) {
+	errBackend := errors.New("backend down")
+	tests := []struct {
+		name       string
+		err        error
+		wantErrors []string
+	}{
+		{
+			name:       "Failures are reported",
+			err:        errBackend,
+			wantErrors: []string{"set", "get", "delete"},
+		},
+		{
+			name: "Misses are not failures",
+			err:  ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported []string
+			c := FromCacheCtx(failingCacheCtx{err: tt.err}, func(operation, key string, err error) {
+				if err != tt.err || key != "test" {
+					t.Errorf("onError(%q, %q, %v), want key %q and error %v", operation, key, err, "test", tt.err)
+				}
+				if !errors.Is(err, ErrNotFound) {
+					reported = append(reported, operation)
+				}
+			})
+
+			c.Set("test", 42, time.Minute)
+			if _, found := c.Get("test"); found {
+				t.Errorf("Get() found a value of a failing cache")
+			}
+			c.Delete("test")
+
+			if len(reported) != len(tt.wantErrors) {
+				t.Fatalf("reported %v, want %v", reported, tt.wantErrors)
+			}
+			for i := range reported {
+				if reported[i] != tt.wantErrors[i] {
+					t.Errorf("reported %v, want %v", reported, tt.wantErrors)
+				}
+			}
+		})
+	}
+}
+
+func TestFromCacheCtx_RoundTrip(t *testing.T) {
+	c := &inMemoryCache{}
+	if got := FromCacheCtx(ToCacheCtx(c), nil); got != Cache(c) {
+		t.Errorf("FromCacheCtx(ToCacheCtx(c)) = %T, want c itself", got)
+	}
+
+	ctxCache := failingCacheCtx{err: ErrNotFound}
+	if got := ToCacheCtx(FromCacheCtx(ctxCache, nil)); got != CacheCtx(ctxCache) {
+		t.Errorf("ToCacheCtx(FromCacheCtx(c)) = %T, want c itself", got)
+	}
+}
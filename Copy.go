@@ -0,0 +1,96 @@
+package cache
+
+import "reflect"
+
+// WithValueCopying stores a copy of every value written and hands out a copy
+// on every read, so that callers cannot mutate values shared through the
+// cache. Watchers get a copy in every event and Snapshot a copy of every
+// value as well. A nil copier uses DeepCopy. Values passed to eviction
+// callbacks are not copied.
+func WithValueCopying(copier func(value interface{}) interface{}) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		if copier == nil {
+			copier = DeepCopy
+		}
+		cache.copier = copier
+	}
+}
+
+func (c *inMemoryCache) copyValue(value interface{}) interface{} {
+	if c.copier == nil || value == nil {
+		return value
+	}
+
+	return c.copier(value)
+}
+
+// DeepCopy returns a copy of value sharing no memory with it through slices,
+// maps, pointers and exported struct fields. Map keys, unexported fields,
+// channels and functions are copied shallowly. Pointers shared within value
+// stay shared in the copy.
+func DeepCopy(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	return deepCopy(reflect.ValueOf(value), make(map[uintptr]reflect.Value)).Interface()
+}
+
+func deepCopy(v reflect.Value, copies map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		if copied, found := copies[v.Pointer()]; found {
+			return copied
+		}
+		copied := reflect.New(v.Type().Elem())
+		copies[v.Pointer()] = copied
+		copied.Elem().Set(deepCopy(v.Elem(), copies))
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(deepCopy(v.Elem(), copies))
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopy(v.Index(i), copies))
+		}
+		return copied
+	case reflect.Array:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopy(v.Index(i), copies))
+		}
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), deepCopy(iter.Value(), copies))
+		}
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if field := copied.Field(i); field.CanSet() {
+				field.Set(deepCopy(v.Field(i), copies))
+			}
+		}
+		return copied
+	}
+
+	return v
+}
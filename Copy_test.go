@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDeepCopy(t *testing.T) {
+	type node struct {
+		Name     string
+		Tags     []string
+		Children map[string]*node
+		Next     *node
+	}
+	leaf := &node{Name: "leaf", Tags: []string{"a"}}
+	root := &node{Name: "root", Tags: []string{"b", "c"}, Children: map[string]*node{"leaf": leaf}, Next: leaf}
+
+	tests := []struct {
+		name   string
+		value  interface{}
+		mutate func(value interface{})
+	}{
+		{
+			name:   "Slice",
+			value:  []int{1, 2, 3},
+			mutate: func(value interface{}) { value.([]int)[0] = 42 },
+		},
+		{
+			name:   "Map",
+			value:  map[string][]byte{"test": []byte("abc")},
+			mutate: func(value interface{}) { value.(map[string][]byte)["test"][0] = 'z' },
+		},
+		{
+			name:   "Pointer graph",
+			value:  root,
+			mutate: func(value interface{}) { value.(*node).Children["leaf"].Tags[0] = "z" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			copied := DeepCopy(tt.value)
+			if !reflect.DeepEqual(copied, tt.value) {
+				t.Fatalf("DeepCopy() = %v, want %v", copied, tt.value)
+			}
+			tt.mutate(copied)
+			if reflect.DeepEqual(copied, tt.value) {
+				t.Errorf("mutating the copy changed the original")
+			}
+		})
+	}
+
+	copied := DeepCopy(root).(*node)
+	if copied.Next != copied.Children["leaf"] {
+		t.Errorf("DeepCopy() did not keep a shared pointer shared")
+	}
+}
+
+func TestWithValueCopying(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithValueCopying(nil)(cache)
+
+	value := []int{1, 2, 3}
+	cache.Set("test", value, time.Minute)
+	value[0] = 42
+
+	got, _ := cache.Get("test")
+	if got.([]int)[0] != 1 {
+		t.Errorf("Get() = %v, want the value as it was stored", got)
+	}
+	got.([]int)[1] = 42
+
+	again, _ := cache.Get("test")
+	if !reflect.DeepEqual(again, []int{1, 2, 3}) {
+		t.Errorf("Get() = %v after mutating a previous result, want %v", again, []int{1, 2, 3})
+	}
+
+	calls := 0
+	WithValueCopying(func(value interface{}) interface{} {
+		calls++
+		return value
+	})(cache)
+	cache.Set("test", value, time.Minute)
+	cache.Get("test")
+	if calls != 2 {
+		t.Errorf("copier called %d times, want %d", calls, 2)
+	}
+}
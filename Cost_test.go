@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithMaxCost(t *testing.T) {
+	type item struct {
+		key  string
+		cost int64
+	}
+	tests := []struct {
+		name      string
+		maxCost   int64
+		items     []item
+		want      []string
+		notWant   []string
+		totalCost int64
+	}{
+		{
+			name:      "Items fit into the budget",
+			maxCost:   10,
+			items:     []item{{"test1", 4}, {"test2", 6}},
+			want:      []string{"test1", "test2"},
+			totalCost: 10,
+		},
+		{
+			name:      "Evict least recently used items until the new one fits",
+			maxCost:   10,
+			items:     []item{{"test1", 4}, {"test2", 4}, {"test3", 6}},
+			want:      []string{"test2", "test3"},
+			notWant:   []string{"test1"},
+			totalCost: 10,
+		},
+		{
+			name:      "Item exceeding the budget is not cached",
+			maxCost:   10,
+			items:     []item{{"test1", 4}, {"test2", 11}},
+			want:      []string{"test1"},
+			notWant:   []string{"test2"},
+			totalCost: 4,
+		},
+		{
+			name:      "Overwriting an item replaces its cost",
+			maxCost:   10,
+			items:     []item{{"test1", 4}, {"test2", 4}, {"test1", 6}},
+			want:      []string{"test1", "test2"},
+			totalCost: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			WithMaxCost(tt.maxCost)(cache)
+
+			for _, item := range tt.items {
+				cache.SetWithCost(item.key, 42, time.Second*10, item.cost)
+			}
+
+			for _, key := range tt.want {
+				if _, ok := cache.Get(key); !ok {
+					t.Errorf("Get() expected key %s to be cached", key)
+				}
+			}
+			for _, key := range tt.notWant {
+				if _, ok := cache.Get(key); ok {
+					t.Errorf("Get() expected key %s not to be cached", key)
+				}
+			}
+			if cache.totalCost != tt.totalCost {
+				t.Errorf("totalCost = %d, want %d", cache.totalCost, tt.totalCost)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_DeleteWithMaxCost(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithMaxCost(10)(cache)
+
+	cache.SetWithCost("test1", 1, time.Second*10, 7)
+	cache.Delete("test1")
+	cache.Set("test2", 2, time.Second*10)
+
+	if cache.totalCost != defaultItemCost {
+		t.Errorf("totalCost = %d, want %d", cache.totalCost, defaultItemCost)
+	}
+}
@@ -0,0 +1,40 @@
+package cache
+
+import "time"
+
+// Deduper suppresses duplicate keys, such as webhook or event IDs.
+type Deduper interface {
+	// Seen records key for window and reports whether it was already
+	// recorded and its window has not passed. A duplicate does not extend
+	// the window of the first sighting.
+	Seen(key string, window time.Duration) bool
+}
+
+type deduper struct {
+	cache Cache
+}
+
+// NewDeduper returns a Deduper recording keys in c. Keys are stored as given,
+// so a cache shared with other data should be given through a Namespace.
+// Concurrent sightings of a key report exactly one first sighting when c is a
+// ConditionalSetter or GetOrSetter, like the in-memory cache; otherwise a key
+// seen by two callers at once may pass both.
+func NewDeduper(c Cache) Deduper {
+	return &deduper{cache: c}
+}
+
+func (d *deduper) Seen(key string, window time.Duration) bool {
+	switch c := d.cache.(type) {
+	case ConditionalSetter:
+		return !c.SetIfAbsent(key, true, window)
+	case GetOrSetter:
+		_, loaded := c.GetOrSet(key, true, window)
+		return loaded
+	default:
+		if _, found := d.cache.Get(key); found {
+			return true
+		}
+		d.cache.Set(key, true, window)
+		return false
+	}
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeduper_Seen(t *testing.T) {
+	caches := []struct {
+		name     string
+		newCache func(c *inMemoryCache) Cache
+	}{
+		{name: "ConditionalSetter", newCache: func(c *inMemoryCache) Cache { return c }},
+		{name: "GetOrSetter", newCache: func(c *inMemoryCache) Cache {
+			return struct {
+				Cache
+				GetOrSetter
+			}{c, c}
+		}},
+		{name: "Cache", newCache: func(c *inMemoryCache) Cache { return struct{ Cache }{c} }},
+	}
+	for _, cc := range caches {
+		t.Run(cc.name, func(t *testing.T) {
+			clock := newFakeClock()
+			c := &inMemoryCache{}
+			WithClock(clock)(c)
+			deduper := NewDeduper(cc.newCache(c))
+			window := time.Millisecond * 50
+
+			if deduper.Seen("event-1", window) {
+				t.Errorf("Seen() of a new key = true, want false")
+			}
+			if !deduper.Seen("event-1", window) {
+				t.Errorf("Seen() of a duplicate = false, want true")
+			}
+			if deduper.Seen("event-2", window) {
+				t.Errorf("Seen() of another key = true, want false")
+			}
+
+			clock.Advance(window + time.Millisecond)
+			if deduper.Seen("event-1", window) {
+				t.Errorf("Seen() after the window = true, want false")
+			}
+		})
+	}
+}
+
+func TestDeduper_Concurrent(t *testing.T) {
+	deduper := NewDeduper(&inMemoryCache{})
+
+	var first atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !deduper.Seen("event", time.Minute) {
+				first.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := first.Load(); got != 1 {
+		t.Errorf("%d callers saw the key first, want 1", got)
+	}
+}
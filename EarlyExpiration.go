@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// WithEarlyExpiration enables probabilistic early expiration ("XFetch"): Get
+// reports a live entry as missing with a probability that grows as it
+// approaches its expiration and with the time its loader took, so a single
+// caller refreshes it before it expires while the others keep the cached
+// value. beta scales how early refreshes happen, 1 being the usual choice.
+// Only entries stored by GetOrLoad carry a loader duration; other entries
+// expire as usual.
+func WithEarlyExpiration(beta float64) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.earlyExpirationBeta = beta
+	}
+}
+
+// expiresEarly implements the check of "Optimal Probabilistic Cache Stampede
+// Prevention": now - delta * beta * ln(rand) >= expiry.
+func (c *inMemoryCache) expiresEarly(item *cacheItem, now time.Time) bool {
+	if c.earlyExpirationBeta <= 0 || item.delta <= 0 || item.validThrough.IsZero() {
+		return false
+	}
+
+	gap := float64(item.delta) * c.earlyExpirationBeta * -math.Log(1-rand.Float64())
+
+	return !now.Add(time.Duration(gap)).Before(item.validThrough)
+}
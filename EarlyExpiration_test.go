@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithEarlyExpiration(t *testing.T) {
+	tests := []struct {
+		name       string
+		beta       float64
+		delta      time.Duration
+		remaining  time.Duration
+		wantAlways bool
+		wantNever  bool
+	}{
+		{
+			name:       "Slow loader close to expiration",
+			beta:       1,
+			delta:      time.Hour,
+			remaining:  time.Millisecond,
+			wantAlways: true,
+		},
+		{
+			name:      "Fast loader far from expiration",
+			beta:      1,
+			delta:     time.Nanosecond,
+			remaining: time.Hour,
+			wantNever: true,
+		},
+		{
+			name:      "Disabled",
+			delta:     time.Hour,
+			remaining: time.Millisecond,
+			wantNever: true,
+		},
+		{
+			name:      "Entry without loader duration",
+			beta:      1,
+			remaining: time.Millisecond,
+			wantNever: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			WithEarlyExpiration(tt.beta)(cache)
+
+			now := time.Now()
+			item := &cacheItem{value: 42, validThrough: now.Add(tt.remaining), delta: tt.delta}
+			early := 0
+			for i := 0; i < 100; i++ {
+				if cache.expiresEarly(item, now) {
+					early++
+				}
+			}
+			if tt.wantAlways && early < 95 {
+				t.Errorf("expiresEarly() = true %d times out of 100, want nearly always", early)
+			}
+			if tt.wantNever && early > 0 {
+				t.Errorf("expiresEarly() = true %d times out of 100, want never", early)
+			}
+		})
+	}
+}
+
+func TestWithEarlyExpiration_GetOrLoad(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithEarlyExpiration(1e6)(cache)
+
+	loads := 0
+	loader := func(ctx context.Context) (interface{}, error) {
+		loads++
+		time.Sleep(time.Millisecond * 5)
+		return loads, nil
+	}
+	if _, err := cache.GetOrLoad(context.Background(), "test", time.Second, loader); err != nil {
+		t.Fatalf("GetOrLoad() error: %v", err)
+	}
+	if _, err := cache.GetOrLoad(context.Background(), "test", time.Second, loader); err != nil {
+		t.Fatalf("GetOrLoad() error: %v", err)
+	}
+	if loads != 2 {
+		t.Errorf("loader ran %d times, want a refresh before the entry expired", loads)
+	}
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/gob"
+	"io"
+	"time"
+)
+
+// sealedValue carries an encrypted stored value through Save, snapshots and
+// the journal, so that those files never hold the plaintext either.
+type sealedValue struct {
+	Data []byte
+}
+
+func init() {
+	gob.Register(sealedValue{})
+}
+
+// WithEncryption encrypts encoded values with AES-GCM under key before they
+// are stored, after any WithCompression. Values are encoded with the
+// WithCodec codec, or GobCodec if none is set. Save, snapshots and the
+// journal write the encrypted values, which can only be loaded by a cache
+// using the same key. key must be 16, 24 or 32 bytes long to select
+// AES-128, AES-192 or AES-256; WithEncryption panics otherwise.
+func WithEncryption(key []byte) func(*inMemoryCache) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic("cache: encryption key must be 16, 24 or 32 bytes long")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic("cache: " + err.Error())
+	}
+
+	return func(cache *inMemoryCache) {
+		cache.aead = aead
+		if cache.codec == nil {
+			cache.codec = GobCodec{}
+		}
+	}
+}
+
+// seal prefixes the ciphertext of data with its random nonce.
+func (c *inMemoryCache) seal(data []byte) ([]byte, error) {
+	if c.aead == nil {
+		return data, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(data)+c.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return c.aead.Seal(nonce, nonce, data, nil), nil
+}
+
+func (c *inMemoryCache) open(data []byte) ([]byte, error) {
+	if c.aead == nil {
+		return data, nil
+	}
+	if len(data) < c.aead.NonceSize() {
+		return nil, ErrCorruptValue
+	}
+
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+
+	return c.aead.Open(nil, nonce, ciphertext, nil)
+}
+
+// savedValue returns the value of item as written by Save and the journal.
+func (c *inMemoryCache) savedValue(item *cacheItem) interface{} {
+	if c.aead == nil {
+		return c.decoded(item)
+	}
+
+	data, _ := item.value.([]byte)
+
+	return sealedValue{Data: data}
+}
+
+// restore stores a value read back by Load or the journal replay for ttl.
+// Encrypted values are skipped by caches without WithEncryption.
+func (c *inMemoryCache) restore(key string, value interface{}, ttl time.Duration) {
+	sealed, ok := value.(sealedValue)
+	if !ok {
+		c.Set(key, value, ttl)
+		return
+	}
+	if c.aead == nil {
+		return
+	}
+
+	item := &cacheItem{value: sealed.Data, size: c.sizeOf(sealed.Data), createdAt: c.now()}
+	c.setExpiration(item, ttl)
+	c.set(key, item, defaultItemCost)
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWithEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	secret := "4111-1111-1111-1111"
+
+	cache := &inMemoryCache{}
+	WithCompression(Gzip, 0)(cache)
+	WithEncryption(key)(cache)
+	cache.Set("card", secret, time.Minute)
+
+	if got, found := cache.Get("card"); !found || got != secret {
+		t.Errorf("Get() = %v, %v, want %v, true", got, found, secret)
+	}
+	stored, _ := cache.storage.Load("card")
+	if bytes.Contains(stored.value.([]byte), []byte(secret)) {
+		t.Errorf("stored value contains the plaintext")
+	}
+
+	var saved bytes.Buffer
+	if err := cache.Save(&saved); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if bytes.Contains(saved.Bytes(), []byte(secret)) {
+		t.Errorf("Save() wrote the plaintext")
+	}
+
+	target := &inMemoryCache{}
+	WithCompression(Gzip, 0)(target)
+	WithEncryption(key)(target)
+	if err := target.Load(bytes.NewReader(saved.Bytes())); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got, found := target.Get("card"); !found || got != secret {
+		t.Errorf("Get() after Load() = %v, %v, want %v, true", got, found, secret)
+	}
+
+	plain := &inMemoryCache{}
+	if err := plain.Load(bytes.NewReader(saved.Bytes())); err != nil {
+		t.Fatalf("Load() without a key error = %v", err)
+	}
+	if _, found := plain.Get("card"); found {
+		t.Errorf("Load() without a key restored an encrypted value")
+	}
+}
+
+func TestWithEncryption_InvalidKey(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("WithEncryption() accepted a 10 byte key")
+		}
+	}()
+	WithEncryption(make([]byte, 10))
+}
+
+func Test_inMemoryCache_open(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithEncryption(make([]byte, 16))(cache)
+
+	sealed, err := cache.seal([]byte("value"))
+	if err != nil {
+		t.Fatalf("seal() error = %v", err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	for _, data := range [][]byte{nil, sealed} {
+		if _, err := cache.open(data); err == nil {
+			t.Errorf("open(%v) returned no error", data)
+		}
+	}
+}
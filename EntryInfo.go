@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// EntryInfo describes a cache entry.
+type EntryInfo struct {
+	// CreatedAt is when the value was stored. Updates in place, such as
+	// Increment, and sliding reads keep it.
+	CreatedAt time.Time
+	// LastAccessedAt is the time of the latest hit, zero if there was none.
+	LastAccessedAt time.Time
+	// Hits counts the reads that returned the value.
+	Hits uint64
+	// ExpiresAt is zero for entries without expiration.
+	ExpiresAt time.Time
+}
+
+type EntryInspector interface {
+	GetWithInfo(key string) (interface{}, EntryInfo, bool)
+}
+
+// entryAccess counts the hits of an item. Its counters are atomic, so items
+// are copied with clone, which carries the counts over to the copy.
+type entryAccess struct {
+	hits           atomic.Uint64
+	lastAccessedAt atomic.Int64
+}
+
+// GetWithInfo reads key like Get and also returns the metadata of the entry,
+// counting this read.
+func (c *inMemoryCache) GetWithInfo(key string) (interface{}, EntryInfo, bool) {
+	item, found := c.get(key)
+	if !found {
+		return nil, EntryInfo{}, false
+	}
+
+	return c.valueOf(item), item.info(), true
+}
+
+func (i *cacheItem) recordHit(now time.Time) {
+	i.access.hits.Add(1)
+	i.access.lastAccessedAt.Store(now.UnixNano())
+}
+
+func (i *cacheItem) info() EntryInfo {
+	info := EntryInfo{CreatedAt: i.createdAt, Hits: i.access.hits.Load(), ExpiresAt: i.validThrough}
+	if lastAccessedAt := i.access.lastAccessedAt.Load(); lastAccessedAt != 0 {
+		info.LastAccessedAt = time.Unix(0, lastAccessedAt)
+	}
+
+	return info
+}
+
+// clone returns a copy of i for an update of its entry. Hits counted on i
+// after the copy are not carried over.
+func (i *cacheItem) clone() *cacheItem {
+	clone := &cacheItem{
+		validThrough: i.validThrough,
+		value:        i.value,
+		tags:         i.tags,
+		interval:     i.interval,
+		sliding:      i.sliding,
+		delta:        i.delta,
+		size:         i.size,
+		negative:     i.negative,
+		createdAt:    i.createdAt,
+	}
+	clone.access.hits.Store(i.access.hits.Load())
+	clone.access.lastAccessedAt.Store(i.access.lastAccessedAt.Load())
+
+	return clone
+}
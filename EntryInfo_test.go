@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_GetWithInfo(t *testing.T) {
+	clock := newFakeClock()
+	c := &inMemoryCache{}
+	WithClock(clock)(c)
+	created := clock.Now()
+
+	if _, _, found := c.GetWithInfo("test"); found {
+		t.Fatalf("GetWithInfo() of a missing key found an entry")
+	}
+
+	c.Set("test", 1, time.Minute)
+	clock.Advance(time.Second)
+	c.Get("test")
+	clock.Advance(time.Second)
+	value, info, found := c.GetWithInfo("test")
+	want := EntryInfo{
+		CreatedAt:      created,
+		LastAccessedAt: created.Add(time.Second * 2),
+		Hits:           2,
+		ExpiresAt:      created.Add(time.Minute),
+	}
+	if !found || value != 1 || !info.CreatedAt.Equal(want.CreatedAt) || !info.LastAccessedAt.Equal(want.LastAccessedAt) ||
+		info.Hits != want.Hits || !info.ExpiresAt.Equal(want.ExpiresAt) {
+		t.Errorf("GetWithInfo() = %v, %+v, %v, want 1, %+v, true", value, info, found, want)
+	}
+
+	// Updates in place keep the metadata, a new value resets it.
+	c.Increment("test", 1, time.Minute)
+	if _, info, _ := c.GetWithInfo("test"); info.Hits != 3 || !info.CreatedAt.Equal(created) {
+		t.Errorf("GetWithInfo() after Increment() = %+v, want 3 hits since %v", info, created)
+	}
+	clock.Advance(time.Second)
+	c.Set("test", 1, NoExpiration)
+	if _, info, _ := c.GetWithInfo("test"); info.Hits != 1 || !info.CreatedAt.Equal(created.Add(time.Second*3)) || !info.ExpiresAt.IsZero() {
+		t.Errorf("GetWithInfo() after Set() = %+v, want 1 hit, a new CreatedAt and no expiration", info)
+	}
+}
+
+func Test_inMemoryCache_GetWithInfoSliding(t *testing.T) {
+	clock := newFakeClock()
+	c := &inMemoryCache{}
+	WithClock(clock)(c)
+
+	c.SetSliding("test", 1, time.Minute)
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Second * 30)
+		c.Get("test")
+	}
+	if _, info, _ := c.GetWithInfo("test"); info.Hits != 4 || !info.ExpiresAt.Equal(clock.Now().Add(time.Minute)) {
+		t.Errorf("GetWithInfo() of a sliding entry = %+v, want 4 hits and expiry a minute from now", info)
+	}
+}
@@ -0,0 +1,146 @@
+package cache
+
+import "time"
+
+type EvictionReason int
+
+const (
+	Expired EvictionReason = iota
+	Deleted
+	Replaced
+	CapacityEvicted
+)
+
+type ExpiredItem struct {
+	Key       string
+	Value     interface{}
+	ExpiredAt time.Time
+}
+
+type ExpirationNotifier interface {
+	Expirations() <-chan ExpiredItem
+}
+
+type evictionNotice struct {
+	key    string
+	value  interface{}
+	reason EvictionReason
+}
+
+func (r EvictionReason) String() string {
+	switch r {
+	case Expired:
+		return "expired"
+	case Deleted:
+		return "deleted"
+	case Replaced:
+		return "replaced"
+	case CapacityEvicted:
+		return "capacity_evicted"
+	default:
+		return "unknown"
+	}
+}
+
+// WithOnEvicted registers fn to be called whenever an entry leaves the cache.
+// It is never invoked while internal locks are held, so it may use the cache.
+func WithOnEvicted(fn func(key string, value interface{}, reason EvictionReason)) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.onEvicted = fn
+	}
+}
+
+// WithExpirations enables the channel returned by Expirations. Items removed
+// by cleanup are sent without blocking, so they are dropped while the buffer
+// is full. The channel is closed when the context of the cache is done; items
+// expired by CleanupNow after that are not sent.
+func WithExpirations(bufferSize int) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.expirations = make(chan ExpiredItem, bufferSize)
+	}
+}
+
+// Expirations returns nil unless the cache was created WithExpirations.
+func (c *inMemoryCache) Expirations() <-chan ExpiredItem {
+	return c.expirations
+}
+
+func (c *inMemoryCache) publishExpiration(key string, item *cacheItem) {
+	if c.expirations == nil {
+		return
+	}
+	c.expirationsMu.RLock()
+	defer c.expirationsMu.RUnlock()
+
+	if c.expirationsClosed {
+		return
+	}
+	select {
+	case c.expirations <- ExpiredItem{Key: key, Value: c.decoded(item), ExpiredAt: item.validThrough}:
+	default:
+	}
+}
+
+func (c *inMemoryCache) closeExpirations() {
+	if c.expirations == nil {
+		return
+	}
+	c.expirationsMu.Lock()
+	defer c.expirationsMu.Unlock()
+
+	c.expirationsClosed = true
+	close(c.expirations)
+}
+
+// Mutations hold c.mu exactly when an evictor is configured, so notices are
+// queued until unlock in that case. Otherwise they are appended to notices if
+// it is not nil, for callers holding other locks that deliver them once those
+// are released, and delivered immediately if it is.
+func (c *inMemoryCache) notifyEvicted(key string, item *cacheItem, reason EvictionReason, notices *[]evictionNotice) {
+	switch reason {
+	case Expired:
+		c.publishEvent(EventExpire, key, item)
+	case Deleted, CapacityEvicted:
+		c.publishEvent(EventDelete, key, item)
+	}
+
+	if c.onEvicted == nil {
+		return
+	}
+	notice := evictionNotice{key: key, value: c.decoded(item), reason: reason}
+	switch {
+	case c.evictor != nil:
+		c.pendingNotices = append(c.pendingNotices, notice)
+	case notices != nil:
+		*notices = append(*notices, notice)
+	default:
+		c.onEvicted(key, notice.value, reason)
+	}
+}
+
+func (c *inMemoryCache) unlock() {
+	notices := c.pendingNotices
+	c.pendingNotices = nil
+	c.mu.Unlock()
+
+	c.deliver(notices)
+}
+
+// unlockInto is unlock for callers holding other locks: the queued notices
+// are appended to notices, if it is not nil, for the caller to deliver.
+func (c *inMemoryCache) unlockInto(notices *[]evictionNotice) {
+	if notices == nil {
+		c.unlock()
+		return
+	}
+
+	*notices = append(*notices, c.pendingNotices...)
+	c.pendingNotices = nil
+	c.mu.Unlock()
+}
+
+func (c *inMemoryCache) deliver(notices []evictionNotice) {
+	for _, notice := range notices {
+		c.onEvicted(notice.key, notice.value, notice.reason)
+	}
+}
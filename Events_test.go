@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordedEviction struct {
+	key    string
+	value  interface{}
+	reason EvictionReason
+}
+
+type evictionRecorder struct {
+	mu        sync.Mutex
+	evictions []recordedEviction
+}
+
+func (r *evictionRecorder) record(key string, value interface{}, reason EvictionReason) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.evictions = append(r.evictions, recordedEviction{key: key, value: value, reason: reason})
+}
+
+func TestWithOnEvicted(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(cache *inMemoryCache)
+		run     func(cache *inMemoryCache)
+		want    []recordedEviction
+	}{
+		{
+			name: "Deleted",
+			run: func(cache *inMemoryCache) {
+				cache.Set("test", 1, time.Second*10)
+				cache.Delete("test")
+				cache.Delete("missing")
+			},
+			want: []recordedEviction{{"test", 1, Deleted}},
+		},
+		{
+			name: "Replaced",
+			run: func(cache *inMemoryCache) {
+				cache.Set("test", 1, time.Second*10)
+				cache.Set("test", 2, time.Second*10)
+			},
+			want: []recordedEviction{{"test", 1, Replaced}},
+		},
+		{
+			name: "Capacity evicted",
+			options: []func(cache *inMemoryCache){
+				WithMaxEntries(1),
+			},
+			run: func(cache *inMemoryCache) {
+				cache.Set("test1", 1, time.Second*10)
+				cache.Set("test2", 2, time.Second*10)
+			},
+			want: []recordedEviction{{"test1", 1, CapacityEvicted}},
+		},
+		{
+			name: "Expired",
+			run: func(cache *inMemoryCache) {
+				cache.Set("test", 1, time.Nanosecond)
+				cache.cleanUpTicker = systemClock{}.NewTicker(time.Millisecond * 5)
+				ctx, cancelFn := context.WithTimeout(context.Background(), time.Millisecond*12)
+				defer cancelFn()
+				cache.cleanUpCache(ctx)
+			},
+			want: []recordedEviction{{"test", 1, Expired}},
+		},
+		{
+			name: "Callback may use the cache",
+			options: []func(cache *inMemoryCache){
+				WithMaxEntries(1),
+			},
+			run: func(cache *inMemoryCache) {
+				onEvicted := cache.onEvicted
+				cache.onEvicted = func(key string, value interface{}, reason EvictionReason) {
+					cache.Get(key)
+					onEvicted(key, value, reason)
+				}
+				cache.Set("test1", 1, time.Second*10)
+				cache.Delete("test1")
+			},
+			want: []recordedEviction{{"test1", 1, Deleted}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &evictionRecorder{}
+			cache := &inMemoryCache{}
+			WithOnEvicted(recorder.record)(cache)
+			for _, optionFn := range tt.options {
+				optionFn(cache)
+			}
+
+			tt.run(cache)
+
+			if !reflect.DeepEqual(recorder.evictions, tt.want) {
+				t.Errorf("OnEvicted calls = %v, want %v", recorder.evictions, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithExpirations(t *testing.T) {
+	tests := []struct {
+		name       string
+		bufferSize int
+		keys       []string
+		wantCount  int
+	}{
+		{
+			name:       "Expired items are published",
+			bufferSize: 10,
+			keys:       []string{"test1", "test2"},
+			wantCount:  2,
+		},
+		{
+			name:       "Items are dropped when the buffer is full",
+			bufferSize: 1,
+			keys:       []string{"test1", "test2"},
+			wantCount:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{cleanUpTicker: systemClock{}.NewTicker(time.Millisecond * 5)}
+			WithExpirations(tt.bufferSize)(cache)
+			for _, key := range tt.keys {
+				cache.Set(key, 42, time.Nanosecond)
+			}
+			cache.Set("alive", 42, time.Second*10)
+
+			ctx, cancelFn := context.WithTimeout(context.Background(), time.Millisecond*12)
+			defer cancelFn()
+			cache.cleanUpCache(ctx)
+
+			var got []string
+			for item := range cache.Expirations() {
+				if item.Value != 42 {
+					t.Errorf("Expirations() item value = %v, want %v", item.Value, 42)
+				}
+				got = append(got, item.Key)
+			}
+			if len(got) != tt.wantCount {
+				t.Errorf("Expirations() received %v, want %d items", got, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestWithExpirations_CleanupNowAfterCancel(t *testing.T) {
+	clock := newFakeClock()
+	ctx, cancelFn := context.WithCancel(context.Background())
+	cache := NewInMemoryCache(ctx, WithExpirations(4), WithoutBackgroundCleanup(), WithClock(clock)).(*inMemoryCache)
+	cache.Set("test", 42, time.Second)
+
+	cancelFn()
+	for range cache.Expirations() {
+	}
+	clock.Advance(time.Second * 2)
+
+	if deleted := cache.CleanupNow(); deleted != 1 {
+		t.Errorf("CleanupNow() after cancel = %d, want 1", deleted)
+	}
+}
+
+func Test_inMemoryCache_ExpirationsDisabled(t *testing.T) {
+	cache := &inMemoryCache{}
+	if cache.Expirations() != nil {
+		t.Errorf("Expirations() expected nil channel when disabled")
+	}
+}
@@ -0,0 +1,131 @@
+package cache
+
+import "log/slog"
+
+type EvictionPolicy int
+
+const (
+	LRU EvictionPolicy = iota
+	LFU
+	ARC
+)
+
+type AdmissionPolicy int
+
+const (
+	AdmitAll AdmissionPolicy = iota
+	TinyLFU
+)
+
+type evictor interface {
+	add(key string) (evicted []string)
+	touch(key string)
+	remove(key string)
+	contains(key string) bool
+	victim() (string, bool)
+	evict() (string, bool)
+	len() int
+	clear()
+}
+
+func WithMaxEntries(maxEntries int) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		if maxEntries <= 0 {
+			return
+		}
+		cache.maxEntries = maxEntries
+		cache.configureEviction()
+	}
+}
+
+func WithMaxCost(maxCost int64) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		if maxCost <= 0 {
+			return
+		}
+		cache.maxCost = maxCost
+		cache.costs = make(map[string]int64)
+		cache.configureEviction()
+	}
+}
+
+// WithMaxMemory bounds the EstimatedSize of the cache: writes evict entries
+// chosen by the eviction policy until the new entry fits, and entries larger
+// than maxBytes on their own are not cached. Sizes are estimates, so the
+// process may still use more memory than maxBytes.
+func WithMaxMemory(maxBytes int64) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		if maxBytes <= 0 {
+			return
+		}
+		cache.maxMemory = maxBytes
+		cache.configureEviction()
+	}
+}
+
+func WithEvictionPolicy(policy EvictionPolicy) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.evictionPolicy = policy
+		cache.configureEviction()
+	}
+}
+
+func WithAdmissionPolicy(policy AdmissionPolicy) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.admissionPolicy = policy
+		cache.configureEviction()
+	}
+}
+
+func (c *inMemoryCache) configureEviction() {
+	if c.maxEntries <= 0 && c.maxCost <= 0 && c.maxMemory <= 0 {
+		return
+	}
+
+	c.evictor = newEvictor(c.evictionPolicy, c.maxEntries)
+	c.admission = nil
+	if c.admissionPolicy == TinyLFU {
+		c.admission = newTinyLFU(c.maxEntries)
+	}
+}
+
+func (c *inMemoryCache) needsEviction(key string, item *cacheItem, cost int64) bool {
+	if c.maxEntries > 0 && !c.evictor.contains(key) && c.evictor.len() >= c.maxEntries {
+		return true
+	}
+	if c.exceedsMemory(key, item) {
+		return true
+	}
+
+	return c.maxCost > 0 && c.totalCost-c.costs[key]+cost > c.maxCost
+}
+
+func (c *inMemoryCache) evictLocked(key string) {
+	if c.deleteItem(key, CapacityEvicted) {
+		c.stats.evicted.Add(1)
+		c.log(slog.LevelDebug, "cache eviction", "key", key)
+	}
+	c.removeCost(key)
+}
+
+func (c *inMemoryCache) removeCost(key string) {
+	if c.costs == nil {
+		return
+	}
+
+	c.totalCost -= c.costs[key]
+	delete(c.costs, key)
+}
+
+// ARC sizes its ghost lists relative to the entry capacity, so a cache
+// bounded only by cost or memory falls back to LRU.
+func newEvictor(policy EvictionPolicy, maxEntries int) evictor {
+	switch {
+	case policy == LFU:
+		return newLFUList(maxEntries)
+	case policy == ARC && maxEntries > 0:
+		return newARCList(maxEntries)
+	default:
+		return newLRUList(maxEntries)
+	}
+}
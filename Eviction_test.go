@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithEvictionPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(cache *inMemoryCache)
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "LRU evicts least recently used",
+			options: []func(cache *inMemoryCache){
+				WithMaxEntries(2),
+				WithEvictionPolicy(LRU),
+			},
+			want:    []string{"test2", "test3"},
+			notWant: []string{"test1"},
+		},
+		{
+			name: "LFU evicts least frequently used",
+			options: []func(cache *inMemoryCache){
+				WithEvictionPolicy(LFU),
+				WithMaxEntries(2),
+			},
+			want:    []string{"test1", "test3"},
+			notWant: []string{"test2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			for _, optionFn := range tt.options {
+				optionFn(cache)
+			}
+
+			cache.Set("test1", 1, time.Second*10)
+			cache.Get("test1")
+			cache.Get("test1")
+			cache.Set("test2", 2, time.Second*10)
+			cache.Get("test2")
+			cache.Set("test3", 3, time.Second*10)
+
+			for _, key := range tt.want {
+				if _, ok := cache.Get(key); !ok {
+					t.Errorf("Get() expected key %s to be cached", key)
+				}
+			}
+			for _, key := range tt.notWant {
+				if _, ok := cache.Get(key); ok {
+					t.Errorf("Get() expected key %s to be evicted", key)
+				}
+			}
+		})
+	}
+}
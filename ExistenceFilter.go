@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"hash/maphash"
+	"math"
+	"sync/atomic"
+)
+
+// bloomFilter records keys in a Bloom filter whose bits are set atomically,
+// so adds and lookups need no lock. It answers false only for keys that were
+// never added.
+type bloomFilter struct {
+	bits   []atomic.Uint64
+	size   uint64
+	hashes uint64
+	seeds  [2]maphash.Seed
+}
+
+// newBloomFilter sizes a filter to hold expectedKeys keys with the given
+// false positive rate.
+func newBloomFilter(expectedKeys int, falsePositiveRate float64) *bloomFilter {
+	n := math.Max(float64(expectedKeys), 1)
+	p := math.Min(math.Max(falsePositiveRate, 1e-9), 0.5)
+	size := uint64(math.Ceil(-n * math.Log(p) / (math.Ln2 * math.Ln2)))
+	hashes := uint64(math.Max(math.Round(float64(size)/n*math.Ln2), 1))
+
+	return &bloomFilter{
+		bits:   make([]atomic.Uint64, (size+63)/64),
+		size:   size,
+		hashes: hashes,
+		seeds:  [2]maphash.Seed{maphash.MakeSeed(), maphash.MakeSeed()},
+	}
+}
+
+// WithExistenceFilter records every key stored in the cache in a Bloom filter
+// sized for expectedKeys keys at falsePositiveRate, and answers reads of keys
+// that were never stored from the filter alone, without a map lookup. It
+// pays off when most reads miss. Deleted and expired keys stay in the filter,
+// so once far more than expectedKeys distinct keys were stored it lets most
+// misses through to the map. It must be passed before any option that loads
+// entries, such as WithSnapshot or WithJournal.
+func WithExistenceFilter(expectedKeys int, falsePositiveRate float64) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.storage.filter = newBloomFilter(expectedKeys, falsePositiveRate)
+	}
+}
+
+// add records key. It is a no-op on a nil filter.
+func (f *bloomFilter) add(key string) {
+	if f == nil {
+		return
+	}
+
+	h1, h2 := f.hash(key)
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % f.size
+		word, mask := &f.bits[bit/64], uint64(1)<<(bit%64)
+		for {
+			old := word.Load()
+			if old&mask != 0 || word.CompareAndSwap(old, old|mask) {
+				break
+			}
+		}
+	}
+}
+
+// clear forgets every key. It is a no-op on a nil filter.
+func (f *bloomFilter) clear() {
+	if f == nil {
+		return
+	}
+
+	for i := range f.bits {
+		f.bits[i].Store(0)
+	}
+}
+
+// mayContain reports false if key was never added.
+func (f *bloomFilter) mayContain(key string) bool {
+	h1, h2 := f.hash(key)
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % f.size
+		if f.bits[bit/64].Load()&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *bloomFilter) hash(key string) (uint64, uint64) {
+	// An odd second hash visits distinct bits for every power-of-two size.
+	return maphash.String(f.seeds[0], key), maphash.String(f.seeds[1], key) | 1
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_bloomFilter(t *testing.T) {
+	filter := newBloomFilter(10000, 0.01)
+	for i := 0; i < 10000; i++ {
+		filter.add("key-" + strconv.Itoa(i))
+	}
+
+	for i := 0; i < 10000; i++ {
+		if !filter.mayContain("key-" + strconv.Itoa(i)) {
+			t.Fatalf("mayContain() of added key-%d = false", i)
+		}
+	}
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if filter.mayContain("other-" + strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 200 {
+		t.Errorf("%d false positives in 10000 lookups, want about 100", falsePositives)
+	}
+}
+
+func TestWithExistenceFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*inMemoryCache)
+	}{
+		{
+			name:    "Default storage",
+			options: []func(*inMemoryCache){WithExistenceFilter(100, 0.01)},
+		},
+		{
+			name:    "Sharded storage",
+			options: []func(*inMemoryCache){WithShardedStorage(4), WithExistenceFilter(100, 0.01)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			c := NewInMemoryCache(ctx, tt.options...).(*inMemoryCache)
+
+			c.Set("set", 1, time.Minute)
+			c.GetOrSet("getOrSet", 2, time.Minute)
+			if _, err := c.Increment("counter", 3, time.Minute); err != nil {
+				t.Fatalf("Increment() error: %v", err)
+			}
+			for key, want := range map[string]interface{}{"set": 1, "getOrSet": 2, "counter": int64(3)} {
+				if value, found := c.Get(key); !found || value != want {
+					t.Errorf("Get(%q) = %v, %v, want %v", key, value, found, want)
+				}
+			}
+
+			if _, found := c.Get("missing"); found {
+				t.Errorf("Get() of a key never set found a value")
+			}
+			c.Delete("set")
+			if _, found := c.Get("set"); found {
+				t.Errorf("Get() of a deleted key found a value")
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// expirationQueue orders keys by the time their entry becomes removable, so
+// a cleanup pass only visits entries that are due instead of scanning the
+// whole storage. It holds one entry per key and is updated lazily: deleting
+// or replacing an entry without expiration leaves the key queued, and cleanup
+// checks the current entry before deleting anything.
+type expirationQueue struct {
+	mu      sync.Mutex
+	entries map[string]*expirationEntry
+	heap    expirationHeap
+}
+
+type expirationEntry struct {
+	key   string
+	at    time.Time
+	index int
+}
+
+type expirationHeap []*expirationEntry
+
+func (h expirationHeap) Len() int { return len(h) }
+
+func (h expirationHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+
+func (h expirationHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expirationHeap) Push(x interface{}) {
+	entry := x.(*expirationEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *expirationHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return entry
+}
+
+// schedule queues key at, replacing its previous time.
+func (q *expirationQueue) schedule(key string, at time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if entry, found := q.entries[key]; found {
+		entry.at = at
+		heap.Fix(&q.heap, entry.index)
+		return
+	}
+
+	if q.entries == nil {
+		q.entries = make(map[string]*expirationEntry)
+	}
+	entry := &expirationEntry{key: key, at: at}
+	q.entries[key] = entry
+	heap.Push(&q.heap, entry)
+}
+
+// due removes and returns up to limit keys queued at or before now, or all of
+// them for a non-positive limit.
+func (q *expirationQueue) due(now time.Time, limit int) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var keys []string
+	for len(q.heap) > 0 && !q.heap[0].at.After(now) && (limit <= 0 || len(keys) < limit) {
+		entry := heap.Pop(&q.heap).(*expirationEntry)
+		delete(q.entries, entry.key)
+		keys = append(keys, entry.key)
+	}
+
+	return keys
+}
+
+// hasDue reports whether a key is queued at or before now.
+func (q *expirationQueue) hasDue(now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.heap) > 0 && !q.heap[0].at.After(now)
+}
+
+func (q *expirationQueue) clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.entries = nil
+	q.heap = nil
+}
+
+func (q *expirationQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.heap)
+}
+
+// scheduleExpiration queues an item that was just stored under key.
+func (c *inMemoryCache) scheduleExpiration(key string, item *cacheItem) {
+	if item.validThrough.IsZero() {
+		return
+	}
+
+	at := item.validThrough
+	if c.staleLoader != nil && item.interval > 0 {
+		at = at.Add(c.staleGrace)
+	}
+	c.expiryQueue.schedule(key, at)
+}
+
+// requeue queues keys taken from the queue but not processed again.
+func (c *inMemoryCache) requeue(keys []string) {
+	for _, key := range keys {
+		if item, found := c.storage.Load(key); found {
+			c.scheduleExpiration(key, item)
+		}
+	}
+}
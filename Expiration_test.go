@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_expirationQueue(t *testing.T) {
+	now := time.Now()
+	queue := &expirationQueue{}
+	queue.schedule("late", now.Add(time.Minute))
+	queue.schedule("first", now.Add(-time.Second*2))
+	queue.schedule("second", now.Add(-time.Second))
+	queue.schedule("moved", now.Add(-time.Second*3))
+	queue.schedule("moved", now.Add(time.Hour))
+
+	if got, want := queue.due(now, 0), []string{"first", "second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("due() = %v, want %v", got, want)
+	}
+	if got := queue.due(now, 0); len(got) != 0 {
+		t.Errorf("second due() = %v, want no keys", got)
+	}
+	if got := queue.len(); got != 2 {
+		t.Errorf("len() = %d, want %d", got, 2)
+	}
+}
+
+func Test_inMemoryCache_CleanupNow_Queue(t *testing.T) {
+	tests := []struct {
+		name        string
+		prepare     func(cache *inMemoryCache)
+		wantDeleted int
+		wantQueued  int
+	}{
+		{
+			name: "Expired entries are deleted",
+			prepare: func(cache *inMemoryCache) {
+				cache.Set("test1", 1, time.Nanosecond)
+				cache.Set("test2", 2, time.Nanosecond)
+				cache.Set("live", 3, time.Minute)
+			},
+			wantDeleted: 2,
+			wantQueued:  1,
+		},
+		{
+			name: "Entries without expiration are not queued",
+			prepare: func(cache *inMemoryCache) {
+				cache.Set("test", 1, NoExpiration)
+			},
+		},
+		{
+			name: "Replaced entry keeps its new expiration",
+			prepare: func(cache *inMemoryCache) {
+				cache.Set("test", 1, time.Nanosecond)
+				cache.Set("test", 2, time.Minute)
+			},
+			wantQueued: 1,
+		},
+		{
+			name: "Entry replaced without expiration is dropped from the queue",
+			prepare: func(cache *inMemoryCache) {
+				cache.Set("test", 1, time.Nanosecond)
+				cache.Set("test", 2, NoExpiration)
+			},
+		},
+		{
+			name: "Deleted entry is dropped from the queue",
+			prepare: func(cache *inMemoryCache) {
+				cache.Set("test", 1, time.Nanosecond)
+				cache.Delete("test")
+			},
+		},
+		{
+			name: "Slid entry is queued again",
+			prepare: func(cache *inMemoryCache) {
+				cache.SetSliding("test", 1, time.Millisecond*50)
+				storageValue, _ := cache.storage.Load("test")
+				cache.expiryQueue.schedule("test", storageValue.validThrough.Add(-time.Hour))
+			},
+			wantQueued: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			clock := newFakeClock()
+			WithClock(clock)(cache)
+			tt.prepare(cache)
+			clock.Advance(time.Millisecond)
+
+			if deleted := cache.CleanupNow(); deleted != tt.wantDeleted {
+				t.Errorf("CleanupNow() = %d, want %d", deleted, tt.wantDeleted)
+			}
+			if queued := cache.expiryQueue.len(); queued != tt.wantQueued {
+				t.Errorf("queued keys after CleanupNow() = %d, want %d", queued, tt.wantQueued)
+			}
+		})
+	}
+}
+
+func TestWithCleanUpBatchSize(t *testing.T) {
+	cache := &inMemoryCache{}
+	clock := newFakeClock()
+	WithClock(clock)(cache)
+	WithCleanUpBatchSize(3)(cache)
+	for _, key := range []string{"test1", "test2", "test3", "test4", "test5"} {
+		cache.Set(key, 1, time.Nanosecond)
+	}
+	clock.Advance(time.Millisecond)
+
+	for _, want := range []int{3, 2, 0} {
+		if deleted := cache.CleanupNow(); deleted != want {
+			t.Errorf("CleanupNow() = %d, want %d", deleted, want)
+		}
+	}
+}
+
+func TestWithMaxCleanUpDuration(t *testing.T) {
+	cache := &inMemoryCache{}
+	clock := newFakeClock()
+	WithClock(clock)(cache)
+	WithMaxCleanUpDuration(time.Nanosecond)(cache)
+	for _, key := range []string{"test1", "test2", "test3"} {
+		cache.Set(key, 1, time.Nanosecond)
+	}
+	clock.Advance(time.Millisecond)
+
+	total := 0
+	for i := 0; i < 10 && total < 3; i++ {
+		deleted := cache.CleanupNow()
+		if deleted > 1 {
+			t.Fatalf("CleanupNow() = %d, want the pass to stop after its time limit", deleted)
+		}
+		total += deleted
+	}
+	if total != 3 {
+		t.Errorf("CleanupNow() deleted %d entries over several passes, want %d", total, 3)
+	}
+}
+
+func Test_inMemoryCache_nextCleanUpInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  time.Duration
+		deleted  int
+		backlog  bool
+		expected time.Duration
+	}{
+		{name: "Nothing expired", current: time.Second * 4, expected: time.Second * 8},
+		{name: "Nothing expired at the maximum", current: time.Second * 10, expected: time.Second * 10},
+		{name: "Backlog left", current: time.Second * 4, deleted: 100, backlog: true, expected: time.Second * 2},
+		{name: "Backlog left at the minimum", current: time.Second, deleted: 100, backlog: true, expected: time.Second},
+		{name: "Entries deleted without backlog", current: time.Second * 4, deleted: 5, expected: time.Second * 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{cleanUpTicker: systemClock{}.NewTicker(time.Minute)}
+			defer cache.cleanUpTicker.Stop()
+			WithAdaptiveCleanUp(time.Second, time.Second*10)(cache)
+			cache.cleanUpInterval = tt.current
+
+			if got := cache.nextCleanUpInterval(tt.deleted, tt.backlog); got != tt.expected {
+				t.Errorf("nextCleanUpInterval() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWithAdaptiveCleanUp(t *testing.T) {
+	clock := newFakeClock()
+	clock.resets = make(chan time.Duration, 1)
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	cache := NewInMemoryCache(ctx,
+		WithAdaptiveCleanUp(time.Second, time.Second*8),
+		WithClock(clock),
+	).(*inMemoryCache)
+
+	interval := time.Second
+	pass := func(want time.Duration) {
+		t.Helper()
+		clock.Advance(interval)
+		select {
+		case interval = <-clock.resets:
+			if interval != want {
+				t.Fatalf("cleanup interval after pass = %v, want %v", interval, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("cleanup pass didn't run")
+		}
+	}
+	for _, want := range []time.Duration{time.Second * 2, time.Second * 4, time.Second * 8, time.Second * 8} {
+		pass(want)
+	}
+
+	cache.Set("test", 1, time.Nanosecond)
+	pass(time.Second * 8)
+	if _, found := cache.storage.Load("test"); found {
+		t.Errorf("expired entry still stored after an adaptive pass")
+	}
+}
@@ -0,0 +1,13 @@
+package cache
+
+import "expvar"
+
+// WithExpvar publishes the cache Stats under name in expvar. Like
+// expvar.Publish, it panics if the name is already in use.
+func WithExpvar(name string) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		expvar.Publish(name, expvar.Func(func() interface{} {
+			return cache.Stats()
+		}))
+	}
+}
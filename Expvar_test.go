@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+)
+
+func TestWithExpvar(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithExpvar("test_cache")(cache)
+
+	cache.Set("test", 42, time.Second*10)
+	cache.Get("test")
+	cache.Get("missing")
+
+	published := expvar.Get("test_cache")
+	if published == nil {
+		t.Fatalf("WithExpvar() didn't publish stats")
+	}
+
+	var got Stats
+	if err := json.Unmarshal([]byte(published.String()), &got); err != nil {
+		t.Fatalf("published stats are not valid JSON: %v", err)
+	}
+	want := Stats{Hits: 1, Misses: 1, Sets: 1, Entries: 1}
+	if got != want {
+		t.Errorf("published stats = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abicur/go-sim-cache/internal/glob"
+)
+
+const keysPath = "/keys/"
+
+type httpHandler struct {
+	cache Cache
+}
+
+// NewHTTPHandler exposes c for operators:
+//
+//	GET    /keys?pattern=user:*   lists keys matching a Redis-style glob
+//	GET    /keys/{key}            returns the value as JSON
+//	PUT    /keys/{key}?ttl=30s    stores the JSON request body, without
+//	                              expiration if ttl is omitted
+//	DELETE /keys/{key}
+//	GET    /stats                 when c is a StatsProvider
+//	POST   /flush                 removes every entry when c is a Clearer
+//
+// Keys are path-unescaped, so keys containing "/" or "?" must be escaped by
+// the client. The handler has no authentication of its own and should only be
+// mounted behind one.
+func NewHTTPHandler(c Cache) http.Handler {
+	return &httpHandler{cache: c}
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch escapedPath := r.URL.EscapedPath(); {
+	case escapedPath == "/keys" || escapedPath == keysPath:
+		h.serveKeys(w, r)
+	case strings.HasPrefix(escapedPath, keysPath):
+		key, err := url.PathUnescape(strings.TrimPrefix(escapedPath, keysPath))
+		if err != nil || key == "" {
+			http.Error(w, "invalid key", http.StatusBadRequest)
+			return
+		}
+		h.serveKey(w, r, key)
+	case escapedPath == "/stats":
+		h.serveStats(w, r)
+	case escapedPath == "/flush":
+		h.serveFlush(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *httpHandler) serveKey(w http.ResponseWriter, r *http.Request, key string) {
+	switch r.Method {
+	case http.MethodGet:
+		value, found := h.cache.Get(key)
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, value)
+	case http.MethodPut:
+		ttl := NoExpiration
+		if rawTTL := r.URL.Query().Get("ttl"); rawTTL != "" {
+			var err error
+			if ttl, err = time.ParseDuration(rawTTL); err != nil {
+				http.Error(w, "invalid ttl: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		var value interface{}
+		if err := json.NewDecoder(r.Body).Decode(&value); err != nil {
+			http.Error(w, "invalid value: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.cache.Set(key, value, ttl)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		h.cache.Delete(key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *httpHandler) serveKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	lister, ok := h.cache.(KeyLister)
+	if !ok {
+		http.Error(w, "listing keys is not supported by this cache", http.StatusNotImplemented)
+		return
+	}
+
+	pattern := r.URL.Query().Get("pattern")
+	if pattern == "" {
+		pattern = "*"
+	}
+	keys, err := matchKeys(lister.Keys(), pattern)
+	if err != nil {
+		http.Error(w, "invalid pattern: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, keys)
+}
+
+func (h *httpHandler) serveStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	provider, ok := h.cache.(StatsProvider)
+	if !ok {
+		http.Error(w, "stats are not supported by this cache", http.StatusNotImplemented)
+		return
+	}
+
+	writeJSON(w, provider.Stats())
+}
+
+func (h *httpHandler) serveFlush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	clearer, ok := h.cache.(Clearer)
+	if !ok {
+		http.Error(w, "flush is not supported by this cache", http.StatusNotImplemented)
+		return
+	}
+
+	clearer.Clear()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, "value is not JSON encodable: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// matchKeys returns the sorted keys matching pattern.
+func matchKeys(keys []string, pattern string) ([]string, error) {
+	if err := glob.Validate(pattern); err != nil {
+		return nil, err
+	}
+
+	matched := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if ok, _ := glob.Match(pattern, key); ok {
+			matched = append(matched, key)
+		}
+	}
+	sort.Strings(matched)
+
+	return matched, nil
+}
@@ -0,0 +1,223 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		cache      Cache
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "Get existing key",
+			method:     http.MethodGet,
+			path:       "/keys/test",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"name":"value"}`,
+		},
+		{
+			name:       "Get escaped key",
+			method:     http.MethodGet,
+			path:       "/keys/a%2Fb",
+			wantStatus: http.StatusOK,
+			wantBody:   `42`,
+		},
+		{
+			name:       "Get missing key",
+			method:     http.MethodGet,
+			path:       "/keys/missing",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Put key",
+			method:     http.MethodPut,
+			path:       "/keys/new?ttl=1m",
+			body:       `[1,2]`,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "Put without ttl",
+			method:     http.MethodPut,
+			path:       "/keys/new",
+			body:       `1`,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "Put invalid ttl",
+			method:     http.MethodPut,
+			path:       "/keys/new?ttl=soon",
+			body:       `1`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Put invalid JSON",
+			method:     http.MethodPut,
+			path:       "/keys/new?ttl=1m",
+			body:       `{`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Delete key",
+			method:     http.MethodDelete,
+			path:       "/keys/test",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "Unsupported key method",
+			method:     http.MethodPost,
+			path:       "/keys/test",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "List keys unsupported",
+			cache:      &singleKeyCache{},
+			method:     http.MethodGet,
+			path:       "/keys",
+			wantStatus: http.StatusNotImplemented,
+		},
+		{
+			name:       "Stats",
+			method:     http.MethodGet,
+			path:       "/stats",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Stats unsupported",
+			cache:      &singleKeyCache{},
+			method:     http.MethodGet,
+			path:       "/stats",
+			wantStatus: http.StatusNotImplemented,
+		},
+		{
+			name:       "Flush",
+			method:     http.MethodPost,
+			path:       "/flush",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "Unknown path",
+			method:     http.MethodGet,
+			path:       "/other",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.cache
+			if c == nil {
+				c = &inMemoryCache{}
+				c.Set("test", map[string]string{"name": "value"}, time.Minute)
+				c.Set("a/b", 42, time.Minute)
+			}
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			NewHTTPHandler(c).ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if tt.wantBody != "" && recorder.Body.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", recorder.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestNewHTTPHandler_PutThenFlush(t *testing.T) {
+	c := &inMemoryCache{}
+	handler := NewHTTPHandler(c)
+
+	request := httptest.NewRequest(http.MethodPut, "/keys/test?ttl=1m", strings.NewReader(`"value"`))
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	if value, found := c.Get("test"); !found || value != "value" {
+		t.Fatalf("Get() after PUT = %v, %v, want %q, true", value, found, "value")
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/flush", nil))
+	if _, found := c.Get("test"); found {
+		t.Errorf("Get() found a key after flush")
+	}
+	if entries := c.Stats().Entries; entries != 0 {
+		t.Errorf("Stats().Entries after flush = %d, want 0", entries)
+	}
+}
+
+func TestNewHTTPHandler_FlushNamespace(t *testing.T) {
+	c := &inMemoryCache{}
+	users := c.Namespace("users")
+	users.Set("test", 1, time.Minute)
+	c.Set("test", 2, time.Minute)
+
+	recorder := httptest.NewRecorder()
+	NewHTTPHandler(users).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/flush", nil))
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusNoContent)
+	}
+	if _, found := users.Get("test"); found {
+		t.Errorf("Get() found a namespaced key after flush")
+	}
+	if value, _ := c.Get("test"); value != 2 {
+		t.Errorf("flush of a namespace changed a root key to %v", value)
+	}
+}
+
+func TestNewHTTPHandler_Keys(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "All keys",
+			path:       "/keys",
+			wantStatus: http.StatusOK,
+			wantBody:   `["session:1","user:1","user:1/profile"]`,
+		},
+		{
+			name:       "Matching keys",
+			path:       "/keys/?pattern=user:*",
+			wantStatus: http.StatusOK,
+			wantBody:   `["user:1","user:1/profile"]`,
+		},
+		{
+			name:       "No matches",
+			path:       "/keys?pattern=none*",
+			wantStatus: http.StatusOK,
+			wantBody:   `[]`,
+		},
+		{
+			name:       "Invalid pattern",
+			path:       "/keys?pattern=user[",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &inMemoryCache{}
+			for _, key := range []string{"user:1", "user:1/profile", "session:1"} {
+				c.Set(key, 1, time.Minute)
+			}
+
+			recorder := httptest.NewRecorder()
+			NewHTTPHandler(c).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if tt.wantBody != "" && recorder.Body.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", recorder.Body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// KeyCount is a key with its estimated number of hits.
+type KeyCount struct {
+	Key  string
+	Hits uint64
+}
+
+type HotKeyTracker interface {
+	TopKeys(n int) []KeyCount
+}
+
+// hotKeys estimates the most hit keys with the Space-Saving algorithm over
+// sampled hits: it counts at most capacity keys, and a key hit when all slots
+// are taken replaces the least hit key and inherits its count. Counts of keys
+// that stay in the top are exact up to sampling, others are overestimated.
+type hotKeys struct {
+	sampleEvery uint64
+	hits        atomic.Uint64
+	capacity    int
+	mu          sync.Mutex
+	counters    hotKeyHeap
+	index       map[string]*hotKeyCounter
+}
+
+type hotKeyCounter struct {
+	key   string
+	count uint64
+	pos   int
+}
+
+// hotKeyHeap is a min-heap of counters by count.
+type hotKeyHeap []*hotKeyCounter
+
+func (h hotKeyHeap) Len() int           { return len(h) }
+func (h hotKeyHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h hotKeyHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos, h[j].pos = i, j
+}
+func (h *hotKeyHeap) Push(x interface{}) {
+	counter := x.(*hotKeyCounter)
+	counter.pos = len(*h)
+	*h = append(*h, counter)
+}
+func (h *hotKeyHeap) Pop() interface{} {
+	old := *h
+	counter := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return counter
+}
+
+// WithHotKeyTracking counts one in every sampleEvery hits by key, keeping
+// counts for up to capacity keys, so that TopKeys can report the most hit
+// keys. Sampling keeps the cost on the read path to an atomic increment for
+// unsampled hits.
+func WithHotKeyTracking(capacity, sampleEvery int) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		if sampleEvery < 1 {
+			sampleEvery = 1
+		}
+		cache.hotKeys = &hotKeys{
+			sampleEvery: uint64(sampleEvery),
+			capacity:    capacity,
+			index:       make(map[string]*hotKeyCounter, capacity),
+		}
+	}
+}
+
+// TopKeys returns up to n keys with the most hits since the cache was
+// created, most hit first, with their estimated hits. Keys may have been
+// deleted since. It returns nil without WithHotKeyTracking.
+func (c *inMemoryCache) TopKeys(n int) []KeyCount {
+	if c.hotKeys == nil {
+		return nil
+	}
+
+	return c.hotKeys.top(n)
+}
+
+func (h *hotKeys) hit(key string) {
+	if h == nil || h.hits.Add(1)%h.sampleEvery != 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if counter, found := h.index[key]; found {
+		counter.count++
+		heap.Fix(&h.counters, counter.pos)
+		return
+	}
+	if len(h.counters) < h.capacity {
+		counter := &hotKeyCounter{key: key, count: 1}
+		heap.Push(&h.counters, counter)
+		h.index[key] = counter
+		return
+	}
+	if len(h.counters) == 0 {
+		return
+	}
+
+	least := h.counters[0]
+	delete(h.index, least.key)
+	least.key = key
+	least.count++
+	h.index[key] = least
+	heap.Fix(&h.counters, 0)
+}
+
+// clear forgets every counted key. It is a no-op without hot key tracking.
+func (h *hotKeys) clear() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.counters = nil
+	h.index = make(map[string]*hotKeyCounter, h.capacity)
+}
+
+func (h *hotKeys) top(n int) []KeyCount {
+	h.mu.Lock()
+	counts := make([]KeyCount, len(h.counters))
+	for i, counter := range h.counters {
+		counts[i] = KeyCount{Key: counter.key, Hits: counter.count * h.sampleEvery}
+	}
+	h.mu.Unlock()
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Hits != counts[j].Hits {
+			return counts[i].Hits > counts[j].Hits
+		}
+		return counts[i].Key < counts[j].Key
+	})
+	if n < len(counts) {
+		counts = counts[:max(n, 0)]
+	}
+
+	return counts
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_TopKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		capacity    int
+		sampleEvery int
+		hits        map[string]int
+		n           int
+		want        []KeyCount
+	}{
+		{
+			name:        "Every hit counted",
+			capacity:    10,
+			sampleEvery: 1,
+			hits:        map[string]int{"a": 5, "b": 9, "c": 1},
+			n:           2,
+			want:        []KeyCount{{Key: "b", Hits: 9}, {Key: "a", Hits: 5}},
+		},
+		{
+			name:        "Fewer keys than n",
+			capacity:    10,
+			sampleEvery: 1,
+			hits:        map[string]int{"a": 2},
+			n:           5,
+			want:        []KeyCount{{Key: "a", Hits: 2}},
+		},
+		{
+			name:        "Sampled hits are scaled",
+			capacity:    10,
+			sampleEvery: 4,
+			hits:        map[string]int{"a": 40},
+			n:           1,
+			want:        []KeyCount{{Key: "a", Hits: 40}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &inMemoryCache{}
+			WithHotKeyTracking(tt.capacity, tt.sampleEvery)(c)
+			for key, hits := range tt.hits {
+				c.Set(key, 1, time.Minute)
+				for i := 0; i < hits; i++ {
+					c.Get(key)
+				}
+			}
+			c.Get("missing")
+
+			if got := c.TopKeys(tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TopKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_TopKeysCapacity(t *testing.T) {
+	c := &inMemoryCache{}
+	WithHotKeyTracking(4, 1)(c)
+	for i := 0; i < 100; i++ {
+		c.Set(strconv.Itoa(i), i, time.Minute)
+	}
+	c.Set("hot", 1, time.Minute)
+	c.Set("warm", 1, time.Minute)
+
+	// Two hot keys among a long tail of keys hit once.
+	for round := 0; round < 20; round++ {
+		c.Get("hot")
+		c.Get("warm")
+		c.Get("hot")
+		for i := 0; i < 5; i++ {
+			c.Get(strconv.Itoa(round*5 + i))
+		}
+	}
+	for i := 0; i < 40; i++ {
+		c.Get("hot")
+		if i%2 == 0 {
+			c.Get("warm")
+		}
+	}
+
+	top := c.TopKeys(2)
+	if len(top) != 2 || top[0].Key != "hot" || top[1].Key != "warm" {
+		t.Errorf("TopKeys() = %v, want hot then warm", top)
+	}
+}
+
+func Test_inMemoryCache_TopKeysConcurrent(t *testing.T) {
+	c := &inMemoryCache{}
+	WithHotKeyTracking(8, 1)(c)
+	c.Set("key", 1, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Get("key")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := c.TopKeys(1); len(got) != 1 || got[0].Hits != 800 {
+		t.Errorf("TopKeys() = %v, want key with 800 hits", got)
+	}
+}
+
+func Test_inMemoryCache_TopKeysWithoutTracking(t *testing.T) {
+	c := &inMemoryCache{}
+	c.Set("key", 1, time.Minute)
+	c.Get("key")
+
+	if got := c.TopKeys(1); got != nil {
+		t.Errorf("TopKeys() without tracking = %v, want nil", got)
+	}
+}
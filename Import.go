@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ItemSpec describes an entry imported into or exported from a cache.
+type ItemSpec struct {
+	Value interface{}
+	// TTL is the lifetime of the entry. Zero or NoExpiration means it does
+	// not expire.
+	TTL time.Duration
+}
+
+type ImportExporter interface {
+	Import(items map[string]ItemSpec) error
+	Export() map[string]ItemSpec
+}
+
+// Import stores every item as Set would and returns the errors of the items
+// that were dropped, joined, or nil.
+func (c *inMemoryCache) Import(items map[string]ItemSpec) error {
+	var errs []error
+	for key, spec := range items {
+		if err := c.set(key, c.newCacheItem(spec.Value, spec.TTL), defaultItemCost); err != nil {
+			errs = append(errs, fmt.Errorf("cache: import %q: %w", key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Export returns a copy of every live entry with its remaining lifetime, or
+// NoExpiration, so that Import on another cache recreates them. Like Snapshot
+// it does not block writers.
+func (c *inMemoryCache) Export() map[string]ItemSpec {
+	entries := c.Snapshot()
+	items := make(map[string]ItemSpec, len(entries))
+	for key, entry := range entries {
+		items[key] = ItemSpec{Value: entry.Value, TTL: entry.TTL}
+	}
+
+	return items
+}
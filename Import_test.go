@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_Import(t *testing.T) {
+	clock := newFakeClock()
+	c := &inMemoryCache{}
+	WithClock(clock)(c)
+	WithKeyValidator(NonEmptyKey)(c)
+
+	err := c.Import(map[string]ItemSpec{
+		"short":   {Value: 1, TTL: time.Minute},
+		"forever": {Value: 2},
+		"":        {Value: 3, TTL: time.Minute},
+	})
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Import() error = %v, want %v", err, ErrInvalidKey)
+	}
+	if ttl, found := c.TTL("short"); !found || ttl != time.Minute {
+		t.Errorf("TTL(short) = %v, %v, want %v, true", ttl, found, time.Minute)
+	}
+
+	clock.Advance(time.Hour)
+	if _, found := c.Get("short"); found {
+		t.Errorf("Get(short) found the entry after its TTL")
+	}
+	if value, found := c.Get("forever"); !found || value != 2 {
+		t.Errorf("Get(forever) = %v, %v, want %v, true", value, found, 2)
+	}
+}
+
+func Test_inMemoryCache_Export(t *testing.T) {
+	clock := newFakeClock()
+	c := &inMemoryCache{}
+	WithClock(clock)(c)
+
+	c.Set("short", 1, time.Minute)
+	c.Set("forever", 2, NoExpiration)
+	c.Set("expired", 3, time.Second)
+	clock.Advance(time.Second * 2)
+
+	items := c.Export()
+	want := map[string]ItemSpec{
+		"short":   {Value: 1, TTL: time.Second * 58},
+		"forever": {Value: 2, TTL: NoExpiration},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("Export() = %v, want %v", items, want)
+	}
+	for key, spec := range want {
+		if items[key] != spec {
+			t.Errorf("Export()[%s] = %+v, want %+v", key, items[key], spec)
+		}
+	}
+
+	// Exported items import into another cache unchanged.
+	other := &inMemoryCache{}
+	WithClock(clock)(other)
+	if err := other.Import(items); err != nil {
+		t.Fatalf("Import() error: %v", err)
+	}
+	if ttl, found := other.TTL("short"); !found || ttl != time.Second*58 {
+		t.Errorf("TTL(short) after Import() = %v, %v, want %v, true", ttl, found, time.Second*58)
+	}
+}
@@ -0,0 +1,288 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	journalSet byte = iota + 1
+	journalDelete
+	journalClear
+)
+
+type journalRecord struct {
+	Op        byte
+	Key       string
+	Value     interface{}
+	ExpiresAt time.Time
+}
+
+type journal struct {
+	path         string
+	compactAfter int64
+	mu           sync.Mutex
+	file         *os.File
+	size         int64
+	compactions  chan struct{}
+}
+
+// WithJournal records every Set and Delete in an append-only file at path and
+// replays it when the cache is created. Once the file grows beyond
+// compactAfter bytes it is rewritten in the background to hold only live
+// entries. Values are gob encoded, see Save for the type requirements.
+func WithJournal(path string, compactAfter int64) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.journal = &journal{
+			path:         path,
+			compactAfter: compactAfter,
+			compactions:  make(chan struct{}, 1),
+		}
+	}
+}
+
+func (c *inMemoryCache) openJournal(ctx context.Context) error {
+	if err := c.replayJournal(); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(c.journal.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	c.journal.mu.Lock()
+	c.journal.file = file
+	c.journal.size = info.Size()
+	c.journal.mu.Unlock()
+
+	go c.compactJournalWhenNeeded(ctx)
+
+	return nil
+}
+
+func (c *inMemoryCache) replayJournal() error {
+	file, err := os.Open(c.journal.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	now := c.now()
+	for {
+		record, err := readJournalRecord(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		switch record.Op {
+		case journalSet:
+			if record.ExpiresAt.IsZero() {
+				c.restore(record.Key, record.Value, NoExpiration)
+			} else if ttl := record.ExpiresAt.Sub(now); ttl > 0 {
+				c.restore(record.Key, record.Value, ttl)
+			} else {
+				c.Delete(record.Key)
+			}
+		case journalDelete:
+			c.Delete(record.Key)
+		case journalClear:
+			c.Clear()
+		}
+	}
+}
+
+func (c *inMemoryCache) journalSet(key string, item *cacheItem) {
+	// Negative entries are not replayed, but must still drop the value they
+	// replaced.
+	if item.negative {
+		c.journalDelete(key)
+	} else if c.journal != nil {
+		c.appendJournal(journalRecord{Op: journalSet, Key: key, Value: c.savedValue(item), ExpiresAt: item.validThrough})
+	}
+}
+
+func (c *inMemoryCache) journalDelete(key string) {
+	if c.journal != nil {
+		c.appendJournal(journalRecord{Op: journalDelete, Key: key})
+	}
+}
+
+func (c *inMemoryCache) journalClear() {
+	if c.journal != nil {
+		c.appendJournal(journalRecord{Op: journalClear})
+	}
+}
+
+func (c *inMemoryCache) appendJournal(record journalRecord) {
+	if err := c.journal.append(record); err != nil {
+		c.log(slog.LevelError, "cache journal write failed", "path", c.journal.path, "key", record.Key, "error", err)
+	}
+}
+
+func (j *journal) append(record journalRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+
+	written, err := writeJournalRecord(j.file, record)
+	j.size += int64(written)
+	if err != nil {
+		return err
+	}
+
+	if j.compactAfter > 0 && j.size > j.compactAfter {
+		select {
+		case j.compactions <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (c *inMemoryCache) compactJournalWhenNeeded(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			c.journal.mu.Lock()
+			if c.journal.file != nil {
+				c.journal.file.Close()
+				c.journal.file = nil
+			}
+			c.journal.mu.Unlock()
+			return
+		case <-c.journal.compactions:
+			if err := c.compactJournal(); err != nil {
+				c.log(slog.LevelError, "cache journal compaction failed", "path", c.journal.path, "error", err)
+			} else {
+				c.log(slog.LevelInfo, "cache journal compacted", "path", c.journal.path)
+			}
+		}
+	}
+}
+
+// compactJournal rewrites the journal with a single Set record per live entry.
+// Writers wait for the rewrite, so no record is lost between the scan and the
+// rename.
+func (c *inMemoryCache) compactJournal() error {
+	j := c.journal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	writer := bufio.NewWriter(file)
+	var size int64
+	now := c.now()
+	c.storage.Range(func(key string, item *cacheItem) bool {
+		if !item.isLive(now) {
+			return true
+		}
+
+		written, writeErr := writeJournalRecord(writer, journalRecord{
+			Op:        journalSet,
+			Key:       key,
+			Value:     c.savedValue(item),
+			ExpiresAt: item.validThrough,
+		})
+		size += int64(written)
+		err = writeErr
+
+		return err == nil
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(file.Name(), j.path); err != nil {
+		return err
+	}
+
+	reopened, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	j.file.Close()
+	j.file = reopened
+	j.size = size
+
+	return nil
+}
+
+// Every record is a length-prefixed gob message with its own type
+// information, so a journal appended to across restarts stays decodable and
+// a torn write only loses the last record.
+func writeJournalRecord(w io.Writer, record journalRecord) (int, error) {
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(record); err != nil {
+		return 0, err
+	}
+
+	frame := make([]byte, 4+payload.Len())
+	binary.BigEndian.PutUint32(frame, uint32(payload.Len()))
+	copy(frame[4:], payload.Bytes())
+
+	return w.Write(frame)
+}
+
+func readJournalRecord(r io.Reader) (journalRecord, error) {
+	var record journalRecord
+
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return record, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return record, err
+	}
+
+	err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&record)
+
+	return record, err
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWithJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.journal")
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	source := NewInMemoryCache(ctx, WithJournal(path, 0))
+	source.Set("test1", 1, time.Second*10)
+	source.Set("test2", 2, time.Second*10)
+	source.Set("test1", 3, time.Second*10)
+	source.Delete("test2")
+	source.Set("test3", 4, time.Millisecond)
+	source.Set("test4", 5, NoExpiration)
+	cancelFn()
+	time.Sleep(time.Millisecond * 5)
+
+	restored := NewInMemoryCache(context.Background(), WithJournal(path, 0))
+	if value, found := restored.Get("test1"); !found || value != 3 {
+		t.Errorf("Get(test1) after replay = %v, %v, want %v, %v", value, found, 3, true)
+	}
+	if value, found := restored.Get("test4"); !found || value != 5 {
+		t.Errorf("Get(test4) after replay = %v, %v, want %v, %v", value, found, 5, true)
+	}
+	for _, key := range []string{"test2", "test3"} {
+		if _, found := restored.Get(key); found {
+			t.Errorf("Get(%s) after replay expected a miss", key)
+		}
+	}
+}
+
+func TestWithJournal_Clear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.journal")
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	source := NewInMemoryCache(ctx, WithJournal(path, 0)).(*inMemoryCache)
+	source.Set("test1", 1, time.Second*10)
+	source.Set("test2", 2, time.Second*10)
+	source.Clear()
+	source.Set("test3", 3, time.Second*10)
+	cancelFn()
+	time.Sleep(time.Millisecond * 5)
+
+	restored := NewInMemoryCache(context.Background(), WithJournal(path, 0))
+	for _, key := range []string{"test1", "test2"} {
+		if _, found := restored.Get(key); found {
+			t.Errorf("Get(%s) after replay of a Clear() expected a miss", key)
+		}
+	}
+	if value, found := restored.Get("test3"); !found || value != 3 {
+		t.Errorf("Get(test3) after replay = %v, %v, want %v, %v", value, found, 3, true)
+	}
+}
+
+func TestWithJournal_Compaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.journal")
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	cache := NewInMemoryCache(ctx, WithJournal(path, 1024)).(*inMemoryCache)
+	for i := 0; i < 100; i++ {
+		cache.Set("test", i, time.Second*10)
+	}
+	time.Sleep(time.Millisecond * 20)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("journal file missing: %v", err)
+	}
+	if info.Size() > 2048 {
+		t.Errorf("journal size = %d, expected compaction to shrink it", info.Size())
+	}
+
+	restored := &inMemoryCache{}
+	WithJournal(path, 0)(restored)
+	if err := restored.replayJournal(); err != nil {
+		t.Fatalf("replayJournal() error: %v", err)
+	}
+	if value, found := restored.Get("test"); !found || value != 99 {
+		t.Errorf("Get(test) after compaction = %v, %v, want %v, %v", value, found, 99, true)
+	}
+}
+
+func TestWithJournal_TornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.journal")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeJournalRecord(file, journalRecord{Op: journalSet, Key: "test", Value: 1, ExpiresAt: time.Now().Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte{0, 0, 1})
+	file.Close()
+
+	cache := &inMemoryCache{}
+	WithJournal(path, 0)(cache)
+	if err := cache.replayJournal(); err != nil {
+		t.Fatalf("replayJournal() error: %v", err)
+	}
+	if value, found := cache.Get("test"); !found || value != 1 {
+		t.Errorf("Get(test) = %v, %v, want %v, %v", value, found, 1, true)
+	}
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"hash/crc32"
+	"sort"
+	"sync"
+)
+
+const keyLockStripes = 256
+
+type KeyLocker interface {
+	LockKey(ctx context.Context, key string) (unlock func(), err error)
+}
+
+// keyLocks serializes work per key with a fixed table of locks, so keys that
+// hash to the same stripe share a lock. The locks are channels to make
+// waiting cancellable.
+type keyLocks struct {
+	once    sync.Once
+	stripes [keyLockStripes]chan struct{}
+}
+
+func (l *keyLocks) stripe(key string) chan struct{} {
+	return l.stripeAt(stripeIndex(key))
+}
+
+func (l *keyLocks) stripeAt(index int) chan struct{} {
+	l.once.Do(func() {
+		for i := range l.stripes {
+			l.stripes[i] = make(chan struct{}, 1)
+		}
+	})
+
+	return l.stripes[index]
+}
+
+func stripeIndex(key string) int {
+	return int(crc32.ChecksumIEEE([]byte(key)) % keyLockStripes)
+}
+
+// LockKey blocks until the caller holds the lock of key or ctx is done, so
+// expensive work for a key, such as loading it from a slow backend, runs once
+// at a time. The lock is advisory: cache operations do not take it. Locks are
+// striped over a fixed table, so two different keys may occasionally share a
+// lock; the holder must therefore not lock a second key while holding one.
+// unlock may be called more than once.
+func (c *inMemoryCache) LockKey(ctx context.Context, key string) (func(), error) {
+	unlock, err := c.keyLocks.lock(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+
+	return func() { once.Do(unlock) }, nil
+}
+
+// lock blocks until the stripe of key is free or ctx is done and returns the
+// function releasing it.
+func (l *keyLocks) lock(ctx context.Context, key string) (func(), error) {
+	stripe := l.stripe(key)
+	select {
+	case stripe <- struct{}{}:
+		return func() { <-stripe }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// lockAll locks the stripes of keys in index order, so that two callers
+// locking overlapping sets of keys cannot deadlock, and returns the function
+// releasing them.
+func (l *keyLocks) lockAll(keys []string) func() {
+	seen := make(map[int]struct{}, len(keys))
+	indexes := make([]int, 0, len(keys))
+	for _, key := range keys {
+		index := stripeIndex(key)
+		if _, found := seen[index]; !found {
+			seen[index] = struct{}{}
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+
+	for _, index := range indexes {
+		l.stripeAt(index) <- struct{}{}
+	}
+
+	return func() {
+		for i := len(indexes) - 1; i >= 0; i-- {
+			<-l.stripeAt(indexes[i])
+		}
+	}
+}
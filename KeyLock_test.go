@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_LockKey(t *testing.T) {
+	cache := &inMemoryCache{}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := cache.LockKey(context.Background(), "test")
+			if err != nil {
+				t.Errorf("LockKey() error: %v", err)
+				return
+			}
+			defer unlock()
+
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if maxRunning != 1 {
+		t.Errorf("LockKey() let %d holders run at once, want 1", maxRunning)
+	}
+}
+
+func Test_inMemoryCache_LockKey_Context(t *testing.T) {
+	cache := &inMemoryCache{}
+	unlock, err := cache.LockKey(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("LockKey() error: %v", err)
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancelFn()
+	if _, err := cache.LockKey(ctx, "test"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LockKey() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	unlock()
+	unlock()
+	relock, err := cache.LockKey(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("LockKey() after unlock error: %v", err)
+	}
+	relock()
+}
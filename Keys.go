@@ -0,0 +1,67 @@
+package cache
+
+import "time"
+
+type KeyLister interface {
+	Keys() []string
+}
+
+type LenProvider interface {
+	Len() int
+}
+
+type ExistenceChecker interface {
+	Has(key string) bool
+}
+
+type Ranger interface {
+	Range(fn func(key string, value interface{}, expiresAt time.Time) bool)
+}
+
+// Range calls fn for each live entry until fn returns false. expiresAt is zero
+// for entries without expiration. Like sync.Map's
+// Range it does not block writers, so entries written while Range runs may
+// or may not be visited. fn may call back into the cache.
+func (c *inMemoryCache) Range(fn func(key string, value interface{}, expiresAt time.Time) bool) {
+	now := c.now()
+	c.storage.Range(func(key string, item *cacheItem) bool {
+		if !item.isLive(now) {
+			return true
+		}
+
+		return fn(key, c.valueOf(item), item.validThrough)
+	})
+}
+
+// Keys returns the keys of live entries in no particular order.
+func (c *inMemoryCache) Keys() []string {
+	var keys []string
+	c.Range(func(key string, _ interface{}, _ time.Time) bool {
+		keys = append(keys, key)
+		return true
+	})
+
+	return keys
+}
+
+// Len returns the number of live entries. Unlike Stats().Entries, it skips
+// expired entries the cleanup loop has not removed yet, at the cost of a full
+// scan.
+func (c *inMemoryCache) Len() int {
+	count := 0
+	c.Range(func(string, interface{}, time.Time) bool {
+		count++
+		return true
+	})
+
+	return count
+}
+
+// Has reports whether key holds a live entry without decoding or copying its
+// value, and without counting as a read for stats or eviction. Expired
+// entries that Get would still serve stale are reported missing.
+func (c *inMemoryCache) Has(key string) bool {
+	item, found := c.load(key)
+
+	return found && item.isLive(c.now())
+}
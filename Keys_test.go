@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_Keys(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    map[string]time.Duration
+		wantKeys []string
+	}{
+		{
+			name: "Empty cache",
+		},
+		{
+			name: "Skip expired entries",
+			items: map[string]time.Duration{
+				"live1":   time.Minute,
+				"live2":   time.Minute,
+				"expired": time.Nanosecond,
+				"forever": NoExpiration,
+			},
+			wantKeys: []string{"forever", "live1", "live2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			clock := newFakeClock()
+			WithClock(clock)(cache)
+			for key, ttl := range tt.items {
+				cache.Set(key, 1, ttl)
+			}
+			clock.Advance(time.Millisecond)
+
+			keys := cache.Keys()
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("Keys() = %v, want %v", keys, tt.wantKeys)
+			}
+			if cache.Len() != len(tt.wantKeys) {
+				t.Errorf("Len() = %d, want %d", cache.Len(), len(tt.wantKeys))
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_Range(t *testing.T) {
+	cache := &inMemoryCache{}
+	clock := newFakeClock()
+	WithClock(clock)(cache)
+	cache.Set("live", 1, time.Minute)
+	cache.Set("expired", 2, time.Nanosecond)
+	clock.Advance(time.Millisecond)
+
+	visited := make(map[string]interface{})
+	cache.Range(func(key string, value interface{}, expiresAt time.Time) bool {
+		visited[key] = value
+		if until := expiresAt.Sub(clock.Now()); until <= 0 || until > time.Minute {
+			t.Errorf("Range() expiresAt for %s is %v from now, want within a minute", key, until)
+		}
+		return true
+	})
+	if !reflect.DeepEqual(visited, map[string]interface{}{"live": 1}) {
+		t.Errorf("Range() visited %v, want only the live entry", visited)
+	}
+
+	for i := 0; i < 5; i++ {
+		cache.Set("key"+string(rune('a'+i)), i, time.Minute)
+	}
+	calls := 0
+	cache.Range(func(string, interface{}, time.Time) bool {
+		calls++
+		return calls < 2
+	})
+	if calls != 2 {
+		t.Errorf("Range() called fn %d times after it returned false, want 2", calls)
+	}
+}
+
+// countingCodec counts the values GobCodec decodes.
+type countingCodec struct {
+	GobCodec
+	unmarshals int
+}
+
+func (c *countingCodec) Unmarshal(data []byte) (interface{}, error) {
+	c.unmarshals++
+	return c.GobCodec.Unmarshal(data)
+}
+
+func Test_inMemoryCache_Has(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     func(c *inMemoryCache)
+		advance time.Duration
+		want    bool
+	}{
+		{
+			name: "Live entry",
+			set:  func(c *inMemoryCache) { c.Set("test", 1, time.Minute) },
+			want: true,
+		},
+		{
+			name: "Entry without expiration",
+			set:  func(c *inMemoryCache) { c.Set("test", 1, NoExpiration) },
+			want: true,
+		},
+		{
+			name: "Missing entry",
+			set:  func(c *inMemoryCache) {},
+		},
+		{
+			name:    "Expired entry",
+			set:     func(c *inMemoryCache) { c.Set("test", 1, time.Millisecond) },
+			advance: time.Millisecond * 5,
+		},
+		{
+			name: "Negative entry",
+			set:  func(c *inMemoryCache) { c.SetNegative("test", time.Minute) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := &countingCodec{}
+			clock := newFakeClock()
+			c := &inMemoryCache{}
+			WithClock(clock)(c)
+			WithCodec(codec)(c)
+			tt.set(c)
+			clock.Advance(tt.advance)
+			codec.unmarshals = 0
+
+			if got := c.Has("test"); got != tt.want {
+				t.Errorf("Has() = %v, want %v", got, tt.want)
+			}
+			if codec.unmarshals != 0 {
+				t.Errorf("Has() decoded %d values, want 0", codec.unmarshals)
+			}
+			if stats := c.Stats(); stats.Hits != 0 || stats.Misses != 0 {
+				t.Errorf("Has() changed stats to %d hits and %d misses", stats.Hits, stats.Misses)
+			}
+		})
+	}
+}
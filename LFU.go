@@ -0,0 +1,170 @@
+package cache
+
+import "container/heap"
+
+// Frequencies are halved once the number of recorded accesses reaches
+// lfuDecayFactor times the capacity, so keys that were hot a long time ago
+// eventually become eviction candidates again. Lists without an entry limit,
+// used for caches bounded only by cost or memory, go by their current number
+// of entries instead, but at least lfuMinDecayEntries.
+const (
+	lfuDecayFactor     = 10
+	lfuMinDecayEntries = 64
+)
+
+type lfuEntry struct {
+	key        string
+	frequency  uint32
+	lastAccess uint64
+	index      int
+}
+
+type lfuHeap []*lfuEntry
+
+func (h lfuHeap) Len() int { return len(h) }
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].frequency == h[j].frequency {
+		return h[i].lastAccess < h[j].lastAccess
+	}
+
+	return h[i].frequency < h[j].frequency
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x interface{}) {
+	entry := x.(*lfuEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *lfuHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return entry
+}
+
+type lfuList struct {
+	capacity int
+	entries  map[string]*lfuEntry
+	heap     lfuHeap
+	clock    uint64
+	accesses int
+}
+
+func newLFUList(capacity int) *lfuList {
+	return &lfuList{
+		capacity: capacity,
+		entries:  make(map[string]*lfuEntry),
+	}
+}
+
+func (l *lfuList) add(key string) []string {
+	if _, found := l.entries[key]; found {
+		l.touch(key)
+		return nil
+	}
+
+	var evicted []string
+	for l.capacity > 0 && len(l.heap) >= l.capacity {
+		evictedKey, _ := l.evict()
+		evicted = append(evicted, evictedKey)
+	}
+
+	l.clock++
+	entry := &lfuEntry{key: key, frequency: 1, lastAccess: l.clock}
+	l.entries[key] = entry
+	heap.Push(&l.heap, entry)
+	l.recordAccess()
+
+	return evicted
+}
+
+func (l *lfuList) touch(key string) {
+	entry, found := l.entries[key]
+	if !found {
+		return
+	}
+
+	l.clock++
+	entry.lastAccess = l.clock
+	if entry.frequency < ^uint32(0) {
+		entry.frequency++
+	}
+	heap.Fix(&l.heap, entry.index)
+	l.recordAccess()
+}
+
+func (l *lfuList) remove(key string) {
+	entry, found := l.entries[key]
+	if !found {
+		return
+	}
+
+	heap.Remove(&l.heap, entry.index)
+	delete(l.entries, key)
+}
+
+func (l *lfuList) contains(key string) bool {
+	_, found := l.entries[key]
+	return found
+}
+
+func (l *lfuList) victim() (string, bool) {
+	if len(l.heap) == 0 {
+		return "", false
+	}
+
+	return l.heap[0].key, true
+}
+
+func (l *lfuList) evict() (string, bool) {
+	if len(l.heap) == 0 {
+		return "", false
+	}
+
+	entry := heap.Pop(&l.heap).(*lfuEntry)
+	delete(l.entries, entry.key)
+
+	return entry.key, true
+}
+
+func (l *lfuList) clear() {
+	*l = *newLFUList(l.capacity)
+}
+
+func (l *lfuList) len() int {
+	return len(l.heap)
+}
+
+func (l *lfuList) recordAccess() {
+	l.accesses++
+	if l.accesses < l.decayPeriod() {
+		return
+	}
+
+	l.accesses = 0
+	for _, entry := range l.heap {
+		entry.frequency /= 2
+	}
+	heap.Init(&l.heap)
+}
+
+// decayPeriod returns the number of accesses after which frequencies are
+// halved.
+func (l *lfuList) decayPeriod() int {
+	if l.capacity > 0 {
+		return l.capacity * lfuDecayFactor
+	}
+
+	return max(len(l.heap), lfuMinDecayEntries) * lfuDecayFactor
+}
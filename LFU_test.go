@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func Test_lfuList_evict(t *testing.T) {
+	tests := []struct {
+		name    string
+		added   []string
+		touched []string
+		removed []string
+		want    []string
+	}{
+		{
+			name:  "Equal frequencies evict the least recent first",
+			added: []string{"test1", "test2", "test3"},
+			want:  []string{"test1", "test2", "test3"},
+		},
+		{
+			name:    "Frequently used keys are evicted last",
+			added:   []string{"test1", "test2", "test3"},
+			touched: []string{"test1", "test1", "test2"},
+			want:    []string{"test3", "test2", "test1"},
+		},
+		{
+			name:    "Removed keys are skipped",
+			added:   []string{"test1", "test2", "test3"},
+			removed: []string{"test2"},
+			want:    []string{"test1", "test3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lfu := newLFUList(100)
+			for _, key := range tt.added {
+				lfu.add(key)
+			}
+			for _, key := range tt.touched {
+				lfu.touch(key)
+			}
+			for _, key := range tt.removed {
+				lfu.remove(key)
+			}
+
+			var got []string
+			for lfu.len() > 0 {
+				key, _ := lfu.evict()
+				got = append(got, key)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("evict() order = %v, want %v", got, tt.want)
+			}
+			if _, ok := lfu.evict(); ok {
+				t.Errorf("evict() on an empty list must return false")
+			}
+		})
+	}
+}
+
+func Test_lfuList_decay(t *testing.T) {
+	tests := []struct {
+		name          string
+		capacity      int
+		entries       int
+		touches       int
+		wantFrequency uint32
+	}{
+		{name: "Bounded by capacity", capacity: 1, entries: 1, touches: 12, wantFrequency: 8},
+		{name: "Unbounded uses the minimum period", entries: 1, touches: 640, wantFrequency: 321},
+		{name: "Unbounded before the entry count period", entries: 100, touches: 899, wantFrequency: 900},
+		{name: "Unbounded at the entry count period", entries: 100, touches: 900, wantFrequency: 450},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lfu := newLFUList(tt.capacity)
+			for i := 0; i < tt.entries; i++ {
+				lfu.add("test" + strconv.Itoa(i))
+			}
+			for i := 0; i < tt.touches; i++ {
+				lfu.touch("test0")
+			}
+
+			if got := lfu.entries["test0"].frequency; got != tt.wantFrequency {
+				t.Errorf("frequency = %d, want %d", got, tt.wantFrequency)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package cache
+
+import "container/list"
+
+type lruList struct {
+	capacity int
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+func newLRUList(capacity int) *lruList {
+	return &lruList{
+		capacity: capacity,
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+func (l *lruList) add(key string) []string {
+	if element, found := l.elements[key]; found {
+		l.order.MoveToFront(element)
+		return nil
+	}
+
+	var evicted []string
+	for l.capacity > 0 && l.order.Len() >= l.capacity {
+		oldestKey, _ := l.evict()
+		evicted = append(evicted, oldestKey)
+	}
+	l.elements[key] = l.order.PushFront(key)
+
+	return evicted
+}
+
+func (l *lruList) touch(key string) {
+	if element, found := l.elements[key]; found {
+		l.order.MoveToFront(element)
+	}
+}
+
+func (l *lruList) remove(key string) {
+	if element, found := l.elements[key]; found {
+		l.order.Remove(element)
+		delete(l.elements, key)
+	}
+}
+
+func (l *lruList) contains(key string) bool {
+	_, found := l.elements[key]
+	return found
+}
+
+func (l *lruList) victim() (string, bool) {
+	element := l.order.Back()
+	if element == nil {
+		return "", false
+	}
+
+	return element.Value.(string), true
+}
+
+func (l *lruList) evict() (string, bool) {
+	element := l.order.Back()
+	if element == nil {
+		return "", false
+	}
+
+	key := element.Value.(string)
+	l.order.Remove(element)
+	delete(l.elements, key)
+
+	return key, true
+}
+
+func (l *lruList) clear() {
+	*l = *newLRUList(l.capacity)
+}
+
+func (l *lruList) len() int {
+	return l.order.Len()
+}
@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_lruList_evict(t *testing.T) {
+	tests := []struct {
+		name    string
+		added   []string
+		touched []string
+		removed []string
+		want    []string
+	}{
+		{
+			name:  "Remove in insertion order",
+			added: []string{"test1", "test2", "test3"},
+			want:  []string{"test1", "test2", "test3"},
+		},
+		{
+			name:    "Touched keys become the most recent",
+			added:   []string{"test1", "test2", "test3"},
+			touched: []string{"test1"},
+			want:    []string{"test2", "test3", "test1"},
+		},
+		{
+			name:    "Removed keys are skipped",
+			added:   []string{"test1", "test2", "test3"},
+			removed: []string{"test2"},
+			want:    []string{"test1", "test3"},
+		},
+		{
+			name:  "Adding an existing key moves it to the front",
+			added: []string{"test1", "test2", "test1"},
+			want:  []string{"test2", "test1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lru := newLRUList(0)
+			for _, key := range tt.added {
+				lru.add(key)
+			}
+			for _, key := range tt.touched {
+				lru.touch(key)
+			}
+			for _, key := range tt.removed {
+				lru.remove(key)
+			}
+
+			var got []string
+			for lru.len() > 0 {
+				key, _ := lru.evict()
+				got = append(got, key)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("evict() order = %v, want %v", got, tt.want)
+			}
+			if _, ok := lru.evict(); ok {
+				t.Errorf("evict() on an empty list must return false")
+			}
+		})
+	}
+}
+
+func TestWithMaxEntries(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		keys       []string
+		accessed   []string
+		want       []string
+		notWant    []string
+	}{
+		{
+			name:       "Evict least recently set item",
+			maxEntries: 2,
+			keys:       []string{"test1", "test2", "test3"},
+			want:       []string{"test2", "test3"},
+			notWant:    []string{"test1"},
+		},
+		{
+			name:       "Evict least recently used item",
+			maxEntries: 2,
+			keys:       []string{"test1", "test2"},
+			accessed:   []string{"test1"},
+			want:       []string{"test1", "test4"},
+			notWant:    []string{"test2"},
+		},
+		{
+			name:       "Non-positive limit keeps cache unbounded",
+			maxEntries: 0,
+			keys:       []string{"test1", "test2", "test3"},
+			want:       []string{"test1", "test2", "test3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			WithMaxEntries(tt.maxEntries)(cache)
+
+			for _, key := range tt.keys {
+				cache.Set(key, 42, time.Second*10)
+			}
+			for _, key := range tt.accessed {
+				cache.Get(key)
+			}
+			if len(tt.accessed) > 0 {
+				cache.Set("test4", 42, time.Second*10)
+			}
+
+			for _, key := range tt.want {
+				if _, ok := cache.Get(key); !ok {
+					t.Errorf("Get() expected key %s to be cached", key)
+				}
+			}
+			for _, key := range tt.notWant {
+				if _, ok := cache.Get(key); ok {
+					t.Errorf("Get() expected key %s to be evicted", key)
+				}
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_DeleteWithMaxEntries(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithMaxEntries(2)(cache)
+
+	cache.Set("test1", 1, time.Second*10)
+	cache.Set("test2", 2, time.Second*10)
+	cache.Delete("test1")
+	cache.Set("test3", 3, time.Second*10)
+
+	if cache.evictor.len() != 2 {
+		t.Errorf("lru length = %d, want %d", cache.evictor.len(), 2)
+	}
+	for _, key := range []string{"test2", "test3"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("Get() expected key %s to be cached", key)
+		}
+	}
+}
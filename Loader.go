@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var ErrLoaderPanicked = errors.New("cache: loader panicked")
+
+type GetOrLoader interface {
+	GetOrLoad(
+		ctx context.Context,
+		key string,
+		expiredInterval time.Duration,
+		loader func(ctx context.Context) (interface{}, error),
+	) (interface{}, error)
+}
+
+type loadCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+type loadGroup struct {
+	mu    sync.Mutex
+	calls map[string]*loadCall
+}
+
+// GetOrLoad returns the cached value or runs loader to produce it. Concurrent
+// misses for the same key share a single loader execution, which runs with the
+// context of the caller that started it. Loader errors are not cached, except
+// ErrNotFound with WithNegativeTTL. Keys recorded by SetNegative return
+// ErrNotFound without running loader.
+func (c *inMemoryCache) GetOrLoad(
+	ctx context.Context,
+	key string,
+	expiredInterval time.Duration,
+	loader func(ctx context.Context) (interface{}, error),
+) (interface{}, error) {
+	if value, result := c.Lookup(key); result != LookupMiss {
+		return value, lookupErr(result)
+	}
+
+	result, err := c.loads.do(ctx, key, func() (interface{}, error) {
+		if value, result := c.Lookup(key); result != LookupMiss {
+			if err := lookupErr(result); err != nil {
+				return nil, err
+			}
+			return loaded{item: c.newCacheItem(value, NoExpiration), value: value}, nil
+		}
+
+		startedAt := time.Now()
+		value, err := loader(ctx)
+		if errors.Is(err, ErrNotFound) && c.negativeTTL > 0 {
+			c.SetNegative(key, c.negativeTTL)
+		}
+		if err != nil {
+			return nil, err
+		}
+		item := c.newCacheItem(value, expiredInterval)
+		item.delta = time.Since(startedAt)
+		c.set(key, item, defaultItemCost)
+
+		return loaded{item: item, value: value}, nil
+	})
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			c.log(slog.LevelWarn, "cache load failed", "key", key, "error", err)
+		}
+		return nil, err
+	}
+
+	// Callers waiting on the same load share its result, so each decodes or
+	// copies its own value from the item.
+	return c.waiterValue(result.(loaded)), nil
+}
+
+// loaded is the result a load shares with the callers waiting on it: the item
+// holding the value in its stored form, and the value itself for items the
+// codec could not encode.
+type loaded struct {
+	item  *cacheItem
+	value interface{}
+}
+
+func (c *inMemoryCache) waiterValue(l loaded) interface{} {
+	if _, failed := l.item.value.(encodingFailure); failed {
+		return c.copyValue(l.value)
+	}
+
+	return c.valueOf(l.item)
+}
+
+// lookupErr returns ErrNotFound for negative entries.
+func lookupErr(result LookupResult) error {
+	if result == LookupNegative {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (g *loadGroup) do(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*loadCall)
+	}
+	if call, found := g.calls[key]; found {
+		g.mu.Unlock()
+		return call.wait(ctx)
+	}
+
+	call := &loadCall{done: make(chan struct{}), err: ErrLoaderPanicked}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.value, call.err = fn()
+
+	return call.value, call.err
+}
+
+// pending reports whether a call for key is in flight.
+func (g *loadGroup) pending(key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	_, found := g.calls[key]
+
+	return found
+}
+
+func (c *loadCall) wait(ctx context.Context) (interface{}, error) {
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
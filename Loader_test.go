@@ -0,0 +1,224 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_GetOrLoad(t *testing.T) {
+	loaderErr := errors.New("loader failed")
+	tests := []struct {
+		name            string
+		isValueExisting bool
+		loaderValue     interface{}
+		loaderErr       error
+		expectedValue   interface{}
+		expectedErr     error
+		expectedCalls   int32
+		expectedCached  bool
+	}{
+		{
+			name:            "Return cached value without calling loader",
+			isValueExisting: true,
+			loaderValue:     43,
+			expectedValue:   42,
+			expectedCalls:   0,
+			expectedCached:  true,
+		},
+		{
+			name:           "Load and store a missing value",
+			loaderValue:    43,
+			expectedValue:  43,
+			expectedCalls:  1,
+			expectedCached: true,
+		},
+		{
+			name:           "Loader error is returned and not cached",
+			loaderErr:      loaderErr,
+			expectedValue:  nil,
+			expectedErr:    loaderErr,
+			expectedCalls:  1,
+			expectedCached: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			if tt.isValueExisting {
+				cache.Set("test", 42, time.Second*10)
+			}
+
+			var calls int32
+			value, err := cache.GetOrLoad(context.Background(), "test", time.Second*10, func(ctx context.Context) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				return tt.loaderValue, tt.loaderErr
+			})
+
+			if !reflect.DeepEqual(value, tt.expectedValue) {
+				t.Errorf("GetOrLoad() value = %v, want %v", value, tt.expectedValue)
+			}
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("GetOrLoad() err = %v, want %v", err, tt.expectedErr)
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("GetOrLoad() loader calls = %d, want %d", calls, tt.expectedCalls)
+			}
+			if _, cached := cache.Get("test"); cached != tt.expectedCached {
+				t.Errorf("Get() after GetOrLoad() found = %v, want %v", cached, tt.expectedCached)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_GetOrLoadCoalescing(t *testing.T) {
+	cache := &inMemoryCache{}
+	release := make(chan struct{})
+	var calls int32
+	loader := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan interface{}, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, _ := cache.GetOrLoad(context.Background(), "test", time.Second*10, loader)
+			results <- value
+		}()
+	}
+	time.Sleep(time.Millisecond * 20)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if calls != 1 {
+		t.Errorf("GetOrLoad() loader calls = %d, want %d", calls, 1)
+	}
+	for value := range results {
+		if value != 42 {
+			t.Errorf("GetOrLoad() value = %v, want %v", value, 42)
+		}
+	}
+}
+
+func Test_inMemoryCache_GetOrLoadWaiterCancellation(t *testing.T) {
+	cache := &inMemoryCache{}
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	go cache.GetOrLoad(context.Background(), "test", time.Second*10, func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-release
+		return 42, nil
+	})
+	<-started
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancelFn()
+	_, err := cache.GetOrLoad(ctx, "test", time.Second*10, func(ctx context.Context) (interface{}, error) {
+		return 43, nil
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetOrLoad() err = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func Test_inMemoryCache_GetOrLoadWaitersGetOwnValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		option func(*inMemoryCache)
+	}{
+		{name: "Codec", option: WithCodec(GobCodec{})},
+		{name: "Value copying", option: WithValueCopying(nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			tt.option(cache)
+			release := make(chan struct{})
+			loader := func(ctx context.Context) (interface{}, error) {
+				<-release
+				return []int{1, 2, 3}, nil
+			}
+
+			var wg sync.WaitGroup
+			results := make(chan []int, 5)
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					value, _ := cache.GetOrLoad(context.Background(), "test", time.Second*10, loader)
+					results <- value.([]int)
+				}()
+			}
+			time.Sleep(time.Millisecond * 20)
+			close(release)
+			wg.Wait()
+			close(results)
+
+			var values [][]int
+			for value := range results {
+				values = append(values, value)
+			}
+			for i, value := range values {
+				for _, other := range values[i+1:] {
+					if &value[0] == &other[0] {
+						t.Fatalf("GetOrLoad() waiters share the same slice")
+					}
+				}
+				value[0] = 100
+			}
+			if value, _ := cache.Get("test"); !reflect.DeepEqual(value, []int{1, 2, 3}) {
+				t.Errorf("Get() = %v after waiters changed their values, want %v", value, []int{1, 2, 3})
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_GetOrLoadDuringRefresh(t *testing.T) {
+	clock := newFakeClock()
+	cache := &inMemoryCache{}
+	WithClock(clock)(cache)
+	release := make(chan struct{})
+	refreshing := make(chan struct{})
+	WithStaleWhileRevalidate(time.Minute, func(ctx context.Context, key string) (interface{}, error) {
+		close(refreshing)
+		<-release
+		return "refreshed", nil
+	})(cache)
+
+	cache.Set("test", "stale", time.Second)
+	clock.Advance(time.Second * 2)
+	cache.Get("test")
+	<-refreshing
+	cache.Delete("test")
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		value, err := cache.GetOrLoad(context.Background(), "test", time.Minute, func(ctx context.Context) (interface{}, error) {
+			return "loaded", nil
+		})
+		results <- result{value, err}
+	}()
+	time.Sleep(time.Millisecond * 20)
+	close(release)
+
+	got := <-results
+	if got.err != nil || got.value == nil {
+		t.Errorf("GetOrLoad() = %v, %v, want a value", got.value, got.err)
+	}
+}
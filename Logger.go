@@ -0,0 +1,23 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger makes the cache log its background work to logger: cleanup
+// passes and evictions at debug level, persistence at info level and
+// failures, which otherwise go unreported, at warn and error level.
+func WithLogger(logger *slog.Logger) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.logger = logger
+	}
+}
+
+func (c *inMemoryCache) log(level slog.Level, msg string, args ...any) {
+	if c.logger == nil {
+		return
+	}
+
+	c.logger.Log(context.Background(), level, msg, args...)
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithLogger(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*inMemoryCache)
+		run     func(cache *inMemoryCache)
+		want    string
+	}{
+		{
+			name: "Cleanup pass",
+			run: func(cache *inMemoryCache) {
+				cache.Set("test", 1, time.Nanosecond)
+				time.Sleep(time.Millisecond)
+				cache.CleanupNow()
+			},
+			want: `level=DEBUG msg="cache cleanup" due=1 removed=1`,
+		},
+		{
+			name:    "Eviction",
+			options: []func(*inMemoryCache){WithMaxEntries(1)},
+			run: func(cache *inMemoryCache) {
+				cache.Set("test1", 1, time.Minute)
+				cache.Set("test2", 2, time.Minute)
+			},
+			want: `level=DEBUG msg="cache eviction" key=test1`,
+		},
+		{
+			name: "Loader failure",
+			run: func(cache *inMemoryCache) {
+				_, _ = cache.GetOrLoad(context.Background(), "test", time.Minute, func(ctx context.Context) (interface{}, error) {
+					return nil, errors.New("backend down")
+				})
+			},
+			want: `level=WARN msg="cache load failed" key=test error="backend down"`,
+		},
+		{
+			name:    "Snapshot failure",
+			options: []func(*inMemoryCache){WithSnapshot(filepath.Join("missing", "dir", "snapshot"), time.Hour)},
+			run: func(cache *inMemoryCache) {
+				cache.saveSnapshot()
+			},
+			want: `level=ERROR msg="cache snapshot failed"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			cache := &inMemoryCache{}
+			WithLogger(slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug})))(cache)
+			for _, optionFn := range tt.options {
+				optionFn(cache)
+			}
+			tt.run(cache)
+
+			if got := output.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("logged %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNotEnumerable = errors.New("cache: cache cannot list its entries")
+
+// ConflictPolicy decides which entry Merge keeps for a key present in both
+// caches.
+type ConflictPolicy int
+
+const (
+	// KeepExisting keeps the entry of the receiving cache.
+	KeepExisting ConflictPolicy = iota
+	// KeepIncoming replaces it with the entry of the other cache.
+	KeepIncoming
+	// KeepNewest keeps the entry written last. Only in-memory caches record
+	// when entries were written; entries of other caches never win.
+	KeepNewest
+	// KeepLongestTTL keeps the entry that expires last, entries without
+	// expiration first.
+	KeepLongestTTL
+)
+
+type Merger interface {
+	Merge(other Cache, conflict ConflictPolicy) (int, error)
+}
+
+// mergeEntry is an entry of the cache being merged. createdAt is zero when
+// the cache does not record it.
+type mergeEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+	createdAt time.Time
+}
+
+// Merge copies the live entries of other into c, resolving keys present in
+// both with conflict, and returns how many entries it wrote. Entries keep
+// their expiration time; tags are not copied. other must be an in-memory
+// cache or a Ranger, otherwise ErrNotEnumerable is returned. Each key is
+// resolved atomically, but writes to c while Merge runs may land before or
+// after the merged entry.
+func (c *inMemoryCache) Merge(other Cache, conflict ConflictPolicy) (int, error) {
+	entries, err := mergeEntries(other)
+	if err != nil {
+		return 0, err
+	}
+
+	merged := 0
+	now := c.now()
+	for _, entry := range entries {
+		if !entry.expiresAt.IsZero() && !entry.expiresAt.After(now) {
+			continue
+		}
+
+		written := false
+		err := c.update(entry.key, func(existing *cacheItem) (*cacheItem, error) {
+			written = existing == nil || conflict.prefersIncoming(existing, entry)
+			if !written {
+				return nil, nil
+			}
+			return c.mergedItem(entry, now), nil
+		})
+		if err == nil && written {
+			merged++
+		}
+	}
+
+	return merged, nil
+}
+
+func mergeEntries(other Cache) ([]mergeEntry, error) {
+	var entries []mergeEntry
+	switch o := other.(type) {
+	case *inMemoryCache:
+		now := o.now()
+		o.storage.Range(func(key string, item *cacheItem) bool {
+			if item.isLive(now) {
+				entries = append(entries, mergeEntry{key: key, value: o.valueOf(item), expiresAt: item.validThrough, createdAt: item.createdAt})
+			}
+			return true
+		})
+	case Ranger:
+		o.Range(func(key string, value interface{}, expiresAt time.Time) bool {
+			entries = append(entries, mergeEntry{key: key, value: value, expiresAt: expiresAt})
+			return true
+		})
+	default:
+		return nil, ErrNotEnumerable
+	}
+
+	return entries, nil
+}
+
+func (p ConflictPolicy) prefersIncoming(existing *cacheItem, incoming mergeEntry) bool {
+	switch p {
+	case KeepIncoming:
+		return true
+	case KeepNewest:
+		return !incoming.createdAt.IsZero() && incoming.createdAt.After(existing.createdAt)
+	case KeepLongestTTL:
+		if existing.validThrough.IsZero() {
+			return false
+		}
+		return incoming.expiresAt.IsZero() || incoming.expiresAt.After(existing.validThrough)
+	default:
+		return false
+	}
+}
+
+// mergedItem stores entry with its original expiration and creation times.
+func (c *inMemoryCache) mergedItem(entry mergeEntry, now time.Time) *cacheItem {
+	item := c.newCacheItem(entry.value, NoExpiration)
+	item.validThrough = entry.expiresAt
+	if !entry.expiresAt.IsZero() {
+		item.interval = entry.expiresAt.Sub(now)
+	}
+	if !entry.createdAt.IsZero() {
+		item.createdAt = entry.createdAt
+	}
+
+	return item
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_Merge(t *testing.T) {
+	tests := []struct {
+		name       string
+		conflict   ConflictPolicy
+		wantMerged int
+		want       map[string]interface{}
+	}{
+		{
+			name:       "Keep existing",
+			conflict:   KeepExisting,
+			wantMerged: 1,
+			want:       map[string]interface{}{"older": "existing", "newer": "existing", "forever": "existing", "only": "incoming"},
+		},
+		{
+			name:       "Keep incoming",
+			conflict:   KeepIncoming,
+			wantMerged: 4,
+			want:       map[string]interface{}{"older": "incoming", "newer": "incoming", "forever": "incoming", "only": "incoming"},
+		},
+		{
+			name:       "Keep newest",
+			conflict:   KeepNewest,
+			wantMerged: 3,
+			want:       map[string]interface{}{"older": "existing", "newer": "incoming", "forever": "incoming", "only": "incoming"},
+		},
+		{
+			name:       "Keep longest TTL",
+			conflict:   KeepLongestTTL,
+			wantMerged: 2,
+			want:       map[string]interface{}{"older": "incoming", "newer": "existing", "forever": "existing", "only": "incoming"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			c := &inMemoryCache{}
+			WithClock(clock)(c)
+			other := &inMemoryCache{}
+			WithClock(clock)(other)
+
+			other.Set("older", "incoming", time.Hour)
+			other.Set("expired", "incoming", time.Second)
+			clock.Advance(time.Second)
+			c.Set("older", "existing", time.Minute)
+			c.Set("newer", "existing", time.Hour)
+			c.Set("forever", "existing", NoExpiration)
+			clock.Advance(time.Second)
+			other.Set("newer", "incoming", time.Minute)
+			other.Set("forever", "incoming", time.Hour)
+			other.Set("only", "incoming", time.Minute)
+
+			merged, err := c.Merge(other, tt.conflict)
+			if err != nil || merged != tt.wantMerged {
+				t.Fatalf("Merge() = %v, %v, want %v, nil", merged, err, tt.wantMerged)
+			}
+			for key, want := range tt.want {
+				if value, found := c.Get(key); !found || value != want {
+					t.Errorf("Get(%s) = %v, %v, want %v, true", key, value, found, want)
+				}
+			}
+			if _, found := c.Get("expired"); found {
+				t.Errorf("Merge() copied an expired entry")
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_Merge_KeepsExpiration(t *testing.T) {
+	clock := newFakeClock()
+	c := &inMemoryCache{}
+	WithClock(clock)(c)
+	other := &inMemoryCache{}
+	WithClock(clock)(other)
+
+	other.Set("test", 1, time.Minute)
+	clock.Advance(time.Second * 20)
+	c.Merge(other, KeepIncoming)
+
+	if ttl, found := c.TTL("test"); !found || ttl != time.Second*40 {
+		t.Errorf("TTL() after Merge() = %v, %v, want %v, true", ttl, found, time.Second*40)
+	}
+}
+
+func Test_inMemoryCache_Merge_NotEnumerable(t *testing.T) {
+	c := &inMemoryCache{}
+	other := struct{ Cache }{&inMemoryCache{}}
+
+	if _, err := c.Merge(other, KeepExisting); !errors.Is(err, ErrNotEnumerable) {
+		t.Errorf("Merge() error = %v, want %v", err, ErrNotEnumerable)
+	}
+}
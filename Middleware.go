@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"log"
+	"time"
+)
+
+// Middleware decorates a Cache, typically to add behaviour around each call
+// before passing it on to next.
+type Middleware func(next Cache) Cache
+
+// Wrap applies middlewares to c so that the first one sees each call first.
+// The result implements only Cache: optional interfaces of c, such as
+// GetOrSetter, are hidden behind the middlewares.
+func Wrap(c Cache, middlewares ...Middleware) Cache {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		c = middlewares[i](c)
+	}
+
+	return c
+}
+
+// Metrics reports the duration of every call to observe. operation is "get",
+// "set" or "delete"; result is "hit" or "miss" for gets and "ok" otherwise.
+func Metrics(observe func(operation, result string, duration time.Duration)) Middleware {
+	return func(next Cache) Cache {
+		return &observedCache{next: next, observe: func(operation, key, result string, duration time.Duration) {
+			observe(operation, result, duration)
+		}}
+	}
+}
+
+// Logging writes a line per call to logger.
+func Logging(logger *log.Logger) Middleware {
+	return func(next Cache) Cache {
+		return &observedCache{next: next, observe: func(operation, key, result string, duration time.Duration) {
+			logger.Printf("cache: %s %q %s in %s", operation, key, result, duration)
+		}}
+	}
+}
+
+// Recover turns panics raised by next into misses for Get and no-ops for Set
+// and Delete, after passing them to onPanic.
+func Recover(onPanic func(operation, key string, recovered interface{})) Middleware {
+	return func(next Cache) Cache {
+		return &recoveringCache{next: next, onPanic: onPanic}
+	}
+}
+
+type observedCache struct {
+	next    Cache
+	observe func(operation, key, result string, duration time.Duration)
+}
+
+func (c *observedCache) Get(key string) (interface{}, bool) {
+	startedAt := time.Now()
+	value, found := c.next.Get(key)
+	result := "miss"
+	if found {
+		result = "hit"
+	}
+	c.observe("get", key, result, time.Since(startedAt))
+
+	return value, found
+}
+
+func (c *observedCache) Set(key string, value interface{}, expiredInterval time.Duration) {
+	startedAt := time.Now()
+	c.next.Set(key, value, expiredInterval)
+	c.observe("set", key, "ok", time.Since(startedAt))
+}
+
+func (c *observedCache) Delete(key string) {
+	startedAt := time.Now()
+	c.next.Delete(key)
+	c.observe("delete", key, "ok", time.Since(startedAt))
+}
+
+type recoveringCache struct {
+	next    Cache
+	onPanic func(operation, key string, recovered interface{})
+}
+
+func (c *recoveringCache) Get(key string) (value interface{}, found bool) {
+	defer c.recoverPanic("get", key)
+
+	return c.next.Get(key)
+}
+
+func (c *recoveringCache) Set(key string, value interface{}, expiredInterval time.Duration) {
+	defer c.recoverPanic("set", key)
+
+	c.next.Set(key, value, expiredInterval)
+}
+
+func (c *recoveringCache) Delete(key string) {
+	defer c.recoverPanic("delete", key)
+
+	c.next.Delete(key)
+}
+
+func (c *recoveringCache) recoverPanic(operation, key string) {
+	if recovered := recover(); recovered != nil && c.onPanic != nil {
+		c.onPanic(operation, key, recovered)
+	}
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"bytes"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type panickingCache struct{}
+
+func (panickingCache) Get(key string) (interface{}, bool) { panic("get") }
+
+func (panickingCache) Set(key string, value interface{}, expiredInterval time.Duration) {
+	panic("set")
+}
+
+func (panickingCache) Delete(key string) { panic("delete") }
+
+func TestWrap(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return Metrics(func(operation, result string, duration time.Duration) {
+			calls = append(calls, name+":"+operation+":"+result)
+		})
+	}
+
+	wrapped := Wrap(&inMemoryCache{}, trace("outer"), trace("inner"))
+	wrapped.Set("test", 1, time.Minute)
+	wrapped.Get("test")
+	wrapped.Delete("test")
+	wrapped.Get("test")
+
+	want := []string{
+		"inner:set:ok", "outer:set:ok",
+		"inner:get:hit", "outer:get:hit",
+		"inner:delete:ok", "outer:delete:ok",
+		"inner:get:miss", "outer:get:miss",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("observed %v, want %v", calls, want)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	var output bytes.Buffer
+	wrapped := Wrap(&inMemoryCache{}, Logging(log.New(&output, "", 0)))
+	wrapped.Get("test")
+
+	if line := output.String(); !strings.HasPrefix(line, `cache: get "test" miss in `) {
+		t.Errorf("Logging() wrote %q", line)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	var recovered []interface{}
+	wrapped := Wrap(panickingCache{}, Recover(func(operation, key string, value interface{}) {
+		recovered = append(recovered, value)
+	}))
+
+	if value, found := wrapped.Get("test"); value != nil || found {
+		t.Errorf("Get() = %v, %v, want <nil>, false", value, found)
+	}
+	wrapped.Set("test", 1, time.Minute)
+	wrapped.Delete("test")
+
+	if want := []interface{}{"get", "set", "delete"}; !reflect.DeepEqual(recovered, want) {
+		t.Errorf("recovered %v, want %v", recovered, want)
+	}
+}
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// namespaceSeparator delimits the name and generation in the key prefix of a
+// namespace. It cannot appear in namespace names, so no two namespaces share
+// a prefix.
+const namespaceSeparator = "\x00"
+
+type NamespacedCache interface {
+	Cache
+	KeyLister
+	// Flush removes every entry of the namespace and returns how many were
+	// removed.
+	Flush() int
+	// Invalidate hides every entry of the namespace in O(1) by moving it to a
+	// new generation. Entries of older generations are removed by the
+	// cleanup loop.
+	Invalidate()
+}
+
+type Namespacer interface {
+	Namespace(name string) NamespacedCache
+}
+
+type namespace struct {
+	cache      *inMemoryCache
+	name       string
+	generation *atomic.Uint64
+}
+
+// Namespace returns a view of c whose keys do not collide with those of other
+// namespaces or with keys set on c directly. Views share c's capacity, stats
+// and options, and entries appear in c.Keys() with an internal prefix. Names
+// must not contain a NUL byte.
+func (c *inMemoryCache) Namespace(name string) NamespacedCache {
+	if strings.Contains(name, namespaceSeparator) {
+		panic("cache: namespace name contains a NUL byte")
+	}
+
+	generation, _ := c.namespaces.LoadOrStore(name, new(atomic.Uint64))
+
+	return &namespace{cache: c, name: name, generation: generation.(*atomic.Uint64)}
+}
+
+func (n *namespace) Get(key string) (interface{}, bool) {
+	return n.cache.Get(n.prefix() + key)
+}
+
+func (n *namespace) Set(key string, value interface{}, expiredInterval time.Duration) {
+	n.cache.Set(n.prefix()+key, value, expiredInterval)
+}
+
+func (n *namespace) Delete(key string) {
+	n.cache.Delete(n.prefix() + key)
+}
+
+func (n *namespace) Keys() []string {
+	prefix := n.prefix()
+	var keys []string
+	n.cache.Range(func(key string, _ interface{}, _ time.Time) bool {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, strings.TrimPrefix(key, prefix))
+		}
+		return true
+	})
+
+	return keys
+}
+
+func (n *namespace) Flush() int {
+	return n.cache.DeleteByPrefix(namespaceSeparator + n.name + namespaceSeparator)
+}
+
+// Clear hides every entry of the namespace at once, like Invalidate, and
+// returns how many live entries it hid. Clearing a namespace leaves the rest
+// of the cache alone.
+func (n *namespace) Clear() int {
+	prefix := n.prefix()
+	n.Invalidate()
+
+	cleared := 0
+	now := n.cache.now()
+	n.cache.storage.Range(func(key string, item *cacheItem) bool {
+		if strings.HasPrefix(key, prefix) && item.isLive(now) {
+			cleared++
+		}
+		return true
+	})
+
+	return cleared
+}
+
+func (n *namespace) Invalidate() {
+	n.generation.Add(1)
+	n.cache.staleGenerations.Store(true)
+}
+
+func (n *namespace) prefix() string {
+	return namespaceSeparator + n.name + namespaceSeparator +
+		strconv.FormatUint(n.generation.Load(), 10) + namespaceSeparator
+}
+
+// sweepGenerations removes entries left behind by Invalidate. It runs from
+// the cleanup loop only after a generation was bumped.
+func (c *inMemoryCache) sweepGenerations() {
+	if !c.staleGenerations.CompareAndSwap(true, false) {
+		return
+	}
+
+	c.deleteMatching(func(key string, _ *cacheItem) bool {
+		name, generation, ok := parseNamespacedKey(key)
+		if !ok {
+			return false
+		}
+		current, found := c.namespaces.Load(name)
+
+		return found && generation < current.(*atomic.Uint64).Load()
+	})
+}
+
+func parseNamespacedKey(key string) (name string, generation uint64, ok bool) {
+	parts := strings.SplitN(key, namespaceSeparator, 4)
+	if len(parts) != 4 || parts[0] != "" {
+		return "", 0, false
+	}
+	generation, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+
+	return parts[1], generation, true
+}
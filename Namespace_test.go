@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_Namespace(t *testing.T) {
+	cache := &inMemoryCache{}
+	sessions := cache.Namespace("sessions")
+	users := cache.Namespace("users")
+
+	cache.Set("id", "root", time.Minute)
+	sessions.Set("id", "session", time.Minute)
+	users.Set("id", "user", time.Minute)
+	users.Set("other", "user", time.Minute)
+
+	for _, tt := range []struct {
+		name string
+		c    Cache
+		want interface{}
+	}{
+		{name: "Root", c: cache, want: "root"},
+		{name: "Sessions", c: sessions, want: "session"},
+		{name: "Users", c: users, want: "user"},
+	} {
+		if value, _ := tt.c.Get("id"); value != tt.want {
+			t.Errorf("%s Get() = %v, want %v", tt.name, value, tt.want)
+		}
+	}
+
+	keys := users.Keys()
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"id", "other"}) {
+		t.Errorf("Keys() = %v, want [id other]", keys)
+	}
+
+	if flushed := users.Flush(); flushed != 2 {
+		t.Errorf("Flush() = %d, want 2", flushed)
+	}
+	if _, found := users.Get("id"); found {
+		t.Errorf("Get() found a key after Flush()")
+	}
+	if value, _ := sessions.Get("id"); value != "session" {
+		t.Errorf("Flush() affected another namespace: Get() = %v", value)
+	}
+	if value, _ := cache.Get("id"); value != "root" {
+		t.Errorf("Flush() affected the root keys: Get() = %v", value)
+	}
+}
+
+func Test_namespace_Clear(t *testing.T) {
+	cache := &inMemoryCache{}
+	users := cache.Namespace("users")
+	users.Set("a", 1, time.Minute)
+	users.Set("b", 2, time.Minute)
+	cache.Set("a", 3, time.Minute)
+
+	if cleared := users.(Clearer).Clear(); cleared != 2 {
+		t.Errorf("Clear() = %d, want 2", cleared)
+	}
+	if _, found := users.Get("a"); found {
+		t.Errorf("Get() found a key after Clear()")
+	}
+	if value, _ := cache.Get("a"); value != 3 {
+		t.Errorf("Clear() affected the root keys: Get() = %v", value)
+	}
+}
+
+func Test_inMemoryCache_NamespacePrefixes(t *testing.T) {
+	cache := &inMemoryCache{}
+	cache.Namespace("a").Set("b:c", 1, time.Minute)
+	cache.Namespace("a:b").Set("c", 2, time.Minute)
+
+	if value, _ := cache.Namespace("a").Get("b:c"); value != 1 {
+		t.Errorf("Get() = %v, want 1", value)
+	}
+	if value, _ := cache.Namespace("a:b").Get("c"); value != 2 {
+		t.Errorf("Get() = %v, want 2", value)
+	}
+}
+
+func Test_namespace_Invalidate(t *testing.T) {
+	cache := &inMemoryCache{cleanUpTicker: systemClock{}.NewTicker(time.Millisecond)}
+	users := cache.Namespace("users")
+	sessions := cache.Namespace("sessions")
+	users.Set("a", 1, time.Minute)
+	users.Set("b", 2, time.Minute)
+	sessions.Set("a", 3, time.Minute)
+
+	users.Invalidate()
+	if _, found := users.Get("a"); found {
+		t.Errorf("Get() found a key after Invalidate()")
+	}
+	if len(users.Keys()) != 0 {
+		t.Errorf("Keys() after Invalidate() = %v, want none", users.Keys())
+	}
+	if _, found := cache.Namespace("users").Get("b"); found {
+		t.Errorf("a new view of an invalidated namespace found an old key")
+	}
+	users.Set("a", 4, time.Minute)
+	if value, _ := users.Get("a"); value != 4 {
+		t.Errorf("Get() after Invalidate() and Set() = %v, want 4", value)
+	}
+	if cache.Len() != 4 {
+		t.Fatalf("Len() before sweep = %d, want 4", cache.Len())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	cache.cleanUpCache(ctx)
+
+	if cache.Len() != 2 {
+		t.Errorf("Len() after sweep = %d, want 2", cache.Len())
+	}
+	if value, _ := sessions.Get("a"); value != 3 {
+		t.Errorf("sweep affected another namespace: Get() = %v, want 3", value)
+	}
+	if value, _ := users.Get("a"); value != 4 {
+		t.Errorf("sweep removed the current generation: Get() = %v, want 4", value)
+	}
+}
+
+func Test_parseNamespacedKey(t *testing.T) {
+	tests := []struct {
+		key            string
+		wantName       string
+		wantGeneration uint64
+		wantOK         bool
+	}{
+		{key: "\x00users\x003\x00id\x00with\x00nul", wantName: "users", wantGeneration: 3, wantOK: true},
+		{key: "plain"},
+		{key: "\x00users\x00x\x00id"},
+	}
+	for _, tt := range tests {
+		name, generation, ok := parseNamespacedKey(tt.key)
+		if name != tt.wantName || generation != tt.wantGeneration || ok != tt.wantOK {
+			t.Errorf("parseNamespacedKey(%q) = %q, %d, %v, want %q, %d, %v",
+				tt.key, name, generation, ok, tt.wantName, tt.wantGeneration, tt.wantOK)
+		}
+	}
+}
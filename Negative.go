@@ -0,0 +1,60 @@
+package cache
+
+import "time"
+
+// LookupResult tells a cached value apart from a cached miss.
+type LookupResult int
+
+const (
+	// LookupMiss means nothing is known about the key.
+	LookupMiss LookupResult = iota
+	// LookupHit means the key holds a value.
+	LookupHit
+	// LookupNegative means the key was recorded as not existing.
+	LookupNegative
+)
+
+type NegativeCacher interface {
+	SetNegative(key string, expiredInterval time.Duration)
+	Lookup(key string) (interface{}, LookupResult)
+}
+
+// WithNegativeTTL makes GetOrLoad record a key as not existing for ttl when
+// its loader returns an error wrapping ErrNotFound, so that lookups of missing
+// entities do not reach the loader again until then. ttl must be positive.
+func WithNegativeTTL(ttl time.Duration) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.negativeTTL = ttl
+	}
+}
+
+// SetNegative records that key does not exist for expiredInterval, replacing
+// its value. Get and the other reads treat the key as missing, Lookup reports
+// LookupNegative, and GetOrLoad returns ErrNotFound without loading. Any write
+// to the key replaces the record.
+func (c *inMemoryCache) SetNegative(key string, expiredInterval time.Duration) {
+	item := &cacheItem{negative: true}
+	c.setExpiration(item, expiredInterval)
+	c.set(key, item, defaultItemCost)
+}
+
+// Lookup returns the value under key with LookupHit, or reports whether the
+// key is recorded as not existing.
+func (c *inMemoryCache) Lookup(key string) (interface{}, LookupResult) {
+	if c.isNegative(key) {
+		c.recordAccess(key, false)
+		return nil, LookupNegative
+	}
+	if value, found := c.Get(key); found {
+		return value, LookupHit
+	}
+
+	return nil, LookupMiss
+}
+
+// isNegative reports whether key holds an unexpired negative entry.
+func (c *inMemoryCache) isNegative(key string) bool {
+	item, found := c.load(key)
+
+	return found && item.negative && !item.isExpired(c.now())
+}
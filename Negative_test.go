@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_SetNegative(t *testing.T) {
+	tests := []struct {
+		name       string
+		update     func(c *inMemoryCache, clock *fakeClock)
+		wantValue  interface{}
+		wantResult LookupResult
+	}{
+		{
+			name:       "Unknown key",
+			update:     func(c *inMemoryCache, clock *fakeClock) {},
+			wantResult: LookupMiss,
+		},
+		{
+			name:       "Negative entry",
+			update:     func(c *inMemoryCache, clock *fakeClock) { c.SetNegative("user:1", time.Minute) },
+			wantResult: LookupNegative,
+		},
+		{
+			name: "Negative entry replaces a value",
+			update: func(c *inMemoryCache, clock *fakeClock) {
+				c.Set("user:1", "ada", time.Minute)
+				c.SetNegative("user:1", time.Minute)
+			},
+			wantResult: LookupNegative,
+		},
+		{
+			name: "Set replaces a negative entry",
+			update: func(c *inMemoryCache, clock *fakeClock) {
+				c.SetNegative("user:1", time.Minute)
+				c.Set("user:1", "ada", time.Minute)
+			},
+			wantValue:  "ada",
+			wantResult: LookupHit,
+		},
+		{
+			name: "SetIfAbsent replaces a negative entry",
+			update: func(c *inMemoryCache, clock *fakeClock) {
+				c.SetNegative("user:1", time.Minute)
+				c.SetIfAbsent("user:1", "ada", time.Minute)
+			},
+			wantValue:  "ada",
+			wantResult: LookupHit,
+		},
+		{
+			name: "Expired negative entry",
+			update: func(c *inMemoryCache, clock *fakeClock) {
+				c.SetNegative("user:1", time.Millisecond)
+				clock.Advance(time.Millisecond * 2)
+			},
+			wantResult: LookupMiss,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &inMemoryCache{}
+			clock := newFakeClock()
+			WithClock(clock)(c)
+			tt.update(c, clock)
+
+			value, result := c.Lookup("user:1")
+			if value != tt.wantValue || result != tt.wantResult {
+				t.Errorf("Lookup() = %v, %v, want %v, %v", value, result, tt.wantValue, tt.wantResult)
+			}
+			if _, found := c.Get("user:1"); found != (tt.wantResult == LookupHit) {
+				t.Errorf("Get() found = %v, want %v", found, tt.wantResult == LookupHit)
+			}
+			if keys := c.Keys(); len(keys) != 0 && tt.wantResult != LookupHit {
+				t.Errorf("Keys() = %v, want no keys", keys)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_GetOrLoadNegative(t *testing.T) {
+	tests := []struct {
+		name        string
+		negativeTTL time.Duration
+		loaderErr   error
+		wantCalls   int
+	}{
+		{
+			name:        "Not found is cached with the negative TTL",
+			negativeTTL: time.Minute,
+			loaderErr:   fmt.Errorf("user 1: %w", ErrNotFound),
+			wantCalls:   1,
+		},
+		{
+			name:      "Not found is not cached without a negative TTL",
+			loaderErr: ErrNotFound,
+			wantCalls: 3,
+		},
+		{
+			name:        "Other errors are not cached",
+			negativeTTL: time.Minute,
+			loaderErr:   errors.New("database down"),
+			wantCalls:   3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &inMemoryCache{}
+			WithNegativeTTL(tt.negativeTTL)(c)
+			calls := 0
+
+			for i := 0; i < 3; i++ {
+				_, err := c.GetOrLoad(context.Background(), "user:1", time.Minute, func(ctx context.Context) (interface{}, error) {
+					calls++
+					return nil, tt.loaderErr
+				})
+				if !errors.Is(err, tt.loaderErr) && !(errors.Is(tt.loaderErr, ErrNotFound) && errors.Is(err, ErrNotFound)) {
+					t.Errorf("GetOrLoad() #%d error = %v, want %v", i, err, tt.loaderErr)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("loader ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_GetOrLoadNegativeExpiry(t *testing.T) {
+	c := &inMemoryCache{}
+	clock := newFakeClock()
+	WithClock(clock)(c)
+	WithNegativeTTL(time.Millisecond * 20)(c)
+	found := false
+	loader := func(ctx context.Context) (interface{}, error) {
+		if !found {
+			return nil, ErrNotFound
+		}
+		return "ada", nil
+	}
+
+	c.GetOrLoad(context.Background(), "user:1", time.Minute, loader)
+	found = true
+	if _, err := c.GetOrLoad(context.Background(), "user:1", time.Minute, loader); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetOrLoad() within the negative TTL error = %v, want %v", err, ErrNotFound)
+	}
+
+	clock.Advance(time.Millisecond * 30)
+	if value, err := c.GetOrLoad(context.Background(), "user:1", time.Minute, loader); err != nil || value != "ada" {
+		t.Errorf("GetOrLoad() after the negative TTL = %v, %v, want ada", value, err)
+	}
+}
@@ -0,0 +1,18 @@
+package cache
+
+type Peeker interface {
+	Peek(key string) (interface{}, bool)
+}
+
+// Peek returns the live value under key like Get, but leaves no trace of the
+// read: recency and frequency for eviction, sliding expirations, hit counts
+// and stats are unchanged, and no refresh or stale revalidation starts. It
+// suits monitoring and debugging code.
+func (c *inMemoryCache) Peek(key string) (interface{}, bool) {
+	item, found := c.load(key)
+	if !found || !item.isLive(c.now()) {
+		return nil, false
+	}
+
+	return c.valueOf(item), true
+}
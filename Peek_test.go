@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_Peek(t *testing.T) {
+	tests := []struct {
+		name      string
+		set       func(c *inMemoryCache, clock *fakeClock)
+		wantValue interface{}
+		wantFound bool
+	}{
+		{
+			name:      "Live entry",
+			set:       func(c *inMemoryCache, clock *fakeClock) { c.Set("test", 1, time.Minute) },
+			wantValue: 1,
+			wantFound: true,
+		},
+		{
+			name: "Missing entry",
+			set:  func(c *inMemoryCache, clock *fakeClock) {},
+		},
+		{
+			name: "Expired entry",
+			set: func(c *inMemoryCache, clock *fakeClock) {
+				c.Set("test", 1, time.Millisecond)
+				clock.Advance(time.Millisecond * 2)
+			},
+		},
+		{
+			name: "Negative entry",
+			set:  func(c *inMemoryCache, clock *fakeClock) { c.SetNegative("test", time.Minute) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &inMemoryCache{}
+			clock := newFakeClock()
+			WithClock(clock)(c)
+			tt.set(c, clock)
+
+			value, found := c.Peek("test")
+			if value != tt.wantValue || found != tt.wantFound {
+				t.Errorf("Peek() = %v, %v, want %v, %v", value, found, tt.wantValue, tt.wantFound)
+			}
+			if stats := c.Stats(); stats.Hits != 0 || stats.Misses != 0 {
+				t.Errorf("Peek() changed stats to %d hits and %d misses", stats.Hits, stats.Misses)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_PeekLeavesNoTrace(t *testing.T) {
+	clock := newFakeClock()
+	c := &inMemoryCache{}
+	WithClock(clock)(c)
+	WithMaxEntries(2)(c)
+
+	c.Set("test1", 1, time.Minute)
+	c.SetSliding("test2", 2, time.Minute)
+	clock.Advance(time.Second * 30)
+	c.Peek("test1")
+	c.Peek("test2")
+
+	// A Get would make test1 the most recently used entry.
+	c.Set("test3", 3, time.Minute)
+	if _, found := c.Peek("test1"); found {
+		t.Errorf("Peek() kept test1 from eviction")
+	}
+	if _, info, _ := c.GetWithInfo("test2"); info.Hits != 1 || !info.ExpiresAt.Equal(clock.Now().Add(time.Second*30)) {
+		t.Errorf("Peek() changed test2 to %+v", info)
+	}
+}
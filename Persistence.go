@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"time"
+)
+
+type Persister interface {
+	Save(w io.Writer) error
+	Load(r io.Reader) error
+}
+
+type persistenceHeader struct {
+	SavedAt time.Time
+}
+
+type persistedItem struct {
+	Key   string
+	Value interface{}
+	TTL   time.Duration
+}
+
+// Save writes every live entry together with its remaining lifetime to w using
+// gob. Concrete value types other than the gob builtins must be registered
+// with gob.Register before saving and loading.
+func (c *inMemoryCache) Save(w io.Writer) error {
+	encoder := gob.NewEncoder(w)
+	now := c.now()
+	if err := encoder.Encode(persistenceHeader{SavedAt: now}); err != nil {
+		return err
+	}
+
+	var err error
+	c.storage.Range(func(key string, item *cacheItem) bool {
+		if !item.isLive(now) {
+			return true
+		}
+
+		ttl := NoExpiration
+		if !item.validThrough.IsZero() {
+			ttl = item.validThrough.Sub(now)
+		}
+		err = encoder.Encode(persistedItem{Key: key, Value: c.savedValue(item), TTL: ttl})
+
+		return err == nil
+	})
+
+	return err
+}
+
+// Load reads entries written by Save and stores them with their remaining
+// lifetimes, minus the time passed since they were saved. Entries that expired
+// in the meantime are skipped, entries already present are overwritten.
+func (c *inMemoryCache) Load(r io.Reader) error {
+	decoder := gob.NewDecoder(r)
+	var header persistenceHeader
+	if err := decoder.Decode(&header); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		return err
+	}
+
+	elapsed := c.now().Sub(header.SavedAt)
+	for {
+		var item persistedItem
+		if err := decoder.Decode(&item); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		switch ttl := item.TTL - elapsed; {
+		case item.TTL <= 0:
+			c.restore(item.Key, item.Value, NoExpiration)
+		case ttl > 0:
+			c.restore(item.Key, item.Value, ttl)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type persistedValue struct {
+	Name  string
+	Count int
+}
+
+func init() {
+	gob.Register(persistedValue{})
+}
+
+func Test_inMemoryCache_SaveLoad(t *testing.T) {
+	source := &inMemoryCache{}
+	source.Set("int", 42, time.Second*10)
+	source.Set("string", "value", time.Second*10)
+	source.Set("struct", persistedValue{Name: "test", Count: 3}, time.Second*10)
+	source.Set("expired", 1, time.Nanosecond)
+	source.Set("forever", 2, NoExpiration)
+	time.Sleep(time.Millisecond)
+
+	var buffer bytes.Buffer
+	if err := source.Save(&buffer); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	target := &inMemoryCache{}
+	if err := target.Load(&buffer); err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"int":     42,
+		"string":  "value",
+		"struct":  persistedValue{Name: "test", Count: 3},
+		"forever": 2,
+	}
+	for key, wantValue := range want {
+		value, found := target.Get(key)
+		if !found || !reflect.DeepEqual(value, wantValue) {
+			t.Errorf("Get(%s) after Load() = %v, %v, want %v", key, value, found, wantValue)
+		}
+	}
+	if _, found := target.Get("expired"); found {
+		t.Errorf("Load() restored an expired entry")
+	}
+
+	storageValue, _ := target.storage.Load("int")
+	if remaining := time.Until(storageValue.validThrough); remaining > time.Second*10 || remaining < time.Second*9 {
+		t.Errorf("Load() remaining TTL = %v, want about %v", remaining, time.Second*10)
+	}
+	if storageValue, _ := target.storage.Load("forever"); !storageValue.validThrough.IsZero() {
+		t.Errorf("Load() set an expiration on an entry stored without one")
+	}
+}
+
+func Test_inMemoryCache_LoadInvalidData(t *testing.T) {
+	cache := &inMemoryCache{}
+	if err := cache.Load(strings.NewReader("not a gob stream")); err == nil {
+		t.Errorf("Load() expected an error for invalid data")
+	}
+}
+
+func Test_inMemoryCache_SaveUnregisteredType(t *testing.T) {
+	type unregistered struct{ Value int }
+	cache := &inMemoryCache{}
+	cache.Set("test", unregistered{Value: 1}, time.Second*10)
+
+	if err := cache.Save(&bytes.Buffer{}); err == nil {
+		t.Errorf("Save() expected an error for an unregistered type")
+	}
+}
@@ -0,0 +1,131 @@
+package cache
+
+import "strings"
+
+type PrefixDeleter interface {
+	DeleteByPrefix(prefix string) int
+}
+
+type ConditionalDeleter interface {
+	DeleteWhere(fn func(key string, value interface{}) bool) int
+}
+
+type Clearer interface {
+	Clear() int
+}
+
+// DeleteByPrefix removes every live entry whose key starts with prefix and
+// returns how many were removed.
+func (c *inMemoryCache) DeleteByPrefix(prefix string) int {
+	return c.deleteMatching(func(key string, _ *cacheItem) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+// DeleteWhere removes every live entry for which fn returns true and returns
+// how many were removed. fn runs without the cache lock held and may call back
+// into the cache.
+func (c *inMemoryCache) DeleteWhere(fn func(key string, value interface{}) bool) int {
+	return c.deleteMatching(func(key string, item *cacheItem) bool {
+		return fn(key, c.decoded(item))
+	})
+}
+
+// deleteMatching works like the cleanup loop: it collects candidates without
+// holding the lock, then deletes each one that is still the same item, so an
+// entry rewritten in between is kept.
+func (c *inMemoryCache) deleteMatching(match func(key string, item *cacheItem) bool) int {
+	type candidate struct {
+		key  string
+		item *cacheItem
+	}
+
+	var candidates []candidate
+	now := c.now()
+	c.storage.Range(func(key string, item *cacheItem) bool {
+		if !item.isExpired(now) && match(key, item) {
+			candidates = append(candidates, candidate{key: key, item: item})
+		}
+		return true
+	})
+
+	deleted := 0
+	for _, candidate := range candidates {
+		if c.deleteUnchanged(candidate.key, candidate.item, nil) {
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+// Clear removes every entry and returns how many live entries were removed.
+// The entries disappear at once: a read or write concurrent with Clear sees
+// the cache either before or after it. Tags, costs, eviction and admission
+// state, hot key counts, the existence filter and queued expirations are
+// dropped with them. Clear is journaled as a single record, and does not
+// report the entries to WithOnEvicted, Watch or Expirations.
+func (c *inMemoryCache) Clear() int {
+	if c.evictor != nil {
+		c.mu.Lock()
+		defer c.unlock()
+	}
+
+	removed := c.storage.Clear(func() {
+		c.expiryQueue.clear()
+		c.hotKeys.clear()
+		if c.evictor != nil {
+			c.evictor.clear()
+			if c.admission != nil {
+				c.admission.clear()
+			}
+			if c.costs != nil {
+				c.costs = make(map[string]int64)
+			}
+			c.totalCost = 0
+		}
+	})
+
+	// Writes that raced Clear may still account for entries it removed, so
+	// the removed entries are taken out of the stats one by one.
+	cleared, count := 0, 0
+	now := c.now()
+	removed(func(key string, item *cacheItem) bool {
+		count++
+		c.resize(key, item, nil)
+		c.untag(key, item)
+		if item.isLive(now) {
+			cleared++
+		}
+		return true
+	})
+	c.stats.entries.Add(-int64(count))
+	c.stats.deletes.Add(uint64(count))
+	c.journalClear()
+
+	return cleared
+}
+
+func (c *inMemoryCache) deleteUnchanged(key string, item *cacheItem, notices *[]evictionNotice) bool {
+	if c.evictor != nil {
+		c.mu.Lock()
+		defer c.unlockInto(notices)
+	}
+
+	if !c.storage.CompareAndDelete(key, item) {
+		return false
+	}
+
+	c.stats.entries.Add(-1)
+	c.stats.deletes.Add(1)
+	c.resize(key, item, nil)
+	c.untag(key, item)
+	c.notifyEvicted(key, item, Deleted, notices)
+	c.journalDelete(key)
+	if c.evictor != nil {
+		c.evictor.remove(key)
+		c.removeCost(key)
+	}
+
+	return true
+}
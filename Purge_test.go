@@ -0,0 +1,215 @@
+package cache
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_DeleteByPrefix(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     []func(cache *inMemoryCache)
+		prefix      string
+		wantDeleted int
+		wantKeys    []string
+	}{
+		{
+			name:        "Delete matching keys",
+			prefix:      "user:1:",
+			wantDeleted: 2,
+			wantKeys:    []string{"user:10:name", "user:2:name"},
+		},
+		{
+			name:        "Delete matching keys with eviction enabled",
+			options:     []func(cache *inMemoryCache){WithMaxEntries(10)},
+			prefix:      "user:",
+			wantDeleted: 4,
+		},
+		{
+			name:        "No matching keys",
+			prefix:      "session:",
+			wantDeleted: 0,
+			wantKeys:    []string{"user:10:name", "user:1:email", "user:1:name", "user:2:name"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			for _, optionFn := range tt.options {
+				optionFn(cache)
+			}
+			for _, key := range []string{"user:1:name", "user:1:email", "user:10:name", "user:2:name"} {
+				cache.Set(key, key, time.Minute)
+			}
+			cache.Set("user:1:expired", 1, time.Nanosecond)
+
+			if deleted := cache.DeleteByPrefix(tt.prefix); deleted != tt.wantDeleted {
+				t.Errorf("DeleteByPrefix() = %d, want %d", deleted, tt.wantDeleted)
+			}
+
+			keys := cache.Keys()
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("Keys() after DeleteByPrefix() = %v, want %v", keys, tt.wantKeys)
+			}
+			if got := cache.Stats().Deletes; got != uint64(tt.wantDeleted) {
+				t.Errorf("Stats().Deletes = %d, want %d", got, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_deleteUnchanged(t *testing.T) {
+	cache := &inMemoryCache{}
+	cache.Set("test", 1, time.Minute)
+	stored, _ := cache.storage.Load("test")
+	cache.Set("test", 2, time.Minute)
+
+	if cache.deleteUnchanged("test", stored, nil) {
+		t.Errorf("deleteUnchanged() removed an entry that was rewritten")
+	}
+	if value, found := cache.Get("test"); !found || value != 2 {
+		t.Errorf("Get() = %v, %v, want 2, true", value, found)
+	}
+}
+
+func Test_inMemoryCache_DeleteWhere(t *testing.T) {
+	type tenantValue struct {
+		tenant string
+	}
+
+	cache := &inMemoryCache{}
+	WithMaxEntries(10)(cache)
+	cache.Set("a", tenantValue{tenant: "deleted"}, time.Minute)
+	cache.Set("b", tenantValue{tenant: "active"}, time.Minute)
+	cache.Set("c", tenantValue{tenant: "deleted"}, time.Minute)
+	cache.Set("d", "unrelated", time.Minute)
+
+	deleted := cache.DeleteWhere(func(key string, value interface{}) bool {
+		v, ok := value.(tenantValue)
+		return ok && v.tenant == "deleted"
+	})
+	if deleted != 2 {
+		t.Errorf("DeleteWhere() = %d, want 2", deleted)
+	}
+
+	keys := cache.Keys()
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"b", "d"}) {
+		t.Errorf("Keys() after DeleteWhere() = %v, want [b d]", keys)
+	}
+	if cache.evictor.len() != 2 {
+		t.Errorf("evictor tracks %d keys, want 2", cache.evictor.len())
+	}
+}
+
+func Test_inMemoryCache_Clear(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(cache *inMemoryCache)
+	}{
+		{
+			name: "Default storage",
+		},
+		{
+			name:    "Sharded storage",
+			options: []func(cache *inMemoryCache){WithShardedStorage(4)},
+		},
+		{
+			name:    "Eviction enabled",
+			options: []func(cache *inMemoryCache){WithMaxEntries(3), WithAdmissionPolicy(TinyLFU)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			c := &inMemoryCache{}
+			evicted := 0
+			options := append([]func(cache *inMemoryCache){
+				WithClock(clock),
+				WithHotKeyTracking(10, 1),
+				WithExistenceFilter(100, 0.01),
+				WithOnEvicted(func(string, interface{}, EvictionReason) { evicted++ }),
+			}, tt.options...)
+			for _, option := range options {
+				option(c)
+			}
+			c.SetWithTags("user:1", 1, time.Minute, "users")
+			c.Set("user:2", 2, time.Minute)
+			c.Set("expired", 3, time.Second)
+			c.Get("user:2")
+			clock.Advance(time.Second * 2)
+
+			if cleared := c.Clear(); cleared != 2 {
+				t.Errorf("Clear() = %d, want 2", cleared)
+			}
+			if keys := c.Keys(); len(keys) != 0 {
+				t.Errorf("Keys() after Clear() = %v, want none", keys)
+			}
+			if stats := c.Stats(); stats.Entries != 0 || c.EstimatedSize() != 0 {
+				t.Errorf("Stats() after Clear() = %d entries of %d bytes, want none", stats.Entries, c.EstimatedSize())
+			}
+			if evicted != 0 {
+				t.Errorf("Clear() reported %d entries to OnEvicted, want none", evicted)
+			}
+			if top := c.TopKeys(10); len(top) != 0 {
+				t.Errorf("TopKeys() after Clear() = %v, want none", top)
+			}
+			if c.storage.filter.mayContain("user:1") {
+				t.Errorf("existence filter still holds a cleared key")
+			}
+			if queued := c.expiryQueue.len(); queued != 0 {
+				t.Errorf("%d expirations queued after Clear(), want 0", queued)
+			}
+
+			// The cache starts over: no stale tags, and room for a full set of
+			// entries.
+			if invalidated := c.InvalidateTag("users"); invalidated != 0 {
+				t.Errorf("InvalidateTag() after Clear() = %d, want 0", invalidated)
+			}
+			for _, key := range []string{"a", "b", "c"} {
+				c.Set(key, 1, time.Minute)
+			}
+			if len(c.Keys()) != 3 {
+				t.Errorf("Keys() after refilling = %v, want 3 keys", c.Keys())
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_Clear_Atomic(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache()
+			keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+			for _, key := range keys {
+				c.Set(key, 1, time.Minute)
+			}
+
+			// Once a reader misses one key, it misses every key after it.
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; i < 1000; i++ {
+					missed := false
+					for _, key := range keys {
+						_, found := c.Get(key)
+						if missed && found {
+							t.Errorf("Get(%s) found a key after another was cleared", key)
+							return
+						}
+						missed = missed || !found
+					}
+				}
+			}()
+			c.Clear()
+			<-done
+
+			if entries := c.Stats().Entries; entries != 0 {
+				t.Errorf("Stats().Entries after Clear() = %d, want 0", entries)
+			}
+		})
+	}
+}
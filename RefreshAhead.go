@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// WithRefreshAhead reloads an entry in the background when it is read more
+// than refreshAfter after it was stored, so hot keys are replaced before they
+// expire while keys nobody reads expire as usual. The reader still gets the
+// current value. Refreshes share loader calls and keep interval and tags like
+// WithStaleWhileRevalidate; a failed refresh is retried on the next read. Only
+// entries stored with an interval are refreshed, and sliding entries are not,
+// as every read restarts their interval.
+func WithRefreshAhead(loader func(ctx context.Context, key string) (interface{}, error), refreshAfter time.Duration) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.refreshLoader = loader
+		cache.refreshAfter = refreshAfter
+	}
+}
+
+// refreshAhead starts a refresh of the live item when it is due. Touch
+// restarts the interval and therefore the refresh delay as well.
+func (c *inMemoryCache) refreshAhead(key string, item *cacheItem, now time.Time) {
+	if c.refreshLoader == nil || item.interval <= 0 || item.sliding {
+		return
+	}
+	if storedAt := item.validThrough.Add(-item.interval); now.Sub(storedAt) < c.refreshAfter {
+		return
+	}
+
+	c.refreshInBackground(key, item, c.refreshLoader)
+}
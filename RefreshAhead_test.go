@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithRefreshAhead(t *testing.T) {
+	tests := []struct {
+		name        string
+		wait        time.Duration
+		wantRefresh bool
+	}{
+		{
+			name:        "Read after refreshAfter refreshes the entry",
+			wait:        time.Millisecond * 30,
+			wantRefresh: true,
+		},
+		{
+			name: "Read before refreshAfter keeps the entry",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var loads atomic.Int32
+			clock := newFakeClock()
+			cache := &inMemoryCache{}
+			WithClock(clock)(cache)
+			WithRefreshAhead(func(ctx context.Context, key string) (interface{}, error) {
+				loads.Add(1)
+				return "fresh", nil
+			}, time.Millisecond*20)(cache)
+
+			cache.Set("test", "stored", time.Millisecond*50)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			events := cache.Watch(ctx, "test")
+			clock.Advance(tt.wait)
+			if value, found := cache.Get("test"); !found || value != "stored" {
+				t.Fatalf("Get() = %v, %v, want the stored value", value, found)
+			}
+			// The refresh starts, if at all, before Get returns.
+			if tt.wantRefresh {
+				waitForEvent(t, events, EventSet)
+			}
+
+			wantValue, wantLoads := "stored", int32(0)
+			if tt.wantRefresh {
+				wantValue, wantLoads = "fresh", 1
+			}
+			if value, _ := cache.Get("test"); value != wantValue {
+				t.Errorf("Get() after refresh = %v, want %v", value, wantValue)
+			}
+			if loads.Load() != wantLoads {
+				t.Errorf("loader ran %d times, want %d", loads.Load(), wantLoads)
+			}
+		})
+	}
+}
+
+func TestWithRefreshAhead_KeepsHotKeysWarm(t *testing.T) {
+	clock := newFakeClock()
+	cache := &inMemoryCache{}
+	WithClock(clock)(cache)
+	WithRefreshAhead(func(ctx context.Context, key string) (interface{}, error) {
+		return "fresh", nil
+	}, time.Millisecond*10)(cache)
+	cache.Set("hot", "stored", time.Millisecond*20)
+	cache.Set("idle", "stored", time.Millisecond*20)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := cache.Watch(ctx, "hot")
+
+	for i := 1; i <= 10; i++ {
+		clock.Advance(time.Millisecond * 5)
+		if _, found := cache.Get("hot"); !found {
+			t.Fatalf("Get(hot) missed after %d reads", i)
+		}
+		// Every second read comes 10ms after the last refresh and starts
+		// the next one.
+		if i%2 == 0 {
+			waitForEvent(t, events, EventSet)
+		}
+	}
+	if _, found := cache.Get("idle"); found {
+		t.Errorf("Get(idle) found an entry nobody read")
+	}
+}
@@ -0,0 +1,214 @@
+package cache
+
+import "reflect"
+
+type SizeEstimator interface {
+	EstimatedSize() int64
+}
+
+// WithSizer replaces the default size estimate of values, which walks them
+// with reflection, with sizer. sizer is called on every write and must be
+// safe for concurrent use.
+func WithSizer(sizer func(value interface{}) int64) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.sizer = sizer
+	}
+}
+
+// WithMaxItemSize keeps values whose estimated size exceeds maxBytes out of
+// the cache. A write of such a value removes the entry it would have
+// replaced, so readers never see the older value, and counts towards
+// Stats.Rejected.
+func WithMaxItemSize(maxBytes int) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		if maxBytes <= 0 {
+			return
+		}
+		cache.maxItemSize = int64(maxBytes)
+	}
+}
+
+// EstimatedSize returns the approximate number of bytes held by the stored
+// keys and values, including expired entries not cleaned up yet. Bookkeeping
+// such as the storage maps and eviction lists is not counted. Unless
+// WithMaxMemory, WithMaxItemSize or WithSizer is set, values other than
+// strings, byte slices and numbers count with their shallow size only, as
+// walking them would slow down every write.
+func (c *inMemoryCache) EstimatedSize() int64 {
+	return c.stats.bytes.Load()
+}
+
+func (c *inMemoryCache) sizeOf(value interface{}) int64 {
+	switch {
+	case c.sizer != nil:
+		return c.sizer(value)
+	case c.maxMemory > 0 || c.maxItemSize > 0:
+		return estimateSize(value)
+	default:
+		return shallowSize(value)
+	}
+}
+
+// itemError returns why item must not be stored: the codec failed to encode
+// its value or the value exceeds WithMaxItemSize.
+func (c *inMemoryCache) itemError(item *cacheItem) error {
+	if failure, ok := item.value.(encodingFailure); ok {
+		return failure.err
+	}
+	if c.maxItemSize > 0 && item.size > c.maxItemSize {
+		return ErrValueTooLarge
+	}
+
+	return nil
+}
+
+// reject drops a write to key of an item itemError refuses along with the
+// entry it would have replaced.
+func (c *inMemoryCache) reject(key string) {
+	c.stats.rejected.Add(1)
+	if c.evictor == nil {
+		c.deleteItem(key, Deleted)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	c.deleteLocked(key)
+}
+
+// exceedsMemory reports whether storing item under key, replacing its current
+// entry, would take the cache over WithMaxMemory.
+func (c *inMemoryCache) exceedsMemory(key string, item *cacheItem) bool {
+	if c.maxMemory <= 0 {
+		return false
+	}
+
+	var previous int64
+	if current, found := c.storage.Load(key); found {
+		previous = int64(len(key)) + current.size
+	}
+
+	return c.stats.bytes.Load()-previous+int64(len(key))+item.size > c.maxMemory
+}
+
+// resize accounts for previous being replaced by item under key; a nil
+// previous means the key was added and a nil item that it was removed.
+func (c *inMemoryCache) resize(key string, previous, item *cacheItem) {
+	var delta int64
+	if previous != nil {
+		delta -= int64(len(key)) + previous.size
+	}
+	if item != nil {
+		delta += int64(len(key)) + item.size
+	}
+	if delta != 0 {
+		c.stats.bytes.Add(delta)
+	}
+}
+
+// estimateSize returns the bytes reachable from value. Common scalar types are
+// sized without reflection; shared pointers are counted once.
+func estimateSize(value interface{}) int64 {
+	if size, ok := scalarSize(value); ok {
+		return size
+	}
+
+	return sizeOfValue(reflect.ValueOf(value), make(map[uintptr]struct{}))
+}
+
+// shallowSize is estimateSize without following references, in constant
+// time.
+func shallowSize(value interface{}) int64 {
+	if size, ok := scalarSize(value); ok {
+		return size
+	}
+
+	return int64(reflect.TypeOf(value).Size())
+}
+
+func scalarSize(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case nil:
+		return 0, true
+	case string:
+		return int64(len(v)), true
+	case []byte:
+		return int64(len(v)), true
+	case bool, int8, uint8:
+		return 1, true
+	case int16, uint16:
+		return 2, true
+	case int32, uint32, float32:
+		return 4, true
+	case int, uint, int64, uint64, float64, uintptr:
+		return 8, true
+	}
+
+	return 0, false
+}
+
+func sizeOfValue(v reflect.Value, seen map[uintptr]struct{}) int64 {
+	return int64(v.Type().Size()) + referencedSize(v, seen)
+}
+
+// referencedSize returns the bytes v points to outside its own memory.
+func referencedSize(v reflect.Value, seen map[uintptr]struct{}) int64 {
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Pointer:
+		if v.IsNil() || visited(v.Pointer(), seen) {
+			return 0
+		}
+		return sizeOfValue(v.Elem(), seen)
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		return sizeOfValue(v.Elem(), seen)
+	case reflect.Slice:
+		if v.IsNil() || visited(v.Pointer(), seen) {
+			return 0
+		}
+		size := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSize(v.Index(i), seen)
+		}
+		return size
+	case reflect.Array:
+		var size int64
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSize(v.Index(i), seen)
+		}
+		return size
+	case reflect.Struct:
+		var size int64
+		for i := 0; i < v.NumField(); i++ {
+			size += referencedSize(v.Field(i), seen)
+		}
+		return size
+	case reflect.Map:
+		if v.IsNil() || visited(v.Pointer(), seen) {
+			return 0
+		}
+		keySize, elemSize := int64(v.Type().Key().Size()), int64(v.Type().Elem().Size())
+		var size int64
+		iter := v.MapRange()
+		for iter.Next() {
+			size += keySize + elemSize + referencedSize(iter.Key(), seen) + referencedSize(iter.Value(), seen)
+		}
+		return size
+	}
+
+	return 0
+}
+
+func visited(pointer uintptr, seen map[uintptr]struct{}) bool {
+	if _, found := seen[pointer]; found {
+		return true
+	}
+	seen[pointer] = struct{}{}
+
+	return false
+}
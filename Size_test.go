@@ -0,0 +1,280 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_EstimatedSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*inMemoryCache)
+		run     func(cache *inMemoryCache)
+		want    int64
+	}{
+		{
+			name: "Keys and values",
+			run: func(cache *inMemoryCache) {
+				cache.Set("test1", "value", time.Minute)
+				cache.Set("test2", []byte("ab"), time.Minute)
+			},
+			want: 5 + 5 + 5 + 2,
+		},
+		{
+			name: "Replaced value",
+			run: func(cache *inMemoryCache) {
+				cache.Set("test", "a long value", time.Minute)
+				cache.Set("test", "short", time.Minute)
+			},
+			want: 4 + 5,
+		},
+		{
+			name: "Deleted and expired entries",
+			run: func(cache *inMemoryCache) {
+				cache.Set("test1", "value", time.Minute)
+				cache.Set("test2", "value", time.Nanosecond)
+				cache.Set("test3", "value", time.Minute)
+				cache.Delete("test3")
+				time.Sleep(time.Millisecond)
+				cache.CleanupNow()
+			},
+			want: 5 + 5,
+		},
+		{
+			name: "Counter",
+			run: func(cache *inMemoryCache) {
+				_, _ = cache.Increment("test", 1, time.Minute)
+				_, _ = cache.Increment("test", 1, time.Minute)
+			},
+			want: 4 + 8,
+		},
+		{
+			name:    "Sizer",
+			options: []func(*inMemoryCache){WithSizer(func(value interface{}) int64 { return 100 })},
+			run: func(cache *inMemoryCache) {
+				cache.Set("test", "value", time.Minute)
+				cache.GetOrSet("other", "value", time.Minute)
+			},
+			want: 4 + 100 + 5 + 100,
+		},
+		{
+			name: "Composite value without a size limit",
+			run: func(cache *inMemoryCache) {
+				cache.Set("test", map[string]int32{"ab": 1}, time.Minute)
+			},
+			want: 4 + 8,
+		},
+		{
+			name:    "Composite value with a size limit",
+			options: []func(*inMemoryCache){WithMaxItemSize(1000)},
+			run: func(cache *inMemoryCache) {
+				cache.Set("test", map[string]int32{"ab": 1}, time.Minute)
+			},
+			want: 4 + 8 + 16 + 4 + 2,
+		},
+		{
+			name:    "Evicted entry",
+			options: []func(*inMemoryCache){WithMaxEntries(1)},
+			run: func(cache *inMemoryCache) {
+				cache.Set("test1", "value", time.Minute)
+				cache.Set("test2", "value", time.Minute)
+			},
+			want: 5 + 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			for _, optionFn := range tt.options {
+				optionFn(cache)
+			}
+			tt.run(cache)
+
+			if got := cache.EstimatedSize(); got != tt.want {
+				t.Errorf("EstimatedSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_estimateSize(t *testing.T) {
+	type pair struct {
+		Name  string
+		Count int32
+	}
+	shared := &pair{Name: "abc"}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  int64
+	}{
+		{name: "Nil", value: nil, want: 0},
+		{name: "String", value: "abc", want: 3},
+		{name: "Int", value: 42, want: 8},
+		{name: "Struct", value: pair{Name: "abc"}, want: 24 + 3},
+		{name: "Pointer", value: &pair{Name: "abc"}, want: 8 + 24 + 3},
+		{name: "Slice", value: []string{"ab", "c"}, want: 24 + 2*16 + 3},
+		{name: "Shared pointer", value: []*pair{shared, shared}, want: 24 + 2*8 + 24 + 3},
+		{name: "Map", value: map[string]int32{"ab": 1}, want: 8 + 16 + 4 + 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateSize(tt.value); got != tt.want {
+				t.Errorf("estimateSize(%v) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithMaxMemory(t *testing.T) {
+	type item struct {
+		key   string
+		value string
+	}
+	tests := []struct {
+		name    string
+		options []func(*inMemoryCache)
+		items   []item
+		want    []string
+		notWant []string
+		size    int64
+	}{
+		{
+			name:  "Items fit into the budget",
+			items: []item{{"test1", "abcde"}, {"test2", "abcde"}},
+			want:  []string{"test1", "test2"},
+			size:  20,
+		},
+		{
+			name:    "Evict least recently used items until the new one fits",
+			items:   []item{{"test1", "abc"}, {"test2", "abc"}, {"test3", "abcdefg"}},
+			want:    []string{"test2", "test3"},
+			notWant: []string{"test1"},
+			size:    20,
+		},
+		{
+			name:    "Item exceeding the budget is not cached",
+			items:   []item{{"test1", "abc"}, {"test2", "abcdefghijklmnopq"}},
+			want:    []string{"test1"},
+			notWant: []string{"test2"},
+			size:    8,
+		},
+		{
+			name:  "Overwriting an item replaces its size",
+			items: []item{{"test1", "abc"}, {"test2", "abc"}, {"test1", "abcde"}},
+			want:  []string{"test1", "test2"},
+			size:  18,
+		},
+		{
+			name:    "Combined with an entry limit",
+			options: []func(*inMemoryCache){WithMaxEntries(1)},
+			items:   []item{{"test1", "abc"}, {"test2", "abc"}},
+			want:    []string{"test2"},
+			notWant: []string{"test1"},
+			size:    8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			WithMaxMemory(20)(cache)
+			for _, optionFn := range tt.options {
+				optionFn(cache)
+			}
+
+			for _, item := range tt.items {
+				cache.Set(item.key, item.value, time.Second*10)
+			}
+
+			for _, key := range tt.want {
+				if _, ok := cache.Get(key); !ok {
+					t.Errorf("Get() expected key %s to be cached", key)
+				}
+			}
+			for _, key := range tt.notWant {
+				if _, ok := cache.Get(key); ok {
+					t.Errorf("Get() expected key %s not to be cached", key)
+				}
+			}
+			if got := cache.EstimatedSize(); got != tt.size {
+				t.Errorf("EstimatedSize() = %d, want %d", got, tt.size)
+			}
+		})
+	}
+}
+
+func TestWithMaxItemSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   []func(*inMemoryCache)
+		run       func(cache *inMemoryCache) (interface{}, bool)
+		wantValue interface{}
+		wantFound bool
+		rejected  uint64
+	}{
+		{
+			name: "Small value is cached",
+			run: func(cache *inMemoryCache) (interface{}, bool) {
+				cache.Set("test", "abc", time.Minute)
+				return cache.Get("test")
+			},
+			wantValue: "abc",
+			wantFound: true,
+		},
+		{
+			name: "Oversized value removes the previous one",
+			run: func(cache *inMemoryCache) (interface{}, bool) {
+				cache.Set("test", "abc", time.Minute)
+				cache.Set("test", "abcdefghijk", time.Minute)
+				return cache.Get("test")
+			},
+			rejected: 1,
+		},
+		{
+			name:    "Oversized value with an entry limit",
+			options: []func(*inMemoryCache){WithMaxEntries(10)},
+			run: func(cache *inMemoryCache) (interface{}, bool) {
+				cache.Set("test", "abc", time.Minute)
+				cache.SetMulti(map[string]interface{}{"test": "abcdefghijk"}, time.Minute)
+				return cache.Get("test")
+			},
+			rejected: 1,
+		},
+		{
+			name: "GetOrSet keeps the live value",
+			run: func(cache *inMemoryCache) (interface{}, bool) {
+				cache.Set("test", "abc", time.Minute)
+				return cache.GetOrSet("test", "abcdefghijk", time.Minute)
+			},
+			wantValue: "abc",
+			wantFound: true,
+		},
+		{
+			name: "Swap",
+			run: func(cache *inMemoryCache) (interface{}, bool) {
+				cache.Set("test", "abc", time.Minute)
+				cache.Swap("test", "abcdefghijk", time.Minute)
+				return cache.Get("test")
+			},
+			rejected: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			WithMaxItemSize(10)(cache)
+			for _, optionFn := range tt.options {
+				optionFn(cache)
+			}
+
+			value, found := tt.run(cache)
+			if value != tt.wantValue || found != tt.wantFound {
+				t.Errorf("got %v, %v, want %v, %v", value, found, tt.wantValue, tt.wantFound)
+			}
+			if rejected := cache.Stats().Rejected; rejected != tt.rejected {
+				t.Errorf("Stats().Rejected = %d, want %d", rejected, tt.rejected)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package cache
+
+import "time"
+
+type SlidingSetter interface {
+	SetSliding(key string, value interface{}, idleTTL time.Duration)
+}
+
+// SetSliding stores value so that it expires only after idleTTL without a
+// read: every Get or GetOrSet hit pushes the expiration forward by idleTTL.
+// A non-positive idleTTL stores the value without expiration. Save, snapshots
+// and the journal keep only the current expiration, so restored entries no
+// longer slide.
+func (c *inMemoryCache) SetSliding(key string, value interface{}, idleTTL time.Duration) {
+	item := c.newCacheItem(value, idleTTL)
+	item.sliding = idleTTL > 0
+	c.set(key, item, defaultItemCost)
+}
+
+// slide replaces a sliding item with a copy whose interval restarts now. Items
+// are never modified in place, so readers holding the old one stay race free;
+// if the entry was replaced meanwhile, the newer value wins.
+func (c *inMemoryCache) slide(key string, item *cacheItem) {
+	if !item.sliding || item.interval <= 0 {
+		return
+	}
+
+	extended := item.clone()
+	extended.validThrough = c.expiresAt(item.interval)
+	c.storage.CompareAndSwap(key, item, extended)
+}
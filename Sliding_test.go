@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_SetSliding(t *testing.T) {
+	tests := []struct {
+		name      string
+		idleTTL   time.Duration
+		reads     int
+		wait      time.Duration
+		wantFound bool
+	}{
+		{
+			name:      "Reads keep the value alive",
+			idleTTL:   time.Millisecond * 30,
+			reads:     5,
+			wait:      time.Millisecond * 10,
+			wantFound: true,
+		},
+		{
+			name:    "Value expires after inactivity",
+			idleTTL: time.Millisecond * 5,
+			wait:    time.Millisecond * 20,
+		},
+		{
+			name:      "Non-positive interval never expires",
+			idleTTL:   0,
+			wait:      time.Millisecond * 5,
+			wantFound: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			cache := &inMemoryCache{}
+			WithClock(clock)(cache)
+			cache.SetSliding("test", 42, tt.idleTTL)
+
+			for i := 0; i < tt.reads; i++ {
+				clock.Advance(tt.wait)
+				if _, found := cache.Get("test"); !found {
+					t.Fatalf("Get() after %d reads expected a hit", i)
+				}
+			}
+			clock.Advance(tt.wait)
+			if _, found := cache.Get("test"); found != tt.wantFound {
+				t.Errorf("Get() found = %v, want %v", found, tt.wantFound)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_SetSliding_Set(t *testing.T) {
+	clock := newFakeClock()
+	cache := &inMemoryCache{}
+	WithClock(clock)(cache)
+	cache.SetSliding("test", 1, time.Millisecond*20)
+	cache.Set("test", 2, time.Millisecond*20)
+
+	clock.Advance(time.Millisecond * 10)
+	cache.Get("test")
+	clock.Advance(time.Millisecond * 15)
+	if _, found := cache.Get("test"); found {
+		t.Errorf("Get() found a value that Set replaced with a fixed expiration")
+	}
+}
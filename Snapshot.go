@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry is a cache entry copied out of the cache.
+type Entry struct {
+	Value interface{}
+	// TTL is the lifetime left when the entry was copied, or NoExpiration.
+	TTL time.Duration
+	// ExpiresAt is zero for entries without expiration.
+	ExpiresAt time.Time
+}
+
+type Snapshotter interface {
+	Snapshot() map[string]Entry
+}
+
+type snapshotConfig struct {
+	path     string
+	interval time.Duration
+}
+
+// WithSnapshot makes the cache restore its entries from path on creation and
+// rewrite the file every interval and once more when ctx is done.
+func WithSnapshot(path string, interval time.Duration) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.snapshot = &snapshotConfig{path: path, interval: interval}
+	}
+}
+
+// snapshotPeriodically saves a snapshot on every tick of ticker, which comes
+// from the cache's clock, and once more when ctx is done.
+func (c *inMemoryCache) snapshotPeriodically(ctx context.Context, ticker Ticker) {
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.saveSnapshot()
+			return
+		case <-ticker.C():
+			c.saveSnapshot()
+		}
+	}
+}
+
+func (c *inMemoryCache) restoreSnapshot() error {
+	file, err := os.Open(c.snapshot.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+	defer file.Close()
+
+	if err := c.Load(bufio.NewReader(file)); err != nil {
+		return err
+	}
+	c.log(slog.LevelInfo, "cache snapshot restored", "path", c.snapshot.path, "entries", c.stats.entries.Load())
+
+	return nil
+}
+
+func (c *inMemoryCache) saveSnapshot() {
+	startedAt := time.Now()
+	if err := c.writeSnapshot(); err != nil {
+		c.log(slog.LevelError, "cache snapshot failed", "path", c.snapshot.path, "error", err)
+		return
+	}
+	c.log(slog.LevelInfo, "cache snapshot written", "path", c.snapshot.path, "duration", time.Since(startedAt))
+}
+
+// writeSnapshot saves into a temporary file next to the target and renames it
+// into place, so readers never observe a partially written snapshot.
+func (c *inMemoryCache) writeSnapshot() error {
+	file, err := os.CreateTemp(filepath.Dir(c.snapshot.path), filepath.Base(c.snapshot.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	writer := bufio.NewWriter(file)
+	if err := c.Save(writer); err != nil {
+		file.Close()
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), c.snapshot.path)
+}
+
+// Snapshot returns a copy of every live entry with its remaining lifetime as
+// of one instant. It only holds a reference to each entry while walking the
+// storage, so writers are never blocked, and decodes or copies the values
+// afterwards. Each entry is consistent, but writes made while the walk runs
+// may or may not be included. Values are read like Get reads them: decoded
+// with WithCodec and copied with WithValueCopying; otherwise they are the
+// stored values themselves, which callers must not mutate.
+func (c *inMemoryCache) Snapshot() map[string]Entry {
+	items := make(map[string]*cacheItem)
+	now := c.now()
+	c.storage.Range(func(key string, item *cacheItem) bool {
+		if item.isLive(now) {
+			items[key] = item
+		}
+		return true
+	})
+
+	entries := make(map[string]Entry, len(items))
+	for key, item := range items {
+		entry := Entry{Value: c.valueOf(item), TTL: NoExpiration, ExpiresAt: item.validThrough}
+		if !item.validThrough.IsZero() {
+			entry.TTL = item.validThrough.Sub(now)
+		}
+		entries[key] = entry
+	}
+
+	return entries
+}
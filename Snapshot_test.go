@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logLines sends every record a slog.TextHandler writes to it.
+type logLines chan string
+
+func (l logLines) Write(p []byte) (int, error) {
+	l <- string(p)
+	return len(p), nil
+}
+
+func TestWithSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	clock := newFakeClock()
+	lines := make(logLines, 4)
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	source := NewInMemoryCache(ctx,
+		WithClock(clock),
+		WithSnapshot(path, time.Minute),
+		WithLogger(slog.New(slog.NewTextHandler(lines, nil))),
+	)
+	source.Set("test1", 1, time.Hour)
+	source.Set("test2", 2, time.Second*30)
+
+	waitForSnapshot := func() {
+		t.Helper()
+		select {
+		case line := <-lines:
+			if !strings.Contains(line, "cache snapshot written") {
+				t.Fatalf("WithSnapshot() logged %q, want a written snapshot", line)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("WithSnapshot() didn't write a snapshot")
+		}
+	}
+	clock.Advance(time.Minute)
+	waitForSnapshot()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("WithSnapshot() didn't write a snapshot: %v", err)
+	}
+	cancelFn()
+	waitForSnapshot()
+
+	restored := NewInMemoryCache(context.Background(), WithClock(clock), WithSnapshot(path, time.Hour))
+	if value, found := restored.Get("test1"); !found || value != 1 {
+		t.Errorf("Get(test1) after restore = %v, %v, want %v, %v", value, found, 1, true)
+	}
+	if _, found := restored.Get("test2"); found {
+		t.Errorf("Get(test2) after restore expected expired entry to be skipped")
+	}
+
+	matches, _ := filepath.Glob(path + ".tmp*")
+	if len(matches) != 0 {
+		t.Errorf("WithSnapshot() left temporary files behind: %v", matches)
+	}
+}
+
+func TestWithSnapshot_MissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.snapshot")
+	cache := &inMemoryCache{}
+	WithSnapshot(path, time.Hour)(cache)
+
+	if err := cache.restoreSnapshot(); err != nil {
+		t.Errorf("restoreSnapshot() error for a missing file: %v", err)
+	}
+}
+
+func Test_inMemoryCache_Snapshot(t *testing.T) {
+	clock := newFakeClock()
+	c := &inMemoryCache{}
+	WithClock(clock)(c)
+	WithValueCopying(nil)(c)
+
+	c.Set("short", 1, time.Minute)
+	c.Set("forever", 2, NoExpiration)
+	c.Set("expired", 3, time.Second)
+	c.SetNegative("negative", time.Minute)
+	c.Set("slice", []int{1, 2}, time.Hour)
+	clock.Advance(time.Second * 2)
+
+	snapshot := c.Snapshot()
+	if len(snapshot) != 3 {
+		t.Fatalf("Snapshot() = %v, want 3 entries", snapshot)
+	}
+	if entry := snapshot["short"]; entry.Value != 1 || entry.TTL != time.Second*58 || !entry.ExpiresAt.Equal(clock.Now().Add(time.Second*58)) {
+		t.Errorf("Snapshot()[short] = %+v, want 1 with 58s left", entry)
+	}
+	if entry := snapshot["forever"]; entry.Value != 2 || entry.TTL != NoExpiration || !entry.ExpiresAt.IsZero() {
+		t.Errorf("Snapshot()[forever] = %+v, want 2 without expiration", entry)
+	}
+
+	// The snapshot is a copy: later writes do not show through it.
+	snapshot["slice"].Value.([]int)[0] = 9
+	c.Set("short", 10, time.Minute)
+	if value, _ := c.Get("slice"); value.([]int)[0] != 1 {
+		t.Errorf("changing a snapshot value changed the cache to %v", value)
+	}
+	if snapshot["short"].Value != 1 {
+		t.Errorf("Snapshot()[short] changed to %v after a Set", snapshot["short"].Value)
+	}
+}
+
+func Test_inMemoryCache_SnapshotValues(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []func(*inMemoryCache)
+		wantShared bool
+	}{
+		{name: "Value copying", options: []func(*inMemoryCache){WithValueCopying(nil)}},
+		{name: "Codec", options: []func(*inMemoryCache){WithCodec(GobCodec{})}},
+		{name: "Stored values", wantShared: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &inMemoryCache{}
+			for _, optionFn := range tt.options {
+				optionFn(c)
+			}
+			c.Set("slice", []int{1, 2}, time.Hour)
+
+			c.Snapshot()["slice"].Value.([]int)[0] = 9
+			value, _ := c.Get("slice")
+			if shared := value.([]int)[0] == 9; shared != tt.wantShared {
+				t.Errorf("cache sees a change to a snapshot value: %v, want %v", shared, tt.wantShared)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// WithStaleWhileRevalidate keeps serving an expired entry for up to grace
+// after its expiration while loader fetches a fresh value in the background.
+// Concurrent refreshes of a key share one loader call, like GetOrLoad, and a
+// failed refresh leaves the stale value in place until the grace window ends.
+// Refreshed entries keep their interval, tags and sliding mode. Entries stored
+// by SetWithDeadline or without expiration are never served stale.
+func WithStaleWhileRevalidate(grace time.Duration, loader func(ctx context.Context, key string) (interface{}, error)) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.staleGrace = grace
+		cache.staleLoader = loader
+	}
+}
+
+// serveStale reports whether the expired item may still be returned and, if
+// so, starts its refresh.
+func (c *inMemoryCache) serveStale(key string, item *cacheItem, now time.Time) bool {
+	if c.staleLoader == nil || item.interval <= 0 || item.isExpired(now.Add(-c.staleGrace)) {
+		return false
+	}
+
+	c.refreshInBackground(key, item, c.staleLoader)
+
+	return true
+}
+
+// refreshInBackground starts refresh unless one is already running for key.
+// Refreshes have their own load group, so GetOrLoad never waits on one and
+// never gets a result meant for the refresh.
+func (c *inMemoryCache) refreshInBackground(key string, item *cacheItem, loader func(ctx context.Context, key string) (interface{}, error)) {
+	if c.refreshes.pending(key) {
+		return
+	}
+
+	go c.refresh(key, item, loader)
+}
+
+// refresh replaces item with a value produced by loader unless the entry was
+// changed in the meantime.
+func (c *inMemoryCache) refresh(key string, item *cacheItem, loader func(ctx context.Context, key string) (interface{}, error)) {
+	ctx := context.Background()
+	_, err := c.refreshes.do(ctx, key, func() (interface{}, error) {
+		if current, found := c.storage.Load(key); !found || current != item {
+			return nil, nil
+		}
+
+		startedAt := time.Now()
+		value, err := loader(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		refreshed := c.newCacheItem(value, item.interval)
+		refreshed.tags = item.tags
+		refreshed.sliding = item.sliding
+		refreshed.delta = time.Since(startedAt)
+		c.set(key, refreshed, defaultItemCost)
+		c.tag(key)
+
+		return value, nil
+	})
+	if err != nil {
+		c.log(slog.LevelWarn, "cache refresh failed", "key", key, "error", err)
+	}
+}
+
+// isRemovable reports whether cleanup may delete item, which is the case once
+// it can no longer be served stale.
+func (c *inMemoryCache) isRemovable(item *cacheItem, now time.Time) bool {
+	if c.staleLoader == nil || item.interval <= 0 {
+		return item.isExpired(now)
+	}
+
+	return item.isExpired(now.Add(-c.staleGrace))
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithStaleWhileRevalidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		loaderErr error
+		wait      time.Duration
+		wantStale bool
+		wantValue interface{}
+	}{
+		{
+			name:      "Stale value is served and refreshed",
+			wait:      time.Millisecond * 10,
+			wantStale: true,
+			wantValue: "fresh",
+		},
+		{
+			name:      "Failed refresh keeps the stale value",
+			loaderErr: errors.New("unavailable"),
+			wait:      time.Millisecond * 10,
+			wantStale: true,
+			wantValue: "stale",
+		},
+		{
+			name: "Value past the grace window is missing",
+			wait: time.Millisecond * 60,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var loads atomic.Int32
+			loaded := make(chan struct{}, 1)
+			loaderErr := tt.loaderErr
+			clock := newFakeClock()
+			cache := &inMemoryCache{}
+			WithClock(clock)(cache)
+			WithStaleWhileRevalidate(time.Millisecond*50, func(ctx context.Context, key string) (interface{}, error) {
+				defer func() { loaded <- struct{}{} }()
+				loads.Add(1)
+				if loaderErr != nil {
+					return nil, loaderErr
+				}
+				return "fresh", nil
+			})(cache)
+
+			cache.Set("test", "stale", time.Millisecond*5)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			events := cache.Watch(ctx, "test")
+			clock.Advance(tt.wait)
+
+			value, found := cache.Get("test")
+			if found != tt.wantStale || tt.wantStale && value != "stale" {
+				t.Fatalf("Get() = %v, %v, want the stale value: %v", value, found, tt.wantStale)
+			}
+			if !tt.wantStale {
+				return
+			}
+
+			select {
+			case <-loaded:
+			case <-time.After(time.Second):
+				t.Fatalf("loader was not called")
+			}
+			if loaderErr == nil {
+				waitForEvent(t, events, EventSet)
+			}
+			if value, _ := cache.Get("test"); value != tt.wantValue {
+				t.Errorf("Get() after refresh = %v, want %v", value, tt.wantValue)
+			}
+			if loads.Load() != 1 {
+				t.Errorf("loader ran %d times, want %d", loads.Load(), 1)
+			}
+		})
+	}
+}
+
+func TestWithStaleWhileRevalidate_CleanUp(t *testing.T) {
+	cache := &inMemoryCache{}
+	clock := newFakeClock()
+	WithClock(clock)(cache)
+	WithStaleWhileRevalidate(time.Minute, func(ctx context.Context, key string) (interface{}, error) {
+		return "fresh", nil
+	})(cache)
+	cache.Set("stale", "value", time.Nanosecond)
+	cache.SetWithDeadline("deadline", "value", clock.Now().Add(-time.Second))
+	clock.Advance(time.Millisecond)
+
+	cache.CleanupNow()
+	if _, found := cache.storage.Load("stale"); !found {
+		t.Errorf("cleanup removed an entry inside its grace window")
+	}
+	if _, found := cache.storage.Load("deadline"); found {
+		t.Errorf("cleanup kept an expired entry that cannot be served stale")
+	}
+}
+
+func Test_inMemoryCache_RefreshDoesNotBlockGetOrLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		option  func(loader func(ctx context.Context, key string) (interface{}, error)) func(*inMemoryCache)
+		advance time.Duration
+	}{
+		{
+			name: "Stale while revalidate",
+			option: func(loader func(ctx context.Context, key string) (interface{}, error)) func(*inMemoryCache) {
+				return WithStaleWhileRevalidate(time.Minute, loader)
+			},
+			advance: time.Second * 2,
+		},
+		{
+			name: "Refresh ahead",
+			option: func(loader func(ctx context.Context, key string) (interface{}, error)) func(*inMemoryCache) {
+				return WithRefreshAhead(loader, time.Millisecond*500)
+			},
+			advance: time.Millisecond * 700,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			cache := &inMemoryCache{}
+			WithClock(clock)(cache)
+			release := make(chan struct{})
+			defer close(release)
+			refreshing := make(chan struct{})
+			tt.option(func(ctx context.Context, key string) (interface{}, error) {
+				close(refreshing)
+				<-release
+				return "refreshed", nil
+			})(cache)
+
+			cache.Set("test", "stored", time.Second)
+			clock.Advance(tt.advance)
+			cache.Get("test")
+			<-refreshing
+			cache.Delete("test")
+
+			value, err := cache.GetOrLoad(context.Background(), "test", time.Minute, func(ctx context.Context) (interface{}, error) {
+				return "loaded", nil
+			})
+			if err != nil || value != "loaded" {
+				t.Errorf("GetOrLoad() = %v, %v, want the value of its own loader", value, err)
+			}
+		})
+	}
+}
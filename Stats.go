@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type Stats struct {
+	Hits            uint64
+	Misses          uint64
+	Sets            uint64
+	Deletes         uint64
+	Entries         int64
+	Expired         uint64
+	Evicted         uint64
+	Rejected        uint64
+	CleanUps        uint64
+	CleanUpDuration time.Duration
+}
+
+type StatsProvider interface {
+	Stats() Stats
+}
+
+type cacheStats struct {
+	hits         atomic.Uint64
+	misses       atomic.Uint64
+	sets         atomic.Uint64
+	deletes      atomic.Uint64
+	entries      atomic.Int64
+	bytes        atomic.Int64
+	expired      atomic.Uint64
+	evicted      atomic.Uint64
+	rejected     atomic.Uint64
+	cleanUps     atomic.Uint64
+	cleanUpNanos atomic.Uint64
+}
+
+func (s Stats) HitRatio() float64 {
+	lookups := s.Hits + s.Misses
+	if lookups == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(lookups)
+}
+
+func (c *inMemoryCache) Stats() Stats {
+	return Stats{
+		Hits:            c.stats.hits.Load(),
+		Misses:          c.stats.misses.Load(),
+		Sets:            c.stats.sets.Load(),
+		Deletes:         c.stats.deletes.Load(),
+		Entries:         c.stats.entries.Load(),
+		Expired:         c.stats.expired.Load(),
+		Evicted:         c.stats.evicted.Load(),
+		Rejected:        c.stats.rejected.Load(),
+		CleanUps:        c.stats.cleanUps.Load(),
+		CleanUpDuration: time.Duration(c.stats.cleanUpNanos.Load()),
+	}
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_Stats(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(cache *inMemoryCache)
+		run     func(cache *inMemoryCache)
+		want    Stats
+	}{
+		{
+			name: "Hits, misses, sets and deletes",
+			run: func(cache *inMemoryCache) {
+				cache.Set("test1", 1, time.Second*10)
+				cache.Set("test1", 2, time.Second*10)
+				cache.Set("test2", 3, time.Second*10)
+				cache.Get("test1")
+				cache.Get("test3")
+				cache.Delete("test2")
+				cache.Delete("test3")
+			},
+			want: Stats{Hits: 1, Misses: 1, Sets: 3, Deletes: 2, Entries: 1},
+		},
+		{
+			name: "GetOrSet",
+			run: func(cache *inMemoryCache) {
+				cache.GetOrSet("test1", 1, time.Second*10)
+				cache.GetOrSet("test1", 2, time.Second*10)
+			},
+			want: Stats{Hits: 1, Misses: 1, Sets: 1, Entries: 1},
+		},
+		{
+			name:    "Evicted items",
+			options: []func(cache *inMemoryCache){WithMaxEntries(1)},
+			run: func(cache *inMemoryCache) {
+				cache.Set("test1", 1, time.Second*10)
+				cache.Set("test2", 2, time.Second*10)
+			},
+			want: Stats{Sets: 2, Entries: 1, Evicted: 1},
+		},
+		{
+			name: "Expired items",
+			run: func(cache *inMemoryCache) {
+				cache.Set("test1", 1, time.Nanosecond)
+				cache.Set("test2", 2, time.Second*10)
+				cache.cleanUpTicker = systemClock{}.NewTicker(time.Millisecond * 5)
+				ctx, cancelFn := context.WithTimeout(context.Background(), time.Millisecond*12)
+				defer cancelFn()
+				cache.cleanUpCache(ctx)
+			},
+			want: Stats{Sets: 2, Entries: 1, Expired: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			for _, optionFn := range tt.options {
+				optionFn(cache)
+			}
+
+			tt.run(cache)
+
+			got := cache.Stats()
+			got.CleanUps, got.CleanUpDuration = 0, 0
+			if got != tt.want {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStats_HitRatio(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats Stats
+		want  float64
+	}{
+		{
+			name:  "No lookups",
+			stats: Stats{},
+			want:  0,
+		},
+		{
+			name:  "Mixed lookups",
+			stats: Stats{Hits: 3, Misses: 1},
+			want:  0.75,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.HitRatio(); got != tt.want {
+				t.Errorf("HitRatio() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// storageEngine holds the entries of a cache. Its zero value is a sync.Map,
+// which suits read-mostly workloads; WithShardedStorage switches it to maps
+// guarded by one lock per shard, which hold up better under heavy writes. The
+// methods mirror those of sync.Map, typed so that callers need no assertions
+// and sharded lookups box nothing.
+type storageEngine struct {
+	// mu is held shared by writes to the sync.Map and exclusively by Clear,
+	// which replaces it. Reads need no lock.
+	mu      sync.RWMutex
+	syncMap atomic.Pointer[sync.Map]
+	shards  []*storageShard
+	mask    uint64
+	hasher  func(key string) uint64
+	filter  *bloomFilter
+}
+
+type storageShard struct {
+	mu      sync.RWMutex
+	entries map[string]*cacheItem
+}
+
+// WithShardedStorage stores entries in shards, each a plain map with its own
+// lock, instead of a sync.Map. The number of shards is rounded up to a power
+// of two. It must be passed before any option that loads entries, such as
+// WithSnapshot or WithJournal.
+func WithShardedStorage(shards int) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		count := 1
+		for count < shards {
+			count <<= 1
+		}
+		cache.storage.shards = make([]*storageShard, count)
+		for i := range cache.storage.shards {
+			cache.storage.shards[i] = &storageShard{entries: make(map[string]*cacheItem)}
+		}
+		cache.storage.mask = uint64(count - 1)
+	}
+}
+
+// WithHasher replaces the FNV-1a hash that spreads keys over the shards of
+// WithShardedStorage, for example with a seeded hash/maphash when keys may be
+// chosen by an adversary. It has no effect on the default storage.
+func WithHasher(hasher func(key string) uint64) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.storage.hasher = hasher
+	}
+}
+
+func (s *storageEngine) shard(key string) *storageShard {
+	if s.hasher != nil {
+		return s.shards[s.hasher(key)&s.mask]
+	}
+
+	return s.shards[fnv64a(key)&s.mask]
+}
+
+// table returns the sync.Map of unsharded storage, creating it on first use.
+func (s *storageEngine) table() *sync.Map {
+	if table := s.syncMap.Load(); table != nil {
+		return table
+	}
+	s.syncMap.CompareAndSwap(nil, new(sync.Map))
+
+	return s.syncMap.Load()
+}
+
+func (s *storageEngine) Load(key string) (*cacheItem, bool) {
+	if s.filter != nil && !s.filter.mayContain(key) {
+		return nil, false
+	}
+	if s.shards == nil {
+		value, found := s.table().Load(key)
+		if !found {
+			return nil, false
+		}
+		return value.(*cacheItem), true
+	}
+
+	shard := s.shard(key)
+	shard.mu.RLock()
+	item, found := shard.entries[key]
+	shard.mu.RUnlock()
+
+	return item, found
+}
+
+// Keys are added to the existence filter before they are stored, so a Load
+// that finds an entry in the map also finds its key in the filter.
+func (s *storageEngine) LoadOrStore(key string, item *cacheItem) (*cacheItem, bool) {
+	if s.shards == nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		s.filter.add(key)
+		actual, loaded := s.table().LoadOrStore(key, item)
+		return actual.(*cacheItem), loaded
+	}
+
+	shard := s.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	s.filter.add(key)
+	if existing, found := shard.entries[key]; found {
+		return existing, true
+	}
+	shard.entries[key] = item
+
+	return item, false
+}
+
+func (s *storageEngine) LoadAndDelete(key string) (*cacheItem, bool) {
+	if s.shards == nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		value, loaded := s.table().LoadAndDelete(key)
+		if !loaded {
+			return nil, false
+		}
+		return value.(*cacheItem), true
+	}
+
+	shard := s.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	item, found := shard.entries[key]
+	delete(shard.entries, key)
+
+	return item, found
+}
+
+func (s *storageEngine) Swap(key string, item *cacheItem) (*cacheItem, bool) {
+	if s.shards == nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		s.filter.add(key)
+		previous, loaded := s.table().Swap(key, item)
+		if !loaded {
+			return nil, false
+		}
+		return previous.(*cacheItem), true
+	}
+
+	shard := s.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	s.filter.add(key)
+	previous, found := shard.entries[key]
+	shard.entries[key] = item
+
+	return previous, found
+}
+
+// CompareAndSwap and CompareAndDelete compare items by identity.
+func (s *storageEngine) CompareAndSwap(key string, old, new *cacheItem) bool {
+	if s.shards == nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		return s.table().CompareAndSwap(key, old, new)
+	}
+
+	shard := s.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if item, found := shard.entries[key]; !found || item != old {
+		return false
+	}
+	shard.entries[key] = new
+
+	return true
+}
+
+func (s *storageEngine) CompareAndDelete(key string, old *cacheItem) bool {
+	if s.shards == nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		return s.table().CompareAndDelete(key, old)
+	}
+
+	shard := s.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if item, found := shard.entries[key]; !found || item != old {
+		return false
+	}
+	delete(shard.entries, key)
+
+	return true
+}
+
+// Range calls f for each entry like sync.Map.Range. Shards are copied before
+// f runs, so f may modify the storage.
+func (s *storageEngine) Range(f func(key string, item *cacheItem) bool) {
+	if s.shards == nil {
+		s.table().Range(func(key, value interface{}) bool {
+			return f(key.(string), value.(*cacheItem))
+		})
+		return
+	}
+
+	type entry struct {
+		key  string
+		item *cacheItem
+	}
+	for _, shard := range s.shards {
+		shard.mu.RLock()
+		entries := make([]entry, 0, len(shard.entries))
+		for key, item := range shard.entries {
+			entries = append(entries, entry{key: key, item: item})
+		}
+		shard.mu.RUnlock()
+
+		for _, e := range entries {
+			if !f(e.key, e.item) {
+				return
+			}
+		}
+	}
+}
+
+// Clear removes every entry at once: readers and writers see either all of
+// them or none. reset runs before any of them can see the storage empty, to
+// drop bookkeeping about the entries. Clear returns a func ranging over the
+// removed entries like Range.
+func (s *storageEngine) Clear(reset func()) func(f func(key string, item *cacheItem) bool) {
+	if s.shards == nil {
+		s.mu.Lock()
+		previous := s.syncMap.Swap(new(sync.Map))
+		s.filter.clear()
+		reset()
+		s.mu.Unlock()
+
+		return func(f func(key string, item *cacheItem) bool) {
+			if previous != nil {
+				previous.Range(func(key, value interface{}) bool {
+					return f(key.(string), value.(*cacheItem))
+				})
+			}
+		}
+	}
+
+	previous := make([]map[string]*cacheItem, len(s.shards))
+	for i, shard := range s.shards {
+		shard.mu.Lock()
+		previous[i] = shard.entries
+		shard.entries = make(map[string]*cacheItem)
+	}
+	s.filter.clear()
+	reset()
+	for _, shard := range s.shards {
+		shard.mu.Unlock()
+	}
+
+	return func(f func(key string, item *cacheItem) bool) {
+		for _, entries := range previous {
+			for key, item := range entries {
+				if !f(key, item) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// fnv64a hashes key without allocating, unlike hash/fnv.
+func fnv64a(key string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+
+	hash := uint64(offset64)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint64(key[i])
+		hash *= prime64
+	}
+
+	return hash
+}
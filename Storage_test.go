@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func Test_storageEngine(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*inMemoryCache)
+	}{
+		{
+			name: "sync.Map",
+		},
+		{
+			name:    "Sharded",
+			options: []func(*inMemoryCache){WithShardedStorage(4)},
+		},
+		{
+			name:    "Single shard",
+			options: []func(*inMemoryCache){WithShardedStorage(0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			for _, optionFn := range tt.options {
+				optionFn(cache)
+			}
+			s := &cache.storage
+			first, second := &cacheItem{value: 1}, &cacheItem{value: 2}
+
+			if value, found := s.Load("test"); value != nil || found {
+				t.Errorf("Load() of a missing key = %v, %v, want <nil>, false", value, found)
+			}
+			if actual, loaded := s.LoadOrStore("test", first); actual != first || loaded {
+				t.Errorf("LoadOrStore() = %v, %v, want the stored item", actual, loaded)
+			}
+			if actual, loaded := s.LoadOrStore("test", second); actual != first || !loaded {
+				t.Errorf("LoadOrStore() of a stored key = %v, %v, want the existing item", actual, loaded)
+			}
+			if s.CompareAndSwap("test", second, first) {
+				t.Errorf("CompareAndSwap() with a stale item = true")
+			}
+			if !s.CompareAndSwap("test", first, second) {
+				t.Errorf("CompareAndSwap() with the current item = false")
+			}
+			if previous, loaded := s.Swap("test", first); previous != second || !loaded {
+				t.Errorf("Swap() = %v, %v, want the previous item", previous, loaded)
+			}
+			if previous, loaded := s.Swap("other", second); previous != nil || loaded {
+				t.Errorf("Swap() of a missing key = %v, %v, want <nil>, false", previous, loaded)
+			}
+
+			var keys []string
+			s.Range(func(key string, _ *cacheItem) bool {
+				keys = append(keys, key)
+				s.LoadAndDelete("other")
+				return true
+			})
+			sort.Strings(keys)
+			if len(keys) == 0 || len(keys) > 2 {
+				t.Errorf("Range() visited %v", keys)
+			}
+
+			if s.CompareAndDelete("test", second) {
+				t.Errorf("CompareAndDelete() with a stale item = true")
+			}
+			if !s.CompareAndDelete("test", first) {
+				t.Errorf("CompareAndDelete() with the current item = false")
+			}
+			if value, loaded := s.LoadAndDelete("test"); value != nil || loaded {
+				t.Errorf("LoadAndDelete() of a missing key = %v, %v, want <nil>, false", value, loaded)
+			}
+		})
+	}
+}
+
+func TestWithShardedStorage(t *testing.T) {
+	cache := &inMemoryCache{}
+	clock := newFakeClock()
+	WithClock(clock)(cache)
+	WithShardedStorage(8)(cache)
+	for _, key := range []string{"test1", "test2", "test3"} {
+		cache.Set(key, key, time.Minute)
+	}
+	cache.Set("expired", 1, time.Nanosecond)
+	clock.Advance(time.Millisecond)
+
+	if value, found := cache.Get("test2"); !found || value != "test2" {
+		t.Errorf("Get() = %v, %v, want %v, true", value, found, "test2")
+	}
+	if deleted := cache.CleanupNow(); deleted != 1 {
+		t.Errorf("CleanupNow() = %d, want %d", deleted, 1)
+	}
+	if keys := cache.Keys(); len(keys) != 3 {
+		t.Errorf("Keys() = %v, want 3 keys", keys)
+	}
+}
+
+func TestWithShardedStorage_ShardCount(t *testing.T) {
+	tests := []struct {
+		shards int
+		want   int
+	}{
+		{shards: -1, want: 1},
+		{shards: 0, want: 1},
+		{shards: 1, want: 1},
+		{shards: 5, want: 8},
+		{shards: 64, want: 64},
+	}
+	for _, tt := range tests {
+		cache := &inMemoryCache{}
+		WithShardedStorage(tt.shards)(cache)
+		if got := len(cache.storage.shards); got != tt.want {
+			t.Errorf("WithShardedStorage(%d) created %d shards, want %d", tt.shards, got, tt.want)
+		}
+	}
+}
+
+func TestWithHasher(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithHasher(func(key string) uint64 { return 3 })(cache)
+	WithShardedStorage(4)(cache)
+	for _, key := range []string{"test1", "test2", "test3"} {
+		cache.Set(key, key, time.Minute)
+	}
+
+	if got := len(cache.storage.shards[3].entries); got != 3 {
+		t.Errorf("shard 3 holds %d entries, want all %d", got, 3)
+	}
+	if value, found := cache.Get("test2"); !found || value != "test2" {
+		t.Errorf("Get() = %v, %v, want %v, true", value, found, "test2")
+	}
+}
+
+func TestWithShardedStorage_Allocations(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithShardedStorage(4)(cache)
+	cache.Set("test", 42, time.Minute)
+
+	if allocs := testing.AllocsPerRun(100, func() { cache.Get("test") }); allocs != 0 {
+		t.Errorf("Get() made %v allocations, want 0", allocs)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { cache.Set("test", 42, time.Minute) }); allocs > 1 {
+		t.Errorf("Set() made %v allocations, want at most 1 for the item", allocs)
+	}
+}
@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"math/rand"
+	"time"
+)
+
+// NoExpiration stores an entry that lives until it is deleted or evicted. Any
+// non-positive interval has the same effect.
+const NoExpiration time.Duration = -1
+
+type DefaultSetter interface {
+	SetDefault(key string, value interface{})
+}
+
+type DeadlineSetter interface {
+	SetWithDeadline(key string, value interface{}, deadline time.Time)
+}
+
+type TTLProvider interface {
+	TTL(key string) (time.Duration, bool)
+}
+
+type Expirer interface {
+	Touch(key string) bool
+	Expire(key string, expiredInterval time.Duration) bool
+	Persist(key string) bool
+}
+
+// WithDefaultTTL sets the interval SetDefault uses, so the TTL policy can be
+// tuned in one place instead of at every call site.
+func WithDefaultTTL(ttl time.Duration) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.defaultTTL = ttl
+	}
+}
+
+// WithTTLJitter randomizes every interval by up to ±fraction of its length,
+// so that entries written together do not all expire together. fraction is
+// clamped to [0, 1]; entries without expiration are not affected.
+func WithTTLJitter(fraction float64) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		switch {
+		case fraction < 0:
+			fraction = 0
+		case fraction > 1:
+			fraction = 1
+		}
+		cache.ttlJitter = fraction
+	}
+}
+
+// SetDefault stores value with the interval configured by WithDefaultTTL, or
+// without expiration if none was configured.
+func (c *inMemoryCache) SetDefault(key string, value interface{}) {
+	c.Set(key, value, c.defaultTTL)
+}
+
+// SetWithDeadline stores value until the absolute time deadline, or without
+// expiration for the zero time. A deadline in the past stores an entry that is
+// already expired. Touch leaves the deadline unchanged.
+func (c *inMemoryCache) SetWithDeadline(key string, value interface{}, deadline time.Time) {
+	item := c.newCacheItem(value, NoExpiration)
+	item.validThrough = deadline
+	c.set(key, item, defaultItemCost)
+}
+
+// TTL returns the remaining lifetime of the live entry under key, or
+// NoExpiration if it never expires. The second result is false for missing and
+// expired entries.
+func (c *inMemoryCache) TTL(key string) (time.Duration, bool) {
+	item, found := c.load(key)
+	if !found {
+		return 0, false
+	}
+	if item.validThrough.IsZero() {
+		return NoExpiration, true
+	}
+
+	remaining := item.validThrough.Sub(c.now())
+	if remaining < 0 {
+		return 0, false
+	}
+
+	return remaining, true
+}
+
+// Touch restarts the interval the live entry under key was stored with and
+// reports whether the entry exists.
+func (c *inMemoryCache) Touch(key string) bool {
+	return c.updateExpiration(key, func(item *cacheItem) {
+		if item.interval > 0 {
+			item.validThrough = c.expiresAt(item.interval)
+		}
+	})
+}
+
+// Expire makes the live entry under key expire expiredInterval from now, or
+// never for a non-positive interval, and reports whether the entry exists.
+// Later calls to Touch restart the new interval.
+func (c *inMemoryCache) Expire(key string, expiredInterval time.Duration) bool {
+	return c.updateExpiration(key, func(item *cacheItem) {
+		c.setExpiration(item, expiredInterval)
+	})
+}
+
+// Persist removes the expiration of the live entry under key and reports
+// whether the entry exists.
+func (c *inMemoryCache) Persist(key string) bool {
+	return c.Expire(key, NoExpiration)
+}
+
+// updateExpiration swaps the live item under key for a copy changed by update,
+// retrying if the item is replaced concurrently.
+func (c *inMemoryCache) updateExpiration(key string, update func(item *cacheItem)) bool {
+	for {
+		item, found := c.storage.Load(key)
+		if !found {
+			return false
+		}
+		if item.isExpired(c.now()) {
+			return false
+		}
+
+		updated := item.clone()
+		update(updated)
+		if c.storage.CompareAndSwap(key, item, updated) {
+			c.scheduleExpiration(key, updated)
+			c.journalSet(key, updated)
+			return true
+		}
+	}
+}
+
+func (c *inMemoryCache) jitter(interval time.Duration) time.Duration {
+	if c.ttlJitter == 0 || interval <= 0 {
+		return interval
+	}
+
+	jittered := interval + time.Duration(float64(interval)*c.ttlJitter*(2*rand.Float64()-1))
+	if jittered <= 0 {
+		return time.Nanosecond
+	}
+
+	return jittered
+}
+
+// expiresAt returns the expiration time of an entry stored now for interval,
+// or the zero time for entries that never expire.
+func (c *inMemoryCache) expiresAt(interval time.Duration) time.Time {
+	if interval <= 0 {
+		return time.Time{}
+	}
+
+	return c.now().Add(interval)
+}
@@ -0,0 +1,276 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithDefaultTTL(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		wait      time.Duration
+		wantFound bool
+	}{
+		{
+			name:      "Value lives for the default TTL",
+			ttl:       time.Minute,
+			wantFound: true,
+		},
+		{
+			name: "Value expires after the default TTL",
+			ttl:  time.Millisecond * 5,
+			wait: time.Millisecond * 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			clock := newFakeClock()
+			WithClock(clock)(cache)
+			WithDefaultTTL(tt.ttl)(cache)
+
+			cache.SetDefault("test", 42)
+			clock.Advance(tt.wait)
+			if _, found := cache.Get("test"); found != tt.wantFound {
+				t.Errorf("Get() found = %v, want %v", found, tt.wantFound)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_Touch(t *testing.T) {
+	clock := newFakeClock()
+	cache := &inMemoryCache{}
+	WithClock(clock)(cache)
+	cache.Set("test", 42, time.Millisecond*30)
+
+	clock.Advance(time.Millisecond * 20)
+	if !cache.Touch("test") {
+		t.Fatalf("Touch() = false for a live entry")
+	}
+	clock.Advance(time.Millisecond * 20)
+	if _, found := cache.Get("test"); !found {
+		t.Errorf("Get() expected a hit after Touch restarted the interval")
+	}
+	if cache.Touch("missing") {
+		t.Errorf("Touch() = true for a missing entry")
+	}
+}
+
+func Test_inMemoryCache_Expire(t *testing.T) {
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		wait      time.Duration
+		wantFound bool
+	}{
+		{
+			name:      "Extend the expiration",
+			interval:  time.Minute,
+			wait:      time.Millisecond * 10,
+			wantFound: true,
+		},
+		{
+			name:     "Shorten the expiration",
+			interval: time.Millisecond,
+			wait:     time.Millisecond * 10,
+		},
+		{
+			name:      "Remove the expiration",
+			interval:  NoExpiration,
+			wait:      time.Millisecond * 10,
+			wantFound: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			clock := newFakeClock()
+			WithClock(clock)(cache)
+			cache.Set("test", 42, time.Millisecond*5)
+
+			if !cache.Expire("test", tt.interval) {
+				t.Fatalf("Expire() = false for a live entry")
+			}
+			clock.Advance(tt.wait)
+			if _, found := cache.Get("test"); found != tt.wantFound {
+				t.Errorf("Get() found = %v, want %v", found, tt.wantFound)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_Persist(t *testing.T) {
+	cache := &inMemoryCache{}
+	clock := newFakeClock()
+	WithClock(clock)(cache)
+	cache.Set("test", 42, time.Millisecond*5)
+	cache.Set("expired", 42, time.Nanosecond)
+	clock.Advance(time.Millisecond)
+
+	if !cache.Persist("test") {
+		t.Fatalf("Persist() = false for a live entry")
+	}
+	if cache.Persist("expired") {
+		t.Errorf("Persist() = true for an expired entry")
+	}
+	clock.Advance(time.Millisecond * 10)
+	if _, found := cache.Get("test"); !found {
+		t.Errorf("Get() expected a hit after Persist")
+	}
+}
+
+func Test_inMemoryCache_TTL(t *testing.T) {
+	cache := &inMemoryCache{}
+	clock := newFakeClock()
+	WithClock(clock)(cache)
+	cache.Set("test", 42, time.Minute)
+	cache.Set("forever", 42, NoExpiration)
+	cache.Set("expired", 42, time.Nanosecond)
+	clock.Advance(time.Millisecond)
+
+	tests := []struct {
+		name      string
+		key       string
+		wantMin   time.Duration
+		wantMax   time.Duration
+		wantFound bool
+	}{
+		{
+			name:      "Remaining lifetime",
+			key:       "test",
+			wantMin:   time.Second * 59,
+			wantMax:   time.Minute,
+			wantFound: true,
+		},
+		{
+			name:      "No expiration",
+			key:       "forever",
+			wantMin:   NoExpiration,
+			wantMax:   NoExpiration,
+			wantFound: true,
+		},
+		{
+			name: "Expired entry",
+			key:  "expired",
+		},
+		{
+			name: "Missing entry",
+			key:  "missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remaining, found := cache.TTL(tt.key)
+			if found != tt.wantFound {
+				t.Fatalf("TTL() found = %v, want %v", found, tt.wantFound)
+			}
+			if remaining < tt.wantMin || remaining > tt.wantMax {
+				t.Errorf("TTL() = %v, want between %v and %v", remaining, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_SetWithDeadline(t *testing.T) {
+	tests := []struct {
+		name      string
+		deadline  time.Time
+		wantFound bool
+	}{
+		{
+			name:      "Deadline in the future",
+			deadline:  time.Now().Add(time.Minute),
+			wantFound: true,
+		},
+		{
+			name:     "Deadline in the past",
+			deadline: time.Now().Add(-time.Minute),
+		},
+		{
+			name:      "Zero deadline never expires",
+			wantFound: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			cache.SetWithDeadline("test", 42, tt.deadline)
+
+			if _, found := cache.Get("test"); found != tt.wantFound {
+				t.Errorf("Get() found = %v, want %v", found, tt.wantFound)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_SetWithDeadline_Touch(t *testing.T) {
+	cache := &inMemoryCache{}
+	deadline := time.Now().Add(time.Minute)
+	cache.SetWithDeadline("test", 42, deadline)
+
+	cache.Touch("test")
+	storageValue, _ := cache.storage.Load("test")
+	if validThrough := storageValue.validThrough; !validThrough.Equal(deadline) {
+		t.Errorf("Touch() moved the deadline to %v, want %v", validThrough, deadline)
+	}
+}
+
+func TestWithTTLJitter(t *testing.T) {
+	tests := []struct {
+		name        string
+		fraction    float64
+		interval    time.Duration
+		wantMin     time.Duration
+		wantMax     time.Duration
+		wantSpread  bool
+		wantForever bool
+	}{
+		{
+			name:       "Intervals vary within the fraction",
+			fraction:   0.1,
+			interval:   time.Minute,
+			wantMin:    time.Second * 54,
+			wantMax:    time.Second * 66,
+			wantSpread: true,
+		},
+		{
+			name:     "Zero fraction keeps the interval",
+			fraction: 0,
+			interval: time.Minute,
+			wantMin:  time.Minute,
+			wantMax:  time.Minute,
+		},
+		{
+			name:        "Entries without expiration are not jittered",
+			fraction:    0.5,
+			interval:    NoExpiration,
+			wantForever: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			WithTTLJitter(tt.fraction)(cache)
+
+			intervals := make(map[time.Duration]struct{})
+			for i := 0; i < 100; i++ {
+				item := cache.newCacheItem(42, tt.interval)
+				if tt.wantForever {
+					if !item.validThrough.IsZero() {
+						t.Fatalf("newCacheItem() set an expiration on an entry without one")
+					}
+					continue
+				}
+				if item.interval < tt.wantMin || item.interval > tt.wantMax {
+					t.Fatalf("newCacheItem() interval = %v, want between %v and %v", item.interval, tt.wantMin, tt.wantMax)
+				}
+				intervals[item.interval] = struct{}{}
+			}
+			if tt.wantSpread && len(intervals) < 2 {
+				t.Errorf("newCacheItem() produced a single interval, want jittered intervals")
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package cache
+
+import "time"
+
+type Tagger interface {
+	SetWithTags(key string, value interface{}, expiredInterval time.Duration, tags ...string)
+	InvalidateTag(tag string) int
+}
+
+type tagIndex map[string]map[string]struct{}
+
+// SetWithTags stores value like Set and records it under each tag so that
+// InvalidateTag can drop it later. Tags are not persisted by Save, snapshots
+// or the journal.
+func (c *inMemoryCache) SetWithTags(key string, value interface{}, expiredInterval time.Duration, tags ...string) {
+	item := c.newCacheItem(value, expiredInterval)
+	item.tags = tags
+	c.set(key, item, defaultItemCost)
+	c.tag(key)
+}
+
+// InvalidateTag removes every live entry carrying tag and returns how many
+// were removed.
+func (c *inMemoryCache) InvalidateTag(tag string) int {
+	c.tagMu.Lock()
+	keys := make([]string, 0, len(c.tags[tag]))
+	for key := range c.tags[tag] {
+		keys = append(keys, key)
+	}
+	c.tagMu.Unlock()
+
+	deleted := 0
+	for _, key := range keys {
+		item, found := c.storage.Load(key)
+		if !found {
+			continue
+		}
+		if item.hasTag(tag) && !item.isExpired(c.now()) && c.deleteUnchanged(key, item, nil) {
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+// tag indexes key under the tags of the item stored under key, after the
+// write. The item is loaded under tagMu, as in untag, so that an item removed
+// concurrently is either not indexed or unindexed afterwards, and a rejected
+// write indexes nothing new.
+func (c *inMemoryCache) tag(key string) {
+	c.tagMu.Lock()
+	defer c.tagMu.Unlock()
+
+	current, found := c.storage.Load(key)
+	if !found || len(current.tags) == 0 {
+		return
+	}
+	if c.tags == nil {
+		c.tags = make(tagIndex)
+	}
+	for _, tag := range current.tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+}
+
+// untag drops the index entries of an item that left the storage, unless the
+// item now stored under key carries the same tag.
+func (c *inMemoryCache) untag(key string, item *cacheItem) {
+	if len(item.tags) == 0 {
+		return
+	}
+
+	c.tagMu.Lock()
+	defer c.tagMu.Unlock()
+
+	current, _ := c.storage.Load(key)
+
+	for _, tag := range item.tags {
+		if current != nil && current.hasTag(tag) {
+			continue
+		}
+		delete(c.tags[tag], key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+func (i *cacheItem) hasTag(tag string) bool {
+	for _, itemTag := range i.tags {
+		if itemTag == tag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_InvalidateTag(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     []func(cache *inMemoryCache)
+		tag         string
+		wantDeleted int
+		wantKeys    []string
+	}{
+		{
+			name:        "Invalidate shared tag",
+			tag:         "user:1",
+			wantDeleted: 2,
+			wantKeys:    []string{"orders", "untagged"},
+		},
+		{
+			name:        "Invalidate tag with eviction enabled",
+			options:     []func(cache *inMemoryCache){WithMaxEntries(10)},
+			tag:         "catalog",
+			wantDeleted: 2,
+			wantKeys:    []string{"profile", "untagged"},
+		},
+		{
+			name:        "Invalidate unknown tag",
+			tag:         "unknown",
+			wantDeleted: 0,
+			wantKeys:    []string{"orders", "profile", "settings", "untagged"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			for _, optionFn := range tt.options {
+				optionFn(cache)
+			}
+			cache.SetWithTags("profile", 1, time.Minute, "user:1")
+			cache.SetWithTags("settings", 2, time.Minute, "user:1", "catalog")
+			cache.SetWithTags("orders", 3, time.Minute, "catalog")
+			cache.Set("untagged", 4, time.Minute)
+
+			if deleted := cache.InvalidateTag(tt.tag); deleted != tt.wantDeleted {
+				t.Errorf("InvalidateTag() = %d, want %d", deleted, tt.wantDeleted)
+			}
+
+			keys := cache.Keys()
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("Keys() after InvalidateTag() = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_tagIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(cache *inMemoryCache)
+		want   tagIndex
+	}{
+		{
+			name:   "Overwrite without tags",
+			change: func(cache *inMemoryCache) { cache.Set("a", 1, time.Minute) },
+			want:   tagIndex{"tag": {"b": {}}},
+		},
+		{
+			name:   "Overwrite with the same tag",
+			change: func(cache *inMemoryCache) { cache.SetWithTags("a", 1, time.Minute, "tag") },
+			want:   tagIndex{"tag": {"a": {}, "b": {}}},
+		},
+		{
+			name:   "Delete",
+			change: func(cache *inMemoryCache) { cache.Delete("a"); cache.Delete("b") },
+			want:   tagIndex{},
+		},
+		{
+			name: "Expire",
+			change: func(cache *inMemoryCache) {
+				cache.SetWithTags("a", 1, time.Nanosecond, "tag")
+				cache.SetWithTags("b", 1, time.Nanosecond, "tag")
+				time.Sleep(time.Millisecond)
+				cache.cleanUpTicker = systemClock{}.NewTicker(time.Millisecond)
+				ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+				defer cancel()
+				cache.cleanUpCache(ctx)
+			},
+			want: tagIndex{},
+		},
+		{
+			name: "Rejected write",
+			change: func(cache *inMemoryCache) {
+				cache.keyValidators = []func(string) error{NonEmptyKey}
+				cache.SetWithTags("", 1, time.Minute, "rejected")
+			},
+			want: tagIndex{"tag": {"a": {}, "b": {}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			cache.SetWithTags("a", 1, time.Minute, "tag")
+			cache.SetWithTags("b", 1, time.Minute, "tag")
+
+			tt.change(cache)
+			if !reflect.DeepEqual(cache.tags, tt.want) {
+				t.Errorf("tag index = %v, want %v", cache.tags, tt.want)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_tagIndex_ConcurrentDelete(t *testing.T) {
+	cache := &inMemoryCache{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 1000; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			cache.SetWithTags("test", 1, time.Minute, "tag")
+		}()
+		go func() {
+			defer wg.Done()
+			cache.Delete("test")
+		}()
+	}
+	wg.Wait()
+
+	want := tagIndex{}
+	if cache.Has("test") {
+		want = tagIndex{"tag": {"test": {}}}
+	}
+	if !reflect.DeepEqual(cache.tags, want) {
+		t.Errorf("tag index = %v, want %v", cache.tags, want)
+	}
+}
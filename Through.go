@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Through returns the value cached under key, or else the result of fetch,
+// which it caches for ttl. Errors of fetch are returned and not cached. A
+// cached value that is not a T is treated as a miss and replaced. Concurrent
+// misses share one fetch when c is a GetOrLoader.
+func Through[T any](
+	ctx context.Context,
+	c Cache,
+	key string,
+	ttl time.Duration,
+	fetch func(ctx context.Context) (T, error),
+) (T, error) {
+	if loader, ok := c.(GetOrLoader); ok {
+		value, err := loader.GetOrLoad(ctx, key, ttl, func(ctx context.Context) (interface{}, error) {
+			return fetch(ctx)
+		})
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		if typed, ok := value.(T); ok {
+			return typed, nil
+		}
+	} else if value, found := c.Get(key); found {
+		if typed, ok := value.(T); ok {
+			return typed, nil
+		}
+	}
+
+	value, err := fetch(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	c.Set(key, value, ttl)
+
+	return value, nil
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestThrough(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+	tests := []struct {
+		name        string
+		cached      interface{}
+		fetchErr    error
+		want        int
+		wantErr     error
+		wantFetches int
+		wantCached  interface{}
+	}{
+		{
+			name:       "Hit",
+			cached:     1,
+			want:       1,
+			wantCached: 1,
+		},
+		{
+			name:        "Miss fetches and caches",
+			want:        2,
+			wantFetches: 1,
+			wantCached:  2,
+		},
+		{
+			name:        "Fetch errors are not cached",
+			fetchErr:    errFetch,
+			wantErr:     errFetch,
+			wantFetches: 1,
+		},
+		{
+			name:        "Value of another type is replaced",
+			cached:      "1",
+			want:        2,
+			wantFetches: 1,
+			wantCached:  2,
+		},
+	}
+	caches := []struct {
+		name     string
+		newCache func() Cache
+	}{
+		{name: "GetOrLoader", newCache: func() Cache { return &inMemoryCache{} }},
+		{name: "Cache", newCache: func() Cache { return struct{ Cache }{&inMemoryCache{}} }},
+	}
+	for _, cc := range caches {
+		for _, tt := range tests {
+			t.Run(cc.name+"/"+tt.name, func(t *testing.T) {
+				c := cc.newCache()
+				if tt.cached != nil {
+					c.Set("test", tt.cached, time.Minute)
+				}
+				fetches := 0
+
+				got, err := Through(context.Background(), c, "test", time.Minute, func(ctx context.Context) (int, error) {
+					fetches++
+					return 2, tt.fetchErr
+				})
+				if got != tt.want || !errors.Is(err, tt.wantErr) {
+					t.Errorf("Through() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+				}
+				if fetches != tt.wantFetches {
+					t.Errorf("fetch ran %d times, want %d", fetches, tt.wantFetches)
+				}
+				if cached, _ := c.Get("test"); cached != tt.wantCached {
+					t.Errorf("Get() = %v, want %v", cached, tt.wantCached)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package cache
+
+import "time"
+
+const defaultL1TTL = time.Minute
+
+type tieredCache struct {
+	l1     Cache
+	l2     Cache
+	l1TTL  time.Duration
+	filter *bloomFilter
+}
+
+// NewTieredCache layers l1 in front of l2. Reads fall through to l2 on an l1
+// miss and backfill l1 on an l2 hit. Writes go to both tiers; l1 entries never
+// outlive l1TTL, so a process-local l1 cannot keep serving a value for longer
+// than that after another process changed it in l2.
+func NewTieredCache(l1, l2 Cache, options ...func(*tieredCache)) Cache {
+	c := &tieredCache{l1: l1, l2: l2, l1TTL: defaultL1TTL}
+	for _, optionFn := range options {
+		optionFn(c)
+	}
+
+	return c
+}
+
+// WithL1TTL sets the lifetime of l1 entries, both for writes and for
+// backfills after l2 hits. Non-positive values are ignored, since l1 entries
+// must expire.
+func WithL1TTL(ttl time.Duration) func(*tieredCache) {
+	return func(c *tieredCache) {
+		if ttl <= 0 {
+			return
+		}
+		c.l1TTL = ttl
+	}
+}
+
+// WithL2ExistenceFilter records the keys written through the tiered cache in
+// a Bloom filter sized like WithExistenceFilter, and answers reads of keys
+// that were never written without asking either tier. Only use it when no
+// other process writes l2, since keys written elsewhere would read as misses.
+func WithL2ExistenceFilter(expectedKeys int, falsePositiveRate float64) func(*tieredCache) {
+	return func(c *tieredCache) {
+		c.filter = newBloomFilter(expectedKeys, falsePositiveRate)
+	}
+}
+
+func (c *tieredCache) Get(key string) (interface{}, bool) {
+	if c.filter != nil && !c.filter.mayContain(key) {
+		return nil, false
+	}
+	if value, found := c.l1.Get(key); found {
+		return value, true
+	}
+
+	value, found := c.l2.Get(key)
+	if found {
+		c.l1.Set(key, value, c.l1TTL)
+	}
+
+	return value, found
+}
+
+// Set writes l2 with expiredInterval and l1 with the shorter of
+// expiredInterval and the l1 TTL.
+func (c *tieredCache) Set(key string, value interface{}, expiredInterval time.Duration) {
+	c.filter.add(key)
+	c.l2.Set(key, value, expiredInterval)
+
+	l1Interval := c.l1TTL
+	if expiredInterval > 0 && expiredInterval < l1Interval {
+		l1Interval = expiredInterval
+	}
+	c.l1.Set(key, value, l1Interval)
+}
+
+// Delete removes key from l2 before l1 so a concurrent read cannot backfill
+// l1 from the stale l2 entry.
+func (c *tieredCache) Delete(key string) {
+	c.l2.Delete(key)
+	c.l1.Delete(key)
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewTieredCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		l1Items   map[string]interface{}
+		l2Items   map[string]interface{}
+		key       string
+		wantValue interface{}
+		wantFound bool
+		wantL1    bool
+	}{
+		{
+			name:      "Hit in l1",
+			l1Items:   map[string]interface{}{"test": 1},
+			l2Items:   map[string]interface{}{"test": 2},
+			key:       "test",
+			wantValue: 1,
+			wantFound: true,
+			wantL1:    true,
+		},
+		{
+			name:      "Hit in l2 backfills l1",
+			l2Items:   map[string]interface{}{"test": 2},
+			key:       "test",
+			wantValue: 2,
+			wantFound: true,
+			wantL1:    true,
+		},
+		{
+			name: "Miss in both",
+			key:  "test",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l1, l2 := &inMemoryCache{}, &inMemoryCache{}
+			for key, value := range tt.l1Items {
+				l1.Set(key, value, time.Minute)
+			}
+			for key, value := range tt.l2Items {
+				l2.Set(key, value, time.Minute)
+			}
+
+			value, found := NewTieredCache(l1, l2).Get(tt.key)
+			if !reflect.DeepEqual(value, tt.wantValue) || found != tt.wantFound {
+				t.Errorf("Get() = %v, %v, want %v, %v", value, found, tt.wantValue, tt.wantFound)
+			}
+			if _, inL1 := l1.Get(tt.key); inL1 != tt.wantL1 {
+				t.Errorf("key in l1 = %v, want %v", inL1, tt.wantL1)
+			}
+		})
+	}
+}
+
+func Test_tieredCache_Set(t *testing.T) {
+	l1, l2 := &inMemoryCache{}, &inMemoryCache{}
+	c := NewTieredCache(l1, l2, WithL1TTL(time.Millisecond*10))
+
+	c.Set("test", 42, time.Minute)
+	if _, found := l1.Get("test"); !found {
+		t.Fatalf("Set() did not write l1")
+	}
+	time.Sleep(time.Millisecond * 20)
+
+	if _, found := l1.Get("test"); found {
+		t.Errorf("l1 entry outlived the l1 TTL")
+	}
+	if value, found := c.Get("test"); !found || value != 42 {
+		t.Errorf("Get() = %v, %v, want 42, true", value, found)
+	}
+}
+
+func TestWithL1TTL(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{name: "Positive TTL", ttl: time.Second, want: time.Second},
+		{name: "Zero TTL keeps the default", ttl: 0, want: defaultL1TTL},
+		{name: "Negative TTL keeps the default", ttl: -time.Second, want: defaultL1TTL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewTieredCache(&inMemoryCache{}, &inMemoryCache{}, WithL1TTL(tt.ttl)).(*tieredCache)
+			if c.l1TTL != tt.want {
+				t.Errorf("l1TTL = %v, want %v", c.l1TTL, tt.want)
+			}
+		})
+	}
+}
+
+func Test_tieredCache_Delete(t *testing.T) {
+	l1, l2 := &inMemoryCache{}, &inMemoryCache{}
+	c := NewTieredCache(l1, l2)
+
+	c.Set("test", 42, time.Minute)
+	c.Delete("test")
+	if _, found := l1.Get("test"); found {
+		t.Errorf("Delete() left the key in l1")
+	}
+	if _, found := l2.Get("test"); found {
+		t.Errorf("Delete() left the key in l2")
+	}
+}
+
+// countingCache counts the reads of the cache it wraps.
+type countingCache struct {
+	Cache
+	gets int
+}
+
+func (c *countingCache) Get(key string) (interface{}, bool) {
+	c.gets++
+	return c.Cache.Get(key)
+}
+
+func TestWithL2ExistenceFilter(t *testing.T) {
+	l2 := &countingCache{Cache: &inMemoryCache{}}
+	c := NewTieredCache(&inMemoryCache{}, l2, WithL2ExistenceFilter(100, 0.01), WithL1TTL(time.Millisecond))
+
+	c.Set("test", 1, time.Minute)
+	time.Sleep(time.Millisecond * 5)
+	if value, found := c.Get("test"); !found || value != 1 {
+		t.Errorf("Get() = %v, %v, want 1, true", value, found)
+	}
+	if l2.gets != 1 {
+		t.Errorf("l2 read %d times after an l1 expiry, want 1", l2.gets)
+	}
+
+	for i := 0; i < 10; i++ {
+		c.Get("missing")
+	}
+	if l2.gets != 1 {
+		t.Errorf("l2 read %d times for keys never set, want 1", l2.gets)
+	}
+}
@@ -0,0 +1,140 @@
+package cache
+
+import "hash/fnv"
+
+const (
+	countMinSketchDepth = 4
+	countMinSketchMax   = 15
+	tinyLFUSampleFactor = 10
+)
+
+// tinyLFU approximates access frequencies with a count-min sketch guarded by a
+// doorkeeper bloom filter, so keys seen only once never reach the sketch. The
+// whole structure is aged once sampleSize increments were recorded.
+type tinyLFU struct {
+	sketch     [countMinSketchDepth][]uint8
+	doorkeeper []uint64
+	mask       uint64
+	increments int
+	sampleSize int
+}
+
+func newTinyLFU(capacity int) *tinyLFU {
+	width := nextPowerOfTwo(uint64(capacity))
+	if width < 16 {
+		width = 16
+	}
+
+	filter := &tinyLFU{
+		doorkeeper: make([]uint64, (width*8+63)/64),
+		mask:       width - 1,
+		sampleSize: int(width) * tinyLFUSampleFactor,
+	}
+	for i := range filter.sketch {
+		filter.sketch[i] = make([]uint8, width)
+	}
+
+	return filter
+}
+
+func (f *tinyLFU) increment(key string) {
+	hash := hashKey(key)
+	if !f.doorkeeperContains(hash) {
+		f.doorkeeperAdd(hash)
+	} else {
+		for i := range f.sketch {
+			index := f.index(hash, i)
+			if f.sketch[i][index] < countMinSketchMax {
+				f.sketch[i][index]++
+			}
+		}
+	}
+
+	f.increments++
+	if f.increments >= f.sampleSize {
+		f.reset()
+	}
+}
+
+func (f *tinyLFU) estimate(key string) int {
+	hash := hashKey(key)
+	estimate := countMinSketchMax
+	for i := range f.sketch {
+		if count := int(f.sketch[i][f.index(hash, i)]); count < estimate {
+			estimate = count
+		}
+	}
+	if f.doorkeeperContains(hash) {
+		estimate++
+	}
+
+	return estimate
+}
+
+func (f *tinyLFU) admit(candidate, victim string) bool {
+	return f.estimate(candidate) > f.estimate(victim)
+}
+
+func (f *tinyLFU) reset() {
+	f.increments = 0
+	for i := range f.sketch {
+		for j := range f.sketch[i] {
+			f.sketch[i][j] /= 2
+		}
+	}
+	for i := range f.doorkeeper {
+		f.doorkeeper[i] = 0
+	}
+}
+
+// clear forgets every recorded access.
+func (f *tinyLFU) clear() {
+	f.increments = 0
+	for i := range f.sketch {
+		for j := range f.sketch[i] {
+			f.sketch[i][j] = 0
+		}
+	}
+	for i := range f.doorkeeper {
+		f.doorkeeper[i] = 0
+	}
+}
+
+func (f *tinyLFU) index(hash uint64, row int) uint64 {
+	h1, h2 := hash, hash>>32|hash<<32
+	return (h1 + uint64(row)*h2) & f.mask
+}
+
+func (f *tinyLFU) doorkeeperContains(hash uint64) bool {
+	bits := uint64(len(f.doorkeeper) * 64)
+	for _, bit := range [2]uint64{hash % bits, (hash >> 32) % bits} {
+		if f.doorkeeper[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *tinyLFU) doorkeeperAdd(hash uint64) {
+	bits := uint64(len(f.doorkeeper) * 64)
+	for _, bit := range [2]uint64{hash % bits, (hash >> 32) % bits} {
+		f.doorkeeper[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func hashKey(key string) uint64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(key))
+
+	return hasher.Sum64()
+}
+
+func nextPowerOfTwo(n uint64) uint64 {
+	power := uint64(1)
+	for power < n {
+		power <<= 1
+	}
+
+	return power
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_tinyLFU_estimate(t *testing.T) {
+	tests := []struct {
+		name       string
+		increments int
+		want       int
+	}{
+		{
+			name:       "Unknown key",
+			increments: 0,
+			want:       0,
+		},
+		{
+			name:       "Key seen once is kept by the doorkeeper",
+			increments: 1,
+			want:       1,
+		},
+		{
+			name:       "Key seen several times",
+			increments: 5,
+			want:       5,
+		},
+		{
+			name:       "Counters saturate",
+			increments: 40,
+			want:       countMinSketchMax + 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := newTinyLFU(100)
+			for i := 0; i < tt.increments; i++ {
+				filter.increment("test")
+			}
+
+			if got := filter.estimate("test"); got != tt.want {
+				t.Errorf("estimate() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_tinyLFU_reset(t *testing.T) {
+	filter := newTinyLFU(1)
+	for i := 0; i < filter.sampleSize-10; i++ {
+		filter.increment("other")
+	}
+	for i := 0; i < 9; i++ {
+		filter.increment("test")
+	}
+	if got := filter.estimate("test"); got != 9 {
+		t.Fatalf("estimate() = %d, want %d", got, 9)
+	}
+
+	filter.increment("test")
+
+	if got := filter.estimate("test"); got != 4 {
+		t.Errorf("estimate() after reset = %d, want %d", got, 4)
+	}
+}
+
+func TestWithAdmissionPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  AdmissionPolicy
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "AdmitAll lets a new key evict a hot one",
+			policy:  AdmitAll,
+			want:    []string{"test2", "test3"},
+			notWant: []string{"test1"},
+		},
+		{
+			name:    "TinyLFU rejects a one-hit key",
+			policy:  TinyLFU,
+			want:    []string{"test1", "test2"},
+			notWant: []string{"test3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			WithAdmissionPolicy(tt.policy)(cache)
+			WithMaxEntries(2)(cache)
+
+			cache.Set("test1", 1, time.Second*10)
+			cache.Set("test2", 2, time.Second*10)
+			for i := 0; i < 3; i++ {
+				cache.Get("test1")
+				cache.Get("test2")
+			}
+			cache.Set("test3", 3, time.Second*10)
+
+			for _, key := range tt.want {
+				if _, ok := cache.Get(key); !ok {
+					t.Errorf("Get() expected key %s to be cached", key)
+				}
+			}
+			for _, key := range tt.notWant {
+				if _, ok := cache.Get(key); ok {
+					t.Errorf("Get() expected key %s not to be cached", key)
+				}
+			}
+		})
+	}
+}
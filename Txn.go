@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tx reads and writes entries inside Txn. Writes become visible only when the
+// transaction commits.
+type Tx interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{}, expiredInterval time.Duration)
+	Delete(key string)
+}
+
+type Transactor interface {
+	Txn(fn func(tx Tx) error) error
+}
+
+type txn struct {
+	cache      *inMemoryCache
+	reads      map[string]*cacheItem
+	writes     map[string]*cacheItem
+	writeOrder []string
+}
+
+// Txn runs fn and applies its writes together. Transactions are optimistic:
+// fn runs without locks, and at commit the keys it used are locked in a fixed
+// order and every entry it read is checked to be unchanged. On a conflict fn
+// runs again, so it must not have side effects outside tx, and a run that is
+// retried may have read an inconsistent state. An error returned by fn aborts
+// the transaction without applying any write. So does a write that cannot be
+// stored, because of a WithKeyValidator validator, WithMaxItemSize, the codec
+// or the cost and memory limits; Txn returns its error, as TrySet would.
+// Transaction writes skip the admission policy.
+//
+// Get, Peek, Has and TTL see a commit all or nothing, and so do other
+// transactions and Update calls. Plain Set and Delete do not take the locks,
+// so a Set racing with a commit may be overwritten by it. fn must not call Txn
+// or Update.
+func (c *inMemoryCache) Txn(fn func(tx Tx) error) error {
+	for {
+		tx := &txn{
+			cache:  c,
+			reads:  make(map[string]*cacheItem),
+			writes: make(map[string]*cacheItem),
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if committed, err := tx.commit(); committed || err != nil {
+			return err
+		}
+	}
+}
+
+func (tx *txn) Get(key string) (interface{}, bool) {
+	if item, found := tx.writes[key]; found {
+		if item == nil {
+			return nil, false
+		}
+		return tx.cache.valueOf(item), true
+	}
+
+	observed, seen := tx.reads[key]
+	if !seen {
+		observed, _ = tx.cache.storage.Load(key)
+		tx.reads[key] = observed
+	}
+	if observed == nil || !observed.isLive(tx.cache.now()) {
+		tx.cache.recordAccess(key, false)
+		return nil, false
+	}
+	tx.cache.recordAccess(key, true)
+
+	return tx.cache.valueOf(observed), true
+}
+
+func (tx *txn) Set(key string, value interface{}, expiredInterval time.Duration) {
+	tx.write(key, tx.cache.newCacheItem(value, expiredInterval))
+}
+
+func (tx *txn) Delete(key string) {
+	tx.write(key, nil)
+}
+
+func (tx *txn) write(key string, item *cacheItem) {
+	if _, found := tx.writes[key]; !found {
+		tx.writeOrder = append(tx.writeOrder, key)
+	}
+	tx.writes[key] = item
+}
+
+// commit applies the writes unless an entry read by the transaction changed,
+// and reports whether it did. Every write is checked before any is applied,
+// and readers going through load see the writes all at once.
+func (tx *txn) commit() (bool, error) {
+	c := tx.cache
+	keys := make([]string, 0, len(tx.reads)+len(tx.writeOrder))
+	for key := range tx.reads {
+		keys = append(keys, key)
+	}
+	keys = append(keys, tx.writeOrder...)
+
+	// Eviction callbacks run once the keys are released, so they may update
+	// them.
+	var notices []evictionNotice
+	unlock := c.updateLocks.lockAll(keys)
+	defer func() {
+		unlock()
+		c.deliver(notices)
+	}()
+
+	for key, observed := range tx.reads {
+		if current, _ := c.storage.Load(key); current != observed {
+			return false, nil
+		}
+	}
+	if err := tx.check(); err != nil {
+		return false, err
+	}
+
+	if c.evictor != nil {
+		c.mu.Lock()
+		defer c.unlockInto(&notices)
+
+		for _, key := range tx.writeOrder {
+			if item := tx.writes[key]; item != nil {
+				c.makeRoom(key, item, defaultItemCost)
+			} else {
+				c.evictor.remove(key)
+				c.removeCost(key)
+			}
+		}
+	}
+
+	previous := make([]*cacheItem, len(tx.writeOrder))
+	loaded := make([]bool, len(tx.writeOrder))
+	c.commitMu.Lock()
+	c.commitSeq.Add(1)
+	for i, key := range tx.writeOrder {
+		if item := tx.writes[key]; item != nil {
+			previous[i], loaded[i] = c.storage.Swap(key, item)
+		} else {
+			previous[i], loaded[i] = c.storage.LoadAndDelete(key)
+		}
+	}
+	c.commitSeq.Add(1)
+	c.commitMu.Unlock()
+
+	for i, key := range tx.writeOrder {
+		if item := tx.writes[key]; item != nil {
+			c.stats.sets.Add(1)
+			c.stored(key, item, previous[i], loaded[i], &notices)
+			continue
+		}
+		c.stats.deletes.Add(1)
+		if loaded[i] {
+			c.deleted(key, previous[i], Deleted, &notices)
+		}
+	}
+
+	return true, nil
+}
+
+// check returns the reason the first write of the transaction that cannot be
+// stored would be dropped.
+func (tx *txn) check() error {
+	c := tx.cache
+	for _, key := range tx.writeOrder {
+		item := tx.writes[key]
+		if item == nil {
+			continue
+		}
+		if err := c.validateKey(key); err != nil {
+			c.stats.rejected.Add(1)
+			return err
+		}
+		if err := c.itemError(item); err != nil {
+			c.stats.rejected.Add(1)
+			return err
+		}
+		if !c.fits(key, item, defaultItemCost) {
+			c.stats.rejected.Add(1)
+			return fmt.Errorf("%w: entry %q exceeds the cache limits", ErrValueTooLarge, key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_Txn(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache()
+			cache.Set("from", 10, time.Minute)
+			cache.Set("to", 0, time.Minute)
+
+			err := cache.Txn(func(tx Tx) error {
+				from, _ := tx.Get("from")
+				to, _ := tx.Get("to")
+				tx.Set("from", from.(int)-3, time.Minute)
+				tx.Set("to", to.(int)+3, time.Minute)
+				tx.Delete("missing")
+
+				if value, _ := tx.Get("from"); value != 7 {
+					t.Errorf("tx.Get() = %v, want the transaction's own write %v", value, 7)
+				}
+				if value, _ := cache.Get("from"); value != 10 {
+					t.Errorf("Get() = %v before commit, want %v", value, 10)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Txn() error: %v", err)
+			}
+
+			from, _ := cache.Get("from")
+			to, _ := cache.Get("to")
+			if from != 7 || to != 3 {
+				t.Errorf("Get() after Txn() = %v, %v, want %v, %v", from, to, 7, 3)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_Txn_Abort(t *testing.T) {
+	cache := &inMemoryCache{}
+	cache.Set("test", 1, time.Minute)
+	errAbort := errors.New("abort")
+
+	err := cache.Txn(func(tx Tx) error {
+		tx.Set("test", 2, time.Minute)
+		tx.Delete("test")
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Errorf("Txn() error = %v, want %v", err, errAbort)
+	}
+	if value, _ := cache.Get("test"); value != 1 {
+		t.Errorf("Get() after aborted Txn() = %v, want %v", value, 1)
+	}
+}
+
+func Test_inMemoryCache_Txn_Concurrent(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache()
+			cache.Set("a", 100, time.Minute)
+			cache.Set("b", 100, time.Minute)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					cache.Txn(func(tx Tx) error {
+						a, _ := tx.Get("a")
+						b, _ := tx.Get("b")
+						tx.Set("a", a.(int)-1, time.Minute)
+						tx.Set("b", b.(int)+1, time.Minute)
+						return nil
+					})
+				}()
+				go func() {
+					defer wg.Done()
+					var sum int
+					cache.Txn(func(tx Tx) error {
+						a, _ := tx.Get("a")
+						b, _ := tx.Get("b")
+						sum = a.(int) + b.(int)
+						return nil
+					})
+					if sum != 200 {
+						t.Errorf("Txn() committed after observing a partial transfer, sum %v", sum)
+					}
+				}()
+			}
+			wg.Wait()
+
+			a, _ := cache.Get("a")
+			b, _ := cache.Get("b")
+			if a != 50 || b != 150 {
+				t.Errorf("Get() after concurrent transfers = %v, %v, want %v, %v", a, b, 50, 150)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_Txn_ConcurrentReader(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache()
+			cache.Set("first", 0, time.Minute)
+			cache.Set("second", 0, time.Minute)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 1; i <= 10000; i++ {
+					cache.Txn(func(tx Tx) error {
+						tx.Set("second", i, time.Minute)
+						tx.Set("first", i, time.Minute)
+						return nil
+					})
+				}
+			}()
+
+			var wg sync.WaitGroup
+			for r := 0; r < 4; r++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+						// A commit is visible all at once, so once the second
+						// key holds a generation the first one does as well.
+						second, _ := cache.Get("second")
+						first, _ := cache.Get("first")
+						if first.(int) < second.(int) {
+							t.Errorf("Get() observed a partial commit: first %v, second %v", first, second)
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func Test_inMemoryCache_Txn_RejectedWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*inMemoryCache)
+		key     string
+		value   interface{}
+		wantErr error
+	}{
+		{
+			name:    "Invalid key",
+			options: []func(*inMemoryCache){WithKeyValidator(NonEmptyKey)},
+			key:     "",
+			value:   2,
+			wantErr: ErrInvalidKey,
+		},
+		{
+			name:    "Value too large",
+			options: []func(*inMemoryCache){WithMaxItemSize(8)},
+			key:     "large",
+			value:   "a value well over eight bytes",
+			wantErr: ErrValueTooLarge,
+		},
+		{
+			name:    "Over memory limit",
+			options: []func(*inMemoryCache){WithMaxMemory(64)},
+			key:     "large",
+			value:   make([]byte, 128),
+			wantErr: ErrValueTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewInMemoryCache(context.Background(), tt.options...).(*inMemoryCache)
+			cache.Set("test", 1, time.Minute)
+
+			err := cache.Txn(func(tx Tx) error {
+				tx.Set("test", 2, time.Minute)
+				tx.Delete("other")
+				tx.Set(tt.key, tt.value, time.Minute)
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Txn() error = %v, want %v", err, tt.wantErr)
+			}
+			if value, _ := cache.Get("test"); value != 1 {
+				t.Errorf("Get() after rejected Txn() = %v, want %v", value, 1)
+			}
+			if rejected := cache.Stats().Rejected; rejected != 1 {
+				t.Errorf("Stats().Rejected = %v, want %v", rejected, 1)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_Txn_OnEvictedUpdatesKey(t *testing.T) {
+	for _, tc := range atomicCaches {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache()
+			var called atomic.Bool
+			WithOnEvicted(func(key string, value interface{}, reason EvictionReason) {
+				if called.Swap(true) {
+					return
+				}
+				_ = cache.Txn(func(tx Tx) error {
+					tx.Set(key, "from callback", time.Minute)
+					return nil
+				})
+			})(cache)
+			cache.Set("test", "stored", time.Minute)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_ = cache.Txn(func(tx Tx) error {
+					tx.Get("test")
+					tx.Set("test", "committed", time.Minute)
+					return nil
+				})
+			}()
+			select {
+			case <-done:
+			case <-time.After(time.Second * 2):
+				t.Fatalf("Txn() deadlocked when the eviction callback wrote the key")
+			}
+
+			if value, _ := cache.Get("test"); value != "from callback" {
+				t.Errorf("Get() = %v, want the value stored by the callback", value)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidKey    = errors.New("cache: invalid key")
+	ErrValueTooLarge = errors.New("cache: value too large")
+)
+
+type TrySetter interface {
+	TrySet(key string, value interface{}, expiredInterval time.Duration) error
+}
+
+// WithKeyValidator checks the key of every write with validate. Writes of keys
+// it returns an error for are dropped and counted in Stats.Rejected; TrySet
+// returns the error. The option may be given several times, and the
+// validators run in order until one fails. Reads are not validated.
+func WithKeyValidator(validate func(key string) error) func(*inMemoryCache) {
+	return func(cache *inMemoryCache) {
+		cache.keyValidators = append(cache.keyValidators, validate)
+	}
+}
+
+// NonEmptyKey rejects the empty key.
+func NonEmptyKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("%w: empty key", ErrInvalidKey)
+	}
+
+	return nil
+}
+
+// MaxKeyLength returns a validator rejecting keys longer than maxBytes.
+func MaxKeyLength(maxBytes int) func(key string) error {
+	return func(key string) error {
+		if len(key) > maxBytes {
+			return fmt.Errorf("%w: key of %d bytes exceeds %d", ErrInvalidKey, len(key), maxBytes)
+		}
+
+		return nil
+	}
+}
+
+// KeyCharset returns a validator rejecting keys that are not valid UTF-8 or
+// contain a rune allowed reports false for, such as unicode.IsPrint.
+func KeyCharset(allowed func(r rune) bool) func(key string) error {
+	return func(key string) error {
+		if !utf8.ValidString(key) {
+			return fmt.Errorf("%w: key is not valid UTF-8", ErrInvalidKey)
+		}
+		for i, r := range key {
+			if !allowed(r) {
+				return fmt.Errorf("%w: disallowed character %q at byte %d", ErrInvalidKey, r, i)
+			}
+		}
+
+		return nil
+	}
+}
+
+// TrySet is Set that reports why a write was dropped: an error wrapping
+// ErrInvalidKey from a WithKeyValidator validator, ErrValueTooLarge for
+// values over WithMaxItemSize, or the error of the WithCodec codec.
+func (c *inMemoryCache) TrySet(key string, value interface{}, expiredInterval time.Duration) error {
+	return c.set(key, c.newCacheItem(value, expiredInterval), defaultItemCost)
+}
+
+func (c *inMemoryCache) validateKey(key string) error {
+	for _, validate := range c.keyValidators {
+		if err := validate(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkWrite returns the reason a write of item to key must be dropped,
+// dropping the entry under key as well for values that cannot be stored.
+func (c *inMemoryCache) checkWrite(key string, item *cacheItem) error {
+	if err := c.validateKey(key); err != nil {
+		c.stats.rejected.Add(1)
+		return err
+	}
+	if err := c.itemError(item); err != nil {
+		c.reject(key)
+		return err
+	}
+
+	return nil
+}
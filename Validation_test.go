@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+)
+
+func TestKeyValidators(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(key string) error
+		key      string
+		wantErr  bool
+	}{
+		{name: "Non-empty key", validate: NonEmptyKey, key: "test"},
+		{name: "Empty key", validate: NonEmptyKey, key: "", wantErr: true},
+		{name: "Key within the length", validate: MaxKeyLength(4), key: "test"},
+		{name: "Key over the length", validate: MaxKeyLength(4), key: "test1", wantErr: true},
+		{name: "Allowed characters", validate: KeyCharset(unicode.IsPrint), key: "user:42"},
+		{name: "Disallowed character", validate: KeyCharset(unicode.IsPrint), key: "user\n42", wantErr: true},
+		{name: "Invalid UTF-8", validate: KeyCharset(unicode.IsPrint), key: "user\xff", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validate(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("validate(%q) error = %v, want ErrInvalidKey", tt.key, err)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_TrySet(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   interface{}
+		wantErr error
+	}{
+		{name: "Valid write", key: "test", value: "abc"},
+		{name: "Empty key", key: "", value: "abc", wantErr: ErrInvalidKey},
+		{name: "Long key", key: strings.Repeat("k", 11), value: "abc", wantErr: ErrInvalidKey},
+		{name: "Oversized value", key: "test", value: strings.Repeat("v", 11), wantErr: ErrValueTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			WithKeyValidator(NonEmptyKey)(cache)
+			WithKeyValidator(MaxKeyLength(10))(cache)
+			WithMaxItemSize(10)(cache)
+
+			err := cache.TrySet(tt.key, tt.value, time.Minute)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("TrySet() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, found := cache.Get(tt.key); found != (tt.wantErr == nil) {
+				t.Errorf("Get() found = %v after TrySet() returned %v", found, err)
+			}
+		})
+	}
+}
+
+func TestWithKeyValidator(t *testing.T) {
+	cache := &inMemoryCache{}
+	WithKeyValidator(NonEmptyKey)(cache)
+
+	cache.Set("", 1, time.Minute)
+	cache.GetOrSet("", 1, time.Minute)
+	cache.SetMulti(map[string]interface{}{"": 1, "test": 2}, time.Minute)
+	if _, err := cache.Increment("", 1, time.Minute); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Increment() error = %v, want ErrInvalidKey", err)
+	}
+
+	if _, found := cache.Get(""); found {
+		t.Errorf("Get() found a value stored under an invalid key")
+	}
+	if _, found := cache.Get("test"); !found {
+		t.Errorf("Get() did not find the valid key written by SetMulti")
+	}
+	if rejected := cache.Stats().Rejected; rejected != 4 {
+		t.Errorf("Stats().Rejected = %d, want %d", rejected, 4)
+	}
+}
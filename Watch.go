@@ -0,0 +1,85 @@
+package cache
+
+import "context"
+
+const watchBufferSize = 16
+
+type EventType int
+
+const (
+	EventSet EventType = iota
+	EventDelete
+	EventExpire
+)
+
+type Event struct {
+	Type  EventType
+	Key   string
+	Value interface{}
+}
+
+type Watcher interface {
+	Watch(ctx context.Context, key string) <-chan Event
+}
+
+func (t EventType) String() string {
+	switch t {
+	case EventSet:
+		return "set"
+	case EventDelete:
+		return "delete"
+	case EventExpire:
+		return "expire"
+	default:
+		return "unknown"
+	}
+}
+
+// Watch streams events for key until ctx is done, then closes the channel.
+// Events are dropped rather than blocking writers when the consumer falls
+// more than watchBufferSize events behind.
+func (c *inMemoryCache) Watch(ctx context.Context, key string) <-chan Event {
+	events := make(chan Event, watchBufferSize)
+
+	c.watchMu.Lock()
+	if c.watchers == nil {
+		c.watchers = make(map[string]map[chan Event]struct{})
+	}
+	if c.watchers[key] == nil {
+		c.watchers[key] = make(map[chan Event]struct{})
+	}
+	c.watchers[key][events] = struct{}{}
+	c.watchMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		c.watchMu.Lock()
+		delete(c.watchers[key], events)
+		if len(c.watchers[key]) == 0 {
+			delete(c.watchers, key)
+		}
+		close(events)
+		c.watchMu.Unlock()
+	}()
+
+	return events
+}
+
+// publishEvent sends item to the watchers of key. Each watcher gets its own
+// decoded or copied value, so none can change what the cache or the other
+// watchers see, and writes without watchers do not pay for it.
+func (c *inMemoryCache) publishEvent(eventType EventType, key string, item *cacheItem) {
+	c.watchMu.RLock()
+	defer c.watchMu.RUnlock()
+
+	if len(c.watchers[key]) == 0 {
+		return
+	}
+	for events := range c.watchers[key] {
+		select {
+		case events <- Event{Type: eventType, Key: key, Value: c.valueOf(item)}:
+		default:
+		}
+	}
+}
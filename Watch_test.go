@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_inMemoryCache_Watch(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(cache *inMemoryCache)
+		run     func(cache *inMemoryCache)
+		want    []Event
+	}{
+		{
+			name: "Set and delete",
+			run: func(cache *inMemoryCache) {
+				cache.Set("test", 1, time.Second*10)
+				cache.Set("other", 2, time.Second*10)
+				cache.Set("test", 3, time.Second*10)
+				cache.Delete("test")
+			},
+			want: []Event{
+				{Type: EventSet, Key: "test", Value: 1},
+				{Type: EventSet, Key: "test", Value: 3},
+				{Type: EventDelete, Key: "test", Value: 3},
+			},
+		},
+		{
+			name: "Expire",
+			run: func(cache *inMemoryCache) {
+				cache.Set("test", 1, time.Nanosecond)
+				cache.cleanUpTicker = systemClock{}.NewTicker(time.Millisecond * 5)
+				ctx, cancelFn := context.WithTimeout(context.Background(), time.Millisecond*12)
+				defer cancelFn()
+				cache.cleanUpCache(ctx)
+			},
+			want: []Event{
+				{Type: EventSet, Key: "test", Value: 1},
+				{Type: EventExpire, Key: "test", Value: 1},
+			},
+		},
+		{
+			name:    "Capacity eviction",
+			options: []func(cache *inMemoryCache){WithMaxEntries(1)},
+			run: func(cache *inMemoryCache) {
+				cache.Set("test", 1, time.Second*10)
+				cache.Set("other", 2, time.Second*10)
+			},
+			want: []Event{
+				{Type: EventSet, Key: "test", Value: 1},
+				{Type: EventDelete, Key: "test", Value: 1},
+			},
+		},
+		{
+			name: "GetOrSet",
+			run: func(cache *inMemoryCache) {
+				cache.GetOrSet("test", 1, time.Second*10)
+				cache.GetOrSet("test", 2, time.Second*10)
+			},
+			want: []Event{
+				{Type: EventSet, Key: "test", Value: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			for _, optionFn := range tt.options {
+				optionFn(cache)
+			}
+			ctx, cancelFn := context.WithCancel(context.Background())
+			events := cache.Watch(ctx, "test")
+
+			tt.run(cache)
+			cancelFn()
+
+			var got []Event
+			for event := range events {
+				got = append(got, event)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Watch() events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_inMemoryCache_WatchUnregister(t *testing.T) {
+	cache := &inMemoryCache{}
+	ctx, cancelFn := context.WithCancel(context.Background())
+	events := cache.Watch(ctx, "test")
+	cancelFn()
+
+	for range events {
+	}
+
+	cache.watchMu.RLock()
+	defer cache.watchMu.RUnlock()
+	if len(cache.watchers) != 0 {
+		t.Errorf("Watch() didn't unregister the watcher after ctx was done")
+	}
+}
+
+// waitForEvent fails the test unless an event of type want arrives on events
+// within a second, skipping events of other types.
+func waitForEvent(t *testing.T, events <-chan Event, want EventType) {
+	t.Helper()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Type == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no %v event within a second", want)
+		}
+	}
+}
+
+func Test_inMemoryCache_WatchWatchersGetOwnValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		option func(*inMemoryCache)
+	}{
+		{name: "Codec", option: WithCodec(GobCodec{})},
+		{name: "Value copying", option: WithValueCopying(nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &inMemoryCache{}
+			tt.option(cache)
+			ctx, cancelFn := context.WithCancel(context.Background())
+			defer cancelFn()
+			first, second := cache.Watch(ctx, "test"), cache.Watch(ctx, "test")
+
+			cache.Set("test", []int{1, 2, 3}, time.Second*10)
+			firstValue := (<-first).Value.([]int)
+			secondValue := (<-second).Value.([]int)
+
+			if &firstValue[0] == &secondValue[0] {
+				t.Fatalf("Watch() watchers share the same slice")
+			}
+			firstValue[0] = 100
+			if secondValue[0] != 1 {
+				t.Errorf("second watcher sees %v after the first changed its value", secondValue)
+			}
+			if value, _ := cache.Get("test"); !reflect.DeepEqual(value, []int{1, 2, 3}) {
+				t.Errorf("Get() = %v after a watcher changed its value, want %v", value, []int{1, 2, 3})
+			}
+		})
+	}
+}
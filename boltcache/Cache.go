@@ -0,0 +1,142 @@
+package boltcache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	bolt "go.etcd.io/bbolt"
+)
+
+const defaultBucket = "cache"
+
+type boltCache struct {
+	db      *bolt.DB
+	bucket  []byte
+	timeout time.Duration
+}
+
+type entry struct {
+	Value     interface{}
+	ExpiresAt time.Time
+}
+
+// Cache is a cache.Cache persisted in a bbolt database. Values are gob encoded,
+// so concrete types other than the gob builtins must be registered with
+// gob.Register. Expired entries are removed lazily when they are read.
+type Cache interface {
+	cache.Cache
+	Close() error
+}
+
+func New(path string, options ...func(*boltCache)) (Cache, error) {
+	c := &boltCache{bucket: []byte(defaultBucket), timeout: time.Second}
+	for _, optionFn := range options {
+		optionFn(c)
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: c.timeout})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(c.bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	c.db = db
+
+	return c, nil
+}
+
+func WithBucket(name string) func(*boltCache) {
+	return func(c *boltCache) {
+		c.bucket = []byte(name)
+	}
+}
+
+func WithOpenTimeout(timeout time.Duration) func(*boltCache) {
+	return func(c *boltCache) {
+		c.timeout = timeout
+	}
+}
+
+func (c *boltCache) Get(key string) (interface{}, bool) {
+	var stored entry
+	var found bool
+	err := c.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(c.bucket).Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		found = true
+
+		return gob.NewDecoder(bytes.NewReader(data)).Decode(&stored)
+	})
+	if err != nil || !found {
+		return nil, false
+	}
+
+	if stored.expired(time.Now()) {
+		c.deleteExpired(key)
+		return nil, false
+	}
+
+	return stored.Value, true
+}
+
+func (c *boltCache) Set(key string, value interface{}, expiredInterval time.Duration) {
+	stored := entry{Value: value}
+	if expiredInterval > 0 {
+		stored.ExpiresAt = time.Now().Add(expiredInterval)
+	}
+
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(stored); err != nil {
+		return
+	}
+
+	_ = c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(c.bucket).Put([]byte(key), data.Bytes())
+	})
+}
+
+func (c *boltCache) Delete(key string) {
+	_ = c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(c.bucket).Delete([]byte(key))
+	})
+}
+
+func (c *boltCache) Close() error {
+	return c.db.Close()
+}
+
+// deleteExpired re-checks the entry inside the write transaction, so a value
+// written after the read is not removed.
+func (c *boltCache) deleteExpired(key string) {
+	_ = c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(c.bucket)
+		data := bucket.Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+
+		var stored entry
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&stored); err != nil || !stored.expired(time.Now()) {
+			return nil
+		}
+
+		return bucket.Delete([]byte(key))
+	})
+}
+
+// expired reports false for entries stored without expiration, whose
+// ExpiresAt is zero.
+func (e entry) expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt)
+}
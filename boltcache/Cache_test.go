@@ -0,0 +1,135 @@
+package boltcache
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"github.com/abicur/go-sim-cache/cachetest"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	c, err := New(path, WithBucket("sessions"))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	c.Set("test", 42, time.Second*10)
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	reopened, err := New(path, WithBucket("sessions"))
+	if err != nil {
+		t.Fatalf("New() error on reopen: %v", err)
+	}
+	defer reopened.Close()
+
+	if value, found := reopened.Get("test"); !found || value != 42 {
+		t.Errorf("Get() after reopen = %v, %v, want %v, %v", value, found, 42, true)
+	}
+}
+
+func Test_boltCache_GetSetDelete(t *testing.T) {
+	tests := []struct {
+		name              string
+		value             interface{}
+		expiredInterval   time.Duration
+		deleteBeforeGet   bool
+		expectedValue     interface{}
+		expectedExistence bool
+	}{
+		{
+			name:              "Get stored value",
+			value:             "value",
+			expiredInterval:   time.Second * 10,
+			expectedValue:     "value",
+			expectedExistence: true,
+		},
+		{
+			name:              "Get expired value",
+			value:             "value",
+			expiredInterval:   time.Nanosecond,
+			expectedValue:     nil,
+			expectedExistence: false,
+		},
+		{
+			name:              "Get value without expiration",
+			value:             "value",
+			expiredInterval:   0,
+			expectedValue:     "value",
+			expectedExistence: true,
+		},
+		{
+			name:              "Get deleted value",
+			value:             "value",
+			expiredInterval:   time.Second * 10,
+			deleteBeforeGet:   true,
+			expectedValue:     nil,
+			expectedExistence: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(filepath.Join(t.TempDir(), "cache.db"))
+			if err != nil {
+				t.Fatalf("New() error: %v", err)
+			}
+			defer c.Close()
+
+			c.Set("test", tt.value, tt.expiredInterval)
+			if tt.deleteBeforeGet {
+				c.Delete("test")
+			}
+			time.Sleep(time.Millisecond)
+
+			value, found := c.Get("test")
+			if !reflect.DeepEqual(value, tt.expectedValue) {
+				t.Errorf("Get() value = %v, want %v", value, tt.expectedValue)
+			}
+			if found != tt.expectedExistence {
+				t.Errorf("Get() found = %v, want %v", found, tt.expectedExistence)
+			}
+		})
+	}
+}
+
+func Test_boltCache_lazyExpiration(t *testing.T) {
+	c, err := New(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer c.Close()
+
+	c.Set("test", 1, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	c.Get("test")
+
+	bc := c.(*boltCache)
+	var stats int
+	bc.db.View(func(tx *bolt.Tx) error {
+		stats = tx.Bucket(bc.bucket).Stats().KeyN
+		return nil
+	})
+	if stats != 0 {
+		t.Errorf("expired entry expected to be removed on read, %d keys left", stats)
+	}
+}
+
+func Test_boltCache_Conformance(t *testing.T) {
+	dir := t.TempDir()
+	opened := 0
+	cachetest.RunConformanceTests(t, func() cache.Cache {
+		opened++
+		c, err := New(filepath.Join(dir, fmt.Sprintf("cache-%d.db", opened)))
+		if err != nil {
+			t.Fatalf("New() error: %v", err)
+		}
+		return c
+	})
+}
@@ -0,0 +1,294 @@
+package cacheserver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+const (
+	maxMemcacheKeyLength = 250
+	// Expiration times above this many seconds are absolute Unix timestamps.
+	relativeExpirationLimit = 60 * 60 * 24 * 30
+)
+
+// MemcacheServer serves the get, gets, set, delete, touch, version and quit
+// commands of the memcached text protocol. Values are stored as strings unless
+// the client sets non-zero flags, in which case flags and data are stored
+// together so they can be returned unchanged.
+type MemcacheServer struct {
+	cache       cache.Cache
+	defaultTTL  time.Duration
+	maxItemSize int
+	now         func() time.Time
+	connections connections
+}
+
+type flaggedValue struct {
+	flags uint32
+	data  string
+}
+
+func NewMemcacheServer(c cache.Cache, options ...func(*MemcacheServer)) *MemcacheServer {
+	s := &MemcacheServer{cache: c, defaultTTL: defaultTTL, maxItemSize: 1 << 20, now: time.Now}
+	for _, optionFn := range options {
+		optionFn(s)
+	}
+
+	return s
+}
+
+// WithMemcacheDefaultTTL sets the lifetime of items stored with an exptime of
+// zero, which by default never expire as in memcached.
+func WithMemcacheDefaultTTL(ttl time.Duration) func(*MemcacheServer) {
+	return func(s *MemcacheServer) {
+		s.defaultTTL = ttl
+	}
+}
+
+// WithMemcacheLogger sets the logger of panics while serving a connection,
+// which close the connection. By default they go to slog.Default.
+func WithMemcacheLogger(logger *slog.Logger) func(*MemcacheServer) {
+	return func(s *MemcacheServer) {
+		s.connections.logger = logger
+	}
+}
+
+func WithMaxItemSize(size int) func(*MemcacheServer) {
+	return func(s *MemcacheServer) {
+		s.maxItemSize = size
+	}
+}
+
+func (s *MemcacheServer) ListenAndServe(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener until Close is called, then returns
+// ErrServerClosed.
+func (s *MemcacheServer) Serve(listener net.Listener) error {
+	return s.connections.serve(listener, s.handle)
+}
+
+// Close stops all listeners and closes open connections.
+func (s *MemcacheServer) Close() error {
+	return s.connections.close()
+}
+
+func (s *MemcacheServer) handle(conn net.Conn) {
+	buffer := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	for {
+		line, err := buffer.ReadSlice('\n')
+		if err != nil {
+			if err == bufio.ErrBufferFull {
+				buffer.WriteString("CLIENT_ERROR line too long\r\n")
+				buffer.Flush()
+			}
+			return
+		}
+
+		fields := strings.Fields(string(line))
+		if len(fields) == 0 {
+			buffer.WriteString("ERROR\r\n")
+		} else if fields[0] == "quit" {
+			return
+		} else if !s.execute(buffer, fields) {
+			buffer.Flush()
+			return
+		}
+		if buffer.Flush() != nil {
+			return
+		}
+	}
+}
+
+// execute runs one command and reports whether the connection is still in a
+// usable state.
+func (s *MemcacheServer) execute(buffer *bufio.ReadWriter, fields []string) bool {
+	switch fields[0] {
+	case "get", "gets":
+		if len(fields) < 2 {
+			buffer.WriteString("ERROR\r\n")
+			return true
+		}
+		for _, key := range fields[1:] {
+			s.writeValue(buffer, key, fields[0] == "gets")
+		}
+		buffer.WriteString("END\r\n")
+	case "set":
+		return s.set(buffer, fields)
+	case "delete":
+		if len(fields) < 2 || len(fields) > 3 || !validMemcacheKey(fields[1]) {
+			buffer.WriteString("CLIENT_ERROR bad command line format\r\n")
+			return true
+		}
+		found := has(s.cache, fields[1])
+		s.cache.Delete(fields[1])
+		if found {
+			s.reply(buffer, fields, "DELETED")
+		} else {
+			s.reply(buffer, fields, "NOT_FOUND")
+		}
+	case "touch":
+		s.touch(buffer, fields)
+	case "version":
+		buffer.WriteString("VERSION go-sim-cache\r\n")
+	default:
+		buffer.WriteString("ERROR\r\n")
+	}
+
+	return true
+}
+
+func (s *MemcacheServer) writeValue(buffer *bufio.ReadWriter, key string, withCAS bool) {
+	value, found := s.cache.Get(key)
+	if !found {
+		return
+	}
+
+	var flags uint32
+	var data string
+	if flagged, ok := value.(flaggedValue); ok {
+		flags, data = flagged.flags, flagged.data
+	} else {
+		data = formatValue(value)
+	}
+
+	if withCAS {
+		// CAS is not supported, so gets reports a zero CAS unique.
+		fmt.Fprintf(buffer, "VALUE %s %d %d 0\r\n%s\r\n", key, flags, len(data), data)
+	} else {
+		fmt.Fprintf(buffer, "VALUE %s %d %d\r\n%s\r\n", key, flags, len(data), data)
+	}
+}
+
+// set handles "set <key> <flags> <exptime> <bytes> [noreply]".
+func (s *MemcacheServer) set(buffer *bufio.ReadWriter, fields []string) bool {
+	if len(fields) < 5 || len(fields) > 6 {
+		buffer.WriteString("ERROR\r\n")
+		return true
+	}
+	flags, flagsErr := strconv.ParseUint(fields[2], 10, 32)
+	exptime, exptimeErr := strconv.ParseInt(fields[3], 10, 64)
+	size, sizeErr := strconv.Atoi(fields[4])
+	if flagsErr != nil || exptimeErr != nil || sizeErr != nil || size < 0 {
+		buffer.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return false
+	}
+	if size > s.maxItemSize {
+		buffer.WriteString("SERVER_ERROR object too large for cache\r\n")
+		if buffer.Flush() != nil {
+			return false
+		}
+		_, err := io.CopyN(io.Discard, buffer, int64(size)+2)
+		return err == nil
+	}
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(buffer, data); err != nil {
+		return false
+	}
+	if data[size] != '\r' || data[size+1] != '\n' {
+		buffer.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return false
+	}
+	if !validMemcacheKey(fields[1]) {
+		buffer.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return true
+	}
+
+	var value interface{} = string(data[:size])
+	if flags != 0 {
+		value = flaggedValue{flags: uint32(flags), data: string(data[:size])}
+	}
+	if ttl, ok := s.expiration(exptime); ok {
+		s.cache.Set(fields[1], value, ttl)
+	} else {
+		s.cache.Delete(fields[1])
+	}
+	s.reply(buffer, fields, "STORED")
+
+	return true
+}
+
+// touch handles "touch <key> <exptime> [noreply]".
+func (s *MemcacheServer) touch(buffer *bufio.ReadWriter, fields []string) {
+	if len(fields) < 3 || len(fields) > 4 || !validMemcacheKey(fields[1]) {
+		buffer.WriteString("ERROR\r\n")
+		return
+	}
+	exptime, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		buffer.WriteString("CLIENT_ERROR invalid exptime argument\r\n")
+		return
+	}
+	e, ok := s.cache.(expirer)
+	if !ok {
+		buffer.WriteString("SERVER_ERROR touch is not supported by this cache\r\n")
+		return
+	}
+
+	ttl, live := s.expiration(exptime)
+	switch {
+	case !live:
+		found := has(s.cache, fields[1])
+		s.cache.Delete(fields[1])
+		if found {
+			s.reply(buffer, fields, "TOUCHED")
+		} else {
+			s.reply(buffer, fields, "NOT_FOUND")
+		}
+	case e.Expire(fields[1], ttl):
+		s.reply(buffer, fields, "TOUCHED")
+	default:
+		s.reply(buffer, fields, "NOT_FOUND")
+	}
+}
+
+// expiration converts a memcached exptime to a TTL. It reports false for
+// items that are already expired.
+func (s *MemcacheServer) expiration(exptime int64) (time.Duration, bool) {
+	switch {
+	case exptime < 0:
+		return 0, false
+	case exptime == 0:
+		return s.defaultTTL, true
+	case exptime > relativeExpirationLimit:
+		ttl := time.Unix(exptime, 0).Sub(s.now())
+		return ttl, ttl > 0
+	default:
+		return time.Duration(exptime) * time.Second, true
+	}
+}
+
+func (s *MemcacheServer) reply(buffer *bufio.ReadWriter, fields []string, message string) {
+	if fields[len(fields)-1] == "noreply" {
+		return
+	}
+	buffer.WriteString(message + "\r\n")
+}
+
+func validMemcacheKey(key string) bool {
+	if len(key) > maxMemcacheKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+
+	return key != ""
+}
@@ -0,0 +1,184 @@
+package cacheserver
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"github.com/abicur/go-sim-cache/memcachecache"
+)
+
+func startMemcacheServer(t *testing.T, c cache.Cache) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	server := NewMemcacheServer(c)
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	return listener.Addr().String()
+}
+
+func TestMemcacheServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		cache   func() cache.Cache
+		request string
+		want    string
+	}{
+		{
+			name:    "Set then get",
+			request: "set key 0 60 5\r\nhello\r\nget key\r\n",
+			want:    "STORED\r\nVALUE key 0 5\r\nhello\r\nEND\r\n",
+		},
+		{
+			name:    "Flags are preserved",
+			request: "set key 7 60 2\r\nhi\r\ngets key missing\r\n",
+			want:    "STORED\r\nVALUE key 7 2 0\r\nhi\r\nEND\r\n",
+		},
+		{
+			name:    "Noreply",
+			request: "set key 0 60 1 noreply\r\nx\r\nget key\r\n",
+			want:    "VALUE key 0 1\r\nx\r\nEND\r\n",
+		},
+		{
+			name:    "Negative exptime stores nothing",
+			request: "set key 0 -1 1\r\nx\r\nget key\r\n",
+			want:    "STORED\r\nEND\r\n",
+		},
+		{
+			name:    "Delete",
+			request: "set key 0 60 1\r\nx\r\ndelete key\r\ndelete key\r\n",
+			want:    "STORED\r\nDELETED\r\nNOT_FOUND\r\n",
+		},
+		{
+			name: "Touch unsupported",
+			cache: func() cache.Cache {
+				return struct{ cache.Cache }{cache.NewInMemoryCache(context.Background())}
+			},
+			request: "touch key 60\r\n",
+			want:    "SERVER_ERROR touch is not supported by this cache\r\n",
+		},
+		{
+			name: "Touch",
+			cache: func() cache.Cache {
+				c := cache.NewInMemoryCache(context.Background())
+				c.Set("key", "x", time.Minute)
+				return &lifetimeCache{Cache: c, ttls: map[string]time.Duration{"key": time.Minute}}
+			},
+			request: "touch key 60\r\ntouch missing 60\r\n",
+			want:    "TOUCHED\r\nNOT_FOUND\r\n",
+		},
+		{
+			name:    "Object too large",
+			request: "set key 0 60 2000000\r\n",
+			want:    "SERVER_ERROR object too large for cache\r\n",
+		},
+		{
+			name:    "Unknown command",
+			request: "incr key 1\r\n",
+			want:    "ERROR\r\n",
+		},
+		{
+			name:    "Version",
+			request: "version\r\n",
+			want:    "VERSION go-sim-cache\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c cache.Cache = cache.NewInMemoryCache(context.Background())
+			if tt.cache != nil {
+				c = tt.cache()
+			}
+			conn, err := net.Dial("tcp", startMemcacheServer(t, c))
+			if err != nil {
+				t.Fatalf("Dial() error: %v", err)
+			}
+			defer conn.Close()
+
+			if _, err := io.WriteString(conn, tt.request); err != nil {
+				t.Fatalf("write error: %v", err)
+			}
+			got := make([]byte, len(tt.want))
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+			if _, err := io.ReadFull(bufio.NewReader(conn), got); err != nil {
+				t.Fatalf("read error: %v, got %q", err, got)
+			}
+			if string(got) != tt.want {
+				t.Errorf("response = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemcacheServer_expiration(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name     string
+		exptime  int64
+		wantTTL  time.Duration
+		wantLive bool
+	}{
+		{name: "Zero uses the default", exptime: 0, wantTTL: defaultTTL, wantLive: true},
+		{name: "Relative seconds", exptime: 60, wantTTL: time.Minute, wantLive: true},
+		{name: "Absolute time", exptime: now.Unix() + 3600, wantTTL: time.Hour, wantLive: true},
+		{name: "Absolute time in the past", exptime: now.Unix() - 1},
+		{name: "Negative", exptime: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemcacheServer(nil)
+			s.now = func() time.Time { return now }
+			ttl, live := s.expiration(tt.exptime)
+			if live != tt.wantLive || (live && ttl != tt.wantTTL) {
+				t.Errorf("expiration() = %v, %v, want %v, %v", ttl, live, tt.wantTTL, tt.wantLive)
+			}
+		})
+	}
+}
+
+func TestMemcacheServer_MemcacheClient(t *testing.T) {
+	address := startMemcacheServer(t, cache.NewInMemoryCache(context.Background()))
+	client := memcachecache.New(memcachecache.WithServers(address))
+	defer client.Close()
+
+	client.Set("key", []int{1, 2}, time.Minute)
+	if value, found := client.Get("key"); !found || !reflect.DeepEqual(value, []int{1, 2}) {
+		t.Errorf("Get() = %v, %v, want %v, true", value, found, []int{1, 2})
+	}
+	client.Delete("key")
+	if _, found := client.Get("key"); found {
+		t.Errorf("Get() found a deleted key")
+	}
+}
+
+func TestMemcacheServer_DeleteIsNotARead(t *testing.T) {
+	c := cache.NewInMemoryCache(context.Background())
+	c.Set("existing", "value", time.Minute)
+	conn, err := net.Dial("tcp", startMemcacheServer(t, c))
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	for _, request := range []struct{ command, want string }{
+		{"delete existing\r\n", "DELETED\r\n"},
+		{"delete missing\r\n", "NOT_FOUND\r\n"},
+	} {
+		conn.Write([]byte(request.command))
+		if reply, err := reader.ReadString('\n'); err != nil || reply != request.want {
+			t.Errorf("reply to %q = %q, %v, want %q", request.command, reply, err, request.want)
+		}
+	}
+	if stats := c.(cache.StatsProvider).Stats(); stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("Stats() = %d hits, %d misses, want no reads", stats.Hits, stats.Misses)
+	}
+}
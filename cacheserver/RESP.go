@@ -0,0 +1,291 @@
+package cacheserver
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"github.com/abicur/go-sim-cache/internal/glob"
+	"github.com/abicur/go-sim-cache/internal/resp"
+)
+
+// expirer is satisfied by caches that can change the lifetime of an entry;
+// EXPIRE and touch answer with an error otherwise, as TTL does for caches that
+// are not a cache.TTLProvider.
+type expirer interface {
+	Expire(key string, expiredInterval time.Duration) bool
+}
+
+// RESPServer serves a subset of the Redis protocol: GET, SET with EX or PX,
+// DEL, EXISTS, KEYS, TTL, EXPIRE, PING, ECHO and QUIT. Values written over the
+// protocol are stored as strings; other values are formatted with fmt.
+type RESPServer struct {
+	cache          cache.Cache
+	defaultTTL     time.Duration
+	maxBulkLength  int
+	maxArrayLength int
+	connections    connections
+}
+
+func NewRESPServer(c cache.Cache, options ...func(*RESPServer)) *RESPServer {
+	s := &RESPServer{cache: c, defaultTTL: defaultTTL, maxBulkLength: resp.DefaultMaxBulkLength, maxArrayLength: resp.DefaultMaxArrayLength}
+	for _, optionFn := range options {
+		optionFn(s)
+	}
+
+	return s
+}
+
+// WithRESPDefaultTTL sets the lifetime of keys written by SET without EX or PX,
+// which by default never expire as in Redis.
+func WithRESPDefaultTTL(ttl time.Duration) func(*RESPServer) {
+	return func(s *RESPServer) {
+		s.defaultTTL = ttl
+	}
+}
+
+// WithRESPLogger sets the logger of panics while serving a connection, which
+// close the connection. By default they go to slog.Default.
+func WithRESPLogger(logger *slog.Logger) func(*RESPServer) {
+	return func(s *RESPServer) {
+		s.connections.logger = logger
+	}
+}
+
+// WithRESPLimits sets the longest bulk string and the most arguments a client
+// may send, by default 512 MiB and 1048576 as in Redis. Connections exceeding
+// them get a protocol error and are closed.
+func WithRESPLimits(maxBulkLength, maxArrayLength int) func(*RESPServer) {
+	return func(s *RESPServer) {
+		s.maxBulkLength = maxBulkLength
+		s.maxArrayLength = maxArrayLength
+	}
+}
+
+func (s *RESPServer) ListenAndServe(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener until Close is called, then returns
+// ErrServerClosed.
+func (s *RESPServer) Serve(listener net.Listener) error {
+	return s.connections.serve(listener, s.handle)
+}
+
+// Close stops all listeners and closes open connections.
+func (s *RESPServer) Close() error {
+	return s.connections.close()
+}
+
+func (s *RESPServer) handle(conn net.Conn) {
+	reader := resp.NewReader(conn)
+	reader.SetLimits(s.maxBulkLength, s.maxArrayLength)
+	writer := resp.NewWriter(conn)
+	for {
+		args, err := reader.ReadCommand()
+		if err != nil {
+			if errors.Is(err, resp.ErrProtocol) {
+				writer.WriteError("ERR Protocol error")
+				writer.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := strings.EqualFold(args[0], "QUIT")
+		s.execute(writer, args)
+		if writer.Flush() != nil || quit {
+			return
+		}
+	}
+}
+
+func (s *RESPServer) execute(writer *resp.Writer, args []string) {
+	command := strings.ToUpper(args[0])
+	arity, known := respArity[command]
+	if !known {
+		writer.WriteError(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+		return
+	}
+	if len(args) < arity.min || (arity.max > 0 && len(args) > arity.max) {
+		writer.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(command)))
+		return
+	}
+
+	switch command {
+	case "PING":
+		if len(args) > 1 {
+			writer.WriteBulkString(args[1])
+		} else {
+			writer.WriteSimpleString("PONG")
+		}
+	case "ECHO":
+		writer.WriteBulkString(args[1])
+	case "QUIT":
+		writer.WriteSimpleString("OK")
+	case "GET":
+		value, found := s.cache.Get(args[1])
+		if !found {
+			writer.WriteNull()
+			return
+		}
+		writer.WriteBulkString(formatValue(value))
+	case "SET":
+		s.set(writer, args)
+	case "DEL":
+		var deleted int64
+		for _, key := range args[1:] {
+			if has(s.cache, key) {
+				deleted++
+			}
+			s.cache.Delete(key)
+		}
+		writer.WriteInteger(deleted)
+	case "EXISTS":
+		var existing int64
+		for _, key := range args[1:] {
+			if has(s.cache, key) {
+				existing++
+			}
+		}
+		writer.WriteInteger(existing)
+	case "KEYS":
+		s.keys(writer, args[1])
+	case "TTL":
+		s.ttl(writer, args[1])
+	case "EXPIRE":
+		s.expire(writer, args[1], args[2])
+	}
+}
+
+// respArity bounds the argument count of each command, including the command
+// name itself. A zero max means any number of keys may follow.
+var respArity = map[string]struct{ min, max int }{
+	"PING":   {1, 2},
+	"ECHO":   {2, 2},
+	"QUIT":   {1, 1},
+	"GET":    {2, 2},
+	"SET":    {3, 5},
+	"DEL":    {2, 0},
+	"EXISTS": {2, 0},
+	"KEYS":   {2, 2},
+	"TTL":    {2, 2},
+	"EXPIRE": {3, 3},
+}
+
+func (s *RESPServer) set(writer *resp.Writer, args []string) {
+	ttl := s.defaultTTL
+	switch {
+	case len(args) == 3:
+	case len(args) == 5 && (strings.EqualFold(args[3], "EX") || strings.EqualFold(args[3], "PX")):
+		amount, err := strconv.ParseInt(args[4], 10, 64)
+		if err != nil || amount <= 0 {
+			writer.WriteError("ERR invalid expire time in 'set' command")
+			return
+		}
+		if strings.EqualFold(args[3], "EX") {
+			ttl = time.Duration(amount) * time.Second
+		} else {
+			ttl = time.Duration(amount) * time.Millisecond
+		}
+	default:
+		writer.WriteError("ERR syntax error")
+		return
+	}
+
+	s.cache.Set(args[1], args[2], ttl)
+	writer.WriteSimpleString("OK")
+}
+
+func (s *RESPServer) keys(writer *resp.Writer, pattern string) {
+	lister, ok := s.cache.(cache.KeyLister)
+	if !ok {
+		writer.WriteError("ERR KEYS is not supported by this cache")
+		return
+	}
+	if err := glob.Validate(pattern); err != nil {
+		writer.WriteError("ERR invalid pattern")
+		return
+	}
+
+	var keys []string
+	for _, key := range lister.Keys() {
+		if ok, _ := glob.Match(pattern, key); ok {
+			keys = append(keys, key)
+		}
+	}
+	writer.WriteArrayHeader(len(keys))
+	for _, key := range keys {
+		writer.WriteBulkString(key)
+	}
+}
+
+func (s *RESPServer) ttl(writer *resp.Writer, key string) {
+	reader, ok := s.cache.(cache.TTLProvider)
+	if !ok {
+		writer.WriteError("ERR TTL is not supported by this cache")
+		return
+	}
+
+	remaining, found := reader.TTL(key)
+	switch {
+	case !found:
+		writer.WriteInteger(-2)
+	case remaining < 0:
+		writer.WriteInteger(-1)
+	default:
+		writer.WriteInteger(int64((remaining + time.Second - 1) / time.Second))
+	}
+}
+
+func (s *RESPServer) expire(writer *resp.Writer, key, seconds string) {
+	e, ok := s.cache.(expirer)
+	if !ok {
+		writer.WriteError("ERR EXPIRE is not supported by this cache")
+		return
+	}
+
+	amount, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		writer.WriteError("ERR value is not an integer or out of range")
+		return
+	}
+	if amount <= 0 {
+		if !has(s.cache, key) {
+			writer.WriteInteger(0)
+			return
+		}
+		s.cache.Delete(key)
+		writer.WriteInteger(1)
+		return
+	}
+
+	if e.Expire(key, time.Duration(amount)*time.Second) {
+		writer.WriteInteger(1)
+	} else {
+		writer.WriteInteger(0)
+	}
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,353 @@
+package cacheserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"github.com/abicur/go-sim-cache/internal/resp"
+	"github.com/abicur/go-sim-cache/rediscache"
+)
+
+// lifetimeCache adds fixed TTL and Expire results to an in-memory cache.
+type lifetimeCache struct {
+	cache.Cache
+	ttls map[string]time.Duration
+}
+
+func (c *lifetimeCache) TTL(key string) (time.Duration, bool) {
+	ttl, found := c.ttls[key]
+	return ttl, found
+}
+
+func (c *lifetimeCache) Keys() []string {
+	return []string{"existing", "number"}
+}
+
+func (c *lifetimeCache) Expire(key string, expiredInterval time.Duration) bool {
+	if _, found := c.ttls[key]; !found {
+		return false
+	}
+	c.ttls[key] = expiredInterval
+
+	return true
+}
+
+func startRESPServer(t *testing.T, c cache.Cache, options ...func(*RESPServer)) (*RESPServer, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	server := NewRESPServer(c, options...)
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	return server, listener.Addr().String()
+}
+
+func TestRESPServer(t *testing.T) {
+	newCache := func() cache.Cache {
+		c := cache.NewInMemoryCache(context.Background())
+		c.Set("existing", "value", time.Minute)
+		c.Set("number", 42, time.Minute)
+		return &lifetimeCache{Cache: c, ttls: map[string]time.Duration{"existing": time.Millisecond * 1500, "forever": -1}}
+	}
+	tests := []struct {
+		name     string
+		commands [][]string
+		want     resp.Value
+	}{
+		{
+			name:     "Ping",
+			commands: [][]string{{"PING"}},
+			want:     resp.Value{Type: resp.SimpleString, Str: "PONG"},
+		},
+		{
+			name:     "Get existing",
+			commands: [][]string{{"get", "existing"}},
+			want:     resp.Value{Type: resp.BulkString, Str: "value"},
+		},
+		{
+			name:     "Get non-string value",
+			commands: [][]string{{"GET", "number"}},
+			want:     resp.Value{Type: resp.BulkString, Str: "42"},
+		},
+		{
+			name:     "Get missing",
+			commands: [][]string{{"GET", "missing"}},
+			want:     resp.Value{Type: resp.BulkString, Null: true},
+		},
+		{
+			name:     "Set then get",
+			commands: [][]string{{"SET", "new", "v", "EX", "10"}, {"GET", "new"}},
+			want:     resp.Value{Type: resp.BulkString, Str: "v"},
+		},
+		{
+			name:     "Set with invalid expire",
+			commands: [][]string{{"SET", "new", "v", "PX", "0"}},
+			want:     resp.Value{Type: resp.Error, Str: "ERR invalid expire time in 'set' command"},
+		},
+		{
+			name:     "Set with unknown option",
+			commands: [][]string{{"SET", "new", "v", "NX", "1"}},
+			want:     resp.Value{Type: resp.Error, Str: "ERR syntax error"},
+		},
+		{
+			name:     "Delete counts existing keys",
+			commands: [][]string{{"DEL", "existing", "missing", "number"}},
+			want:     resp.Value{Type: resp.Integer, Int: 2},
+		},
+		{
+			name:     "Exists",
+			commands: [][]string{{"EXISTS", "existing", "missing"}},
+			want:     resp.Value{Type: resp.Integer, Int: 1},
+		},
+		{
+			name:     "Keys",
+			commands: [][]string{{"KEYS", "ex*"}},
+			want:     resp.Value{Type: resp.Array, Array: []resp.Value{{Type: resp.BulkString, Str: "existing"}}},
+		},
+		{
+			name:     "Keys with invalid pattern",
+			commands: [][]string{{"KEYS", "ex["}},
+			want:     resp.Value{Type: resp.Error, Str: "ERR invalid pattern"},
+		},
+		{
+			name:     "TTL rounds up",
+			commands: [][]string{{"TTL", "existing"}},
+			want:     resp.Value{Type: resp.Integer, Int: 2},
+		},
+		{
+			name:     "TTL of missing key",
+			commands: [][]string{{"TTL", "missing"}},
+			want:     resp.Value{Type: resp.Integer, Int: -2},
+		},
+		{
+			name:     "TTL without expiration",
+			commands: [][]string{{"TTL", "forever"}},
+			want:     resp.Value{Type: resp.Integer, Int: -1},
+		},
+		{
+			name:     "Expire then TTL",
+			commands: [][]string{{"EXPIRE", "existing", "30"}, {"TTL", "existing"}},
+			want:     resp.Value{Type: resp.Integer, Int: 30},
+		},
+		{
+			name:     "Expire missing key",
+			commands: [][]string{{"EXPIRE", "missing", "30"}},
+			want:     resp.Value{Type: resp.Integer, Int: 0},
+		},
+		{
+			name:     "Non-positive expire deletes",
+			commands: [][]string{{"EXPIRE", "existing", "0"}, {"GET", "existing"}},
+			want:     resp.Value{Type: resp.BulkString, Null: true},
+		},
+		{
+			name:     "Wrong number of arguments",
+			commands: [][]string{{"GET"}},
+			want:     resp.Value{Type: resp.Error, Str: "ERR wrong number of arguments for 'get' command"},
+		},
+		{
+			name:     "Unknown command",
+			commands: [][]string{{"FLUSHALL"}},
+			want:     resp.Value{Type: resp.Error, Str: "ERR unknown command 'FLUSHALL'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, address := startRESPServer(t, newCache())
+			conn, err := net.Dial("tcp", address)
+			if err != nil {
+				t.Fatalf("Dial() error: %v", err)
+			}
+			defer conn.Close()
+			reader, writer := resp.NewReader(conn), resp.NewWriter(conn)
+
+			var got resp.Value
+			for _, command := range tt.commands {
+				if err := writer.WriteCommand(command...); err != nil {
+					t.Fatalf("WriteCommand() error: %v", err)
+				}
+				if got, err = reader.ReadValue(); err != nil {
+					t.Fatalf("ReadValue() error: %v", err)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reply = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRESPServer_TTLUnsupported(t *testing.T) {
+	_, address := startRESPServer(t, struct{ cache.Cache }{cache.NewInMemoryCache(context.Background())})
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+	defer conn.Close()
+
+	resp.NewWriter(conn).WriteCommand("TTL", "key")
+	reply, err := resp.NewReader(conn).ReadValue()
+	if err != nil || reply.Type != resp.Error {
+		t.Errorf("TTL reply = %+v, %v, want an error", reply, err)
+	}
+}
+
+func TestRESPServer_RedisClient(t *testing.T) {
+	_, address := startRESPServer(t, cache.NewInMemoryCache(context.Background()))
+	client := rediscache.New(rediscache.WithAddress(address), rediscache.WithCodec(rediscache.StringCodec{}))
+	defer client.Close()
+
+	client.Set("key", "value", time.Minute)
+	if value, found := client.Get("key"); !found || value != "value" {
+		t.Errorf("Get() = %v, %v, want %q, true", value, found, "value")
+	}
+	client.Delete("key")
+	if _, found := client.Get("key"); found {
+		t.Errorf("Get() found a deleted key")
+	}
+}
+
+func TestRESPServer_OversizedRequest(t *testing.T) {
+	_, address := startRESPServer(t, cache.NewInMemoryCache(context.Background()))
+	for _, request := range []string{
+		"*1\r\n$9223372036854775807\r\n",
+		"*9223372036854775807\r\n",
+	} {
+		conn, err := net.Dial("tcp", address)
+		if err != nil {
+			t.Fatalf("Dial() error: %v", err)
+		}
+		conn.Write([]byte(request))
+		if reply, err := resp.NewReader(conn).ReadValue(); err != nil || reply.Type != resp.Error {
+			t.Errorf("reply to %q = %+v, %v, want an error", request, reply, err)
+		}
+		conn.Close()
+	}
+
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+	defer conn.Close()
+	resp.NewWriter(conn).WriteCommand("PING")
+	if reply, err := resp.NewReader(conn).ReadValue(); err != nil || reply.Str != "PONG" {
+		t.Errorf("PING after oversized requests = %+v, %v, want PONG", reply, err)
+	}
+}
+
+func TestRESPServer_Close(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	server := NewRESPServer(cache.NewInMemoryCache(context.Background()))
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+	defer conn.Close()
+	resp.NewWriter(conn).WriteCommand("PING")
+	if _, err := resp.NewReader(conn).ReadValue(); err != nil {
+		t.Fatalf("PING error: %v", err)
+	}
+
+	if err := server.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	select {
+	case err := <-served:
+		if !errors.Is(err, ErrServerClosed) {
+			t.Errorf("Serve() error = %v, want %v", err, ErrServerClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Serve() did not return after Close()")
+	}
+	if err := server.Serve(listener); !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Serve() after Close() error = %v, want %v", err, ErrServerClosed)
+	}
+}
+
+// panickingCache panics on every read.
+type panickingCache struct {
+	cache.Cache
+}
+
+func (panickingCache) Get(key string) (interface{}, bool) {
+	panic("broken cache")
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buffer.String()
+}
+
+func TestRESPServer_LogsPanics(t *testing.T) {
+	var logs syncBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	_, address := startRESPServer(t, panickingCache{}, WithRESPLogger(logger))
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+	defer conn.Close()
+
+	resp.NewWriter(conn).WriteCommand("GET", "key")
+	if _, err := resp.NewReader(conn).ReadValue(); !errors.Is(err, io.EOF) {
+		t.Fatalf("ReadValue() error = %v, want the connection closed", err)
+	}
+	// The panic is logged before the connection is closed.
+	if got := logs.String(); !strings.Contains(got, "broken cache") || !strings.Contains(got, "stack=") {
+		t.Errorf("logs = %q, want the panic and its stack", got)
+	}
+}
+
+func TestRESPServer_PresenceChecksAreNotReads(t *testing.T) {
+	c := cache.NewInMemoryCache(context.Background())
+	c.Set("existing", "value", time.Minute)
+	_, address := startRESPServer(t, c)
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+	defer conn.Close()
+	reader, writer := resp.NewReader(conn), resp.NewWriter(conn)
+
+	for _, command := range [][]string{{"EXISTS", "existing", "missing"}, {"DEL", "existing", "missing"}} {
+		writer.WriteCommand(command...)
+		if reply, err := reader.ReadValue(); err != nil || reply.Int != 1 {
+			t.Errorf("%s reply = %+v, %v, want 1", command[0], reply, err)
+		}
+	}
+	if stats := c.(cache.StatsProvider).Stats(); stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("Stats() = %d hits, %d misses, want no reads", stats.Hits, stats.Misses)
+	}
+}
@@ -0,0 +1,165 @@
+package cacheserver
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+const defaultTTL = cache.NoExpiration
+
+var ErrServerClosed = errors.New("cacheserver: server closed")
+
+// connections tracks listeners and open connections so Close can stop a
+// server that is serving on any number of listeners.
+type connections struct {
+	logger    *slog.Logger
+	mu        sync.Mutex
+	closed    bool
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	wg        sync.WaitGroup
+}
+
+func (c *connections) serve(listener net.Listener, handle func(conn net.Conn)) error {
+	if !c.addListener(listener) {
+		listener.Close()
+		return ErrServerClosed
+	}
+	defer c.removeListener(listener)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if c.isClosed() {
+				return ErrServerClosed
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				time.Sleep(time.Millisecond * 5)
+				continue
+			}
+			return err
+		}
+		if !c.addConn(conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+
+		go func() {
+			defer c.removeConn(conn)
+			defer c.recoverPanic(conn)
+			handle(conn)
+		}()
+	}
+}
+
+// recoverPanic logs a panic while serving conn, which then only drops that
+// connection.
+func (c *connections) recoverPanic(conn net.Conn) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	logger := c.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Error("cacheserver: panic serving connection",
+		"remote", conn.RemoteAddr().String(),
+		"panic", recovered,
+		"stack", string(debug.Stack()),
+	)
+}
+
+// has reports whether c holds key, without the side effects of a read when c
+// is a cache.ExistenceChecker.
+func has(c cache.Cache, key string) bool {
+	if checker, ok := c.(cache.ExistenceChecker); ok {
+		return checker.Has(key)
+	}
+	_, found := c.Get(key)
+
+	return found
+}
+
+func (c *connections) close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	for listener := range c.listeners {
+		listener.Close()
+	}
+	for conn := range c.conns {
+		conn.Close()
+	}
+	c.mu.Unlock()
+
+	c.wg.Wait()
+
+	return nil
+}
+
+func (c *connections) addListener(listener net.Listener) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+	if c.listeners == nil {
+		c.listeners = make(map[net.Listener]struct{})
+	}
+	c.listeners[listener] = struct{}{}
+
+	return true
+}
+
+func (c *connections) removeListener(listener net.Listener) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.listeners, listener)
+}
+
+func (c *connections) addConn(conn net.Conn) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+	if c.conns == nil {
+		c.conns = make(map[net.Conn]struct{})
+	}
+	c.conns[conn] = struct{}{}
+	c.wg.Add(1)
+
+	return true
+}
+
+func (c *connections) removeConn(conn net.Conn) {
+	conn.Close()
+
+	c.mu.Lock()
+	delete(c.conns, conn)
+	c.mu.Unlock()
+
+	c.wg.Done()
+}
+
+func (c *connections) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.closed
+}
@@ -0,0 +1,92 @@
+package cachetest
+
+import (
+	"sync"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+// Clock is a cache.Clock that only moves when advanced, for testing
+// expiration and background cleanup without sleeping. Pass it to
+// cache.WithClock. Its tickers fire at most once per Advance, like
+// time.Ticker drops ticks for slow receivers. The zero value is not usable;
+// create one with NewClock.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*ticker
+}
+
+type ticker struct {
+	clock  *Clock
+	c      chan time.Time
+	period time.Duration
+	next   time.Time
+}
+
+var _ cache.Clock = (*Clock)(nil)
+
+// NewClock returns a Clock reading start until it is advanced.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTicker returns a ticker that fires on the first Advance reaching d from
+// now, and every period after that.
+func (c *Clock) NewTicker(d time.Duration) cache.Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &ticker{clock: c, c: make(chan time.Time, 1), period: d, next: c.now.Add(d)}
+	c.tickers = append(c.tickers, t)
+
+	return t
+}
+
+// Advance moves the clock forward by d and fires the tickers that came due.
+// A ticker that came due several times fires once.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		if t.period <= 0 || c.now.Before(t.next) {
+			continue
+		}
+		for !t.next.After(c.now) {
+			t.next = t.next.Add(t.period)
+		}
+		select {
+		case t.c <- c.now:
+		default:
+		}
+	}
+}
+
+func (t *ticker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *ticker) Reset(d time.Duration) {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.period = d
+	t.next = t.clock.now.Add(d)
+}
+
+func (t *ticker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.period = 0
+}
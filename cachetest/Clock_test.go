@@ -0,0 +1,74 @@
+package cachetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+func TestClock_Expiration(t *testing.T) {
+	tests := []struct {
+		name      string
+		advance   time.Duration
+		wantFound bool
+	}{
+		{name: "Before expiration", advance: time.Minute - time.Nanosecond, wantFound: true},
+		{name: "After expiration", advance: time.Minute + time.Nanosecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			clock := NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			c := cache.NewInMemoryCache(ctx, cache.WithClock(clock))
+
+			c.Set("test", 1, time.Minute)
+			clock.Advance(tt.advance)
+			if _, found := c.Get("test"); found != tt.wantFound {
+				t.Errorf("Get() found = %v, want %v", found, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestClock_NewTicker(t *testing.T) {
+	clock := NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ticker := clock.NewTicker(time.Minute)
+
+	ticked := func() bool {
+		select {
+		case <-ticker.C():
+			return true
+		default:
+			return false
+		}
+	}
+	steps := []struct {
+		advance time.Duration
+		want    bool
+	}{
+		{advance: time.Second * 59},
+		{advance: time.Second, want: true},
+		{advance: time.Minute * 3, want: true},
+		{advance: time.Second * 59},
+	}
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		if got := ticked(); got != step.want {
+			t.Errorf("step %d: ticked = %v, want %v", i, got, step.want)
+		}
+	}
+
+	ticker.Reset(time.Second)
+	clock.Advance(time.Second)
+	if !ticked() {
+		t.Errorf("ticker didn't fire after Reset")
+	}
+	ticker.Stop()
+	clock.Advance(time.Hour)
+	if ticked() {
+		t.Errorf("ticker fired after Stop")
+	}
+}
@@ -0,0 +1,178 @@
+package cachetest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+const (
+	conformanceTTL = time.Millisecond * 50
+	conformanceGap = time.Millisecond * 100
+)
+
+// RunConformanceTests checks that the caches returned by newCache behave like
+// the in-memory cache for the methods of cache.Cache. Every subtest gets a new,
+// empty cache, which is closed at the end when it has a Close() error method.
+// Values are strings and ints, so backends that encode values with GobCodec
+// need no registration. The expiration tests sleep for a fraction of a second.
+func RunConformanceTests(t *testing.T, newCache func() cache.Cache) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, c cache.Cache)
+	}{
+		{name: "Get missing key", run: testGetMissing},
+		{name: "Set and Get", run: testSetGet},
+		{name: "Keys are distinct", run: testDistinctKeys},
+		{name: "Overwrite replaces value", run: testOverwriteValue},
+		{name: "Overwrite replaces expiration", run: testOverwriteExpiration},
+		{name: "Delete", run: testDelete},
+		{name: "Delete missing key", run: testDeleteMissing},
+		{name: "Entry expires", run: testExpiry},
+		{name: "Non-positive interval never expires", run: testNoExpiry},
+		{name: "Concurrent access", run: testConcurrency},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCache()
+			if closer, ok := c.(interface{ Close() error }); ok {
+				t.Cleanup(func() {
+					if err := closer.Close(); err != nil {
+						t.Errorf("Close() error = %v", err)
+					}
+				})
+			}
+			tt.run(t, c)
+		})
+	}
+}
+
+func expectHit(t *testing.T, c cache.Cache, key string, want interface{}) {
+	t.Helper()
+
+	if value, found := c.Get(key); !found || value != want {
+		t.Errorf("Get(%q) = %v, %v, want %v, true", key, value, found, want)
+	}
+}
+
+func expectMiss(t *testing.T, c cache.Cache, key string) {
+	t.Helper()
+
+	if value, found := c.Get(key); found {
+		t.Errorf("Get(%q) = %v, true, want a miss", key, value)
+	}
+}
+
+func testGetMissing(t *testing.T, c cache.Cache) {
+	expectMiss(t, c, "missing")
+}
+
+func testSetGet(t *testing.T, c cache.Cache) {
+	c.Set("string", "value", time.Minute)
+	c.Set("int", 42, time.Minute)
+
+	expectHit(t, c, "string", "value")
+	expectHit(t, c, "int", 42)
+}
+
+func testDistinctKeys(t *testing.T, c cache.Cache) {
+	keys := []string{"key", "Key", "key ", "key:1", "key:10"}
+	for i, key := range keys {
+		c.Set(key, i, time.Minute)
+	}
+	for i, key := range keys {
+		expectHit(t, c, key, i)
+	}
+}
+
+func testOverwriteValue(t *testing.T, c cache.Cache) {
+	c.Set("test", "first", time.Minute)
+	c.Set("test", "second", time.Minute)
+
+	expectHit(t, c, "test", "second")
+}
+
+func testOverwriteExpiration(t *testing.T, c cache.Cache) {
+	c.Set("extended", "first", conformanceTTL)
+	c.Set("extended", "second", time.Minute)
+	c.Set("shortened", "first", time.Minute)
+	c.Set("shortened", "second", conformanceTTL)
+
+	time.Sleep(conformanceGap)
+	expectHit(t, c, "extended", "second")
+	expectMiss(t, c, "shortened")
+}
+
+func testDelete(t *testing.T, c cache.Cache) {
+	c.Set("test", "value", time.Minute)
+	c.Set("other", "value", time.Minute)
+	c.Delete("test")
+
+	expectMiss(t, c, "test")
+	expectHit(t, c, "other", "value")
+
+	c.Set("test", "again", time.Minute)
+	expectHit(t, c, "test", "again")
+}
+
+func testDeleteMissing(t *testing.T, c cache.Cache) {
+	c.Delete("missing")
+
+	expectMiss(t, c, "missing")
+}
+
+func testExpiry(t *testing.T, c cache.Cache) {
+	c.Set("test", "value", conformanceTTL)
+	expectHit(t, c, "test", "value")
+
+	time.Sleep(conformanceGap)
+	expectMiss(t, c, "test")
+}
+
+func testNoExpiry(t *testing.T, c cache.Cache) {
+	c.Set("zero", "value", 0)
+	c.Set("negative", "value", cache.NoExpiration)
+
+	time.Sleep(conformanceGap)
+	expectHit(t, c, "zero", "value")
+	expectHit(t, c, "negative", "value")
+}
+
+func testConcurrency(t *testing.T, c cache.Cache) {
+	const (
+		workers    = 8
+		iterations = 200
+	)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			own := fmt.Sprintf("worker:%d", w)
+			for i := 0; i < iterations; i++ {
+				c.Set(own, i, time.Minute)
+				c.Set("shared", w, time.Minute)
+				c.Get("shared")
+				c.Delete(fmt.Sprintf("scratch:%d", i%10))
+				c.Set(fmt.Sprintf("scratch:%d", i%10), i, time.Minute)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for w := 0; w < workers; w++ {
+		expectHit(t, c, fmt.Sprintf("worker:%d", w), iterations-1)
+	}
+	value, found := c.Get("shared")
+	if shared, ok := value.(int); !found || !ok || shared < 0 || shared >= workers {
+		t.Errorf("Get(shared) = %v, %v, want a value written by a worker", value, found)
+	}
+}
@@ -0,0 +1,43 @@
+package cachetest
+
+import (
+	"context"
+	"testing"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+func TestRunConformanceTests(t *testing.T) {
+	tests := []struct {
+		name     string
+		newCache func(ctx context.Context) cache.Cache
+	}{
+		{
+			name: "Default",
+			newCache: func(ctx context.Context) cache.Cache {
+				return cache.NewInMemoryCache(ctx)
+			},
+		},
+		{
+			name: "Sharded storage",
+			newCache: func(ctx context.Context) cache.Cache {
+				return cache.NewInMemoryCache(ctx, cache.WithShardedStorage(4))
+			},
+		},
+		{
+			name: "Encoded values",
+			newCache: func(ctx context.Context) cache.Cache {
+				return cache.NewInMemoryCache(ctx, cache.WithCompression(cache.Snappy, 0))
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			RunConformanceTests(t, func() cache.Cache { return tt.newCache(ctx) })
+		})
+	}
+}
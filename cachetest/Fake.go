@@ -0,0 +1,194 @@
+package cachetest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+// Call records one call made to a Fake. Value and TTL are zero for calls that
+// do not take them.
+type Call struct {
+	Method string
+	Key    string
+	Value  interface{}
+	TTL    time.Duration
+}
+
+type result struct {
+	value interface{}
+	found bool
+}
+
+// Fake is a Cache that records its calls and can be scripted to return given
+// results. Unscripted reads are served from the values written to it, which
+// never expire. The zero value is not usable; create one with NewFake.
+type Fake struct {
+	mu      sync.Mutex
+	values  map[string]interface{}
+	results map[string][]result
+	errs    map[string]error
+	calls   []Call
+}
+
+var (
+	_ cache.Cache       = (*Fake)(nil)
+	_ cache.TrySetter   = (*Fake)(nil)
+	_ cache.GetOrLoader = (*Fake)(nil)
+)
+
+func NewFake() *Fake {
+	return &Fake{
+		values:  make(map[string]interface{}),
+		results: make(map[string][]result),
+		errs:    make(map[string]error),
+	}
+}
+
+// Hit makes the next unanswered read of key find value, whatever is stored.
+// Results scripted for the same key are returned in order.
+func (f *Fake) Hit(key string, value interface{}) *Fake {
+	return f.script(key, result{value: value, found: true})
+}
+
+// Miss makes the next unanswered read of key miss, whatever is stored.
+func (f *Fake) Miss(key string) *Fake {
+	return f.script(key, result{})
+}
+
+func (f *Fake) script(key string, r result) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.results[key] = append(f.results[key], r)
+
+	return f
+}
+
+// Fail makes writes and loads of key fail with err until Fail is called again
+// with a nil error: Set drops the value, TrySet returns err and GetOrLoad
+// returns err without running the loader.
+func (f *Fake) Fail(key string, err error) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		delete(f.errs, key)
+	} else {
+		f.errs[key] = err
+	}
+
+	return f
+}
+
+// Calls returns the calls made so far, oldest first.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo returns the calls made so far to method, such as "Get".
+func (f *Fake) CallsTo(method string) []Call {
+	var calls []Call
+	for _, call := range f.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+func (f *Fake) Get(key string) (interface{}, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, Call{Method: "Get", Key: key})
+
+	return f.lookup(key)
+}
+
+func (f *Fake) Set(key string, value interface{}, expiredInterval time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, Call{Method: "Set", Key: key, Value: value, TTL: expiredInterval})
+	f.store(key, value)
+}
+
+func (f *Fake) TrySet(key string, value interface{}, expiredInterval time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, Call{Method: "TrySet", Key: key, Value: value, TTL: expiredInterval})
+
+	return f.store(key, value)
+}
+
+func (f *Fake) Delete(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, Call{Method: "Delete", Key: key})
+	delete(f.values, key)
+}
+
+// GetOrLoad returns the value a read of key would, or else runs loader and
+// stores its result. Unlike the in-memory cache, concurrent misses each run
+// loader.
+func (f *Fake) GetOrLoad(
+	ctx context.Context,
+	key string,
+	expiredInterval time.Duration,
+	loader func(ctx context.Context) (interface{}, error),
+) (interface{}, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{Method: "GetOrLoad", Key: key, TTL: expiredInterval})
+	if err := f.errs[key]; err != nil {
+		f.mu.Unlock()
+		return nil, err
+	}
+	if value, found := f.lookup(key); found {
+		f.mu.Unlock()
+		return value, nil
+	}
+	f.mu.Unlock()
+
+	value, err := loader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.store(key, value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (f *Fake) lookup(key string) (interface{}, bool) {
+	if scripted := f.results[key]; len(scripted) > 0 {
+		f.results[key] = scripted[1:]
+		return scripted[0].value, scripted[0].found
+	}
+
+	value, found := f.values[key]
+
+	return value, found
+}
+
+func (f *Fake) store(key string, value interface{}) error {
+	if err := f.errs[key]; err != nil {
+		return err
+	}
+	f.values[key] = value
+
+	return nil
+}
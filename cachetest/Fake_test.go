@@ -0,0 +1,122 @@
+package cachetest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFake_Get(t *testing.T) {
+	tests := []struct {
+		name   string
+		script func(f *Fake)
+		want   []interface{}
+		found  []bool
+	}{
+		{
+			name:   "Unscripted reads see stored values",
+			script: func(f *Fake) { f.Set("test", 1, time.Minute) },
+			want:   []interface{}{1, 1},
+			found:  []bool{true, true},
+		},
+		{
+			name:   "Unscripted miss",
+			script: func(f *Fake) {},
+			want:   []interface{}{nil},
+			found:  []bool{false},
+		},
+		{
+			name: "Scripted results in order, then stored values",
+			script: func(f *Fake) {
+				f.Set("test", 1, time.Minute)
+				f.Miss("test").Hit("test", 2)
+			},
+			want:  []interface{}{nil, 2, 1},
+			found: []bool{false, true, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFake()
+			tt.script(f)
+
+			for i := range tt.want {
+				value, found := f.Get("test")
+				if value != tt.want[i] || found != tt.found[i] {
+					t.Errorf("Get() #%d = %v, %v, want %v, %v", i, value, found, tt.want[i], tt.found[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFake_Fail(t *testing.T) {
+	errBackend := errors.New("backend down")
+	f := NewFake().Fail("test", errBackend)
+
+	f.Set("test", 1, time.Minute)
+	if _, found := f.Get("test"); found {
+		t.Errorf("Get() found a value whose Set failed")
+	}
+	if err := f.TrySet("test", 1, time.Minute); !errors.Is(err, errBackend) {
+		t.Errorf("TrySet() error = %v, want %v", err, errBackend)
+	}
+	loaded := false
+	_, err := f.GetOrLoad(context.Background(), "test", time.Minute, func(ctx context.Context) (interface{}, error) {
+		loaded = true
+		return 1, nil
+	})
+	if !errors.Is(err, errBackend) || loaded {
+		t.Errorf("GetOrLoad() error = %v, loaded = %v, want %v without loading", err, loaded, errBackend)
+	}
+	if err := f.TrySet("other", 1, time.Minute); err != nil {
+		t.Errorf("TrySet() of another key error = %v", err)
+	}
+
+	f.Fail("test", nil)
+	if err := f.TrySet("test", 1, time.Minute); err != nil {
+		t.Errorf("TrySet() after clearing the failure error = %v", err)
+	}
+}
+
+func TestFake_GetOrLoad(t *testing.T) {
+	f := NewFake()
+	loads := 0
+	loader := func(ctx context.Context) (interface{}, error) {
+		loads++
+		return loads, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		value, err := f.GetOrLoad(context.Background(), "test", time.Minute, loader)
+		if err != nil || value != 1 {
+			t.Errorf("GetOrLoad() #%d = %v, %v, want 1, nil", i, value, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loader ran %d times, want 1", loads)
+	}
+}
+
+func TestFake_Calls(t *testing.T) {
+	f := NewFake()
+	f.Set("a", 1, time.Minute)
+	f.Get("a")
+	f.Delete("a")
+	f.Get("b")
+
+	want := []Call{
+		{Method: "Set", Key: "a", Value: 1, TTL: time.Minute},
+		{Method: "Get", Key: "a"},
+		{Method: "Delete", Key: "a"},
+		{Method: "Get", Key: "b"},
+	}
+	if got := f.Calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Calls() = %v, want %v", got, want)
+	}
+	if got := f.CallsTo("Get"); !reflect.DeepEqual(got, []Call{want[1], want[3]}) {
+		t.Errorf("CallsTo(Get) = %v, want %v", got, []Call{want[1], want[3]})
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abicur/go-sim-cache/internal/resp"
+)
+
+var errNotFound = errors.New("key not found")
+
+// client is the subset of operations both frontends can serve. Values are
+// exchanged in the frontend's own representation: JSON for HTTP, strings for
+// RESP.
+type client interface {
+	get(key string) (string, error)
+	set(key, value string, ttl time.Duration) error
+	del(key string) error
+	keys(pattern string) ([]string, error)
+	stats() (string, error)
+	close() error
+}
+
+func newClient(address string, timeout time.Duration) (client, error) {
+	if strings.HasPrefix(address, "http://") || strings.HasPrefix(address, "https://") {
+		return &httpClient{
+			baseURL: strings.TrimSuffix(address, "/"),
+			client:  &http.Client{Timeout: timeout},
+		}, nil
+	}
+
+	conn, err := net.DialTimeout("tcp", strings.TrimPrefix(address, "redis://"), timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respClient{conn: conn, reader: resp.NewReader(conn), writer: resp.NewWriter(conn), timeout: timeout}, nil
+}
+
+type httpClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func (c *httpClient) get(key string) (string, error) {
+	body, err := c.do(http.MethodGet, "/keys/"+url.PathEscape(key), nil)
+	return string(body), err
+}
+
+// set sends value as JSON when it parses as JSON and as a JSON string
+// otherwise, so `set name alice` works without quoting.
+func (c *httpClient) set(key, value string, ttl time.Duration) error {
+	body := []byte(value)
+	if !json.Valid(body) {
+		body, _ = json.Marshal(value)
+	}
+
+	_, err := c.do(http.MethodPut, "/keys/"+url.PathEscape(key)+"?ttl="+ttl.String(), body)
+	return err
+}
+
+func (c *httpClient) del(key string) error {
+	_, err := c.do(http.MethodDelete, "/keys/"+url.PathEscape(key), nil)
+	return err
+}
+
+func (c *httpClient) keys(pattern string) ([]string, error) {
+	body, err := c.do(http.MethodGet, "/keys?pattern="+url.QueryEscape(pattern), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	err = json.Unmarshal(body, &keys)
+
+	return keys, err
+}
+
+func (c *httpClient) stats() (string, error) {
+	body, err := c.do(http.MethodGet, "/stats", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err != nil {
+		return "", err
+	}
+
+	return indented.String(), nil
+}
+
+func (c *httpClient) close() error {
+	return nil
+}
+
+func (c *httpClient) do(method, path string, body []byte) ([]byte, error) {
+	request, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case response.StatusCode == http.StatusNotFound && method == http.MethodGet:
+		return nil, errNotFound
+	case response.StatusCode >= 300:
+		return nil, fmt.Errorf("%s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+
+	return data, nil
+}
+
+type respClient struct {
+	conn    net.Conn
+	reader  *resp.Reader
+	writer  *resp.Writer
+	timeout time.Duration
+}
+
+func (c *respClient) get(key string) (string, error) {
+	reply, err := c.do("GET", key)
+	if err != nil {
+		return "", err
+	}
+	if reply.Null {
+		return "", errNotFound
+	}
+
+	return reply.Str, nil
+}
+
+func (c *respClient) set(key, value string, ttl time.Duration) error {
+	_, err := c.do("SET", key, value, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+func (c *respClient) del(key string) error {
+	_, err := c.do("DEL", key)
+	return err
+}
+
+func (c *respClient) keys(pattern string) ([]string, error) {
+	reply, err := c.do("KEYS", pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(reply.Array))
+	for i, key := range reply.Array {
+		keys[i] = key.Str
+	}
+
+	return keys, nil
+}
+
+func (c *respClient) stats() (string, error) {
+	return "", errors.New("stats are only available over HTTP")
+}
+
+func (c *respClient) close() error {
+	return c.conn.Close()
+}
+
+func (c *respClient) do(args ...string) (resp.Value, error) {
+	_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if err := c.writer.WriteCommand(args...); err != nil {
+		return resp.Value{}, err
+	}
+
+	reply, err := c.reader.ReadValue()
+	if err != nil {
+		return resp.Value{}, err
+	}
+
+	return reply, reply.Err()
+}
@@ -0,0 +1,145 @@
+// Command simcache inspects a cache exposed through the HTTP admin handler or
+// the RESP server of the cacheserver package.
+//
+// Usage:
+//
+//	simcache [-addr address] [-ttl duration] command [arguments]
+//
+// The commands are:
+//
+//	get <key>
+//	set <key> <value>
+//	del <key>...
+//	keys [glob]
+//	stats
+//	dump [glob]
+//
+// Addresses starting with http:// or https:// use the HTTP admin API; any
+// other address is dialled as a RESP server.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const defaultAddress = "http://localhost:8080"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("simcache", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	address := flags.String("addr", envOr("SIMCACHE_ADDR", defaultAddress), "cache frontend address")
+	ttl := flags.Duration("ttl", time.Hour, "lifetime of values written by set")
+	timeout := flags.Duration("timeout", time.Second*5, "network timeout")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: simcache [flags] get|set|del|keys|stats|dump [arguments]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	c, err := newClient(*address, *timeout)
+	if err != nil {
+		fmt.Fprintln(stderr, "simcache:", err)
+		return 1
+	}
+	defer c.close()
+
+	if err := execute(c, flags.Args(), *ttl, stdout); err != nil {
+		fmt.Fprintln(stderr, "simcache:", err)
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		return 1
+	}
+
+	return 0
+}
+
+var errUsage = errors.New("invalid arguments")
+
+func execute(c client, args []string, ttl time.Duration, stdout io.Writer) error {
+	command, args := args[0], args[1:]
+	switch command {
+	case "get":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: get <key>", errUsage)
+		}
+		value, err := c.get(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, value)
+	case "set":
+		if len(args) != 2 {
+			return fmt.Errorf("%w: set <key> <value>", errUsage)
+		}
+		return c.set(args[0], args[1], ttl)
+	case "del":
+		if len(args) == 0 {
+			return fmt.Errorf("%w: del <key>...", errUsage)
+		}
+		for _, key := range args {
+			if err := c.del(key); err != nil {
+				return err
+			}
+		}
+	case "keys", "dump":
+		if len(args) > 1 {
+			return fmt.Errorf("%w: %s [glob]", errUsage, command)
+		}
+		pattern := "*"
+		if len(args) == 1 {
+			pattern = args[0]
+		}
+		keys, err := c.keys(pattern)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if command == "keys" {
+				fmt.Fprintln(stdout, key)
+				continue
+			}
+			value, err := c.get(key)
+			if errors.Is(err, errNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "%s\t%s\n", key, value)
+		}
+	case "stats":
+		stats, err := c.stats()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, stats)
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+
+	return nil
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+
+	return fallback
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"github.com/abicur/go-sim-cache/cacheserver"
+)
+
+type mapCache struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+func (c *mapCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, found := c.values[key]
+	return value, found
+}
+
+func (c *mapCache) Set(key string, value interface{}, expiredInterval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] = value
+}
+
+func (c *mapCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.values, key)
+}
+
+func (c *mapCache) Keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (c *mapCache) Stats() cache.Stats {
+	return cache.Stats{Hits: 3}
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{values: map[string]interface{}{"user:1": "alice", "user:2": "bob", "other": "x"}}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantOut  string
+		wantHTTP string
+	}{
+		{
+			name:    "Get",
+			args:    []string{"get", "user:1"},
+			wantOut: "alice\n",
+			// Values are JSON over HTTP.
+			wantHTTP: "\"alice\"\n",
+		},
+		{
+			name:     "Get missing",
+			args:     []string{"get", "missing"},
+			wantCode: 1,
+		},
+		{
+			name:     "Set",
+			args:     []string{"set", "new", "value"},
+			wantCode: 0,
+		},
+		{
+			name:    "Keys",
+			args:    []string{"keys", "user:*"},
+			wantOut: "user:1\nuser:2\n",
+		},
+		{
+			name:     "Dump",
+			args:     []string{"dump", "user:*"},
+			wantOut:  "user:1\talice\nuser:2\tbob\n",
+			wantHTTP: "user:1\t\"alice\"\nuser:2\t\"bob\"\n",
+		},
+		{
+			name:     "Unknown command",
+			args:     []string{"flush"},
+			wantCode: 2,
+		},
+		{
+			name:     "Missing arguments",
+			args:     []string{"get"},
+			wantCode: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" over HTTP", func(t *testing.T) {
+			server := httptest.NewServer(cache.NewHTTPHandler(newMapCache()))
+			defer server.Close()
+
+			want := tt.wantOut
+			if tt.wantHTTP != "" {
+				want = tt.wantHTTP
+			}
+			assertRun(t, append([]string{"-addr", server.URL}, tt.args...), tt.wantCode, want)
+		})
+		t.Run(tt.name+" over RESP", func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Listen() error: %v", err)
+			}
+			server := cacheserver.NewRESPServer(newMapCache())
+			go server.Serve(listener)
+			defer server.Close()
+
+			assertRun(t, append([]string{"-addr", listener.Addr().String()}, tt.args...), tt.wantCode, tt.wantOut)
+		})
+	}
+}
+
+func TestRun_Stats(t *testing.T) {
+	server := httptest.NewServer(cache.NewHTTPHandler(newMapCache()))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-addr", server.URL, "stats"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, stderr %q", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"Hits": 3`) {
+		t.Errorf("stats output = %q, want indented stats JSON", stdout.String())
+	}
+}
+
+func assertRun(t *testing.T, args []string, wantCode int, wantOut string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	if code := run(args, &stdout, &stderr); code != wantCode {
+		t.Fatalf("run(%v) = %d, want %d, stderr %q", args, code, wantCode, stderr.String())
+	}
+	if wantCode == 0 && stdout.String() != wantOut {
+		t.Errorf("run(%v) output = %q, want %q", args, stdout.String(), wantOut)
+	}
+}
@@ -0,0 +1,412 @@
+package dnscache
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	defaultNegativeTTL = time.Second * 30
+	defaultTimeout     = time.Second * 5
+	// maxUDPSize is the EDNS0 payload size advertised to servers, the size
+	// recommended to avoid IP fragmentation.
+	maxUDPSize = 1232
+)
+
+var errTruncated = errors.New("dnscache: truncated response")
+
+// Resolver looks up host addresses. *net.Resolver implements it, so the
+// caching resolver can stand in for it.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+}
+
+// answer is the cached result of a lookup. NotFound marks an NXDOMAIN; an
+// answer without IPs and NotFound is a name without records of the type.
+type answer struct {
+	IPs      []net.IP
+	NotFound bool
+}
+
+func init() {
+	gob.Register(answer{})
+}
+
+type resolver struct {
+	cache       cache.Cache
+	servers     []string
+	dial        func(ctx context.Context, network, address string) (net.Conn, error)
+	timeout     time.Duration
+	negativeTTL time.Duration
+	maxTTL      time.Duration
+}
+
+// New returns a Resolver that queries DNS servers directly and caches every
+// A and AAAA answer in c for the smallest TTL of its records. NXDOMAIN and
+// empty answers are cached for the negative TTL of the zone's SOA record, or
+// for 30 seconds without one. Hosts are looked up as fully qualified names;
+// search domains are not applied. The servers are those of /etc/resolv.conf
+// unless WithServers sets them.
+func New(c cache.Cache, options ...func(*resolver)) Resolver {
+	dialer := &net.Dialer{}
+	r := &resolver{
+		cache:       c,
+		dial:        dialer.DialContext,
+		timeout:     defaultTimeout,
+		negativeTTL: defaultNegativeTTL,
+	}
+	for _, optionFn := range options {
+		optionFn(r)
+	}
+	if len(r.servers) == 0 {
+		r.servers = systemServers()
+	}
+
+	return r
+}
+
+// WithServers sets the addresses, as host:port, of the servers queried in
+// order until one answers.
+func WithServers(addresses ...string) func(*resolver) {
+	return func(r *resolver) {
+		r.servers = addresses
+	}
+}
+
+// WithDialer sets how connections to servers are made.
+func WithDialer(dial func(ctx context.Context, network, address string) (net.Conn, error)) func(*resolver) {
+	return func(r *resolver) {
+		r.dial = dial
+	}
+}
+
+// WithTimeout sets how long each server has to answer, 5 seconds by default.
+func WithTimeout(timeout time.Duration) func(*resolver) {
+	return func(r *resolver) {
+		r.timeout = timeout
+	}
+}
+
+// WithNegativeTTL sets how long NXDOMAIN and empty answers without an SOA
+// record are cached.
+func WithNegativeTTL(ttl time.Duration) func(*resolver) {
+	return func(r *resolver) {
+		r.negativeTTL = ttl
+	}
+}
+
+// WithMaxTTL caps how long answers are cached, whatever the TTL of their
+// records.
+func WithMaxTTL(ttl time.Duration) func(*resolver) {
+	return func(r *resolver) {
+		r.maxTTL = ttl
+	}
+}
+
+func (r *resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	ips, err := r.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, len(ips))
+	for i, ip := range ips {
+		addrs[i] = ip.String()
+	}
+
+	return addrs, nil
+}
+
+func (r *resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, err := r.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]net.IPAddr, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.IPAddr{IP: ip}
+	}
+
+	return addrs, nil
+}
+
+func (r *resolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	var types []dnsmessage.Type
+	switch network {
+	case "ip":
+		types = []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA}
+	case "ip4":
+		types = []dnsmessage.Type{dnsmessage.TypeA}
+	case "ip6":
+		types = []dnsmessage.Type{dnsmessage.TypeAAAA}
+	default:
+		return nil, net.UnknownNetworkError(network)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if (network == "ip4" && ip.To4() == nil) || (network == "ip6" && ip.To4() != nil) {
+			return nil, &net.DNSError{Err: "no suitable address", Name: host, IsNotFound: true}
+		}
+		return []net.IP{ip}, nil
+	}
+
+	type result struct {
+		answer answer
+		err    error
+	}
+	results := make([]chan result, len(types))
+	for i, qtype := range types {
+		results[i] = make(chan result, 1)
+		go func(qtype dnsmessage.Type, results chan<- result) {
+			a, err := r.lookup(ctx, host, qtype)
+			results <- result{a, err}
+		}(qtype, results[i])
+	}
+
+	var ips []net.IP
+	for _, results := range results {
+		result := <-results
+		if result.err != nil {
+			return nil, result.err
+		}
+		ips = append(ips, result.answer.IPs...)
+	}
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	return ips, nil
+}
+
+// lookup returns the cached answer for host and qtype, querying the servers
+// on a miss.
+func (r *resolver) lookup(ctx context.Context, host string, qtype dnsmessage.Type) (answer, error) {
+	name := host
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	key := "dns:" + qtype.String() + ":" + strings.ToLower(name)
+	if value, found := r.cache.Get(key); found {
+		if a, ok := value.(answer); ok {
+			return a, nil
+		}
+	}
+
+	var lastErr error
+	for _, server := range r.servers {
+		a, ttl, err := r.query(ctx, server, name, qtype)
+		if err != nil {
+			lastErr = &net.DNSError{
+				Err:         err.Error(),
+				Name:        host,
+				Server:      server,
+				IsTimeout:   errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, context.DeadlineExceeded),
+				IsTemporary: true,
+			}
+			continue
+		}
+		if r.maxTTL > 0 && ttl > r.maxTTL {
+			ttl = r.maxTTL
+		}
+		// A TTL of 0 forbids caching; the cache would keep it forever.
+		if ttl > 0 {
+			r.cache.Set(key, a, ttl)
+		}
+		return a, nil
+	}
+	if lastErr == nil {
+		lastErr = &net.DNSError{Err: "no DNS servers", Name: host}
+	}
+
+	return answer{}, lastErr
+}
+
+// query asks server about name over UDP, and again over TCP if the answer
+// does not fit in a datagram.
+func (r *resolver) query(ctx context.Context, server, name string, qtype dnsmessage.Type) (answer, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	a, ttl, err := r.exchange(ctx, "udp", server, name, qtype)
+	if errors.Is(err, errTruncated) {
+		return r.exchange(ctx, "tcp", server, name, qtype)
+	}
+
+	return a, ttl, err
+}
+
+func (r *resolver) exchange(ctx context.Context, network, server, name string, qtype dnsmessage.Type) (answer, time.Duration, error) {
+	var idBytes [2]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return answer{}, 0, err
+	}
+	id := binary.BigEndian.Uint16(idBytes[:])
+	msg, err := newQuery(id, name, qtype)
+	if err != nil {
+		return answer{}, 0, err
+	}
+
+	conn, err := r.dial(ctx, network, server)
+	if err != nil {
+		return answer{}, 0, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if network == "tcp" {
+		framed := binary.BigEndian.AppendUint16(make([]byte, 0, len(msg)+2), uint16(len(msg)))
+		if _, err := conn.Write(append(framed, msg...)); err != nil {
+			return answer{}, 0, err
+		}
+		reader := bufio.NewReader(conn)
+		var length uint16
+		if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+			return answer{}, 0, err
+		}
+		resp := make([]byte, length)
+		if _, err := io.ReadFull(reader, resp); err != nil {
+			return answer{}, 0, err
+		}
+		return r.parse(resp, id, qtype)
+	}
+
+	if _, err := conn.Write(msg); err != nil {
+		return answer{}, 0, err
+	}
+	resp := make([]byte, maxUDPSize)
+	for {
+		n, err := conn.Read(resp)
+		if err != nil {
+			return answer{}, 0, err
+		}
+		// Datagrams with another ID are stray or spoofed; wait for ours.
+		if n >= 2 && binary.BigEndian.Uint16(resp) == id {
+			return r.parse(resp[:n], id, qtype)
+		}
+	}
+}
+
+// parse returns the answer in resp and how long it may be cached.
+func (r *resolver) parse(resp []byte, id uint16, qtype dnsmessage.Type) (answer, time.Duration, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(resp)
+	if err != nil {
+		return answer{}, 0, err
+	}
+	if header.ID != id || !header.Response {
+		return answer{}, 0, errors.New("dnscache: response does not match query")
+	}
+	if header.Truncated {
+		return answer{}, 0, errTruncated
+	}
+	if header.RCode != dnsmessage.RCodeSuccess && header.RCode != dnsmessage.RCodeNameError {
+		return answer{}, 0, fmt.Errorf("dnscache: server answered %v", header.RCode)
+	}
+	if err := parser.SkipAllQuestions(); err != nil {
+		return answer{}, 0, err
+	}
+	resources, err := parser.AllAnswers()
+	if err != nil {
+		return answer{}, 0, err
+	}
+
+	a := answer{NotFound: header.RCode == dnsmessage.RCodeNameError}
+	var minTTL uint32
+	for i, resource := range resources {
+		if i == 0 || resource.Header.TTL < minTTL {
+			minTTL = resource.Header.TTL
+		}
+		switch body := resource.Body.(type) {
+		case *dnsmessage.AResource:
+			if qtype == dnsmessage.TypeA {
+				a.IPs = append(a.IPs, net.IP(body.A[:]))
+			}
+		case *dnsmessage.AAAAResource:
+			if qtype == dnsmessage.TypeAAAA {
+				a.IPs = append(a.IPs, net.IP(body.AAAA[:]))
+			}
+		}
+	}
+	if len(a.IPs) > 0 && !a.NotFound {
+		return a, time.Duration(minTTL) * time.Second, nil
+	}
+
+	// Negative answers are cached for the negative TTL of the zone, the
+	// smaller of the TTL and the minimum of its SOA record.
+	a.IPs = nil
+	authorities, err := parser.AllAuthorities()
+	if err != nil {
+		return answer{}, 0, err
+	}
+	for _, resource := range authorities {
+		if soa, ok := resource.Body.(*dnsmessage.SOAResource); ok {
+			return a, time.Duration(min(resource.Header.TTL, soa.MinTTL)) * time.Second, nil
+		}
+	}
+
+	return a, r.negativeTTL, nil
+}
+
+func newQuery(id uint16, name string, qtype dnsmessage.Type) ([]byte, error) {
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, err
+	}
+	builder := dnsmessage.NewBuilder(make([]byte, 0, maxUDPSize), dnsmessage.Header{ID: id, RecursionDesired: true})
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(dnsmessage.Question{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, err
+	}
+	if err := builder.StartAdditionals(); err != nil {
+		return nil, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(maxUDPSize, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, err
+	}
+	if err := builder.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, err
+	}
+
+	return builder.Finish()
+}
+
+// systemServers returns the name servers of /etc/resolv.conf, or the local
+// server if there are none.
+func systemServers() []string {
+	var servers []string
+	if file, err := os.Open("/etc/resolv.conf"); err == nil {
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) >= 2 && fields[0] == "nameserver" {
+				servers = append(servers, net.JoinHostPort(fields[1], "53"))
+			}
+		}
+	}
+	if len(servers) == 0 {
+		servers = []string{"127.0.0.1:53"}
+	}
+
+	return servers
+}
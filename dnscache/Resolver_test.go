@@ -0,0 +1,297 @@
+package dnscache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// record is an address of a name served by testServer.
+type record struct {
+	ip  net.IP
+	ttl uint32
+}
+
+// testServer answers A and AAAA queries from records over UDP and TCP on one
+// port, with NXDOMAIN and an SOA record for unknown names. Answers of names in
+// truncate are truncated over UDP.
+type testServer struct {
+	addr     string
+	records  map[string][]record
+	truncate map[string]bool
+	mu       sync.Mutex
+	queries  map[string]int
+}
+
+func newTestServer(t *testing.T, records map[string][]record, truncate ...string) *testServer {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error: %v", err)
+	}
+	listener, err := net.Listen("tcp", conn.LocalAddr().String())
+	if err != nil {
+		conn.Close()
+		t.Skipf("TCP port of the test server not available: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		listener.Close()
+	})
+
+	s := &testServer{
+		addr:     conn.LocalAddr().String(),
+		records:  records,
+		truncate: make(map[string]bool),
+		queries:  make(map[string]int),
+	}
+	for _, name := range truncate {
+		s.truncate[name] = true
+	}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := s.answer(buf[:n], true); resp != nil {
+				conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			var length uint16
+			if binary.Read(c, binary.BigEndian, &length) == nil {
+				msg := make([]byte, length)
+				if _, err := io.ReadFull(c, msg); err == nil {
+					resp := s.answer(msg, false)
+					c.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(resp))), resp...))
+				}
+			}
+			c.Close()
+		}
+	}()
+
+	return s
+}
+
+func (s *testServer) Queries(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.queries[name]
+}
+
+func (s *testServer) answer(msg []byte, udp bool) []byte {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(msg)
+	if err != nil {
+		return nil
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil
+	}
+	name := question.Name.String()
+	s.mu.Lock()
+	s.queries[name]++
+	s.mu.Unlock()
+
+	records, found := s.records[name]
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: header.ID, Response: true, RCode: dnsmessage.RCodeSuccess},
+		Questions: []dnsmessage.Question{question},
+	}
+	switch {
+	case !found:
+		resp.Header.RCode = dnsmessage.RCodeNameError
+		resp.Authorities = []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("test."), Type: dnsmessage.TypeSOA, Class: dnsmessage.ClassINET, TTL: 60},
+			Body: &dnsmessage.SOAResource{
+				NS:     dnsmessage.MustNewName("ns.test."),
+				MBox:   dnsmessage.MustNewName("admin.test."),
+				MinTTL: 1,
+			},
+		}}
+	case udp && s.truncate[name]:
+		resp.Header.Truncated = true
+	default:
+		for _, record := range records {
+			header := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: record.ttl}
+			if ip4 := record.ip.To4(); ip4 != nil && question.Type == dnsmessage.TypeA {
+				header.Type = dnsmessage.TypeA
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.AResource{A: [4]byte(ip4)}})
+			} else if ip4 == nil && question.Type == dnsmessage.TypeAAAA {
+				header.Type = dnsmessage.TypeAAAA
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.AAAAResource{AAAA: [16]byte(record.ip.To16())}})
+			}
+		}
+	}
+	packed, err := resp.Pack()
+	if err != nil {
+		return nil
+	}
+
+	return packed
+}
+
+func newTestResolver(t *testing.T, s *testServer, options ...func(*resolver)) Resolver {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return New(cache.NewInMemoryCache(ctx), append([]func(*resolver){WithServers(s.addr)}, options...)...)
+}
+
+func TestResolver_LookupIP(t *testing.T) {
+	s := newTestServer(t, map[string][]record{
+		"dual.test.": {{ip: net.ParseIP("192.0.2.1"), ttl: 300}, {ip: net.ParseIP("2001:db8::1"), ttl: 300}},
+		"v4.test.":   {{ip: net.ParseIP("192.0.2.2"), ttl: 300}},
+	})
+	r := newTestResolver(t, s)
+
+	tests := []struct {
+		name         string
+		network      string
+		host         string
+		want         []string
+		wantNotFound bool
+	}{
+		{
+			name:    "Both families",
+			network: "ip",
+			host:    "dual.test",
+			want:    []string{"192.0.2.1", "2001:db8::1"},
+		},
+		{
+			name:    "IPv6 only",
+			network: "ip6",
+			host:    "dual.test",
+			want:    []string{"2001:db8::1"},
+		},
+		{
+			name:         "No records of the family",
+			network:      "ip6",
+			host:         "v4.test",
+			wantNotFound: true,
+		},
+		{
+			name:         "NXDOMAIN",
+			network:      "ip",
+			host:         "missing.test",
+			wantNotFound: true,
+		},
+		{
+			name:    "IP literal",
+			network: "ip",
+			host:    "198.51.100.7",
+			want:    []string{"198.51.100.7"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ips, err := r.LookupIP(context.Background(), tt.network, tt.host)
+			var dnsErr *net.DNSError
+			if tt.wantNotFound {
+				if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+					t.Fatalf("LookupIP() error = %v, want a not found DNSError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LookupIP() error: %v", err)
+			}
+			if len(ips) != len(tt.want) {
+				t.Fatalf("LookupIP() = %v, want %v", ips, tt.want)
+			}
+			for i, ip := range ips {
+				if ip.String() != tt.want[i] {
+					t.Errorf("LookupIP()[%d] = %v, want %v", i, ip, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResolver_Caching(t *testing.T) {
+	s := newTestServer(t, map[string][]record{
+		"short.test.": {{ip: net.ParseIP("192.0.2.1"), ttl: 1}},
+		"long.test.":  {{ip: net.ParseIP("192.0.2.2"), ttl: 300}, {ip: net.ParseIP("192.0.2.3"), ttl: 1}},
+		"zero.test.":  {{ip: net.ParseIP("192.0.2.4"), ttl: 0}},
+	})
+	r := newTestResolver(t, s)
+
+	for _, host := range []string{"short.test", "long.test", "zero.test", "missing.test"} {
+		for i := 0; i < 3; i++ {
+			r.LookupIP(context.Background(), "ip4", host)
+		}
+	}
+	if got := s.Queries("short.test."); got != 1 {
+		t.Errorf("short.test queried %d times, want 1", got)
+	}
+	if got := s.Queries("zero.test."); got != 3 {
+		t.Errorf("zero.test with TTL 0 queried %d times, want 3", got)
+	}
+	if got := s.Queries("missing.test."); got != 1 {
+		t.Errorf("missing.test queried %d times, want 1", got)
+	}
+
+	// Answers expire with their smallest record TTL, NXDOMAIN with the SOA
+	// minimum.
+	time.Sleep(time.Second + time.Millisecond*100)
+	for _, host := range []string{"short.test", "long.test", "missing.test"} {
+		r.LookupIP(context.Background(), "ip4", host)
+		if got := s.Queries(host + "."); got != 2 {
+			t.Errorf("%s queried %d times after its TTL, want 2", host, got)
+		}
+	}
+}
+
+func TestResolver_Truncated(t *testing.T) {
+	s := newTestServer(t, map[string][]record{
+		"big.test.": {{ip: net.ParseIP("192.0.2.1"), ttl: 300}},
+	}, "big.test.")
+	r := newTestResolver(t, s)
+
+	addrs, err := r.LookupHost(context.Background(), "big.test")
+	if err != nil || len(addrs) != 1 || addrs[0] != "192.0.2.1" {
+		t.Errorf("LookupHost() = %v, %v, want [192.0.2.1]", addrs, err)
+	}
+}
+
+func TestResolver_Unreachable(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := New(cache.NewInMemoryCache(ctx), WithServers(conn.LocalAddr().String()), WithTimeout(time.Millisecond*50))
+
+	_, err = r.LookupIPAddr(context.Background(), "example.test")
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsTimeout {
+		t.Errorf("LookupIPAddr() error = %v, want a timeout DNSError", err)
+	}
+}
+
+func TestResolver_ImplementedByNetResolver(t *testing.T) {
+	var _ Resolver = net.DefaultResolver
+}
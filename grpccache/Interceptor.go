@@ -0,0 +1,115 @@
+package grpccache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+type interceptor struct {
+	cache   cache.Cache
+	methods map[string]time.Duration
+	onError func(method string, err error)
+}
+
+// UnaryClientInterceptor returns an interceptor that serves the unary RPCs
+// safelisted with WithMethod from c. Replies are cached under the method and
+// a hash of the deterministically marshalled request; failed calls are not
+// cached. Calls served from the cache do not reach the server, so call
+// options such as grpc.Header receive nothing. Requests and replies must be
+// protocol buffer messages; other calls are passed on uncached.
+func UnaryClientInterceptor(c cache.Cache, options ...func(*interceptor)) grpc.UnaryClientInterceptor {
+	i := &interceptor{cache: c, methods: make(map[string]time.Duration), onError: func(string, error) {}}
+	for _, optionFn := range options {
+		optionFn(i)
+	}
+
+	return i.intercept
+}
+
+// WithMethod caches replies of method for ttl. method is a full method name
+// such as "/pkg.Service/Method", or a service name ending in "/" such as
+// "/pkg.Service/" for all of its methods; a full method name takes precedence
+// over its service. Only idempotent methods should be safelisted.
+func WithMethod(method string, ttl time.Duration) func(*interceptor) {
+	return func(i *interceptor) {
+		i.methods[method] = ttl
+	}
+}
+
+// WithErrorHandler receives the errors marshalling requests and replies.
+// Such calls are passed on uncached.
+func WithErrorHandler(handler func(method string, err error)) func(*interceptor) {
+	return func(i *interceptor) {
+		i.onError = handler
+	}
+}
+
+func (i *interceptor) intercept(
+	ctx context.Context,
+	method string,
+	req, reply interface{},
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	ttl, found := i.ttl(method)
+	requestMessage, isRequestMessage := req.(proto.Message)
+	replyMessage, isReplyMessage := reply.(proto.Message)
+	if !found || !isRequestMessage || !isReplyMessage {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	key, err := requestKey(method, requestMessage)
+	if err != nil {
+		i.onError(method, err)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	if value, found := i.cache.Get(key); found {
+		if data, ok := value.([]byte); ok {
+			if err := proto.Unmarshal(data, replyMessage); err == nil {
+				return nil
+			}
+		}
+	}
+
+	if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+		return err
+	}
+	data, err := proto.Marshal(replyMessage)
+	if err != nil {
+		i.onError(method, err)
+		return nil
+	}
+	i.cache.Set(key, data, ttl)
+
+	return nil
+}
+
+func (i *interceptor) ttl(method string) (time.Duration, bool) {
+	if ttl, found := i.methods[method]; found {
+		return ttl, true
+	}
+	if slash := strings.LastIndex(method, "/"); slash > 0 {
+		ttl, found := i.methods[method[:slash+1]]
+		return ttl, found
+	}
+
+	return 0, false
+}
+
+func requestKey(method string, req proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+
+	return "grpc:" + method + ":" + hex.EncodeToString(sum[:]), nil
+}
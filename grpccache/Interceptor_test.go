@@ -0,0 +1,100 @@
+package grpccache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+	tests := []struct {
+		name         string
+		options      []func(*interceptor)
+		method       string
+		requests     []string
+		invokeErr    error
+		wantReplies  []string
+		wantInvokes  int
+		wantErrCount int
+	}{
+		{
+			name:        "Safelisted method",
+			options:     []func(*interceptor){WithMethod("/lookup.Countries/Get", time.Minute)},
+			method:      "/lookup.Countries/Get",
+			requests:    []string{"fr", "fr", "de"},
+			wantReplies: []string{"reply 1 to fr", "reply 1 to fr", "reply 2 to de"},
+			wantInvokes: 2,
+		},
+		{
+			name:        "Safelisted service",
+			options:     []func(*interceptor){WithMethod("/lookup.Countries/", time.Minute)},
+			method:      "/lookup.Countries/Get",
+			requests:    []string{"fr", "fr"},
+			wantReplies: []string{"reply 1 to fr", "reply 1 to fr"},
+			wantInvokes: 1,
+		},
+		{
+			name:        "Method not safelisted",
+			options:     []func(*interceptor){WithMethod("/lookup.Countries/Get", time.Minute)},
+			method:      "/lookup.Countries/Update",
+			requests:    []string{"fr", "fr"},
+			wantReplies: []string{"reply 1 to fr", "reply 2 to fr"},
+			wantInvokes: 2,
+		},
+		{
+			name:         "Errors are not cached",
+			options:      []func(*interceptor){WithMethod("/lookup.Countries/Get", time.Minute)},
+			method:       "/lookup.Countries/Get",
+			requests:     []string{"fr", "fr"},
+			invokeErr:    errUnavailable,
+			wantReplies:  []string{"", ""},
+			wantInvokes:  2,
+			wantErrCount: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			invokes := 0
+			invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				invokes++
+				if tt.invokeErr != nil {
+					return tt.invokeErr
+				}
+				reply.(*wrapperspb.StringValue).Value = fmt.Sprintf("reply %d to %s", invokes, req.(*wrapperspb.StringValue).Value)
+				return nil
+			}
+			intercept := UnaryClientInterceptor(cache.NewInMemoryCache(ctx), tt.options...)
+
+			errCount := 0
+			for i, request := range tt.requests {
+				reply := &wrapperspb.StringValue{}
+				err := intercept(ctx, tt.method, wrapperspb.String(request), reply, nil, invoker)
+				if err != nil {
+					errCount++
+					if !errors.Is(err, tt.invokeErr) {
+						t.Errorf("call #%d error = %v, want %v", i, err, tt.invokeErr)
+					}
+				}
+				if reply.Value != tt.wantReplies[i] {
+					t.Errorf("call #%d reply = %q, want %q", i, reply.Value, tt.wantReplies[i])
+				}
+			}
+			if invokes != tt.wantInvokes {
+				t.Errorf("invoker ran %d times, want %d", invokes, tt.wantInvokes)
+			}
+			if errCount != tt.wantErrCount {
+				t.Errorf("%d calls failed, want %d", errCount, tt.wantErrCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package httpcache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	directives := cacheControl{}
+	for _, line := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(line, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name == "" {
+				continue
+			}
+			directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+
+	return directives
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, found := cc[directive]
+	return found
+}
+
+// seconds returns the value of a delta-seconds directive such as max-age.
+func (cc cacheControl) seconds(directive string) (time.Duration, bool) {
+	value, found := cc[directive]
+	if !found {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
+
+// cacheableStatus lists the status codes that may be cached when the response
+// gives an explicit lifetime.
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// freshness returns how long a response stays fresh according to its
+// Cache-Control max-age or its Expires and Date headers, less its Age. maxAge
+// is the directive that takes precedence over max-age, such as s-maxage for
+// shared caches, or "" for none. It reports false when the response gives no
+// lifetime.
+func freshness(header http.Header, maxAge string, now time.Time) (time.Duration, bool) {
+	cc := parseCacheControl(header)
+
+	lifetime, found := cc.seconds(maxAge)
+	if !found {
+		lifetime, found = cc.seconds("max-age")
+	}
+	if !found {
+		expires, err := http.ParseTime(header.Get("Expires"))
+		if err != nil {
+			// An invalid Expires, such as "0", means already expired.
+			return 0, header.Get("Expires") != ""
+		}
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = now
+		}
+		lifetime, found = expires.Sub(date), true
+	}
+
+	if age, err := strconv.ParseInt(header.Get("Age"), 10, 64); err == nil && age > 0 {
+		lifetime -= time.Duration(age) * time.Second
+	}
+	if lifetime < 0 {
+		lifetime = 0
+	}
+
+	return lifetime, found
+}
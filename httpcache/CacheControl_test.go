@@ -0,0 +1,88 @@
+package httpcache
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test_freshness(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		header    http.Header
+		maxAge    string
+		want      time.Duration
+		wantFound bool
+	}{
+		{
+			name:   "No lifetime",
+			header: http.Header{},
+		},
+		{
+			name:      "max-age",
+			header:    http.Header{"Cache-Control": {"public, max-age=60"}},
+			want:      time.Minute,
+			wantFound: true,
+		},
+		{
+			name:      "max-age less Age",
+			header:    http.Header{"Cache-Control": {"max-age=60"}, "Age": {"20"}},
+			want:      time.Second * 40,
+			wantFound: true,
+		},
+		{
+			name:      "Age past max-age",
+			header:    http.Header{"Cache-Control": {"max-age=60"}, "Age": {"90"}},
+			wantFound: true,
+		},
+		{
+			name: "max-age takes precedence over Expires",
+			header: http.Header{
+				"Cache-Control": {"max-age=60"},
+				"Expires":       {now.Add(time.Hour).Format(http.TimeFormat)},
+			},
+			want:      time.Minute,
+			wantFound: true,
+		},
+		{
+			name:      "Directive given precedence",
+			header:    http.Header{"Cache-Control": {"max-age=60, s-maxage=10"}},
+			maxAge:    "s-maxage",
+			want:      time.Second * 10,
+			wantFound: true,
+		},
+		{
+			name: "Expires relative to Date",
+			header: http.Header{
+				"Date":    {now.Add(-time.Hour).Format(http.TimeFormat)},
+				"Expires": {now.Format(http.TimeFormat)},
+			},
+			want:      time.Hour,
+			wantFound: true,
+		},
+		{
+			name:      "Expires relative to now",
+			header:    http.Header{"Expires": {now.Add(time.Minute).Format(http.TimeFormat)}},
+			want:      time.Minute,
+			wantFound: true,
+		},
+		{
+			name:      "Invalid Expires",
+			header:    http.Header{"Expires": {"0"}},
+			wantFound: true,
+		},
+		{
+			name:   "Invalid max-age",
+			header: http.Header{"Cache-Control": {"max-age=soon"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := freshness(tt.header, tt.maxAge, now)
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("freshness() = %v, %v, want %v, %v", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
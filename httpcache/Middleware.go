@@ -0,0 +1,301 @@
+package httpcache
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+type middleware struct {
+	cache       cache.Cache
+	varyHeaders []string
+	routes      []route
+	defaultTTL  time.Duration
+	bypass      []func(r *http.Request) bool
+	maxBodySize int
+}
+
+type route struct {
+	prefix string
+	ttl    time.Duration
+}
+
+// Middleware returns a handler wrapper that serves GET requests from c, as a
+// shared cache would. Responses are cached under their method, host and URL,
+// plus the request values of the headers given to WithVaryHeaders and of
+// those named by the response's Vary header, for the TTL of the longest
+// WithRouteTTL prefix of their path, or else their Cache-Control s-maxage or
+// max-age, or else the WithDefaultTTL TTL. Responses with a status that is not
+// cacheable, Vary: *, a Set-Cookie header or Cache-Control no-store, no-cache
+// or private are not cached. Requests with an
+// Authorization header bypass the cache, as do those matching WithBypass.
+// Conditional requests whose If-None-Match or If-Modified-Since matches the
+// cached response's ETag or Last-Modified are answered 304 Not Modified.
+func Middleware(c cache.Cache, options ...func(*middleware)) func(http.Handler) http.Handler {
+	m := &middleware{cache: c}
+	for _, optionFn := range options {
+		optionFn(m)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.serveHTTP(w, r, next)
+		})
+	}
+}
+
+// WithVaryHeaders caches a response per value of the named request headers.
+func WithVaryHeaders(names ...string) func(*middleware) {
+	return func(m *middleware) {
+		for _, name := range names {
+			m.varyHeaders = append(m.varyHeaders, http.CanonicalHeaderKey(name))
+		}
+	}
+}
+
+// WithRouteTTL caches responses to requests whose path starts with prefix for
+// ttl, whatever lifetime their headers give. A non-positive ttl leaves them
+// uncached. The longest matching prefix wins.
+func WithRouteTTL(prefix string, ttl time.Duration) func(*middleware) {
+	return func(m *middleware) {
+		m.routes = append(m.routes, route{prefix: prefix, ttl: ttl})
+	}
+}
+
+// WithDefaultTTL caches responses that match no route and give no lifetime
+// for ttl. Without it, such responses are not cached.
+func WithDefaultTTL(ttl time.Duration) func(*middleware) {
+	return func(m *middleware) {
+		m.defaultTTL = ttl
+	}
+}
+
+// WithBypass passes requests for which bypass returns true straight to the
+// handler. The option may be given several times.
+func WithBypass(bypass func(r *http.Request) bool) func(*middleware) {
+	return func(m *middleware) {
+		m.bypass = append(m.bypass, bypass)
+	}
+}
+
+// WithMaxResponseSize leaves responses with bodies over maxBytes uncached.
+func WithMaxResponseSize(maxBytes int) func(*middleware) {
+	return func(m *middleware) {
+		m.maxBodySize = maxBytes
+	}
+}
+
+func (m *middleware) serveHTTP(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	if !m.cacheable(r) {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	key := m.requestKey(r)
+	if cached, found := m.lookup(key, r); found {
+		cached.write(w, r)
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK, maxBodySize: m.maxBodySize}
+	next.ServeHTTP(recorder, r)
+
+	ttl, cacheable := m.lifetime(r, recorder)
+	if !cacheable {
+		return
+	}
+	e := entry{
+		StatusCode: recorder.statusCode,
+		Header:     recorder.Header().Clone(),
+		Body:       recorder.body.Bytes(),
+	}
+	names := varyNames(e.Header)
+	if len(names) == 0 {
+		m.cache.Set(key, e, ttl)
+		return
+	}
+
+	// The response varies on request headers: the names are stored under the
+	// request key, and the response under the key extended with the values
+	// the request has for them.
+	e.Vary = http.Header{}
+	for _, name := range names {
+		e.Vary[name] = r.Header.Values(name)
+	}
+	m.cache.Set(key, variants(names), ttl)
+	m.cache.Set(variantKey(key, names, r), e, ttl)
+}
+
+// variants is stored under the request key of a response with a Vary header
+// and lists the request headers it names.
+type variants []string
+
+// lookup returns the entry cached for r, following variants to the entry for
+// the values r has for the headers they name.
+func (m *middleware) lookup(key string, r *http.Request) (entry, bool) {
+	value, found := m.cache.Get(key)
+	if !found {
+		return entry{}, false
+	}
+	if names, ok := value.(variants); ok {
+		if value, found = m.cache.Get(variantKey(key, names, r)); !found {
+			return entry{}, false
+		}
+	}
+	cached, ok := value.(entry)
+	if !ok || !cached.matches(r) {
+		return entry{}, false
+	}
+
+	return cached, true
+}
+
+func (m *middleware) cacheable(r *http.Request) bool {
+	if r.Method != http.MethodGet || r.Header.Get("Authorization") != "" || r.Header.Get("Range") != "" {
+		return false
+	}
+	for _, bypass := range m.bypass {
+		if bypass(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *middleware) requestKey(r *http.Request) string {
+	return variantKey(r.Method+" "+r.Host+r.URL.RequestURI(), m.varyHeaders, r)
+}
+
+// variantKey extends key with the values r has for the named headers.
+func variantKey(key string, names []string, r *http.Request) string {
+	var extended strings.Builder
+	extended.WriteString(key)
+	for _, name := range names {
+		extended.WriteString("\n" + name + ": " + strings.Join(r.Header.Values(name), ","))
+	}
+
+	return extended.String()
+}
+
+// lifetime returns how long the recorded response may be cached, and false if
+// it may not be.
+func (m *middleware) lifetime(r *http.Request, recorder *responseRecorder) (time.Duration, bool) {
+	header := recorder.Header()
+	if recorder.overflowed || !cacheableStatus[recorder.statusCode] || header.Get("Set-Cookie") != "" || slices.Contains(varyNames(header), "*") {
+		return 0, false
+	}
+	responseControl := parseCacheControl(header)
+	if responseControl.has("no-store") || responseControl.has("no-cache") || responseControl.has("private") {
+		return 0, false
+	}
+
+	matched := -1
+	for i, route := range m.routes {
+		if strings.HasPrefix(r.URL.Path, route.prefix) && (matched < 0 || len(route.prefix) > len(m.routes[matched].prefix)) {
+			matched = i
+		}
+	}
+	if matched >= 0 {
+		return m.routes[matched].ttl, m.routes[matched].ttl > 0
+	}
+
+	if ttl, found := freshness(header, "s-maxage", time.Now()); found {
+		return ttl, ttl > 0
+	}
+
+	return m.defaultTTL, m.defaultTTL > 0
+}
+
+// responseRecorder passes a response on to the client while keeping a copy of
+// its body, up to maxBodySize bytes if positive.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+	maxBodySize int
+	overflowed  bool
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	if !r.overflowed {
+		if r.maxBodySize > 0 && r.body.Len()+len(data) > r.maxBodySize {
+			r.overflowed = true
+			r.body.Reset()
+		} else {
+			r.body.Write(data)
+		}
+	}
+
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (e entry) write(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	for name, values := range e.Header {
+		header[name] = append([]string(nil), values...)
+	}
+	header.Set(XFromCache, "1")
+
+	if e.notModified(r) {
+		header.Del("Content-Length")
+		header.Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(e.StatusCode)
+	w.Write(e.Body)
+}
+
+// notModified reports whether the validators of r match those of e, giving
+// If-None-Match precedence over If-Modified-Since.
+func (e entry) notModified(r *http.Request) bool {
+	if e.StatusCode != http.StatusOK {
+		return false
+	}
+	if ifNoneMatch := r.Header.Values("If-None-Match"); len(ifNoneMatch) > 0 {
+		return etagMatches(strings.Join(ifNoneMatch, ","), e.Header.Get("ETag"))
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lastModified, err := http.ParseTime(e.Header.Get("Last-Modified"))
+
+	return err == nil && !lastModified.After(since)
+}
+
+// etagMatches compares etag weakly with the entity tags of an If-None-Match
+// list.
+func etagMatches(list, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,274 @@
+package httpcache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// countingHandler answers every request with the number of requests it has
+// served, using the status and headers set by respond.
+func countingHandler(respond func(w http.ResponseWriter, r *http.Request)) (http.Handler, *int) {
+	requests := 0
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		respond(w, r)
+		fmt.Fprintf(w, "response %d", requests)
+	}), &requests
+}
+
+func serve(handler http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	return recorder
+}
+
+func TestMiddleware(t *testing.T) {
+	maxAge := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+	}
+	tests := []struct {
+		name          string
+		respond       func(w http.ResponseWriter, r *http.Request)
+		options       []func(*middleware)
+		target        string
+		requestHeader http.Header
+		wantRequests  int
+	}{
+		{
+			name:         "Fresh response is reused",
+			respond:      maxAge,
+			wantRequests: 1,
+		},
+		{
+			name: "s-maxage takes precedence",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=60, s-maxage=0")
+			},
+			wantRequests: 2,
+		},
+		{
+			name:         "No lifetime",
+			respond:      func(w http.ResponseWriter, r *http.Request) {},
+			wantRequests: 2,
+		},
+		{
+			name:         "Default TTL",
+			respond:      func(w http.ResponseWriter, r *http.Request) {},
+			options:      []func(*middleware){WithDefaultTTL(time.Minute)},
+			wantRequests: 1,
+		},
+		{
+			name: "Private response",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "private, max-age=60")
+			},
+			wantRequests: 2,
+		},
+		{
+			name: "Set-Cookie",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				maxAge(w, r)
+				w.Header().Set("Set-Cookie", "session=1")
+			},
+			wantRequests: 2,
+		},
+		{
+			name: "Uncacheable status",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				maxAge(w, r)
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantRequests: 2,
+		},
+		{
+			name:          "Authorization",
+			respond:       maxAge,
+			requestHeader: http.Header{"Authorization": {"Bearer token"}},
+			wantRequests:  2,
+		},
+		{
+			name:    "Bypass",
+			respond: maxAge,
+			options: []func(*middleware){WithBypass(func(r *http.Request) bool {
+				return r.URL.Query().Get("fresh") == "1"
+			})},
+			target:       "/items?fresh=1",
+			wantRequests: 2,
+		},
+		{
+			name:         "Route TTL",
+			respond:      func(w http.ResponseWriter, r *http.Request) {},
+			options:      []func(*middleware){WithRouteTTL("/items", time.Minute)},
+			target:       "/items/1",
+			wantRequests: 1,
+		},
+		{
+			name:    "Longest route wins",
+			respond: maxAge,
+			options: []func(*middleware){
+				WithRouteTTL("/items", time.Minute),
+				WithRouteTTL("/items/live", 0),
+			},
+			target:       "/items/live/1",
+			wantRequests: 2,
+		},
+		{
+			name: "Vary: *",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				maxAge(w, r)
+				w.Header().Set("Vary", "Accept, *")
+			},
+			wantRequests: 2,
+		},
+		{
+			name:         "Response over limit",
+			respond:      maxAge,
+			options:      []func(*middleware){WithMaxResponseSize(4)},
+			wantRequests: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, requests := countingHandler(tt.respond)
+			handler = Middleware(newTestCache(t), tt.options...)(handler)
+			target := tt.target
+			if target == "" {
+				target = "/items"
+			}
+
+			first := serve(handler, target, tt.requestHeader)
+			second := serve(handler, target, tt.requestHeader)
+
+			if first.Code != second.Code {
+				t.Errorf("second status = %d, want %d", second.Code, first.Code)
+			}
+			if want := fmt.Sprintf("response %d", tt.wantRequests); second.Body.String() != want {
+				t.Errorf("second body = %q, want %q", second.Body.String(), want)
+			}
+			if fromCache := second.Header().Get(XFromCache) == "1"; fromCache != (tt.wantRequests == 1) {
+				t.Errorf("second %s = %v, want %v", XFromCache, fromCache, tt.wantRequests == 1)
+			}
+			if *requests != tt.wantRequests {
+				t.Errorf("handler got %d requests, want %d", *requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestWithVaryHeaders(t *testing.T) {
+	handler, requests := countingHandler(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Language", r.Header.Get("Accept-Language"))
+	})
+	handler = Middleware(newTestCache(t), WithDefaultTTL(time.Minute), WithVaryHeaders("accept-language"))(handler)
+	english := http.Header{"Accept-Language": {"en"}}
+	french := http.Header{"Accept-Language": {"fr"}}
+
+	serve(handler, "/items", english)
+	serve(handler, "/items", french)
+	if got := serve(handler, "/items", english); got.Body.String() != "response 1" || got.Header().Get("Content-Language") != "en" {
+		t.Errorf("English response = %q in %q, want %q in %q", got.Body.String(), got.Header().Get("Content-Language"), "response 1", "en")
+	}
+	if got := serve(handler, "/items", french); got.Body.String() != "response 2" || got.Header().Get("Content-Language") != "fr" {
+		t.Errorf("French response = %q in %q, want %q in %q", got.Body.String(), got.Header().Get("Content-Language"), "response 2", "fr")
+	}
+	if *requests != 2 {
+		t.Errorf("handler got %d requests, want 2", *requests)
+	}
+}
+
+func TestMiddleware_ResponseVary(t *testing.T) {
+	handler, requests := countingHandler(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", r.Header.Get("Accept-Language"))
+	})
+	handler = Middleware(newTestCache(t))(handler)
+
+	tests := []struct {
+		name         string
+		language     string
+		wantBody     string
+		wantLanguage string
+	}{
+		{name: "First English request", language: "en", wantBody: "response 1", wantLanguage: "en"},
+		{name: "First French request", language: "fr", wantBody: "response 2", wantLanguage: "fr"},
+		{name: "Cached English response", language: "en", wantBody: "response 1", wantLanguage: "en"},
+		{name: "Cached French response", language: "fr", wantBody: "response 2", wantLanguage: "fr"},
+	}
+	for _, tt := range tests {
+		got := serve(handler, "/items", http.Header{"Accept-Language": {tt.language}})
+		if got.Body.String() != tt.wantBody || got.Header().Get("Content-Language") != tt.wantLanguage {
+			t.Errorf("%s = %q in %q, want %q in %q", tt.name, got.Body.String(), got.Header().Get("Content-Language"), tt.wantBody, tt.wantLanguage)
+		}
+	}
+	if *requests != 2 {
+		t.Errorf("handler got %d requests, want 2", *requests)
+	}
+}
+
+func TestMiddleware_Conditional(t *testing.T) {
+	lastModified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		requestHeader http.Header
+		wantStatus    int
+	}{
+		{
+			name:          "Matching If-None-Match",
+			requestHeader: http.Header{"If-None-Match": {`"v0", W/"v1"`}},
+			wantStatus:    http.StatusNotModified,
+		},
+		{
+			name:          "Other If-None-Match",
+			requestHeader: http.Header{"If-None-Match": {`"v0"`}},
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:          "If-None-Match takes precedence",
+			requestHeader: http.Header{"If-None-Match": {`"v0"`}, "If-Modified-Since": {lastModified.Format(http.TimeFormat)}},
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:          "Not modified since",
+			requestHeader: http.Header{"If-Modified-Since": {lastModified.Add(time.Hour).Format(http.TimeFormat)}},
+			wantStatus:    http.StatusNotModified,
+		},
+		{
+			name:          "Modified since",
+			requestHeader: http.Header{"If-Modified-Since": {lastModified.Add(-time.Hour).Format(http.TimeFormat)}},
+			wantStatus:    http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, requests := countingHandler(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.Header().Set("ETag", `"v1"`)
+				w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+			})
+			handler = Middleware(newTestCache(t))(handler)
+
+			serve(handler, "/items", nil)
+			got := serve(handler, "/items", tt.requestHeader)
+
+			if got.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", got.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusNotModified && got.Body.Len() != 0 {
+				t.Errorf("304 response has body %q", got.Body.String())
+			}
+			if *requests != 1 {
+				t.Errorf("handler got %d requests, want 1", *requests)
+			}
+		})
+	}
+}
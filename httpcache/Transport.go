@@ -0,0 +1,318 @@
+package httpcache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+// XFromCache is set to "1" on responses served from the cache.
+const XFromCache = "X-From-Cache"
+
+const defaultRevalidationWindow = time.Hour * 24
+
+// entry is a cached response, fresh until Expires. Vary holds the values the
+// request had for the headers named by the response's Vary header.
+type entry struct {
+	Status     string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Vary       http.Header
+	Expires    time.Time
+}
+
+func init() {
+	gob.Register(entry{})
+	gob.Register(variants{})
+}
+
+type transport struct {
+	cache              cache.Cache
+	next               http.RoundTripper
+	ttl                time.Duration
+	maxBodySize        int64
+	revalidationWindow time.Duration
+}
+
+// NewTransport returns a RoundTripper that serves GET requests from c while
+// the cached response is fresh, as a private cache would. Responses are fresh
+// for their Cache-Control max-age, or else until their Expires time. Once
+// stale, responses with an ETag or Last-Modified validator are revalidated
+// with If-None-Match or If-Modified-Since, and served from the cache if the
+// server answers 304 Not Modified. Responses with no-store, and those with
+// no-cache or no lifetime that lack validators, are not cached. Requests with
+// Cache-Control no-cache are always revalidated and no-store bypass the cache
+// entirely, as do Range and conditional requests.
+func NewTransport(c cache.Cache, options ...func(*transport)) http.RoundTripper {
+	t := &transport{cache: c, next: http.DefaultTransport, revalidationWindow: defaultRevalidationWindow}
+	for _, optionFn := range options {
+		optionFn(t)
+	}
+
+	return t
+}
+
+// WithTransport sets the RoundTripper that makes the requests not served from
+// the cache, http.DefaultTransport by default.
+func WithTransport(next http.RoundTripper) func(*transport) {
+	return func(t *transport) {
+		t.next = next
+	}
+}
+
+// WithTTL keeps every cacheable response fresh for ttl, whatever lifetime its
+// headers give. Responses with no-store are still not cached and those with
+// no-cache are still revalidated.
+func WithTTL(ttl time.Duration) func(*transport) {
+	return func(t *transport) {
+		t.ttl = ttl
+	}
+}
+
+// WithMaxBodySize leaves responses with bodies over maxBytes uncached. They
+// are passed on without being buffered in full.
+func WithMaxBodySize(maxBytes int64) func(*transport) {
+	return func(t *transport) {
+		t.maxBodySize = maxBytes
+	}
+}
+
+// WithRevalidationWindow keeps stale responses with validators for window
+// after they expire, 24 hours by default, so that they can be revalidated. A
+// non-positive window drops responses once stale.
+func WithRevalidationWindow(window time.Duration) func(*transport) {
+	return func(t *transport) {
+		t.revalidationWindow = window
+	}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" || isConditional(req) {
+		return t.next.RoundTrip(req)
+	}
+	requestControl := parseCacheControl(req.Header)
+	if requestControl.has("no-store") {
+		return t.next.RoundTrip(req)
+	}
+
+	key := requestKey(req)
+	cached, found := t.lookup(key, req)
+	if found && !requestControl.has("no-cache") && time.Now().Before(cached.Expires) {
+		return cached.response(req), nil
+	}
+
+	outgoing := req
+	if found && cached.hasValidators() {
+		outgoing = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			outgoing.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			outgoing.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	if found && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		cached = cached.revalidated(resp)
+		if fresh, cacheable := t.lifetime(cached.StatusCode, cached.Header); cacheable {
+			t.store(key, cached, fresh)
+		}
+		return cached.response(req), nil
+	}
+
+	fresh, cacheable := t.lifetime(resp.StatusCode, resp.Header)
+	if !cacheable {
+		return resp, nil
+	}
+	body, complete, err := readBody(resp, t.maxBodySize)
+	if err != nil {
+		return nil, err
+	}
+	if complete {
+		t.store(key, newEntry(req, resp, body), fresh)
+	}
+
+	return resp, nil
+}
+
+// store caches e for fresh, plus the revalidation window if it has
+// validators.
+func (t *transport) store(key string, e entry, fresh time.Duration) {
+	e.Expires = time.Now().Add(fresh)
+	ttl := fresh
+	if e.hasValidators() && t.revalidationWindow > 0 {
+		ttl += t.revalidationWindow
+	}
+	if ttl > 0 {
+		t.cache.Set(key, e, ttl)
+	}
+}
+
+func (t *transport) lookup(key string, req *http.Request) (entry, bool) {
+	value, found := t.cache.Get(key)
+	if !found {
+		return entry{}, false
+	}
+	cached, ok := value.(entry)
+	if !ok || !cached.matches(req) {
+		return entry{}, false
+	}
+
+	return cached, true
+}
+
+// lifetime returns how long a response stays fresh, and false if it may not
+// be cached. Responses that are stale from the start may be cached for
+// revalidation if they have validators.
+func (t *transport) lifetime(statusCode int, header http.Header) (time.Duration, bool) {
+	if !cacheableStatus[statusCode] || header.Get("Vary") == "*" {
+		return 0, false
+	}
+	responseControl := parseCacheControl(header)
+	if responseControl.has("no-store") {
+		return 0, false
+	}
+	revalidatable := t.revalidationWindow > 0 && (header.Get("ETag") != "" || header.Get("Last-Modified") != "")
+	if responseControl.has("no-cache") {
+		return 0, revalidatable
+	}
+	if t.ttl > 0 {
+		return t.ttl, true
+	}
+
+	fresh, found := freshness(header, "", time.Now())
+	if !found || fresh <= 0 {
+		return 0, revalidatable
+	}
+
+	return fresh, true
+}
+
+func isConditional(req *http.Request) bool {
+	return req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+}
+
+func requestKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String()
+}
+
+// readBody reads the body of resp and replaces it with an unread copy. Bodies
+// over maxBytes, if positive, are not read in full and reported incomplete.
+func readBody(resp *http.Response, maxBytes int64) (body []byte, complete bool, err error) {
+	reader := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		reader = io.LimitReader(resp.Body, maxBytes+1)
+	}
+	body, err = io.ReadAll(reader)
+	if err != nil {
+		resp.Body.Close()
+		return nil, false, err
+	}
+
+	if maxBytes > 0 && int64(len(body)) > maxBytes {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return nil, false, nil
+	}
+
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, true, nil
+}
+
+func newEntry(req *http.Request, resp *http.Response, body []byte) entry {
+	e := entry{
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+	}
+	for _, name := range varyNames(resp.Header) {
+		if e.Vary == nil {
+			e.Vary = http.Header{}
+		}
+		e.Vary[name] = req.Header.Values(name)
+	}
+
+	return e
+}
+
+// varyNames returns the canonical names of the request headers a response
+// varies on, in the order its Vary header lists them.
+func varyNames(header http.Header) []string {
+	var names []string
+	for _, line := range header.Values("Vary") {
+		for _, name := range strings.Split(line, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	return names
+}
+
+func (e entry) hasValidators() bool {
+	return e.Header.Get("ETag") != "" || e.Header.Get("Last-Modified") != ""
+}
+
+// revalidated returns e updated with the headers of a 304 Not Modified
+// response to its revalidation.
+func (e entry) revalidated(notModified *http.Response) entry {
+	e.Header = e.Header.Clone()
+	for name, values := range notModified.Header {
+		switch name {
+		case "Content-Length", "Content-Type", "Content-Encoding", "Transfer-Encoding":
+			continue
+		}
+		e.Header[name] = values
+	}
+
+	return e
+}
+
+// matches reports whether req has the values the cached request had for the
+// headers the response varies on.
+func (e entry) matches(req *http.Request) bool {
+	for name, values := range e.Vary {
+		if strings.Join(req.Header.Values(name), ",") != strings.Join(values, ",") {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e entry) response(req *http.Request) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(XFromCache, "1")
+
+	return &http.Response{
+		Status:        e.Status,
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,295 @@
+package httpcache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+// countingServer answers every request with the number of requests it has
+// served, using the response headers set by header.
+func countingServer(t *testing.T, header func(h http.Header)) (*httptest.Server, *int) {
+	t.Helper()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		header(w.Header())
+		fmt.Fprintf(w, "response %d", requests)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func newTestCache(t *testing.T) cache.Cache {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return cache.NewInMemoryCache(ctx)
+}
+
+func get(t *testing.T, client *http.Client, url string, header http.Header) (string, bool) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error: %v", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+
+	return string(body), resp.Header.Get(XFromCache) == "1"
+}
+
+func TestNewTransport(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        http.Header
+		options       []func(*transport)
+		requestHeader http.Header
+		wantRequests  int
+	}{
+		{
+			name:         "Fresh response is reused",
+			header:       http.Header{"Cache-Control": {"max-age=60"}},
+			wantRequests: 1,
+		},
+		{
+			name:         "Expires",
+			header:       http.Header{"Expires": {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}},
+			wantRequests: 1,
+		},
+		{
+			name:         "No lifetime",
+			header:       http.Header{},
+			wantRequests: 2,
+		},
+		{
+			name:         "Response no-store",
+			header:       http.Header{"Cache-Control": {"no-store, max-age=60"}},
+			wantRequests: 2,
+		},
+		{
+			name:         "Response no-cache",
+			header:       http.Header{"Cache-Control": {"no-cache, max-age=60"}},
+			wantRequests: 2,
+		},
+		{
+			name:          "Request no-cache",
+			header:        http.Header{"Cache-Control": {"max-age=60"}},
+			requestHeader: http.Header{"Cache-Control": {"no-cache"}},
+			wantRequests:  2,
+		},
+		{
+			name:         "Vary *",
+			header:       http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"*"}},
+			wantRequests: 2,
+		},
+		{
+			name:         "Override TTL",
+			header:       http.Header{},
+			options:      []func(*transport){WithTTL(time.Minute)},
+			wantRequests: 1,
+		},
+		{
+			name:         "Override TTL keeps no-store",
+			header:       http.Header{"Cache-Control": {"no-store"}},
+			options:      []func(*transport){WithTTL(time.Minute)},
+			wantRequests: 2,
+		},
+		{
+			name:         "Body over limit",
+			header:       http.Header{"Cache-Control": {"max-age=60"}},
+			options:      []func(*transport){WithMaxBodySize(4)},
+			wantRequests: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := countingServer(t, func(h http.Header) {
+				for name, values := range tt.header {
+					h[name] = values
+				}
+			})
+			client := &http.Client{Transport: NewTransport(newTestCache(t), tt.options...)}
+
+			first, _ := get(t, client, server.URL, tt.requestHeader)
+			if first != "response 1" {
+				t.Errorf("first body = %q, want %q", first, "response 1")
+			}
+			second, fromCache := get(t, client, server.URL, tt.requestHeader)
+			if want := fmt.Sprintf("response %d", tt.wantRequests); second != want {
+				t.Errorf("second body = %q, want %q", second, want)
+			}
+			if fromCache != (tt.wantRequests == 1) {
+				t.Errorf("second %s = %v, want %v", XFromCache, fromCache, tt.wantRequests == 1)
+			}
+			if *requests != tt.wantRequests {
+				t.Errorf("server got %d requests, want %d", *requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestNewTransport_Vary(t *testing.T) {
+	server, requests := countingServer(t, func(h http.Header) {
+		h.Set("Cache-Control", "max-age=60")
+		h.Set("Vary", "Accept-Language")
+	})
+	client := &http.Client{Transport: NewTransport(newTestCache(t))}
+	english := http.Header{"Accept-Language": {"en"}}
+
+	get(t, client, server.URL, english)
+	if _, fromCache := get(t, client, server.URL, english); !fromCache {
+		t.Errorf("request with the same Accept-Language not served from the cache")
+	}
+	if _, fromCache := get(t, client, server.URL, http.Header{"Accept-Language": {"fr"}}); fromCache {
+		t.Errorf("request with another Accept-Language served from the cache")
+	}
+	if *requests != 2 {
+		t.Errorf("server got %d requests, want 2", *requests)
+	}
+}
+
+func TestNewTransport_Expiry(t *testing.T) {
+	server, requests := countingServer(t, func(h http.Header) {})
+	client := &http.Client{Transport: NewTransport(newTestCache(t), WithTTL(time.Millisecond*20))}
+
+	get(t, client, server.URL, nil)
+	get(t, client, server.URL, nil)
+	time.Sleep(time.Millisecond * 40)
+	if body, _ := get(t, client, server.URL, nil); body != "response 2" {
+		t.Errorf("body after expiry = %q, want %q", body, "response 2")
+	}
+	if *requests != 2 {
+		t.Errorf("server got %d requests, want 2", *requests)
+	}
+}
+
+func TestNewTransport_Revalidation(t *testing.T) {
+	lastModified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+	tests := []struct {
+		name          string
+		header        http.Header
+		options       []func(*transport)
+		wantCondition string
+		wantBody      string
+		wantRequests  int
+	}{
+		{
+			name:          "ETag",
+			header:        http.Header{"Cache-Control": {"max-age=0"}, "ETag": {`"v1"`}},
+			wantCondition: `If-None-Match: "v1"`,
+			wantBody:      "response 1",
+			wantRequests:  2,
+		},
+		{
+			name:          "Last-Modified",
+			header:        http.Header{"Last-Modified": {lastModified}},
+			wantCondition: "If-Modified-Since: " + lastModified,
+			wantBody:      "response 1",
+			wantRequests:  2,
+		},
+		{
+			name:          "no-cache",
+			header:        http.Header{"Cache-Control": {"no-cache"}, "ETag": {`"v1"`}},
+			wantCondition: `If-None-Match: "v1"`,
+			wantBody:      "response 1",
+			wantRequests:  2,
+		},
+		{
+			name:         "Without revalidation window",
+			header:       http.Header{"Cache-Control": {"max-age=0"}, "ETag": {`"v1"`}},
+			options:      []func(*transport){WithRevalidationWindow(0)},
+			wantBody:     "response 2",
+			wantRequests: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			var condition string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				for name, values := range tt.header {
+					w.Header()[name] = values
+				}
+				if etag := r.Header.Get("If-None-Match"); etag != "" {
+					condition = "If-None-Match: " + etag
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				if since := r.Header.Get("If-Modified-Since"); since != "" {
+					condition = "If-Modified-Since: " + since
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				fmt.Fprintf(w, "response %d", requests)
+			}))
+			defer server.Close()
+			client := &http.Client{Transport: NewTransport(newTestCache(t), tt.options...)}
+
+			get(t, client, server.URL, nil)
+			body, fromCache := get(t, client, server.URL, nil)
+
+			if body != tt.wantBody {
+				t.Errorf("second body = %q, want %q", body, tt.wantBody)
+			}
+			if fromCache != (tt.wantCondition != "") {
+				t.Errorf("second %s = %v, want %v", XFromCache, fromCache, tt.wantCondition != "")
+			}
+			if condition != tt.wantCondition {
+				t.Errorf("server got condition %q, want %q", condition, tt.wantCondition)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("server got %d requests, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestNewTransport_RevalidationRefreshes(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=0")
+		fmt.Fprint(w, "body")
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: NewTransport(newTestCache(t))}
+
+	for i := 0; i < 3; i++ {
+		if body, _ := get(t, client, server.URL, nil); body != "body" {
+			t.Errorf("body #%d = %q, want %q", i, body, "body")
+		}
+	}
+	if requests != 2 {
+		t.Errorf("server got %d requests, want 2: the 304 should make the entry fresh", requests)
+	}
+}
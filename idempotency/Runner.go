@@ -0,0 +1,167 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+const (
+	defaultMarkerTTL = time.Minute
+	defaultKeyPrefix = "idempotency:"
+)
+
+var ErrInProgress = errors.New("idempotency: operation in progress")
+
+// Status is the state of an idempotency key.
+type Status int
+
+const (
+	// StatusUnknown is the status of keys without a running or completed
+	// operation.
+	StatusUnknown Status = iota
+	StatusInProgress
+	StatusCompleted
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusInProgress:
+		return "in progress"
+	case StatusCompleted:
+		return "completed"
+	default:
+		return "unknown"
+	}
+}
+
+// Store is a cache that can store a value only if its key is absent, like the
+// in-memory cache.
+type Store interface {
+	cache.Cache
+	cache.ConditionalSetter
+}
+
+// Runner runs operations at most once per idempotency key.
+type Runner interface {
+	// Do runs fn unless an operation with key ran or is running. The result
+	// of fn is stored for ttl and returned again for later calls with key.
+	// While the first call runs, others get ErrInProgress. An error of fn
+	// is returned and not stored, so the operation can be retried.
+	Do(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (interface{}, error)) (interface{}, error)
+	// Status returns the state of key.
+	Status(key string) Status
+}
+
+// marker claims a key while its operation runs.
+type marker struct {
+	Owner string
+}
+
+// result is the stored outcome of a completed operation.
+type result struct {
+	Value interface{}
+}
+
+func init() {
+	gob.Register(marker{})
+	gob.Register(result{})
+}
+
+type runner struct {
+	store     Store
+	markerTTL time.Duration
+	keyPrefix string
+}
+
+// New returns a Runner keeping keys in store.
+func New(store Store, options ...func(*runner)) Runner {
+	r := &runner{
+		store:     store,
+		markerTTL: defaultMarkerTTL,
+		keyPrefix: defaultKeyPrefix,
+	}
+	for _, optionFn := range options {
+		optionFn(r)
+	}
+
+	return r
+}
+
+// WithMarkerTTL sets how long a key stays in progress, a minute by default.
+// It bounds how long a key stays blocked by an operation whose process died,
+// and must exceed the longest operation.
+func WithMarkerTTL(ttl time.Duration) func(*runner) {
+	return func(r *runner) {
+		r.markerTTL = ttl
+	}
+}
+
+// WithKeyPrefix sets the prefix of cache keys, "idempotency:" by default.
+func WithKeyPrefix(prefix string) func(*runner) {
+	return func(r *runner) {
+		r.keyPrefix = prefix
+	}
+}
+
+func (r *runner) Do(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	cacheKey := r.keyPrefix + key
+	owner, err := newOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	for !r.store.SetIfAbsent(cacheKey, marker{Owner: owner}, r.markerTTL) {
+		value, found := r.store.Get(cacheKey)
+		if !found {
+			// The entry expired since SetIfAbsent; claim the key again.
+			continue
+		}
+		switch v := value.(type) {
+		case result:
+			return v.Value, nil
+		case marker:
+			return nil, ErrInProgress
+		default:
+			return nil, fmt.Errorf("idempotency: key %q holds a %T", key, value)
+		}
+	}
+
+	value, err := fn(ctx)
+	if err != nil {
+		if current, _ := r.store.Get(cacheKey); current == (marker{Owner: owner}) {
+			r.store.Delete(cacheKey)
+		}
+		return nil, err
+	}
+	r.store.Set(cacheKey, result{Value: value}, ttl)
+
+	return value, nil
+}
+
+func (r *runner) Status(key string) Status {
+	value, _ := r.store.Get(r.keyPrefix + key)
+	switch value.(type) {
+	case marker:
+		return StatusInProgress
+	case result:
+		return StatusCompleted
+	default:
+		return StatusUnknown
+	}
+}
+
+func newOwner() (string, error) {
+	var owner [16]byte
+	if _, err := rand.Read(owner[:]); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(owner[:]), nil
+}
@@ -0,0 +1,173 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+func newTestRunner(t *testing.T, options ...func(*runner)) Runner {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return New(cache.NewInMemoryCache(ctx).(Store), options...)
+}
+
+func TestRunner_Do(t *testing.T) {
+	r := newTestRunner(t)
+	calls := 0
+	charge := func(ctx context.Context) (interface{}, error) {
+		calls++
+		return "receipt-1", nil
+	}
+
+	if r.Status("payment") != StatusUnknown {
+		t.Errorf("Status() before Do() = %v, want %v", r.Status("payment"), StatusUnknown)
+	}
+	for i := 0; i < 3; i++ {
+		value, err := r.Do(context.Background(), "payment", time.Hour, charge)
+		if err != nil || value != "receipt-1" {
+			t.Errorf("Do() #%d = %v, %v, want receipt-1", i, value, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("operation ran %d times, want 1", calls)
+	}
+	if r.Status("payment") != StatusCompleted {
+		t.Errorf("Status() after Do() = %v, want %v", r.Status("payment"), StatusCompleted)
+	}
+}
+
+func TestRunner_InProgress(t *testing.T) {
+	r := newTestRunner(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	done := make(chan error)
+	go func() {
+		_, err := r.Do(context.Background(), "payment", time.Hour, func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-release
+			return "receipt-1", nil
+		})
+		done <- err
+	}()
+	<-started
+
+	if _, err := r.Do(context.Background(), "payment", time.Hour, func(ctx context.Context) (interface{}, error) {
+		t.Errorf("operation ran while another was in progress")
+		return nil, nil
+	}); !errors.Is(err, ErrInProgress) {
+		t.Errorf("Do() during the operation error = %v, want %v", err, ErrInProgress)
+	}
+	if r.Status("payment") != StatusInProgress {
+		t.Errorf("Status() during the operation = %v, want %v", r.Status("payment"), StatusInProgress)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Do() error: %v", err)
+	}
+}
+
+func TestRunner_Error(t *testing.T) {
+	r := newTestRunner(t)
+	errDeclined := errors.New("declined")
+
+	if _, err := r.Do(context.Background(), "payment", time.Hour, func(ctx context.Context) (interface{}, error) {
+		return nil, errDeclined
+	}); !errors.Is(err, errDeclined) {
+		t.Fatalf("Do() error = %v, want %v", err, errDeclined)
+	}
+	if r.Status("payment") != StatusUnknown {
+		t.Errorf("Status() after a failure = %v, want %v", r.Status("payment"), StatusUnknown)
+	}
+	if value, err := r.Do(context.Background(), "payment", time.Hour, func(ctx context.Context) (interface{}, error) {
+		return "receipt-2", nil
+	}); err != nil || value != "receipt-2" {
+		t.Errorf("Do() retry = %v, %v, want receipt-2", value, err)
+	}
+}
+
+func TestRunner_Expiry(t *testing.T) {
+	tests := []struct {
+		name      string
+		markerTTL time.Duration
+		ttl       time.Duration
+		fail      bool
+	}{
+		{
+			name:      "Result expires",
+			markerTTL: time.Minute,
+			ttl:       time.Millisecond * 20,
+		},
+		{
+			name:      "Abandoned marker expires",
+			markerTTL: time.Millisecond * 20,
+			ttl:       time.Hour,
+			fail:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(t, WithMarkerTTL(tt.markerTTL))
+
+			if tt.fail {
+				// An operation that hangs past its marker TTL leaves the key.
+				hung := make(chan struct{})
+				defer close(hung)
+				go r.Do(context.Background(), "payment", tt.ttl, func(ctx context.Context) (interface{}, error) {
+					<-hung
+					return nil, errors.New("hung")
+				})
+			} else {
+				r.Do(context.Background(), "payment", tt.ttl, func(ctx context.Context) (interface{}, error) {
+					return "receipt-1", nil
+				})
+			}
+			time.Sleep(time.Millisecond * 50)
+
+			if value, err := r.Do(context.Background(), "payment", tt.ttl, func(ctx context.Context) (interface{}, error) {
+				return "receipt-2", nil
+			}); err != nil || value != "receipt-2" {
+				t.Errorf("Do() after expiry = %v, %v, want receipt-2", value, err)
+			}
+		})
+	}
+}
+
+func TestRunner_Concurrent(t *testing.T) {
+	r := newTestRunner(t)
+	var calls, inProgress atomic.Int64
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.Do(context.Background(), "payment", time.Hour, func(ctx context.Context) (interface{}, error) {
+				calls.Add(1)
+				time.Sleep(time.Millisecond * 10)
+				return "receipt-1", nil
+			})
+			if errors.Is(err, ErrInProgress) {
+				inProgress.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("operation ran %d times, want 1", got)
+	}
+	if inProgress.Load() == 0 {
+		t.Errorf("no concurrent call got %v", ErrInProgress)
+	}
+}
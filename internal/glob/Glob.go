@@ -0,0 +1,128 @@
+package glob
+
+import "errors"
+
+var ErrBadPattern = errors.New("glob: malformed pattern")
+
+// Match reports whether s matches pattern using Redis KEYS semantics: "*"
+// matches any sequence including "/", "?" matches one byte, "[abc]", "[^a]"
+// and "[a-z]" match byte classes, and "\" escapes the next byte.
+func Match(pattern, s string) (bool, error) {
+	if err := Validate(pattern); err != nil {
+		return false, err
+	}
+
+	return match(pattern, s), nil
+}
+
+// Validate reports ErrBadPattern for unterminated classes or escapes.
+func Validate(pattern string) error {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			if i++; i == len(pattern) {
+				return ErrBadPattern
+			}
+		case '[':
+			end := classEnd(pattern, i+1)
+			if end < 0 {
+				return ErrBadPattern
+			}
+			i = end
+		}
+	}
+
+	return nil
+}
+
+func match(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if match(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		case '[':
+			end := classEnd(pattern, 1)
+			if len(s) == 0 || !matchClass(pattern[1:end], s[0]) {
+				return false
+			}
+			pattern, s = pattern[end+1:], s[1:]
+		case '\\':
+			pattern = pattern[1:]
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+
+	return len(s) == 0
+}
+
+// classEnd returns the index of the "]" closing the class that starts at
+// start, or -1 if the class is unterminated.
+func classEnd(pattern string, start int) int {
+	i := start
+	if i < len(pattern) && pattern[i] == '^' {
+		i++
+	}
+	for ; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case ']':
+			if i > start {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func matchClass(class string, b byte) bool {
+	negate := len(class) > 0 && class[0] == '^'
+	if negate {
+		class = class[1:]
+	}
+
+	matched := false
+	for i := 0; i < len(class); i++ {
+		low := class[i]
+		if low == '\\' && i+1 < len(class) {
+			i++
+			low = class[i]
+		}
+		high := low
+		if i+2 < len(class) && class[i+1] == '-' {
+			high = class[i+2]
+			if high == '\\' && i+3 < len(class) {
+				i++
+				high = class[i+2]
+			}
+			i += 2
+		}
+		if low <= b && b <= high {
+			matched = true
+		}
+	}
+
+	return matched != negate
+}
@@ -0,0 +1,45 @@
+package glob
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+		wantErr error
+	}{
+		{pattern: "*", s: "", want: true},
+		{pattern: "*", s: "a/b", want: true},
+		{pattern: "user:*", s: "user:1/profile", want: true},
+		{pattern: "user:*", s: "session:1", want: false},
+		{pattern: "*:profile", s: "user:1:profile", want: true},
+		{pattern: "h?llo", s: "hello", want: true},
+		{pattern: "h?llo", s: "hllo", want: false},
+		{pattern: "h[ae]llo", s: "hallo", want: true},
+		{pattern: "h[ae]llo", s: "hillo", want: false},
+		{pattern: "h[^e]llo", s: "hallo", want: true},
+		{pattern: "h[^e]llo", s: "hello", want: false},
+		{pattern: "key[0-9]", s: "key7", want: true},
+		{pattern: "key[0-9]", s: "keyx", want: false},
+		{pattern: `a\*b`, s: "a*b", want: true},
+		{pattern: `a\*b`, s: "axb", want: false},
+		{pattern: "[]a]", s: "]", want: true},
+		{pattern: "a[", s: "a", wantErr: ErrBadPattern},
+		{pattern: `a\`, s: "a", wantErr: ErrBadPattern},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.s, func(t *testing.T) {
+			got, err := Match(tt.pattern, tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Match() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	SimpleString = '+'
+	Error        = '-'
+	Integer      = ':'
+	BulkString   = '$'
+	Array        = '*'
+)
+
+// The default limits of a Reader are those of Redis.
+const (
+	DefaultMaxBulkLength  = 512 << 20
+	DefaultMaxArrayLength = 1 << 20
+)
+
+// bufferedLength and bufferedElements cap what is allocated for a value
+// before its data arrives. maxDepth bounds the nesting of arrays, which is
+// otherwise only limited by the stack.
+const (
+	bufferedLength   = 64 << 10
+	bufferedElements = 16
+	maxDepth         = 32
+)
+
+var ErrProtocol = errors.New("resp: protocol error")
+
+type Value struct {
+	Type  byte
+	Str   string
+	Int   int64
+	Array []Value
+	Null  bool
+}
+
+type Reader struct {
+	reader         *bufio.Reader
+	maxBulkLength  int
+	maxArrayLength int
+}
+
+type Writer struct {
+	writer *bufio.Writer
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{reader: bufio.NewReader(r), maxBulkLength: DefaultMaxBulkLength, maxArrayLength: DefaultMaxArrayLength}
+}
+
+// SetLimits sets the largest bulk string and array a Reader accepts; longer
+// ones fail with ErrProtocol before anything is allocated for them. Memory is
+// only allocated as the data of a value arrives, so a length alone cannot
+// exhaust it.
+func (r *Reader) SetLimits(maxBulkLength, maxArrayLength int) {
+	r.maxBulkLength = maxBulkLength
+	r.maxArrayLength = maxArrayLength
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{writer: bufio.NewWriter(w)}
+}
+
+func (v Value) Err() error {
+	if v.Type != Error {
+		return nil
+	}
+
+	return errors.New(v.Str)
+}
+
+func (r *Reader) ReadValue() (Value, error) {
+	return r.readValue(0)
+}
+
+func (r *Reader) readValue(depth int) (Value, error) {
+	if depth > maxDepth {
+		return Value{}, fmt.Errorf("%w: arrays nested deeper than %d", ErrProtocol, maxDepth)
+	}
+	line, err := r.readLine()
+	if err != nil {
+		return Value{}, err
+	}
+	if len(line) == 0 {
+		return Value{}, ErrProtocol
+	}
+
+	value := Value{Type: line[0]}
+	payload := string(line[1:])
+	switch value.Type {
+	case SimpleString, Error:
+		value.Str = payload
+	case Integer:
+		value.Int, err = strconv.ParseInt(payload, 10, 64)
+	case BulkString:
+		var size int
+		if size, err = strconv.Atoi(payload); err != nil || size < 0 {
+			value.Null = err == nil
+			break
+		}
+		if size > r.maxBulkLength {
+			return Value{}, fmt.Errorf("%w: bulk length %d exceeds %d", ErrProtocol, size, r.maxBulkLength)
+		}
+		var data bytes.Buffer
+		data.Grow(min(size+2, bufferedLength))
+		if _, err = io.CopyN(&data, r.reader, int64(size+2)); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			break
+		}
+		value.Str = string(data.Bytes()[:size])
+	case Array:
+		var size int
+		if size, err = strconv.Atoi(payload); err != nil || size < 0 {
+			value.Null = err == nil
+			break
+		}
+		if size > r.maxArrayLength {
+			return Value{}, fmt.Errorf("%w: array length %d exceeds %d", ErrProtocol, size, r.maxArrayLength)
+		}
+		value.Array = make([]Value, 0, min(size, bufferedElements))
+		for i := 0; i < size; i++ {
+			var element Value
+			if element, err = r.readValue(depth + 1); err != nil {
+				break
+			}
+			value.Array = append(value.Array, element)
+		}
+	default:
+		return Value{}, fmt.Errorf("%w: unexpected type %q", ErrProtocol, value.Type)
+	}
+
+	return value, err
+}
+
+// ReadCommand reads a client request, accepting both arrays of bulk strings
+// and inline commands as sent by telnet-style clients.
+func (r *Reader) ReadCommand() ([]string, error) {
+	first, err := r.reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if first[0] != Array {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		return splitInline(string(line)), nil
+	}
+
+	value, err := r.ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, len(value.Array))
+	for i, arg := range value.Array {
+		if arg.Type != BulkString {
+			return nil, ErrProtocol
+		}
+		args[i] = arg.Str
+	}
+
+	return args, nil
+}
+
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.reader.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, ErrProtocol
+	}
+
+	return line[:len(line)-2], nil
+}
+
+func (w *Writer) WriteCommand(args ...string) error {
+	return w.WriteCommands(args)
+}
+
+// WriteCommands writes commands back to back and flushes once, so that they
+// reach the server as one pipeline.
+func (w *Writer) WriteCommands(commands ...[]string) error {
+	for _, args := range commands {
+		w.WriteArrayHeader(len(args))
+		for _, arg := range args {
+			w.WriteBulkString(arg)
+		}
+	}
+
+	return w.Flush()
+}
+
+func (w *Writer) WriteSimpleString(s string) {
+	w.writeLine(SimpleString, s)
+}
+
+func (w *Writer) WriteError(s string) {
+	w.writeLine(Error, s)
+}
+
+func (w *Writer) WriteInteger(n int64) {
+	w.writeLine(Integer, strconv.FormatInt(n, 10))
+}
+
+func (w *Writer) WriteBulkString(s string) {
+	w.writeLine(BulkString, strconv.Itoa(len(s)))
+	w.writer.WriteString(s)
+	w.writer.WriteString("\r\n")
+}
+
+func (w *Writer) WriteNull() {
+	w.writeLine(BulkString, "-1")
+}
+
+func (w *Writer) WriteArrayHeader(size int) {
+	w.writeLine(Array, strconv.Itoa(size))
+}
+
+func (w *Writer) Flush() error {
+	return w.writer.Flush()
+}
+
+func (w *Writer) writeLine(prefix byte, s string) {
+	w.writer.WriteByte(prefix)
+	w.writer.WriteString(s)
+	w.writer.WriteString("\r\n")
+}
+
+func splitInline(line string) []string {
+	var args []string
+	start := -1
+	for i := 0; i < len(line); i++ {
+		if line[i] == ' ' || line[i] == '\t' {
+			if start >= 0 {
+				args = append(args, line[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		args = append(args, line[start:])
+	}
+
+	return args
+}
@@ -0,0 +1,187 @@
+package resp
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReader_ReadValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Value
+		wantErr bool
+	}{
+		{
+			name:  "Simple string",
+			input: "+OK\r\n",
+			want:  Value{Type: SimpleString, Str: "OK"},
+		},
+		{
+			name:  "Error",
+			input: "-ERR unknown\r\n",
+			want:  Value{Type: Error, Str: "ERR unknown"},
+		},
+		{
+			name:  "Integer",
+			input: ":42\r\n",
+			want:  Value{Type: Integer, Int: 42},
+		},
+		{
+			name:  "Bulk string",
+			input: "$5\r\nhe\r\no\r\n",
+			want:  Value{Type: BulkString, Str: "he\r\no"},
+		},
+		{
+			name:  "Null bulk string",
+			input: "$-1\r\n",
+			want:  Value{Type: BulkString, Null: true},
+		},
+		{
+			name:  "Array",
+			input: "*2\r\n$3\r\nGET\r\n:1\r\n",
+			want: Value{Type: Array, Array: []Value{
+				{Type: BulkString, Str: "GET"},
+				{Type: Integer, Int: 1},
+			}},
+		},
+		{
+			name:    "Unknown type",
+			input:   "?1\r\n",
+			wantErr: true,
+		},
+		{
+			name:    "Missing carriage return",
+			input:   "+OK\n",
+			wantErr: true,
+		},
+		{
+			name:    "Bulk string over the limit",
+			input:   "$9223372036854775807\r\n",
+			wantErr: true,
+		},
+		{
+			name:    "Truncated bulk string",
+			input:   "$1000000\r\nabc",
+			wantErr: true,
+		},
+		{
+			name:    "Array over the limit",
+			input:   "*9223372036854775807\r\n",
+			wantErr: true,
+		},
+		{
+			name:    "Arrays nested too deep",
+			input:   strings.Repeat("*1\r\n", 64) + ":1\r\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewReader(strings.NewReader(tt.input)).ReadValue()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadValue() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReader_SetLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name:  "Within the limits",
+			input: "*2\r\n$4\r\nabcd\r\n$0\r\n\r\n",
+		},
+		{
+			name:    "Bulk string over the limit",
+			input:   "*1\r\n$5\r\nabcde\r\n",
+			wantErr: true,
+		},
+		{
+			name:    "Array over the limit",
+			input:   "*3\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewReader(strings.NewReader(tt.input))
+			reader.SetLimits(4, 2)
+
+			_, err := reader.ReadValue()
+			if tt.wantErr != errors.Is(err, ErrProtocol) {
+				t.Errorf("ReadValue() error = %v, want protocol error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReader_ReadCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "Array command",
+			input: "*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$5\r\nvalue\r\n",
+			want:  []string{"SET", "key", "value"},
+		},
+		{
+			name:  "Inline command",
+			input: "GET  key\r\n",
+			want:  []string{"GET", "key"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewReader(strings.NewReader(tt.input)).ReadCommand()
+			if err != nil {
+				t.Fatalf("ReadCommand() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := NewWriter(&buffer)
+	writer.WriteSimpleString("OK")
+	writer.WriteError("ERR failed")
+	writer.WriteInteger(7)
+	writer.WriteNull()
+	if err := writer.WriteCommand("GET", "key"); err != nil {
+		t.Fatalf("WriteCommand() error: %v", err)
+	}
+
+	want := "+OK\r\n-ERR failed\r\n:7\r\n$-1\r\n*2\r\n$3\r\nGET\r\n$3\r\nkey\r\n"
+	if buffer.String() != want {
+		t.Errorf("written = %q, want %q", buffer.String(), want)
+	}
+}
+
+func TestWriter_WriteCommands(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := NewWriter(&buffer)
+	if err := writer.WriteCommands([]string{"GET", "a"}, []string{"DEL", "b"}); err != nil {
+		t.Fatalf("WriteCommands() error: %v", err)
+	}
+
+	want := "*2\r\n$3\r\nGET\r\n$1\r\na\r\n*2\r\n$3\r\nDEL\r\n$1\r\nb\r\n"
+	if buffer.String() != want {
+		t.Errorf("written = %q, want %q", buffer.String(), want)
+	}
+}
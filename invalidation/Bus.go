@@ -0,0 +1,119 @@
+package invalidation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+// Transport broadcasts invalidation messages between instances.
+type Transport interface {
+	Publish(ctx context.Context, payload []byte) error
+	// Subscribe delivers every published payload, including those published
+	// by this instance, to handler until ctx is done.
+	Subscribe(ctx context.Context, handler func(payload []byte)) error
+}
+
+type invalidatingCache struct {
+	ctx            context.Context
+	local          cache.Cache
+	transport      Transport
+	origin         string
+	publishTimeout time.Duration
+	onError        func(err error)
+}
+
+// New wraps local so that every Set and Delete is broadcast over transport and
+// keys changed by other instances are dropped from local. Subscribing stops
+// when ctx is done.
+func New(ctx context.Context, local cache.Cache, transport Transport, options ...func(*invalidatingCache)) cache.Cache {
+	c := &invalidatingCache{
+		ctx:            ctx,
+		local:          local,
+		transport:      transport,
+		origin:         newOrigin(),
+		publishTimeout: time.Second,
+		onError:        func(error) {},
+	}
+	for _, optionFn := range options {
+		optionFn(c)
+	}
+
+	go func() {
+		if err := c.transport.Subscribe(ctx, c.receive); err != nil && ctx.Err() == nil {
+			c.onError(err)
+		}
+	}()
+
+	return c
+}
+
+func WithPublishTimeout(timeout time.Duration) func(*invalidatingCache) {
+	return func(c *invalidatingCache) {
+		c.publishTimeout = timeout
+	}
+}
+
+// WithErrorHandler receives publish and subscribe failures. Failed publishes
+// are not retried, so other instances may keep a stale copy until it expires.
+func WithErrorHandler(handler func(err error)) func(*invalidatingCache) {
+	return func(c *invalidatingCache) {
+		c.onError = handler
+	}
+}
+
+func (c *invalidatingCache) Get(key string) (interface{}, bool) {
+	return c.local.Get(key)
+}
+
+func (c *invalidatingCache) Set(key string, value interface{}, expiredInterval time.Duration) {
+	c.local.Set(key, value, expiredInterval)
+	c.publish(key)
+}
+
+func (c *invalidatingCache) Delete(key string) {
+	c.local.Delete(key)
+	c.publish(key)
+}
+
+func (c *invalidatingCache) publish(key string) {
+	ctx, cancel := context.WithTimeout(c.ctx, c.publishTimeout)
+	defer cancel()
+
+	if err := c.transport.Publish(ctx, encodeMessage(c.origin, key)); err != nil {
+		c.onError(err)
+	}
+}
+
+func (c *invalidatingCache) receive(payload []byte) {
+	origin, key, ok := decodeMessage(payload)
+	if !ok || origin == c.origin {
+		return
+	}
+	c.local.Delete(key)
+}
+
+func newOrigin() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+func encodeMessage(origin, key string) []byte {
+	return []byte(origin + " " + key)
+}
+
+func decodeMessage(payload []byte) (origin, key string, ok bool) {
+	message := string(payload)
+	separator := strings.IndexByte(message, ' ')
+	if separator < 0 {
+		return "", "", false
+	}
+
+	return message[:separator], message[separator+1:], true
+}
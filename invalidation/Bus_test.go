@@ -0,0 +1,151 @@
+package invalidation
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+type hub struct {
+	mu          sync.Mutex
+	subscribers []chan []byte
+	err         error
+}
+
+type hubTransport struct {
+	hub *hub
+}
+
+func (h *hub) transport() Transport {
+	return hubTransport{hub: h}
+}
+
+func (t hubTransport) Publish(ctx context.Context, payload []byte) error {
+	t.hub.mu.Lock()
+	defer t.hub.mu.Unlock()
+
+	if t.hub.err != nil {
+		return t.hub.err
+	}
+	for _, subscriber := range t.hub.subscribers {
+		subscriber <- payload
+	}
+
+	return nil
+}
+
+func (t hubTransport) Subscribe(ctx context.Context, handler func(payload []byte)) error {
+	messages := make(chan []byte, 16)
+	t.hub.mu.Lock()
+	t.hub.subscribers = append(t.hub.subscribers, messages)
+	t.hub.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case payload := <-messages:
+			handler(payload)
+		}
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within a second")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c cache.Cache)
+	}{
+		{
+			name:   "Overwrite invalidates other instances",
+			change: func(c cache.Cache) { c.Set("test", 2, time.Minute) },
+		},
+		{
+			name:   "Delete invalidates other instances",
+			change: func(c cache.Cache) { c.Delete("test") },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			h := &hub{}
+			firstLocal := cache.NewInMemoryCache(ctx)
+			secondLocal := cache.NewInMemoryCache(ctx)
+			first := New(ctx, firstLocal, h.transport())
+			second := New(ctx, secondLocal, h.transport())
+			waitFor(t, func() bool {
+				h.mu.Lock()
+				defer h.mu.Unlock()
+				return len(h.subscribers) == 2
+			})
+
+			secondLocal.Set("test", 1, time.Minute)
+			tt.change(first)
+
+			waitFor(t, func() bool {
+				_, found := second.Get("test")
+				return !found
+			})
+			if value, found := first.Get("test"); tt.name == "Overwrite invalidates other instances" && (!found || value != 2) {
+				t.Errorf("own Set() was invalidated: Get() = %v, %v", value, found)
+			}
+		})
+	}
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errPublish := errors.New("publish failed")
+	h := &hub{err: errPublish}
+	var handledErr error
+	c := New(ctx, cache.NewInMemoryCache(ctx), h.transport(), WithErrorHandler(func(err error) { handledErr = err }))
+
+	c.Set("test", 1, time.Minute)
+	if !errors.Is(handledErr, errPublish) {
+		t.Errorf("handled error = %v, want %v", handledErr, errPublish)
+	}
+	if value, found := c.Get("test"); !found || value != 1 {
+		t.Errorf("Get() = %v, %v, want the local write to succeed", value, found)
+	}
+}
+
+func Test_decodeMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    string
+		wantOrigin string
+		wantKey    string
+		wantOK     bool
+	}{
+		{name: "Key with spaces", payload: "abc key with spaces", wantOrigin: "abc", wantKey: "key with spaces", wantOK: true},
+		{name: "Empty key", payload: "abc ", wantOrigin: "abc", wantOK: true},
+		{name: "Malformed", payload: "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origin, key, ok := decodeMessage([]byte(tt.payload))
+			if origin != tt.wantOrigin || key != tt.wantKey || ok != tt.wantOK {
+				t.Errorf("decodeMessage() = %q, %q, %v, want %q, %q, %v",
+					origin, key, ok, tt.wantOrigin, tt.wantKey, tt.wantOK)
+			}
+		})
+	}
+}
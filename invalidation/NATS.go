@@ -0,0 +1,278 @@
+package invalidation
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultNATSAddress = "localhost:4222"
+
+var ErrNATS = errors.New("invalidation: nats error")
+
+type natsTransport struct {
+	subject     string
+	address     string
+	username    string
+	password    string
+	token       string
+	dialTimeout time.Duration
+	retryDelay  time.Duration
+	mu          sync.Mutex
+	publisher   *natsConn
+}
+
+type natsConn struct {
+	netConn net.Conn
+	buffer  *bufio.ReadWriter
+}
+
+type natsConnectOptions struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Name     string `json:"name"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+	Token    string `json:"auth_token,omitempty"`
+}
+
+// NewNATSTransport broadcasts over a NATS subject using core NATS, which like
+// Redis pub/sub drops messages published while a subscriber is disconnected.
+func NewNATSTransport(subject string, options ...func(*natsTransport)) Transport {
+	t := &natsTransport{
+		subject:     subject,
+		address:     defaultNATSAddress,
+		dialTimeout: defaultDialTimeout,
+		retryDelay:  defaultRetryDelay,
+	}
+	for _, optionFn := range options {
+		optionFn(t)
+	}
+
+	return t
+}
+
+func WithNATSAddress(address string) func(*natsTransport) {
+	return func(t *natsTransport) {
+		t.address = address
+	}
+}
+
+func WithNATSCredentials(username, password string) func(*natsTransport) {
+	return func(t *natsTransport) {
+		t.username = username
+		t.password = password
+	}
+}
+
+func WithNATSToken(token string) func(*natsTransport) {
+	return func(t *natsTransport) {
+		t.token = token
+	}
+}
+
+func WithNATSDialTimeout(timeout time.Duration) func(*natsTransport) {
+	return func(t *natsTransport) {
+		t.dialTimeout = timeout
+	}
+}
+
+func WithNATSRetryDelay(delay time.Duration) func(*natsTransport) {
+	return func(t *natsTransport) {
+		t.retryDelay = delay
+	}
+}
+
+// Publish waits for the server to acknowledge a PING sent after the message,
+// which confirms the server processed it and answers any pending server PING.
+func (t *natsTransport) Publish(ctx context.Context, payload []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.publisher == nil {
+		cn, err := t.dial(ctx)
+		if err != nil {
+			return err
+		}
+		t.publisher = cn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = t.publisher.netConn.SetDeadline(deadline)
+	} else {
+		_ = t.publisher.netConn.SetDeadline(time.Time{})
+	}
+	fmt.Fprintf(t.publisher.buffer, "PUB %s %d\r\n", t.subject, len(payload))
+	t.publisher.buffer.Write(payload)
+	t.publisher.buffer.WriteString("\r\nPING\r\n")
+	err := t.publisher.buffer.Flush()
+	if err == nil {
+		err = t.publisher.waitForPong()
+	}
+	if err != nil {
+		t.publisher.netConn.Close()
+		t.publisher = nil
+	}
+
+	return err
+}
+
+func (t *natsTransport) Subscribe(ctx context.Context, handler func(payload []byte)) error {
+	for {
+		_ = t.subscribe(ctx, handler)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(t.retryDelay):
+		}
+	}
+}
+
+func (t *natsTransport) subscribe(ctx context.Context, handler func(payload []byte)) error {
+	cn, err := t.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer cn.netConn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			cn.netConn.Close()
+		case <-done:
+		}
+	}()
+
+	fmt.Fprintf(cn.buffer, "SUB %s 1\r\n", t.subject)
+	if err := cn.buffer.Flush(); err != nil {
+		return err
+	}
+	for {
+		line, err := cn.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case strings.HasPrefix(line, "MSG "):
+			payload, err := cn.readPayload(line)
+			if err != nil {
+				return err
+			}
+			handler(payload)
+		case line == "PING":
+			cn.buffer.WriteString("PONG\r\n")
+			if err := cn.buffer.Flush(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("%w: %s", ErrNATS, line)
+		}
+	}
+}
+
+func (t *natsTransport) dial(ctx context.Context) (*natsConn, error) {
+	dialer := net.Dialer{Timeout: t.dialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", t.address)
+	if err != nil {
+		return nil, err
+	}
+	cn := &natsConn{
+		netConn: netConn,
+		buffer:  bufio.NewReadWriter(bufio.NewReader(netConn), bufio.NewWriter(netConn)),
+	}
+	_ = netConn.SetDeadline(time.Now().Add(t.dialTimeout))
+
+	err = cn.handshake(natsConnectOptions{
+		Name:  "go-sim-cache",
+		User:  t.username,
+		Pass:  t.password,
+		Token: t.token,
+	})
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	_ = netConn.SetDeadline(time.Time{})
+
+	return cn, nil
+}
+
+func (cn *natsConn) handshake(options natsConnectOptions) error {
+	line, err := cn.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("%w: unexpected greeting %q", ErrNATS, line)
+	}
+
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cn.buffer, "CONNECT %s\r\nPING\r\n", connect)
+	if err := cn.buffer.Flush(); err != nil {
+		return err
+	}
+
+	return cn.waitForPong()
+}
+
+func (cn *natsConn) waitForPong() error {
+	for {
+		line, err := cn.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			cn.buffer.WriteString("PONG\r\n")
+			if err := cn.buffer.Flush(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("%w: %s", ErrNATS, line)
+		}
+	}
+}
+
+// readPayload reads the body of a "MSG <subject> <sid> [reply-to] <bytes>"
+// frame.
+func (cn *natsConn) readPayload(header string) ([]byte, error) {
+	fields := strings.Fields(header)
+	size, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || len(fields) < 4 {
+		return nil, fmt.Errorf("%w: malformed message header %q", ErrNATS, header)
+	}
+
+	payload := make([]byte, size+2)
+	if _, err := io.ReadFull(cn.buffer, payload); err != nil {
+		return nil, err
+	}
+
+	return payload[:size], nil
+}
+
+func (cn *natsConn) readLine() (string, error) {
+	line, err := cn.buffer.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,149 @@
+package invalidation
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeNATS struct {
+	listener    net.Listener
+	token       string
+	mu          sync.Mutex
+	subscribers []*bufio.Writer
+}
+
+func newFakeNATS(t *testing.T, token string) *fakeNATS {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	server := &fakeNATS{listener: listener, token: token}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.handle(netConn)
+		}
+	}()
+
+	return server
+}
+
+func (s *fakeNATS) handle(netConn net.Conn) {
+	defer netConn.Close()
+	reader := bufio.NewReader(netConn)
+	writer := bufio.NewWriter(netConn)
+	writer.WriteString("INFO {\"server_id\":\"fake\"}\r\n")
+	writer.Flush()
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		fields := strings.Fields(line)
+
+		s.mu.Lock()
+		switch fields[0] {
+		case "CONNECT":
+			if s.token != "" && !strings.Contains(line, `"auth_token":"`+s.token+`"`) {
+				writer.WriteString("-ERR 'Authorization Violation'\r\n")
+			}
+		case "PING":
+			writer.WriteString("PONG\r\n")
+		case "SUB":
+			s.subscribers = append(s.subscribers, writer)
+			// Exercise the client's keepalive handling.
+			writer.WriteString("PING\r\n")
+		case "PUB":
+			size, _ := strconv.Atoi(fields[2])
+			payload := make([]byte, size+2)
+			io.ReadFull(reader, payload)
+			for _, subscriber := range s.subscribers {
+				fmt.Fprintf(subscriber, "MSG %s 1 %d\r\n%s\r\n", fields[1], size, payload[:size])
+				subscriber.Flush()
+			}
+		}
+		writer.Flush()
+		s.mu.Unlock()
+	}
+}
+
+func (s *fakeNATS) subscriberCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.subscribers)
+}
+
+func TestNewNATSTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := newFakeNATS(t, "")
+	transport := NewNATSTransport("invalidations", WithNATSAddress(server.listener.Addr().String()))
+
+	received := make(chan string, 1)
+	subscribed := make(chan error, 1)
+	go func() {
+		subscribed <- transport.Subscribe(ctx, func(payload []byte) { received <- string(payload) })
+	}()
+	waitFor(t, func() bool { return server.subscriberCount() == 1 })
+
+	if err := transport.Publish(ctx, []byte("origin key with\r\nnewline")); err != nil {
+		t.Fatalf("Publish() error: %v", err)
+	}
+	select {
+	case payload := <-received:
+		if payload != "origin key with\r\nnewline" {
+			t.Errorf("received %q, want %q", payload, "origin key with\r\nnewline")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no message received")
+	}
+
+	cancel()
+	select {
+	case <-subscribed:
+	case <-time.After(time.Second):
+		t.Errorf("Subscribe() did not return after the context was cancelled")
+	}
+}
+
+func TestWithNATSToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "Valid token", token: "secret"},
+		{name: "Invalid token", token: "wrong", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeNATS(t, "secret")
+			transport := NewNATSTransport("invalidations",
+				WithNATSAddress(server.listener.Addr().String()),
+				WithNATSToken(tt.token),
+			)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := transport.Publish(ctx, []byte("payload")); (err != nil) != tt.wantErr {
+				t.Errorf("Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
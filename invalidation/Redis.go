@@ -0,0 +1,182 @@
+package invalidation
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/abicur/go-sim-cache/internal/resp"
+)
+
+const (
+	defaultRedisAddress = "localhost:6379"
+	defaultDialTimeout  = time.Second * 5
+	defaultRetryDelay   = time.Second
+)
+
+type redisTransport struct {
+	channel     string
+	address     string
+	username    string
+	password    string
+	dialTimeout time.Duration
+	retryDelay  time.Duration
+	mu          sync.Mutex
+	publisher   *redisConn
+}
+
+type redisConn struct {
+	netConn net.Conn
+	reader  *resp.Reader
+	writer  *resp.Writer
+}
+
+// NewRedisTransport broadcasts over the Redis pub/sub channel. The subscriber
+// reconnects after failures; messages published while it is disconnected are
+// lost, as Redis pub/sub does not buffer them.
+func NewRedisTransport(channel string, options ...func(*redisTransport)) Transport {
+	t := &redisTransport{
+		channel:     channel,
+		address:     defaultRedisAddress,
+		dialTimeout: defaultDialTimeout,
+		retryDelay:  defaultRetryDelay,
+	}
+	for _, optionFn := range options {
+		optionFn(t)
+	}
+
+	return t
+}
+
+func WithRedisAddress(address string) func(*redisTransport) {
+	return func(t *redisTransport) {
+		t.address = address
+	}
+}
+
+func WithRedisCredentials(username, password string) func(*redisTransport) {
+	return func(t *redisTransport) {
+		t.username = username
+		t.password = password
+	}
+}
+
+func WithRedisDialTimeout(timeout time.Duration) func(*redisTransport) {
+	return func(t *redisTransport) {
+		t.dialTimeout = timeout
+	}
+}
+
+func WithRedisRetryDelay(delay time.Duration) func(*redisTransport) {
+	return func(t *redisTransport) {
+		t.retryDelay = delay
+	}
+}
+
+func (t *redisTransport) Publish(ctx context.Context, payload []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.publisher == nil {
+		cn, err := t.dial(ctx)
+		if err != nil {
+			return err
+		}
+		t.publisher = cn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = t.publisher.netConn.SetDeadline(deadline)
+	} else {
+		_ = t.publisher.netConn.SetDeadline(time.Time{})
+	}
+	reply, err := t.publisher.roundTrip("PUBLISH", t.channel, string(payload))
+	if err != nil {
+		t.publisher.netConn.Close()
+		t.publisher = nil
+		return err
+	}
+
+	return reply.Err()
+}
+
+func (t *redisTransport) Subscribe(ctx context.Context, handler func(payload []byte)) error {
+	for {
+		_ = t.subscribe(ctx, handler)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(t.retryDelay):
+		}
+	}
+}
+
+func (t *redisTransport) subscribe(ctx context.Context, handler func(payload []byte)) error {
+	cn, err := t.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer cn.netConn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			cn.netConn.Close()
+		case <-done:
+		}
+	}()
+
+	if err := cn.writer.WriteCommand("SUBSCRIBE", t.channel); err != nil {
+		return err
+	}
+	for {
+		reply, err := cn.reader.ReadValue()
+		if err != nil {
+			return err
+		}
+		if err := reply.Err(); err != nil {
+			return err
+		}
+		if len(reply.Array) == 3 && reply.Array[0].Str == "message" {
+			handler([]byte(reply.Array[2].Str))
+		}
+	}
+}
+
+func (t *redisTransport) dial(ctx context.Context) (*redisConn, error) {
+	dialer := net.Dialer{Timeout: t.dialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", t.address)
+	if err != nil {
+		return nil, err
+	}
+	cn := &redisConn{netConn: netConn, reader: resp.NewReader(netConn), writer: resp.NewWriter(netConn)}
+
+	if t.password != "" {
+		args := []string{"AUTH", t.password}
+		if t.username != "" {
+			args = []string{"AUTH", t.username, t.password}
+		}
+		reply, err := cn.roundTrip(args...)
+		if err == nil {
+			err = reply.Err()
+		}
+		if err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+
+	return cn, nil
+}
+
+func (cn *redisConn) roundTrip(args ...string) (resp.Value, error) {
+	if err := cn.writer.WriteCommand(args...); err != nil {
+		return resp.Value{}, err
+	}
+
+	return cn.reader.ReadValue()
+}
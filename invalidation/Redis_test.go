@@ -0,0 +1,114 @@
+package invalidation
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abicur/go-sim-cache/internal/resp"
+)
+
+type fakeRedis struct {
+	listener    net.Listener
+	mu          sync.Mutex
+	subscribers []*resp.Writer
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	server := &fakeRedis{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.handle(netConn)
+		}
+	}()
+
+	return server
+}
+
+func (s *fakeRedis) handle(netConn net.Conn) {
+	defer netConn.Close()
+	reader := resp.NewReader(netConn)
+	writer := resp.NewWriter(netConn)
+	for {
+		args, err := reader.ReadCommand()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		switch strings.ToUpper(args[0]) {
+		case "SUBSCRIBE":
+			s.subscribers = append(s.subscribers, writer)
+			writer.WriteArrayHeader(3)
+			writer.WriteBulkString("subscribe")
+			writer.WriteBulkString(args[1])
+			writer.WriteInteger(1)
+		case "PUBLISH":
+			for _, subscriber := range s.subscribers {
+				subscriber.WriteArrayHeader(3)
+				subscriber.WriteBulkString("message")
+				subscriber.WriteBulkString(args[1])
+				subscriber.WriteBulkString(args[2])
+				subscriber.Flush()
+			}
+			writer.WriteInteger(int64(len(s.subscribers)))
+		default:
+			writer.WriteError("ERR unknown command")
+		}
+		writer.Flush()
+		s.mu.Unlock()
+	}
+}
+
+func (s *fakeRedis) subscriberCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.subscribers)
+}
+
+func TestNewRedisTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := newFakeRedis(t)
+	transport := NewRedisTransport("invalidations", WithRedisAddress(server.listener.Addr().String()))
+
+	received := make(chan string, 1)
+	subscribed := make(chan error, 1)
+	go func() {
+		subscribed <- transport.Subscribe(ctx, func(payload []byte) { received <- string(payload) })
+	}()
+	waitFor(t, func() bool { return server.subscriberCount() == 1 })
+
+	if err := transport.Publish(ctx, []byte("origin key")); err != nil {
+		t.Fatalf("Publish() error: %v", err)
+	}
+	select {
+	case payload := <-received:
+		if payload != "origin key" {
+			t.Errorf("received %q, want %q", payload, "origin key")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no message received")
+	}
+
+	cancel()
+	select {
+	case <-subscribed:
+	case <-time.After(time.Second):
+		t.Errorf("Subscribe() did not return after the context was cancelled")
+	}
+}
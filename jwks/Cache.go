@@ -0,0 +1,287 @@
+package jwks
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+const (
+	defaultTTL         = time.Hour
+	defaultStaleWindow = time.Hour * 24
+	minRefetchInterval = time.Minute
+	// Documents are refreshed in the background once this share of their
+	// lifetime has passed.
+	refreshAfter = 0.8
+)
+
+var ErrKeyNotFound = errors.New("jwks: key not found")
+
+// Cache fetches and caches the JSON Web Key Sets of issuers.
+type Cache interface {
+	// Keys returns the key set of issuer.
+	Keys(ctx context.Context, issuer string) (*Set, error)
+	// Key returns the key of issuer with ID kid. Unknown IDs fetch the key
+	// set again, at most once a minute, to pick up rotated keys.
+	Key(ctx context.Context, issuer, kid string) (Key, error)
+}
+
+// document is a cached key set, fresh until ExpiresAt.
+type document struct {
+	Set       Set
+	FetchedAt time.Time
+	ExpiresAt time.Time
+}
+
+func init() {
+	gob.Register(document{})
+}
+
+type fetch struct {
+	done chan struct{}
+	doc  document
+	err  error
+}
+
+type jwksCache struct {
+	cache       cache.Cache
+	client      *http.Client
+	ttl         time.Duration
+	staleWindow time.Duration
+	discovery   bool
+	onError     func(issuer string, err error)
+	mu          sync.Mutex
+	fetches     map[string]*fetch
+}
+
+// New returns a Cache keeping key sets in c. The key set of an issuer is
+// found through its OpenID Connect discovery document and is fresh for the
+// Cache-Control max-age of the key set response, or else for an hour. Key
+// sets are refreshed in the background before they expire, and stale ones
+// are served for a day while fetches fail. Concurrent fetches of an issuer
+// share one request.
+func New(c cache.Cache, options ...func(*jwksCache)) Cache {
+	j := &jwksCache{
+		cache:       c,
+		client:      http.DefaultClient,
+		ttl:         defaultTTL,
+		staleWindow: defaultStaleWindow,
+		discovery:   true,
+		onError:     func(string, error) {},
+		fetches:     make(map[string]*fetch),
+	}
+	for _, optionFn := range options {
+		optionFn(j)
+	}
+
+	return j
+}
+
+// WithHTTPClient sets the client fetching documents, http.DefaultClient by
+// default.
+func WithHTTPClient(client *http.Client) func(*jwksCache) {
+	return func(j *jwksCache) {
+		j.client = client
+	}
+}
+
+// WithTTL sets how long key sets without a Cache-Control max-age stay fresh.
+func WithTTL(ttl time.Duration) func(*jwksCache) {
+	return func(j *jwksCache) {
+		j.ttl = ttl
+	}
+}
+
+// WithStaleWindow sets how long after they expire key sets are served while
+// fetches fail.
+func WithStaleWindow(window time.Duration) func(*jwksCache) {
+	return func(j *jwksCache) {
+		j.staleWindow = window
+	}
+}
+
+// WithoutDiscovery fetches key sets from the issuer URL itself, for callers
+// that pass JWKS URLs.
+func WithoutDiscovery() func(*jwksCache) {
+	return func(j *jwksCache) {
+		j.discovery = false
+	}
+}
+
+// WithErrorHandler receives the errors of background refreshes and of fetches
+// answered with a stale key set.
+func WithErrorHandler(handler func(issuer string, err error)) func(*jwksCache) {
+	return func(j *jwksCache) {
+		j.onError = handler
+	}
+}
+
+func (j *jwksCache) Keys(ctx context.Context, issuer string) (*Set, error) {
+	doc, found := j.cached(issuer)
+	now := time.Now()
+	if found && now.Before(doc.ExpiresAt) {
+		if now.After(doc.refreshAt()) {
+			go j.refreshInBackground(issuer)
+		}
+		return &doc.Set, nil
+	}
+
+	fetched, err := j.refresh(ctx, issuer)
+	if err != nil {
+		if found {
+			j.onError(issuer, err)
+			return &doc.Set, nil
+		}
+		return nil, err
+	}
+
+	return &fetched.Set, nil
+}
+
+func (j *jwksCache) Key(ctx context.Context, issuer, kid string) (Key, error) {
+	set, err := j.Keys(ctx, issuer)
+	if err != nil {
+		return Key{}, err
+	}
+	if key, found := set.Key(kid); found {
+		return key, nil
+	}
+
+	if doc, found := j.cached(issuer); found && time.Since(doc.FetchedAt) < minRefetchInterval {
+		return Key{}, ErrKeyNotFound
+	}
+	fetched, err := j.refresh(ctx, issuer)
+	if err != nil {
+		return Key{}, err
+	}
+	if key, found := fetched.Set.Key(kid); found {
+		return key, nil
+	}
+
+	return Key{}, ErrKeyNotFound
+}
+
+func (j *jwksCache) cached(issuer string) (document, bool) {
+	value, found := j.cache.Get(cacheKey(issuer))
+	if !found {
+		return document{}, false
+	}
+	doc, ok := value.(document)
+
+	return doc, ok
+}
+
+func (j *jwksCache) refreshInBackground(issuer string) {
+	if _, err := j.refresh(context.Background(), issuer); err != nil {
+		j.onError(issuer, err)
+	}
+}
+
+// refresh fetches the key set of issuer and caches it. Concurrent refreshes
+// of an issuer share the fetch of the first, which runs with its context.
+func (j *jwksCache) refresh(ctx context.Context, issuer string) (document, error) {
+	j.mu.Lock()
+	if f, found := j.fetches[issuer]; found {
+		j.mu.Unlock()
+		<-f.done
+		return f.doc, f.err
+	}
+	f := &fetch{done: make(chan struct{})}
+	j.fetches[issuer] = f
+	j.mu.Unlock()
+
+	f.doc, f.err = j.fetch(ctx, issuer)
+	if f.err == nil {
+		j.cache.Set(cacheKey(issuer), f.doc, f.doc.ExpiresAt.Sub(f.doc.FetchedAt)+j.staleWindow)
+	}
+
+	j.mu.Lock()
+	delete(j.fetches, issuer)
+	j.mu.Unlock()
+	close(f.done)
+
+	return f.doc, f.err
+}
+
+func (j *jwksCache) fetch(ctx context.Context, issuer string) (document, error) {
+	jwksURL := issuer
+	if j.discovery {
+		var configuration struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if _, err := j.get(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &configuration); err != nil {
+			return document{}, err
+		}
+		if configuration.JWKSURI == "" {
+			return document{}, fmt.Errorf("jwks: discovery document of %s has no jwks_uri", issuer)
+		}
+		jwksURL = configuration.JWKSURI
+	}
+
+	doc := document{FetchedAt: time.Now()}
+	header, err := j.get(ctx, jwksURL, &doc.Set)
+	if err != nil {
+		return document{}, err
+	}
+	ttl := j.ttl
+	if maxAge, found := maxAge(header); found {
+		ttl = maxAge
+	}
+	doc.ExpiresAt = doc.FetchedAt.Add(ttl)
+
+	return doc, nil
+}
+
+func (j *jwksCache) get(ctx context.Context, url string, target interface{}) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: GET %s: %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return nil, fmt.Errorf("jwks: GET %s: %w", url, err)
+	}
+
+	return resp.Header, nil
+}
+
+func (d document) refreshAt() time.Time {
+	return d.FetchedAt.Add(time.Duration(float64(d.ExpiresAt.Sub(d.FetchedAt)) * refreshAfter))
+}
+
+func maxAge(header http.Header) (time.Duration, bool) {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if strings.EqualFold(name, "max-age") {
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || seconds < 0 {
+				return 0, false
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	return 0, false
+}
+
+func cacheKey(issuer string) string {
+	return "jwks:" + issuer
+}
@@ -0,0 +1,217 @@
+package jwks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+// provider serves a discovery document and a key set holding the key IDs in
+// kids, or fails while failing is set.
+type provider struct {
+	mu           sync.Mutex
+	kids         []string
+	cacheControl string
+	failing      bool
+	fetches      int
+}
+
+func (p *provider) set(update func(p *provider)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	update(p)
+}
+
+func (p *provider) Fetches() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.fetches
+}
+
+func newProvider(t *testing.T, kids ...string) (*provider, string) {
+	t.Helper()
+
+	p := &provider{kids: kids}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"jwks_uri": server.URL + "/keys"})
+		case "/keys":
+			p.fetches++
+			if p.failing {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			if p.cacheControl != "" {
+				w.Header().Set("Cache-Control", p.cacheControl)
+			}
+			set := Set{}
+			for _, kid := range p.kids {
+				set.Keys = append(set.Keys, Key{KeyID: kid, KeyType: "RSA"})
+			}
+			json.NewEncoder(w).Encode(set)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return p, server.URL
+}
+
+func newTestCache(t *testing.T) cache.Cache {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return cache.NewInMemoryCache(ctx)
+}
+
+func TestCache_Keys(t *testing.T) {
+	p, issuer := newProvider(t, "a")
+	keys := New(newTestCache(t))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if set, err := keys.Keys(context.Background(), issuer); err != nil || len(set.Keys) != 1 {
+				t.Errorf("Keys() = %v, %v, want one key", set, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := p.Fetches(); got != 1 {
+		t.Errorf("provider served %d key sets, want 1", got)
+	}
+}
+
+func TestCache_Refresh(t *testing.T) {
+	p, issuer := newProvider(t, "a")
+	p.set(func(p *provider) { p.cacheControl = "max-age=1" })
+	keys := New(newTestCache(t), WithErrorHandler(func(issuer string, err error) {
+		t.Errorf("refresh error: %v", err)
+	}))
+
+	keys.Keys(context.Background(), issuer)
+	p.set(func(p *provider) { p.kids = []string{"b"} })
+
+	// Past 80% of the lifetime, the old set is served while a refresh runs.
+	time.Sleep(time.Millisecond * 850)
+	set, err := keys.Keys(context.Background(), issuer)
+	if err != nil || set.Keys[0].KeyID != "a" {
+		t.Fatalf("Keys() before expiry = %v, %v, want key a", set, err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		set, err := keys.Keys(context.Background(), issuer)
+		if err == nil && set.Keys[0].KeyID == "b" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Keys() after refresh = %v, %v, want key b", set, err)
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+}
+
+func TestCache_StaleFallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		staleWindow time.Duration
+		wantErr     bool
+	}{
+		{
+			name:        "Within stale window",
+			staleWindow: time.Minute,
+		},
+		{
+			name:    "Without stale window",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, issuer := newProvider(t, "a")
+			var reported []error
+			keys := New(newTestCache(t), WithTTL(time.Millisecond*20), WithStaleWindow(tt.staleWindow),
+				WithErrorHandler(func(issuer string, err error) {
+					reported = append(reported, err)
+				}))
+
+			keys.Keys(context.Background(), issuer)
+			p.set(func(p *provider) { p.failing = true })
+			time.Sleep(time.Millisecond * 40)
+
+			set, err := keys.Keys(context.Background(), issuer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Keys() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (len(set.Keys) != 1 || len(reported) != 1) {
+				t.Errorf("Keys() = %v with %d reported errors, want the stale key and 1 error", set, len(reported))
+			}
+		})
+	}
+}
+
+func TestCache_Key(t *testing.T) {
+	p, issuer := newProvider(t, "a")
+	keys := New(newTestCache(t))
+
+	if key, err := keys.Key(context.Background(), issuer, "a"); err != nil || key.KeyID != "a" {
+		t.Fatalf("Key(a) = %v, %v, want key a", key, err)
+	}
+
+	// An unknown ID right after a fetch does not fetch again.
+	p.set(func(p *provider) { p.kids = []string{"a", "b"} })
+	if _, err := keys.Key(context.Background(), issuer, "b"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Key(b) error = %v, want %v", err, ErrKeyNotFound)
+	}
+	if got := p.Fetches(); got != 1 {
+		t.Errorf("provider served %d key sets, want 1", got)
+	}
+}
+
+func TestCache_KeyRotation(t *testing.T) {
+	p, issuer := newProvider(t, "a")
+	c := newTestCache(t)
+	keys := New(c)
+
+	keys.Keys(context.Background(), issuer)
+	p.set(func(p *provider) { p.kids = []string{"b"} })
+
+	// Age the cached set past the refetch interval.
+	doc, _ := c.Get(cacheKey(issuer))
+	aged := doc.(document)
+	aged.FetchedAt = aged.FetchedAt.Add(-minRefetchInterval)
+	c.Set(cacheKey(issuer), aged, time.Hour)
+
+	if key, err := keys.Key(context.Background(), issuer, "b"); err != nil || key.KeyID != "b" {
+		t.Errorf("Key(b) after rotation = %v, %v, want key b", key, err)
+	}
+}
+
+func TestWithoutDiscovery(t *testing.T) {
+	_, issuer := newProvider(t, "a")
+	keys := New(newTestCache(t), WithoutDiscovery())
+
+	if set, err := keys.Keys(context.Background(), issuer+"/keys"); err != nil || len(set.Keys) != 1 {
+		t.Errorf("Keys() = %v, %v, want one key", set, err)
+	}
+}
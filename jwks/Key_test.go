@@ -0,0 +1,93 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestKey_PublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error: %v", err)
+	}
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		key     Key
+		want    interface{ Equal(x crypto.PublicKey) bool }
+		wantErr error
+	}{
+		{
+			name: "RSA",
+			key:  Key{KeyType: "RSA", N: encode(rsaKey.N.Bytes()), E: encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+			want: &rsaKey.PublicKey,
+		},
+		{
+			name: "EC",
+			key:  Key{KeyType: "EC", Curve: "P-256", X: encode(ecKey.X.Bytes()), Y: encode(ecKey.Y.Bytes())},
+			want: &ecKey.PublicKey,
+		},
+		{
+			name: "Ed25519",
+			key:  Key{KeyType: "OKP", Curve: "Ed25519", X: encode(edKey)},
+			want: edKey,
+		},
+		{
+			name:    "Point off the curve",
+			key:     Key{KeyType: "EC", Curve: "P-256", X: encode(ecKey.X.Bytes()), Y: encode(ecKey.X.Bytes())},
+			wantErr: ErrUnsupportedKey,
+		},
+		{
+			name:    "Unknown curve",
+			key:     Key{KeyType: "EC", Curve: "P-224"},
+			wantErr: ErrUnsupportedKey,
+		},
+		{
+			name:    "Symmetric key",
+			key:     Key{KeyType: "oct"},
+			wantErr: ErrUnsupportedKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.key.PublicKey()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PublicKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.want != nil && !tt.want.Equal(got) {
+				t.Errorf("PublicKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet_Key(t *testing.T) {
+	set := &Set{Keys: []Key{{KeyID: "a", KeyType: "RSA"}, {KeyID: "b", KeyType: "EC"}}}
+
+	if key, found := set.Key("b"); !found || key.KeyType != "EC" {
+		t.Errorf("Key(b) = %v, %v, want the EC key", key, found)
+	}
+	if _, found := set.Key("c"); found {
+		t.Errorf("Key(c) found a key")
+	}
+}
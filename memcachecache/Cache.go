@@ -0,0 +1,479 @@
+package memcachecache
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+const (
+	defaultAddress     = "localhost:11211"
+	defaultDialTimeout = time.Second * 5
+	defaultIOTimeout   = time.Second * 3
+	defaultPoolSize    = 10
+	maxKeyLength       = 250
+
+	// Expiration times above this many seconds are treated by memcached as
+	// absolute Unix timestamps rather than relative offsets.
+	relativeExpirationLimit = 60 * 60 * 24 * 30
+)
+
+var (
+	ErrInvalidKey = errors.New("memcachecache: invalid key")
+	ErrClosed     = errors.New("memcachecache: cache is closed")
+	ErrServer     = errors.New("memcachecache: server error")
+)
+
+// Cache is a cache.Cache backed by one or more memcached servers. Keys are
+// spread across servers by CRC32. The Cache interface has no error results, so
+// failed reads are reported as misses and errors are passed to the handler set
+// with WithErrorHandler.
+type Cache interface {
+	cache.Cache
+	cache.MultiCache
+	Close() error
+}
+
+type memcacheCache struct {
+	addresses   []string
+	dialTimeout time.Duration
+	ioTimeout   time.Duration
+	poolSize    int
+	codec       Codec
+	onError     func(err error)
+	now         func() time.Time
+	pools       []*pool
+}
+
+type pool struct {
+	address string
+	idle    chan *conn
+	mu      sync.Mutex
+	closed  bool
+}
+
+type conn struct {
+	netConn net.Conn
+	buffer  *bufio.ReadWriter
+}
+
+func New(options ...func(*memcacheCache)) Cache {
+	c := &memcacheCache{
+		addresses:   []string{defaultAddress},
+		dialTimeout: defaultDialTimeout,
+		ioTimeout:   defaultIOTimeout,
+		poolSize:    defaultPoolSize,
+		codec:       GobCodec{},
+		onError:     func(error) {},
+		now:         time.Now,
+	}
+	for _, optionFn := range options {
+		optionFn(c)
+	}
+
+	for _, address := range c.addresses {
+		c.pools = append(c.pools, &pool{address: address, idle: make(chan *conn, c.poolSize)})
+	}
+
+	return c
+}
+
+func WithServers(addresses ...string) func(*memcacheCache) {
+	return func(c *memcacheCache) {
+		c.addresses = addresses
+	}
+}
+
+func WithDialTimeout(timeout time.Duration) func(*memcacheCache) {
+	return func(c *memcacheCache) {
+		c.dialTimeout = timeout
+	}
+}
+
+// WithIOTimeout bounds each command round trip.
+func WithIOTimeout(timeout time.Duration) func(*memcacheCache) {
+	return func(c *memcacheCache) {
+		c.ioTimeout = timeout
+	}
+}
+
+// WithPoolSize sets the number of idle connections kept per server.
+func WithPoolSize(size int) func(*memcacheCache) {
+	return func(c *memcacheCache) {
+		c.poolSize = size
+	}
+}
+
+func WithCodec(codec Codec) func(*memcacheCache) {
+	return func(c *memcacheCache) {
+		c.codec = codec
+	}
+}
+
+func WithErrorHandler(handler func(err error)) func(*memcacheCache) {
+	return func(c *memcacheCache) {
+		c.onError = handler
+	}
+}
+
+func (c *memcacheCache) Get(key string) (interface{}, bool) {
+	var data []byte
+	found := false
+	err := c.do(key, func(cn *conn) error {
+		return cn.get([]string{key}, func(_ string, value []byte) {
+			data, found = value, true
+		})
+	})
+	if err != nil {
+		c.onError(err)
+		return nil, false
+	}
+	if !found {
+		return nil, false
+	}
+
+	value, err := c.codec.Unmarshal(data)
+	if err != nil {
+		c.onError(err)
+		return nil, false
+	}
+
+	return value, true
+}
+
+// GetMulti fetches the keys of each server with a single multi-key get.
+// Missing keys are absent from the result.
+func (c *memcacheCache) GetMulti(keys []string) map[string]interface{} {
+	values := make(map[string]interface{}, len(keys))
+	for p, serverKeys := range c.byServer(keys) {
+		err := c.doOn(p, func(cn *conn) error {
+			return cn.get(serverKeys, func(key string, data []byte) {
+				value, err := c.codec.Unmarshal(data)
+				if err != nil {
+					c.onError(err)
+					return
+				}
+				values[key] = value
+			})
+		})
+		if err != nil {
+			c.onError(err)
+		}
+	}
+
+	return values
+}
+
+// Set stores value with an expiration converted to memcached's format. A
+// non-positive interval stores the key without expiration.
+func (c *memcacheCache) Set(key string, value interface{}, expiredInterval time.Duration) {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		c.onError(err)
+		return
+	}
+
+	err = c.do(key, func(cn *conn) error {
+		cn.writeSet(key, data, c.expiration(expiredInterval))
+		if err := cn.buffer.Flush(); err != nil {
+			return err
+		}
+
+		return cn.readReply("STORED")
+	})
+	if err != nil {
+		c.onError(err)
+	}
+}
+
+// SetMulti pipelines the sets of each server: all of them are written before
+// the first reply is read.
+func (c *memcacheCache) SetMulti(items map[string]interface{}, expiredInterval time.Duration) {
+	data := make(map[string][]byte, len(items))
+	keys := make([]string, 0, len(items))
+	for key, value := range items {
+		encoded, err := c.codec.Marshal(value)
+		if err != nil {
+			c.onError(err)
+			continue
+		}
+		data[key] = encoded
+		keys = append(keys, key)
+	}
+
+	exptime := c.expiration(expiredInterval)
+	for p, serverKeys := range c.byServer(keys) {
+		err := c.doOn(p, func(cn *conn) error {
+			for _, key := range serverKeys {
+				cn.writeSet(key, data[key], exptime)
+			}
+			if err := cn.buffer.Flush(); err != nil {
+				return err
+			}
+
+			return cn.readReplies(len(serverKeys), c.onError, "STORED")
+		})
+		if err != nil {
+			c.onError(err)
+		}
+	}
+}
+
+func (c *memcacheCache) Delete(key string) {
+	err := c.do(key, func(cn *conn) error {
+		fmt.Fprintf(cn.buffer, "delete %s\r\n", key)
+		if err := cn.buffer.Flush(); err != nil {
+			return err
+		}
+
+		return cn.readReply("DELETED", "NOT_FOUND")
+	})
+	if err != nil {
+		c.onError(err)
+	}
+}
+
+// DeleteMulti pipelines the deletes of each server like SetMulti.
+func (c *memcacheCache) DeleteMulti(keys []string) {
+	for p, serverKeys := range c.byServer(keys) {
+		err := c.doOn(p, func(cn *conn) error {
+			for _, key := range serverKeys {
+				fmt.Fprintf(cn.buffer, "delete %s\r\n", key)
+			}
+			if err := cn.buffer.Flush(); err != nil {
+				return err
+			}
+
+			return cn.readReplies(len(serverKeys), c.onError, "DELETED", "NOT_FOUND")
+		})
+		if err != nil {
+			c.onError(err)
+		}
+	}
+}
+
+func (c *memcacheCache) Close() error {
+	for _, p := range c.pools {
+		p.close()
+	}
+
+	return nil
+}
+
+// expiration converts an interval to memcached's exptime: zero for no
+// expiration, whole seconds rounded up, and an absolute Unix time beyond the
+// 30 day threshold.
+func (c *memcacheCache) expiration(expiredInterval time.Duration) int64 {
+	if expiredInterval <= 0 {
+		return 0
+	}
+
+	seconds := int64((expiredInterval + time.Second - 1) / time.Second)
+	if seconds > relativeExpirationLimit {
+		return c.now().Add(expiredInterval).Unix()
+	}
+
+	return seconds
+}
+
+func (c *memcacheCache) do(key string, fn func(cn *conn) error) error {
+	if !validKey(key) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	return c.doOn(c.server(key), fn)
+}
+
+// server returns the pool of the server key is stored on.
+func (c *memcacheCache) server(key string) *pool {
+	return c.pools[crc32.ChecksumIEEE([]byte(key))%uint32(len(c.pools))]
+}
+
+// byServer groups keys by server, passing invalid keys to the error handler.
+func (c *memcacheCache) byServer(keys []string) map[*pool][]string {
+	groups := make(map[*pool][]string)
+	for _, key := range keys {
+		if !validKey(key) {
+			c.onError(fmt.Errorf("%w: %q", ErrInvalidKey, key))
+			continue
+		}
+		p := c.server(key)
+		groups[p] = append(groups[p], key)
+	}
+
+	return groups
+}
+
+func (c *memcacheCache) doOn(p *pool, fn func(cn *conn) error) error {
+	cn, err := p.get(c.dialTimeout)
+	if err != nil {
+		return err
+	}
+	if c.ioTimeout > 0 {
+		_ = cn.netConn.SetDeadline(time.Now().Add(c.ioTimeout))
+	}
+
+	if err := fn(cn); err != nil {
+		cn.netConn.Close()
+		return err
+	}
+	p.put(cn)
+
+	return nil
+}
+
+func (p *pool) get(dialTimeout time.Duration) (*conn, error) {
+	select {
+	case cn, ok := <-p.idle:
+		if !ok {
+			return nil, ErrClosed
+		}
+		return cn, nil
+	default:
+	}
+
+	netConn, err := net.DialTimeout("tcp", p.address, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{
+		netConn: netConn,
+		buffer:  bufio.NewReadWriter(bufio.NewReader(netConn), bufio.NewWriter(netConn)),
+	}, nil
+}
+
+func (p *pool) put(cn *conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		cn.netConn.Close()
+		return
+	}
+	select {
+	case p.idle <- cn:
+	default:
+		cn.netConn.Close()
+	}
+}
+
+func (p *pool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.idle)
+	for cn := range p.idle {
+		cn.netConn.Close()
+	}
+}
+
+// get sends a get for keys and calls found with the data of each hit.
+func (cn *conn) get(keys []string, found func(key string, data []byte)) error {
+	fmt.Fprintf(cn.buffer, "get %s\r\n", strings.Join(keys, " "))
+	if err := cn.buffer.Flush(); err != nil {
+		return err
+	}
+
+	for {
+		line, err := cn.readLine()
+		if err != nil {
+			return err
+		}
+		if line == "END" {
+			return nil
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 4 || fields[0] != "VALUE" {
+			return replyError(line)
+		}
+		size, err := strconv.Atoi(fields[3])
+		if err != nil || size < 0 {
+			return replyError(line)
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(cn.buffer, data); err != nil {
+			return err
+		}
+		found(fields[1], data[:size])
+	}
+}
+
+func (cn *conn) writeSet(key string, data []byte, exptime int64) {
+	fmt.Fprintf(cn.buffer, "set %s 0 %d %d\r\n", key, exptime, len(data))
+	cn.buffer.Write(data)
+	cn.buffer.WriteString("\r\n")
+}
+
+// readReply reads a reply line and fails unless it is one of want.
+func (cn *conn) readReply(want ...string) error {
+	line, err := cn.readLine()
+	if err != nil {
+		return err
+	}
+	for _, reply := range want {
+		if line == reply {
+			return nil
+		}
+	}
+
+	return replyError(line)
+}
+
+// readReplies reads n pipelined replies. Unexpected replies are passed to
+// onError, as the connection stays in sync; read errors end the batch.
+func (cn *conn) readReplies(n int, onError func(err error), want ...string) error {
+	for i := 0; i < n; i++ {
+		err := cn.readReply(want...)
+		if errors.Is(err, ErrServer) {
+			onError(err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cn *conn) readLine() (string, error) {
+	line, err := cn.buffer.ReadSlice('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes.TrimSuffix(line, []byte("\r\n"))), nil
+}
+
+func replyError(line string) error {
+	return fmt.Errorf("%w: %s", ErrServer, line)
+}
+
+func validKey(key string) bool {
+	if key == "" || len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+
+	return true
+}
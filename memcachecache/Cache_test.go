@@ -0,0 +1,283 @@
+package memcachecache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	values   map[string][]byte
+	commands []string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	server := &fakeServer{listener: listener, values: make(map[string][]byte)}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+
+	return server
+}
+
+func (s *fakeServer) serve() {
+	for {
+		netConn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(netConn)
+	}
+}
+
+func (s *fakeServer) handle(netConn net.Conn) {
+	defer netConn.Close()
+	buffer := bufio.NewReadWriter(bufio.NewReader(netConn), bufio.NewWriter(netConn))
+	for {
+		line, err := buffer.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSuffix(line, "\r\n")
+		fields := strings.Fields(line)
+
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		switch fields[0] {
+		case "get":
+			for _, key := range fields[1:] {
+				if value, found := s.values[key]; found {
+					fmt.Fprintf(buffer, "VALUE %s 0 %d\r\n%s\r\n", key, len(value), value)
+				}
+			}
+			buffer.WriteString("END\r\n")
+		case "set":
+			size, _ := strconv.Atoi(fields[4])
+			data := make([]byte, size+2)
+			io.ReadFull(buffer, data)
+			s.values[fields[1]] = data[:size]
+			buffer.WriteString("STORED\r\n")
+		case "delete":
+			if _, found := s.values[fields[1]]; found {
+				delete(s.values, fields[1])
+				buffer.WriteString("DELETED\r\n")
+			} else {
+				buffer.WriteString("NOT_FOUND\r\n")
+			}
+		default:
+			buffer.WriteString("ERROR\r\n")
+		}
+		s.mu.Unlock()
+
+		if buffer.Flush() != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) recorded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.commands...)
+}
+
+func Test_memcacheCache(t *testing.T) {
+	server := newFakeServer(t)
+	c := New(WithServers(server.listener.Addr().String()))
+	defer c.Close()
+
+	c.Set("test", []int{1, 2}, time.Minute)
+	value, found := c.Get("test")
+	if !found || !reflect.DeepEqual(value, []int{1, 2}) {
+		t.Errorf("Get() = %v, %v, want %v, true", value, found, []int{1, 2})
+	}
+
+	c.Delete("test")
+	if _, found := c.Get("test"); found {
+		t.Errorf("Get() found a deleted key")
+	}
+
+	c.Set("test", 42, 0)
+	if value, found := c.Get("test"); !found || value != 42 {
+		t.Errorf("Get() = %v, %v, want %v, true for a key set without expiration", value, found, 42)
+	}
+}
+
+func Test_memcacheCache_expiration(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name            string
+		expiredInterval time.Duration
+		want            int64
+	}{
+		{
+			name:            "Sub-second interval rounds up",
+			expiredInterval: time.Millisecond * 10,
+			want:            1,
+		},
+		{
+			name:            "Relative seconds",
+			expiredInterval: time.Minute,
+			want:            60,
+		},
+		{
+			name:            "Exactly 30 days stays relative",
+			expiredInterval: time.Hour * 24 * 30,
+			want:            relativeExpirationLimit,
+		},
+		{
+			name:            "Beyond 30 days becomes absolute",
+			expiredInterval: time.Hour * 24 * 31,
+			want:            now.Add(time.Hour * 24 * 31).Unix(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &memcacheCache{now: func() time.Time { return now }}
+			if got := c.expiration(tt.expiredInterval); got != tt.want {
+				t.Errorf("expiration() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_memcacheCache_InvalidKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{name: "Empty key", key: ""},
+		{name: "Key with space", key: "a b"},
+		{name: "Key with newline", key: "a\nb"},
+		{name: "Key too long", key: strings.Repeat("k", maxKeyLength+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer(t)
+			var handledErr error
+			c := New(
+				WithServers(server.listener.Addr().String()),
+				WithErrorHandler(func(err error) { handledErr = err }),
+			)
+			defer c.Close()
+
+			c.Set(tt.key, 42, time.Minute)
+			if !errors.Is(handledErr, ErrInvalidKey) {
+				t.Errorf("Set() error = %v, want %v", handledErr, ErrInvalidKey)
+			}
+			if commands := server.recorded(); len(commands) != 0 {
+				t.Errorf("commands sent for an invalid key: %v", commands)
+			}
+		})
+	}
+}
+
+func Test_memcacheCache_Servers(t *testing.T) {
+	first, second := newFakeServer(t), newFakeServer(t)
+	c := New(WithServers(first.listener.Addr().String(), second.listener.Addr().String()))
+	defer c.Close()
+
+	for i := 0; i < 20; i++ {
+		c.Set("key"+strconv.Itoa(i), i, time.Minute)
+	}
+	for i := 0; i < 20; i++ {
+		if value, found := c.Get("key" + strconv.Itoa(i)); !found || value != i {
+			t.Errorf("Get(key%d) = %v, %v, want %d, true", i, value, found, i)
+		}
+	}
+
+	if len(first.recorded()) == 0 || len(second.recorded()) == 0 {
+		t.Errorf("keys were not spread across servers: %d and %d commands",
+			len(first.recorded()), len(second.recorded()))
+	}
+}
+
+func Test_memcacheCache_Multi(t *testing.T) {
+	first, second := newFakeServer(t), newFakeServer(t)
+	var handledErr error
+	c := New(
+		WithServers(first.listener.Addr().String(), second.listener.Addr().String()),
+		WithErrorHandler(func(err error) { handledErr = err }),
+	)
+	defer c.Close()
+
+	items := make(map[string]interface{})
+	keys := []string{"missing"}
+	for i := 0; i < 20; i++ {
+		items["key"+strconv.Itoa(i)] = i
+		keys = append(keys, "key"+strconv.Itoa(i))
+	}
+	c.SetMulti(items, time.Minute)
+	if got := c.GetMulti(keys); !reflect.DeepEqual(got, items) {
+		t.Errorf("GetMulti() = %v, want %v", got, items)
+	}
+	c.DeleteMulti(keys)
+	if got := c.GetMulti(keys); len(got) != 0 {
+		t.Errorf("GetMulti() after DeleteMulti() = %v, want none", got)
+	}
+	if handledErr != nil {
+		t.Errorf("batch error: %v", handledErr)
+	}
+
+	for _, server := range []*fakeServer{first, second} {
+		gets := 0
+		for _, command := range server.recorded() {
+			if strings.HasPrefix(command, "get ") {
+				gets++
+			}
+		}
+		if gets != 2 {
+			t.Errorf("server received %d get commands for two GetMulti calls, want one each", gets)
+		}
+	}
+}
+
+func Test_memcacheCache_Multi_InvalidKey(t *testing.T) {
+	server := newFakeServer(t)
+	var handledErr error
+	c := New(
+		WithServers(server.listener.Addr().String()),
+		WithErrorHandler(func(err error) { handledErr = err }),
+	)
+	defer c.Close()
+
+	c.SetMulti(map[string]interface{}{"a b": 1, "valid": 2}, time.Minute)
+	if !errors.Is(handledErr, ErrInvalidKey) {
+		t.Errorf("SetMulti() error = %v, want %v", handledErr, ErrInvalidKey)
+	}
+	if got := c.GetMulti([]string{"valid", "a b"}); !reflect.DeepEqual(got, map[string]interface{}{"valid": 2}) {
+		t.Errorf("GetMulti() = %v, want only the valid key", got)
+	}
+}
+
+func Test_memcacheCache_Close(t *testing.T) {
+	server := newFakeServer(t)
+	var handledErr error
+	c := New(
+		WithServers(server.listener.Addr().String()),
+		WithErrorHandler(func(err error) { handledErr = err }),
+	)
+	c.Set("test", 42, time.Minute)
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	c.Get("test")
+	if !errors.Is(handledErr, ErrClosed) {
+		t.Errorf("Get() after Close() error = %v, want %v", handledErr, ErrClosed)
+	}
+}
@@ -0,0 +1,10 @@
+package memcachecache
+
+import cache "github.com/abicur/go-sim-cache"
+
+// Codec converts cache values to and from the bytes stored in memcached.
+type Codec = cache.Codec
+
+// GobCodec is the default codec. Concrete types other than the gob builtins
+// must be registered with gob.Register.
+type GobCodec = cache.GobCodec
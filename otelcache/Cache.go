@@ -0,0 +1,217 @@
+package otelcache
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/abicur/go-sim-cache/otelcache"
+
+const (
+	operationKey = attribute.Key("cache.operation")
+	keyHashKey   = attribute.Key("cache.key_hash")
+	resultKey    = attribute.Key("cache.result")
+	nameKey      = attribute.Key("cache.name")
+)
+
+type instrumentedCache struct {
+	next           cache.Cache
+	name           string
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	tracer         trace.Tracer
+	operations     metric.Int64Counter
+	duration       metric.Float64Histogram
+}
+
+type instrumentedCacheCtx struct {
+	next        cache.CacheCtx
+	instruments *instrumentedCache
+}
+
+// New wraps next so every operation emits a span and metrics. Cache methods
+// carry no context, so the spans are recorded as roots of their own traces;
+// use NewCtx to record them in the caller's trace. Errors creating the
+// instruments are passed to otel.Handle and disable the affected metric.
+func New(next cache.Cache, options ...func(*instrumentedCache)) cache.Cache {
+	c := newInstrumentedCache(options)
+	c.next = next
+
+	return c
+}
+
+// NewCtx is New for a cache.CacheCtx. Spans are children of the span in the
+// context of each call, and failed operations are recorded with result
+// "error" and the error set on the span. Misses are not failures.
+func NewCtx(next cache.CacheCtx, options ...func(*instrumentedCache)) cache.CacheCtx {
+	return &instrumentedCacheCtx{next: next, instruments: newInstrumentedCache(options)}
+}
+
+func newInstrumentedCache(options []func(*instrumentedCache)) *instrumentedCache {
+	c := &instrumentedCache{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, optionFn := range options {
+		optionFn(c)
+	}
+
+	c.tracer = c.tracerProvider.Tracer(instrumentationName)
+	meter := c.meterProvider.Meter(instrumentationName)
+	var err error
+	c.operations, err = meter.Int64Counter(
+		"cache.operations",
+		metric.WithDescription("Number of cache operations by result."),
+	)
+	if err != nil {
+		otel.Handle(err)
+		c.operations = noop.Int64Counter{}
+	}
+	c.duration, err = meter.Float64Histogram(
+		"cache.operation.duration",
+		metric.WithDescription("Duration of cache operations."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		otel.Handle(err)
+		c.duration = noop.Float64Histogram{}
+	}
+
+	return c
+}
+
+// Middleware returns New as a cache.Middleware for use with cache.Wrap.
+func Middleware(options ...func(*instrumentedCache)) cache.Middleware {
+	return func(next cache.Cache) cache.Cache {
+		return New(next, options...)
+	}
+}
+
+func WithName(name string) func(*instrumentedCache) {
+	return func(c *instrumentedCache) {
+		c.name = name
+	}
+}
+
+func WithTracerProvider(provider trace.TracerProvider) func(*instrumentedCache) {
+	return func(c *instrumentedCache) {
+		c.tracerProvider = provider
+	}
+}
+
+func WithMeterProvider(provider metric.MeterProvider) func(*instrumentedCache) {
+	return func(c *instrumentedCache) {
+		c.meterProvider = provider
+	}
+}
+
+func (c *instrumentedCache) Get(key string) (interface{}, bool) {
+	finish := c.start(context.Background(), "get", key)
+	value, found := c.next.Get(key)
+	if found {
+		finish("hit", nil)
+	} else {
+		finish("miss", nil)
+	}
+
+	return value, found
+}
+
+func (c *instrumentedCache) Set(key string, value interface{}, expiredInterval time.Duration) {
+	finish := c.start(context.Background(), "set", key)
+	c.next.Set(key, value, expiredInterval)
+	finish("ok", nil)
+}
+
+func (c *instrumentedCache) Delete(key string) {
+	finish := c.start(context.Background(), "delete", key)
+	c.next.Delete(key)
+	finish("ok", nil)
+}
+
+func (c *instrumentedCacheCtx) Get(ctx context.Context, key string) (interface{}, error) {
+	finish := c.instruments.start(ctx, "get", key)
+	value, err := c.next.Get(ctx, key)
+	switch {
+	case err == nil:
+		finish("hit", nil)
+	case errors.Is(err, cache.ErrNotFound):
+		finish("miss", nil)
+	default:
+		finish("error", err)
+	}
+
+	return value, err
+}
+
+func (c *instrumentedCacheCtx) Set(ctx context.Context, key string, value interface{}, expiredInterval time.Duration) error {
+	finish := c.instruments.start(ctx, "set", key)
+	err := c.next.Set(ctx, key, value, expiredInterval)
+	finish(okOrError(err), err)
+
+	return err
+}
+
+func (c *instrumentedCacheCtx) Delete(ctx context.Context, key string) error {
+	finish := c.instruments.start(ctx, "delete", key)
+	err := c.next.Delete(ctx, key)
+	finish(okOrError(err), err)
+
+	return err
+}
+
+// start begins the span of an operation as a child of the span in ctx and
+// returns the function ending it and recording its metrics.
+func (c *instrumentedCache) start(ctx context.Context, operation, key string) func(result string, err error) {
+	startedAt := time.Now()
+	attributes := []attribute.KeyValue{operationKey.String(operation)}
+	if c.name != "" {
+		attributes = append(attributes, nameKey.String(c.name))
+	}
+
+	ctx, span := c.tracer.Start(
+		ctx,
+		"cache."+operation,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(append(attributes, keyHashKey.String(hashKey(key)))...),
+	)
+
+	return func(result string, err error) {
+		span.SetAttributes(resultKey.String(result))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		attributes = append(attributes, resultKey.String(result))
+		c.operations.Add(ctx, 1, metric.WithAttributes(attributes...))
+		c.duration.Record(ctx, time.Since(startedAt).Seconds(), metric.WithAttributes(attributes...))
+	}
+}
+
+func okOrError(err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	return "ok"
+}
+
+func hashKey(key string) string {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(key))
+
+	return strconv.FormatUint(hasher.Sum64(), 16)
+}
@@ -0,0 +1,217 @@
+package otelcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNew(t *testing.T) {
+	spanRecorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder))
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	c := New(
+		cache.NewInMemoryCache(context.Background()),
+		WithName("sessions"),
+		WithTracerProvider(tracerProvider),
+		WithMeterProvider(meterProvider),
+	)
+
+	c.Set("test", 42, time.Second*10)
+	if value, found := c.Get("test"); !found || value != 42 {
+		t.Errorf("Get() = %v, %v, want %v, %v", value, found, 42, true)
+	}
+	c.Delete("test")
+	c.Get("test")
+
+	spans := spanRecorder.Ended()
+	wantSpans := []struct {
+		name   string
+		result string
+	}{
+		{"cache.set", "ok"},
+		{"cache.get", "hit"},
+		{"cache.delete", "ok"},
+		{"cache.get", "miss"},
+	}
+	if len(spans) != len(wantSpans) {
+		t.Fatalf("recorded %d spans, want %d", len(spans), len(wantSpans))
+	}
+	for i, want := range wantSpans {
+		if spans[i].Name() != want.name {
+			t.Errorf("span[%d] name = %s, want %s", i, spans[i].Name(), want.name)
+		}
+		attributes := attribute.NewSet(spans[i].Attributes()...)
+		if result, _ := attributes.Value(resultKey); result.AsString() != want.result {
+			t.Errorf("span[%d] result = %s, want %s", i, result.AsString(), want.result)
+		}
+		if keyHash, _ := attributes.Value(keyHashKey); keyHash.AsString() != hashKey("test") {
+			t.Errorf("span[%d] key hash = %s, want %s", i, keyHash.AsString(), hashKey("test"))
+		}
+	}
+
+	var metrics metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &metrics); err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	var operations int64
+	for _, scopeMetrics := range metrics.ScopeMetrics {
+		for _, m := range scopeMetrics.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "cache.operations" {
+				for _, point := range sum.DataPoints {
+					operations += point.Value
+				}
+			}
+		}
+	}
+	if operations != 4 {
+		t.Errorf("cache.operations = %d, want %d", operations, 4)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	spanRecorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder))
+
+	c := cache.Wrap(cache.NewInMemoryCache(context.Background()), Middleware(WithTracerProvider(tracerProvider)))
+	c.Get("test")
+
+	if spans := spanRecorder.Ended(); len(spans) != 1 || spans[0].Name() != "cache.get" {
+		t.Errorf("recorded %v, want a single cache.get span", spans)
+	}
+}
+
+func TestNewCtx(t *testing.T) {
+	failure := errors.New("backend down")
+	tests := []struct {
+		name       string
+		next       cache.CacheCtx
+		call       func(c cache.CacheCtx, ctx context.Context)
+		wantName   string
+		wantResult string
+		wantStatus codes.Code
+	}{
+		{
+			name:       "Set",
+			next:       cache.ToCacheCtx(cache.NewInMemoryCache(context.Background())),
+			call:       func(c cache.CacheCtx, ctx context.Context) { _ = c.Set(ctx, "test", 42, time.Second*10) },
+			wantName:   "cache.set",
+			wantResult: "ok",
+		},
+		{
+			name:       "Miss is not a failure",
+			next:       cache.ToCacheCtx(cache.NewInMemoryCache(context.Background())),
+			call:       func(c cache.CacheCtx, ctx context.Context) { _, _ = c.Get(ctx, "test") },
+			wantName:   "cache.get",
+			wantResult: "miss",
+		},
+		{
+			name:       "Failure",
+			next:       failingCacheCtx{err: failure},
+			call:       func(c cache.CacheCtx, ctx context.Context) { _ = c.Delete(ctx, "test") },
+			wantName:   "cache.delete",
+			wantResult: "error",
+			wantStatus: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spanRecorder := tracetest.NewSpanRecorder()
+			tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder))
+			c := NewCtx(tt.next, WithTracerProvider(tracerProvider))
+
+			ctx, parent := tracerProvider.Tracer("test").Start(context.Background(), "parent")
+			tt.call(c, ctx)
+			parent.End()
+
+			spans := spanRecorder.Ended()
+			if len(spans) != 2 {
+				t.Fatalf("recorded %d spans, want %d", len(spans), 2)
+			}
+			span := spans[0]
+			if span.Name() != tt.wantName {
+				t.Errorf("span name = %s, want %s", span.Name(), tt.wantName)
+			}
+			if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+				t.Errorf("span is not a child of the span in the context")
+			}
+			attributes := attribute.NewSet(span.Attributes()...)
+			if result, _ := attributes.Value(resultKey); result.AsString() != tt.wantResult {
+				t.Errorf("span result = %s, want %s", result.AsString(), tt.wantResult)
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("span status = %v, want %v", span.Status().Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestNew_InstrumentErrors(t *testing.T) {
+	var handled []error
+	previous := otel.GetErrorHandler()
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		handled = append(handled, err)
+	}))
+	defer otel.SetErrorHandler(previous)
+
+	c := New(cache.NewInMemoryCache(context.Background()), WithMeterProvider(failingMeterProvider{}))
+	c.Set("test", 42, time.Second*10)
+
+	if len(handled) != 2 || !errors.Is(handled[0], errInstrument) {
+		t.Errorf("handled errors = %v, want an instrument error for each metric", handled)
+	}
+}
+
+// failingCacheCtx fails every call with err.
+type failingCacheCtx struct {
+	err error
+}
+
+func (c failingCacheCtx) Get(ctx context.Context, key string) (interface{}, error) {
+	return nil, c.err
+}
+
+func (c failingCacheCtx) Set(ctx context.Context, key string, value interface{}, expiredInterval time.Duration) error {
+	return c.err
+}
+
+func (c failingCacheCtx) Delete(ctx context.Context, key string) error {
+	return c.err
+}
+
+var errInstrument = errors.New("instrument unavailable")
+
+// failingMeterProvider provides meters that fail to create instruments.
+type failingMeterProvider struct {
+	noop.MeterProvider
+}
+
+func (failingMeterProvider) Meter(name string, options ...metric.MeterOption) metric.Meter {
+	return failingMeter{}
+}
+
+type failingMeter struct {
+	noop.Meter
+}
+
+func (failingMeter) Int64Counter(name string, options ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return nil, errInstrument
+}
+
+func (failingMeter) Float64Histogram(name string, options ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return nil, errInstrument
+}
@@ -0,0 +1,256 @@
+package peercache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+const (
+	defaultBasePath = "/_simcache/"
+	defaultTimeout  = time.Second * 5
+)
+
+var (
+	ErrUnknownGroup = errors.New("peercache: unknown group")
+	ErrPeer         = errors.New("peercache: peer request failed")
+)
+
+// Getter loads the value for a key on the peer that owns it.
+type Getter func(ctx context.Context, key string) (interface{}, error)
+
+// Pool is the set of peers sharing the key space. It serves the groups of this
+// instance to other peers over HTTP and fetches from them the keys it does
+// not own. Values cross the wire gob encoded, so concrete types other than the
+// gob builtins must be registered with gob.Register on every peer.
+type Pool struct {
+	self     string
+	basePath string
+	replicas int
+	client   *http.Client
+	mu       sync.RWMutex
+	ring     *ring
+	groups   map[string]*Group
+}
+
+type Group struct {
+	name   string
+	pool   *Pool
+	local  cache.Cache
+	ttl    time.Duration
+	hotTTL time.Duration
+	getter Getter
+}
+
+type envelope struct {
+	Value interface{}
+}
+
+// NewPool creates a pool for the instance reachable by other peers at self,
+// a base URL such as "http://10.0.0.1:8080".
+func NewPool(self string, options ...func(*Pool)) *Pool {
+	p := &Pool{
+		self:     self,
+		basePath: defaultBasePath,
+		replicas: defaultReplicas,
+		client:   &http.Client{Timeout: defaultTimeout},
+		groups:   make(map[string]*Group),
+	}
+	for _, optionFn := range options {
+		optionFn(p)
+	}
+	p.ring = newRing(p.replicas, self)
+
+	return p
+}
+
+func WithBasePath(path string) func(*Pool) {
+	return func(p *Pool) {
+		p.basePath = path
+	}
+}
+
+func WithReplicas(replicas int) func(*Pool) {
+	return func(p *Pool) {
+		p.replicas = replicas
+	}
+}
+
+func WithHTTPClient(client *http.Client) func(*Pool) {
+	return func(p *Pool) {
+		p.client = client
+	}
+}
+
+// SetPeers replaces the peer list. It must include self; every instance must
+// be given the same list for them to agree on key ownership.
+func (p *Pool) SetPeers(peers ...string) {
+	r := newRing(p.replicas, peers...)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.ring = r
+}
+
+// NewGroup registers a named key space. Keys owned by this instance are
+// loaded with getter and stored in local for ttl. Keys owned by another peer
+// are fetched from it.
+func (p *Pool) NewGroup(name string, local cache.Cache, ttl time.Duration, getter Getter, options ...func(*Group)) *Group {
+	g := &Group{name: name, pool: p, local: local, ttl: ttl, getter: getter}
+	for _, optionFn := range options {
+		optionFn(g)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.groups[name] = g
+
+	return g
+}
+
+// WithHotTTL keeps values fetched from other peers in the local cache for ttl,
+// trading staleness for fewer network round trips on hot keys.
+func WithHotTTL(ttl time.Duration) func(*Group) {
+	return func(g *Group) {
+		g.hotTTL = ttl
+	}
+}
+
+func (p *Pool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, p.basePath) {
+		http.NotFound(w, r)
+		return
+	}
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.EscapedPath(), p.basePath), "/", 2)
+	if len(parts) != 2 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	groupName, err := url.PathUnescape(parts[0])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key, err := url.PathUnescape(parts[1])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p.mu.RLock()
+	g, found := p.groups[groupName]
+	p.mu.RUnlock()
+	if !found {
+		http.Error(w, ErrUnknownGroup.Error(), http.StatusNotFound)
+		return
+	}
+
+	value, err := g.load(r.Context(), key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(envelope{Value: value}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(buffer.Bytes())
+}
+
+func (p *Pool) owner(key string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	owner, _ := p.ring.owner(key)
+
+	return owner
+}
+
+func (p *Pool) fetch(ctx context.Context, peer, group, key string) (interface{}, error) {
+	target := peer + p.basePath + url.PathEscape(group) + "/" + url.PathEscape(key)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return nil, fmt.Errorf("%w: %s: %s", ErrPeer, response.Status, strings.TrimSpace(string(message)))
+	}
+
+	var decoded envelope
+	if err := gob.NewDecoder(response.Body).Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	return decoded.Value, nil
+}
+
+// Get returns the value for key, loading it on the owning peer if needed.
+// When the owner cannot be reached the value is loaded locally instead, so a
+// failed peer degrades to duplicate loads rather than errors. Errors reported
+// by a reachable owner, including getter errors, are returned as is.
+func (g *Group) Get(ctx context.Context, key string) (interface{}, error) {
+	if value, found := g.local.Get(key); found {
+		return value, nil
+	}
+
+	owner := g.pool.owner(key)
+	if owner == "" || owner == g.pool.self {
+		return g.load(ctx, key)
+	}
+
+	value, err := g.pool.fetch(ctx, owner, g.name, key)
+	if errors.Is(err, ErrPeer) || ctx.Err() != nil {
+		return value, err
+	}
+	if err != nil {
+		return g.load(ctx, key)
+	}
+	if g.hotTTL > 0 {
+		g.local.Set(key, value, g.hotTTL)
+	}
+
+	return value, nil
+}
+
+func (g *Group) load(ctx context.Context, key string) (interface{}, error) {
+	loader := func(ctx context.Context) (interface{}, error) {
+		return g.getter(ctx, key)
+	}
+	if getOrLoader, ok := g.local.(cache.GetOrLoader); ok {
+		return getOrLoader.GetOrLoad(ctx, key, g.ttl, loader)
+	}
+
+	if value, found := g.local.Get(key); found {
+		return value, nil
+	}
+	value, err := loader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	g.local.Set(key, value, g.ttl)
+
+	return value, nil
+}
@@ -0,0 +1,132 @@
+package peercache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+type testPeer struct {
+	server *httptest.Server
+	pool   *Pool
+	group  *Group
+	loads  int32
+}
+
+func newTestPeers(t *testing.T, count int, getter func(key string) (interface{}, error)) []*testPeer {
+	peers := make([]*testPeer, count)
+	addresses := make([]string, count)
+	for i := range peers {
+		peer := &testPeer{}
+		peer.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer.pool.ServeHTTP(w, r)
+		}))
+		t.Cleanup(peer.server.Close)
+		peers[i] = peer
+		addresses[i] = peer.server.URL
+	}
+
+	for _, peer := range peers {
+		peer := peer
+		peer.pool = NewPool(peer.server.URL)
+		peer.pool.SetPeers(addresses...)
+		local := cache.NewInMemoryCache(context.Background())
+		peer.group = peer.pool.NewGroup("test", local, time.Minute, func(ctx context.Context, key string) (interface{}, error) {
+			atomic.AddInt32(&peer.loads, 1)
+			return getter(key)
+		})
+	}
+
+	return peers
+}
+
+func TestGroup_Get(t *testing.T) {
+	peers := newTestPeers(t, 3, func(key string) (interface{}, error) {
+		return "value:" + key, nil
+	})
+
+	for i := 0; i < 30; i++ {
+		key := "key/" + strconv.Itoa(i)
+		for _, peer := range peers {
+			value, err := peer.group.Get(context.Background(), key)
+			if err != nil || value != "value:"+key {
+				t.Fatalf("Get(%q) = %v, %v, want %q", key, value, err, "value:"+key)
+			}
+		}
+	}
+
+	var total int32
+	for _, peer := range peers {
+		if peer.loads == 0 {
+			t.Errorf("peer %s never loaded a key", peer.server.URL)
+		}
+		total += peer.loads
+	}
+	if total != 30 {
+		t.Errorf("getter ran %d times for 30 keys, want each key loaded once by its owner", total)
+	}
+}
+
+func TestGroup_GetError(t *testing.T) {
+	errLoad := errors.New("load failed")
+	peers := newTestPeers(t, 2, func(key string) (interface{}, error) {
+		return nil, errLoad
+	})
+
+	for i := 0; i < 10; i++ {
+		key := "key" + strconv.Itoa(i)
+		for _, peer := range peers {
+			if _, err := peer.group.Get(context.Background(), key); err == nil {
+				t.Errorf("Get(%q) error = nil, want an error", key)
+			}
+		}
+	}
+}
+
+func TestGroup_GetUnreachablePeer(t *testing.T) {
+	peers := newTestPeers(t, 2, func(key string) (interface{}, error) {
+		return key, nil
+	})
+	peers[1].server.Close()
+
+	for i := 0; i < 10; i++ {
+		key := "key" + strconv.Itoa(i)
+		value, err := peers[0].group.Get(context.Background(), key)
+		if err != nil || value != key {
+			t.Errorf("Get(%q) = %v, %v, want a local load", key, value, err)
+		}
+	}
+}
+
+func TestPool_ServeHTTP(t *testing.T) {
+	pool := NewPool("http://self")
+	pool.NewGroup("test", cache.NewInMemoryCache(context.Background()), time.Minute,
+		func(ctx context.Context, key string) (interface{}, error) { return key, nil })
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "Known group", path: "/_simcache/test/key", wantStatus: http.StatusOK},
+		{name: "Unknown group", path: "/_simcache/other/key", wantStatus: http.StatusNotFound},
+		{name: "Missing key", path: "/_simcache/test", wantStatus: http.StatusBadRequest},
+		{name: "Outside base path", path: "/other", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			pool.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+		})
+	}
+}
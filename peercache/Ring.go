@@ -0,0 +1,46 @@
+package peercache
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+const defaultReplicas = 50
+
+// ring maps keys to peers by consistent hashing. Each peer is placed on the
+// ring replicas times so keys spread evenly and only about 1/n of them move
+// when a peer joins or leaves.
+type ring struct {
+	replicas int
+	hashes   []uint32
+	peers    map[uint32]string
+}
+
+func newRing(replicas int, peers ...string) *ring {
+	r := &ring{replicas: replicas, peers: make(map[uint32]string, replicas*len(peers))}
+	for _, peer := range peers {
+		for i := 0; i < replicas; i++ {
+			hash := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + peer))
+			r.hashes = append(r.hashes, hash)
+			r.peers[hash] = peer
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+
+	return r
+}
+
+func (r *ring) owner(key string) (string, bool) {
+	if len(r.hashes) == 0 {
+		return "", false
+	}
+
+	hash := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= hash })
+	if i == len(r.hashes) {
+		i = 0
+	}
+
+	return r.peers[r.hashes[i]], true
+}
@@ -0,0 +1,46 @@
+package peercache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_ring_owner(t *testing.T) {
+	if _, ok := newRing(defaultReplicas).owner("test"); ok {
+		t.Errorf("owner() on an empty ring reported an owner")
+	}
+
+	peers := []string{"http://a", "http://b", "http://c"}
+	r := newRing(defaultReplicas, peers...)
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		owner, _ := r.owner("key" + strconv.Itoa(i))
+		counts[owner]++
+	}
+	for _, peer := range peers {
+		if counts[peer] < 500 {
+			t.Errorf("peer %s owns %d of 3000 keys, want a roughly even share", peer, counts[peer])
+		}
+	}
+}
+
+func Test_ring_stability(t *testing.T) {
+	before := newRing(defaultReplicas, "http://a", "http://b", "http://c")
+	after := newRing(defaultReplicas, "http://a", "http://b", "http://c", "http://d")
+
+	moved := 0
+	for i := 0; i < 3000; i++ {
+		key := "key" + strconv.Itoa(i)
+		ownerBefore, _ := before.owner(key)
+		ownerAfter, _ := after.owner(key)
+		if ownerBefore != ownerAfter {
+			if ownerAfter != "http://d" {
+				t.Fatalf("key %s moved from %s to %s, want moves only to the new peer", key, ownerBefore, ownerAfter)
+			}
+			moved++
+		}
+	}
+	if moved > 1200 {
+		t.Errorf("%d of 3000 keys moved when adding a fourth peer", moved)
+	}
+}
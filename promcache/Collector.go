@@ -0,0 +1,72 @@
+package promcache
+
+import (
+	cache "github.com/abicur/go-sim-cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "cache"
+
+type collector struct {
+	provider cache.StatsProvider
+
+	hits            *prometheus.Desc
+	misses          *prometheus.Desc
+	hitRatio        *prometheus.Desc
+	sets            *prometheus.Desc
+	deletes         *prometheus.Desc
+	entries         *prometheus.Desc
+	expired         *prometheus.Desc
+	evictions       *prometheus.Desc
+	cleanUps        *prometheus.Desc
+	cleanUpDuration *prometheus.Desc
+}
+
+func NewCollector(provider cache.StatsProvider, name string) prometheus.Collector {
+	labels := prometheus.Labels{"name": name}
+	newDesc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", metric), help, nil, labels)
+	}
+
+	return &collector{
+		provider:        provider,
+		hits:            newDesc("hits_total", "Number of lookups that found a live entry."),
+		misses:          newDesc("misses_total", "Number of lookups that found no live entry."),
+		hitRatio:        newDesc("hit_ratio", "Ratio of hits to all lookups."),
+		sets:            newDesc("sets_total", "Number of stored entries."),
+		deletes:         newDesc("deletes_total", "Number of explicit deletions."),
+		entries:         newDesc("entries", "Number of entries currently stored."),
+		expired:         newDesc("expired_total", "Number of entries removed by the cleanup loop."),
+		evictions:       newDesc("evictions_total", "Number of entries evicted to respect capacity limits."),
+		cleanUps:        newDesc("cleanup_runs_total", "Number of cleanup passes."),
+		cleanUpDuration: newDesc("cleanup_duration_seconds_total", "Time spent in cleanup passes."),
+	}
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.hitRatio
+	ch <- c.sets
+	ch <- c.deletes
+	ch <- c.entries
+	ch <- c.expired
+	ch <- c.evictions
+	ch <- c.cleanUps
+	ch <- c.cleanUpDuration
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.provider.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.hitRatio, prometheus.GaugeValue, stats.HitRatio())
+	ch <- prometheus.MustNewConstMetric(c.sets, prometheus.CounterValue, float64(stats.Sets))
+	ch <- prometheus.MustNewConstMetric(c.deletes, prometheus.CounterValue, float64(stats.Deletes))
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries))
+	ch <- prometheus.MustNewConstMetric(c.expired, prometheus.CounterValue, float64(stats.Expired))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evicted))
+	ch <- prometheus.MustNewConstMetric(c.cleanUps, prometheus.CounterValue, float64(stats.CleanUps))
+	ch <- prometheus.MustNewConstMetric(c.cleanUpDuration, prometheus.CounterValue, stats.CleanUpDuration.Seconds())
+}
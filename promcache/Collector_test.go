@@ -0,0 +1,57 @@
+package promcache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type staticStats cache.Stats
+
+func (s staticStats) Stats() cache.Stats {
+	return cache.Stats(s)
+}
+
+func TestNewCollector(t *testing.T) {
+	stats := staticStats{
+		Hits:            3,
+		Misses:          1,
+		Sets:            4,
+		Deletes:         1,
+		Entries:         2,
+		Expired:         1,
+		Evicted:         0,
+		CleanUps:        2,
+		CleanUpDuration: time.Millisecond * 500,
+	}
+	collector := NewCollector(stats, "sessions")
+
+	expected := `
+# HELP cache_entries Number of entries currently stored.
+# TYPE cache_entries gauge
+cache_entries{name="sessions"} 2
+# HELP cache_hit_ratio Ratio of hits to all lookups.
+# TYPE cache_hit_ratio gauge
+cache_hit_ratio{name="sessions"} 0.75
+# HELP cache_cleanup_duration_seconds_total Time spent in cleanup passes.
+# TYPE cache_cleanup_duration_seconds_total counter
+cache_cleanup_duration_seconds_total{name="sessions"} 0.5
+`
+	err := testutil.CollectAndCompare(
+		collector,
+		strings.NewReader(expected),
+		"cache_entries",
+		"cache_hit_ratio",
+		"cache_cleanup_duration_seconds_total",
+	)
+	if err != nil {
+		t.Errorf("Collect() unexpected metrics: %v", err)
+	}
+
+	if count := testutil.CollectAndCount(collector); count != 10 {
+		t.Errorf("Collect() metrics count = %d, want %d", count, 10)
+	}
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"strconv"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+// Limiter decides whether events of a key are allowed.
+type Limiter interface {
+	// Allow reports whether one event of key is allowed now.
+	Allow(key string) bool
+	// AllowN reports whether n events of key are allowed now. Allowed
+	// events count against the limit; denied ones do not.
+	AllowN(key string, n int64) bool
+}
+
+// Store is a cache with atomic counters, like the in-memory cache.
+type Store interface {
+	cache.Cache
+	cache.Counter
+}
+
+type slidingWindow struct {
+	store  Store
+	limit  int64
+	window time.Duration
+}
+
+// NewSlidingWindow returns a Limiter allowing limit events per key in any
+// window of the given length. Events are counted per fixed window in store,
+// and the count of the previous window is weighted by how much of it the
+// sliding window still covers, so the limit holds approximately, without
+// keeping a timestamp per event.
+func NewSlidingWindow(store Store, limit int64, window time.Duration) Limiter {
+	return &slidingWindow{
+		store:  store,
+		limit:  limit,
+		window: window,
+	}
+}
+
+func (s *slidingWindow) Allow(key string) bool {
+	return s.AllowN(key, 1)
+}
+
+func (s *slidingWindow) AllowN(key string, n int64) bool {
+	if n > s.limit {
+		return false
+	}
+
+	now := time.Now()
+	index := now.UnixNano() / int64(s.window)
+	elapsed := float64(now.UnixNano()%int64(s.window)) / float64(s.window)
+
+	// Counting first and taking the events back when over the limit keeps
+	// concurrent callers from both passing a check of the same count.
+	count, err := s.store.Increment(s.windowKey(key, index), n, s.window*2)
+	if err != nil {
+		return false
+	}
+	// Adding zero reads the previous count the way the store keeps counters,
+	// which Get may not return as an int64, for example through a JSON codec.
+	// A missing previous window is stored as zero for the same lifetime.
+	previous, _ := s.store.Increment(s.windowKey(key, index-1), 0, s.window*2)
+	if float64(previous)*(1-elapsed)+float64(count) > float64(s.limit) {
+		s.store.Decrement(s.windowKey(key, index), n, s.window*2)
+		return false
+	}
+
+	return true
+}
+
+func (s *slidingWindow) windowKey(key string, index int64) string {
+	return "ratelimit:" + key + ":" + strconv.FormatInt(index, 10)
+}
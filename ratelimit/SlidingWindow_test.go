@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+func newTestStore(t *testing.T) Store {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return cache.NewInMemoryCache(ctx).(Store)
+}
+
+func TestSlidingWindow_AllowN(t *testing.T) {
+	tests := []struct {
+		name string
+		n    []int64
+		want []bool
+	}{
+		{
+			name: "Up to the limit",
+			n:    []int64{1, 1, 1, 1, 1, 1},
+			want: []bool{true, true, true, true, true, false},
+		},
+		{
+			name: "Denied events do not count",
+			n:    []int64{3, 3, 2},
+			want: []bool{true, false, true},
+		},
+		{
+			name: "More than the limit",
+			n:    []int64{6, 1},
+			want: []bool{false, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewSlidingWindow(newTestStore(t), 5, time.Hour)
+
+			for i, n := range tt.n {
+				if got := limiter.AllowN("client", n); got != tt.want[i] {
+					t.Errorf("AllowN(%d) #%d = %v, want %v", n, i, got, tt.want[i])
+				}
+			}
+			if !limiter.Allow("other") {
+				t.Errorf("Allow() of another key = false, want true")
+			}
+		})
+	}
+}
+
+func TestSlidingWindow_Slides(t *testing.T) {
+	tests := []struct {
+		name  string
+		store func(ctx context.Context) Store
+	}{
+		{
+			name: "Native values",
+			store: func(ctx context.Context) Store {
+				return cache.NewInMemoryCache(ctx).(Store)
+			},
+		},
+		{
+			name: "JSON codec",
+			store: func(ctx context.Context) Store {
+				return cache.NewInMemoryCache(ctx, cache.WithCodec(cache.JSONCodec{})).(Store)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			window := time.Millisecond * 200
+			limiter := NewSlidingWindow(tt.store(ctx), 4, window)
+
+			// Start right after a window boundary so the events fill one window.
+			time.Sleep(window - time.Duration(time.Now().UnixNano()%int64(window)))
+			for i := 0; i < 4; i++ {
+				limiter.Allow("client")
+			}
+			if limiter.Allow("client") {
+				t.Fatalf("Allow() over the limit = true, want false")
+			}
+
+			// Half into the next window, half of the previous events still count.
+			time.Sleep(window + window/2)
+			allowed := 0
+			for i := 0; i < 4; i++ {
+				if limiter.Allow("client") {
+					allowed++
+				}
+			}
+			if allowed != 2 {
+				t.Errorf("Allow() halfway into the next window allowed %d events, want 2", allowed)
+			}
+		})
+	}
+}
+
+func TestSlidingWindow_Concurrent(t *testing.T) {
+	limiter := NewSlidingWindow(newTestStore(t), 50, time.Hour)
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.Allow("client") {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := allowed.Load(); got != 50 {
+		t.Errorf("concurrent Allow() allowed %d events, want 50", got)
+	}
+}
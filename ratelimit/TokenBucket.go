@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+// bucket is the state of a token bucket at updated.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type tokenBucket struct {
+	store cache.Updater
+	rate  float64
+	burst int64
+	ttl   time.Duration
+}
+
+// NewTokenBucket returns a Limiter with a bucket of burst tokens per key,
+// refilled at rate tokens per second. Each event takes a token. A bucket is
+// dropped from store once it would be full again, so idle keys cost nothing.
+// Buckets are stored as strings, so they survive any codec of store. It
+// panics if rate is not positive.
+func NewTokenBucket(store cache.Updater, rate float64, burst int64) Limiter {
+	if rate <= 0 || math.IsNaN(rate) {
+		panic("ratelimit: token bucket rate must be positive")
+	}
+
+	return &tokenBucket{
+		store: store,
+		rate:  rate,
+		burst: burst,
+		ttl:   time.Duration(math.Ceil(float64(burst)/rate*float64(time.Second))) + time.Millisecond,
+	}
+}
+
+func (b *tokenBucket) Allow(key string) bool {
+	return b.AllowN(key, 1)
+}
+
+// AllowN replaces a stored bucket of key that cannot be decoded with an empty
+// one rather than a full one, so a broken store does not lift the limit.
+func (b *tokenBucket) AllowN(key string, n int64) bool {
+	allowed := false
+	b.store.Update("ratelimit:"+key, b.ttl, func(old interface{}, exists bool) (interface{}, bool) {
+		allowed = false
+		now := time.Now()
+		state := bucket{tokens: float64(b.burst), updated: now}
+		if exists {
+			previous, ok := parseBucket(old)
+			if !ok {
+				previous = bucket{updated: now}
+			}
+			refilled := previous.tokens + now.Sub(previous.updated).Seconds()*b.rate
+			state.tokens = math.Min(float64(b.burst), refilled)
+		}
+		allowed = state.tokens >= float64(n)
+		if allowed {
+			state.tokens -= float64(n)
+		}
+
+		return state.String(), true
+	})
+
+	return allowed
+}
+
+// String encodes b as its tokens and update time in Unix nanoseconds.
+func (b bucket) String() string {
+	return strconv.FormatFloat(b.tokens, 'g', -1, 64) + " " + strconv.FormatInt(b.updated.UnixNano(), 10)
+}
+
+func parseBucket(value interface{}) (bucket, bool) {
+	encoded, ok := value.(string)
+	if !ok {
+		return bucket{}, false
+	}
+	tokens, updated, found := strings.Cut(encoded, " ")
+	if !found {
+		return bucket{}, false
+	}
+	b := bucket{}
+	var err error
+	if b.tokens, err = strconv.ParseFloat(tokens, 64); err != nil {
+		return bucket{}, false
+	}
+	nanos, err := strconv.ParseInt(updated, 10, 64)
+	if err != nil {
+		return bucket{}, false
+	}
+	b.updated = time.Unix(0, nanos)
+
+	return b, true
+}
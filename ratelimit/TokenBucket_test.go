@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+func TestTokenBucket_AllowN(t *testing.T) {
+	store := newTestStore(t).(cache.Updater)
+	limiter := NewTokenBucket(store, 20, 5)
+
+	if !limiter.AllowN("client", 5) {
+		t.Fatalf("AllowN() of the burst = false, want true")
+	}
+	if limiter.Allow("client") {
+		t.Fatalf("Allow() of an empty bucket = true, want false")
+	}
+	if !limiter.Allow("other") {
+		t.Errorf("Allow() of another key = false, want true")
+	}
+
+	// 20 tokens per second refill one token in 50ms.
+	time.Sleep(time.Millisecond * 60)
+	if !limiter.Allow("client") {
+		t.Errorf("Allow() after a refill = false, want true")
+	}
+	if limiter.Allow("client") {
+		t.Errorf("Allow() after using the refilled token = true, want false")
+	}
+	if limiter.AllowN("client", 6) {
+		t.Errorf("AllowN() over the burst = true, want false")
+	}
+}
+
+func TestTokenBucket_Expiry(t *testing.T) {
+	store := newTestStore(t)
+	limiter := NewTokenBucket(store.(cache.Updater), 100, 5)
+
+	limiter.AllowN("client", 5)
+	if _, found := store.Get("ratelimit:client"); !found {
+		t.Fatalf("bucket not stored")
+	}
+	time.Sleep(time.Millisecond * 80)
+	if _, found := store.Get("ratelimit:client"); found {
+		t.Errorf("full bucket still stored")
+	}
+}
+
+func TestTokenBucket_Codecs(t *testing.T) {
+	tests := []struct {
+		name  string
+		store func(ctx context.Context) cache.Updater
+	}{
+		{
+			name: "Native values",
+			store: func(ctx context.Context) cache.Updater {
+				return cache.NewInMemoryCache(ctx).(cache.Updater)
+			},
+		},
+		{
+			name: "JSON codec",
+			store: func(ctx context.Context) cache.Updater {
+				return cache.NewInMemoryCache(ctx, cache.WithCodec(cache.JSONCodec{})).(cache.Updater)
+			},
+		},
+		{
+			name: "Gob codec",
+			store: func(ctx context.Context) cache.Updater {
+				return cache.NewInMemoryCache(ctx, cache.WithCodec(cache.GobCodec{})).(cache.Updater)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			limiter := NewTokenBucket(tt.store(ctx), 1, 2)
+
+			allowed := 0
+			for i := 0; i < 10; i++ {
+				if limiter.Allow("client") {
+					allowed++
+				}
+			}
+			if allowed != 2 {
+				t.Errorf("Allow() allowed %d of 10 events with burst 2, want 2", allowed)
+			}
+		})
+	}
+}
+
+func TestTokenBucket_UndecodableState(t *testing.T) {
+	store := newTestStore(t)
+	store.Set("ratelimit:client", 42, time.Minute)
+	limiter := NewTokenBucket(store.(cache.Updater), 1, 2)
+
+	if limiter.Allow("client") {
+		t.Errorf("Allow() with undecodable state = true, want false")
+	}
+}
+
+func TestNewTokenBucket_NonPositiveRate(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewTokenBucket() with rate %v did not panic", rate)
+				}
+			}()
+			NewTokenBucket(newTestStore(t).(cache.Updater), rate, 2)
+		}()
+	}
+}
@@ -0,0 +1,404 @@
+package rediscache
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"github.com/abicur/go-sim-cache/internal/resp"
+)
+
+const (
+	defaultAddress     = "localhost:6379"
+	defaultDialTimeout = time.Second * 5
+	defaultIOTimeout   = time.Second * 3
+	defaultPoolSize    = 10
+)
+
+var (
+	ErrUnsupportedValue = errors.New("rediscache: unsupported value type")
+	ErrClosed           = errors.New("rediscache: cache is closed")
+)
+
+// Cache is a cache.Cache backed by a Redis server. The Cache interface has no
+// error results, so failed reads are reported as misses and failed writes are
+// passed to the handler set with WithErrorHandler.
+type Cache interface {
+	cache.Cache
+	cache.MultiCache
+	Close() error
+}
+
+type redisCache struct {
+	address     string
+	username    string
+	password    string
+	db          int
+	keyPrefix   string
+	dialTimeout time.Duration
+	ioTimeout   time.Duration
+	codec       Codec
+	onError     func(err error)
+	idle        chan *conn
+	mu          sync.Mutex
+	closed      bool
+}
+
+type conn struct {
+	netConn net.Conn
+	reader  *resp.Reader
+	writer  *resp.Writer
+}
+
+// New returns a Redis-backed cache. Connections are dialled lazily and reused
+// up to the configured pool size.
+func New(options ...func(*redisCache)) Cache {
+	c := &redisCache{
+		address:     defaultAddress,
+		dialTimeout: defaultDialTimeout,
+		ioTimeout:   defaultIOTimeout,
+		codec:       GobCodec{},
+		onError:     func(error) {},
+		idle:        make(chan *conn, defaultPoolSize),
+	}
+	for _, optionFn := range options {
+		optionFn(c)
+	}
+
+	return c
+}
+
+func WithAddress(address string) func(*redisCache) {
+	return func(c *redisCache) {
+		c.address = address
+	}
+}
+
+// WithCredentials authenticates new connections with AUTH. An empty username
+// uses the legacy single-password form.
+func WithCredentials(username, password string) func(*redisCache) {
+	return func(c *redisCache) {
+		c.username = username
+		c.password = password
+	}
+}
+
+func WithDB(db int) func(*redisCache) {
+	return func(c *redisCache) {
+		c.db = db
+	}
+}
+
+func WithKeyPrefix(prefix string) func(*redisCache) {
+	return func(c *redisCache) {
+		c.keyPrefix = prefix
+	}
+}
+
+func WithDialTimeout(timeout time.Duration) func(*redisCache) {
+	return func(c *redisCache) {
+		c.dialTimeout = timeout
+	}
+}
+
+// WithIOTimeout bounds each command round trip.
+func WithIOTimeout(timeout time.Duration) func(*redisCache) {
+	return func(c *redisCache) {
+		c.ioTimeout = timeout
+	}
+}
+
+func WithPoolSize(size int) func(*redisCache) {
+	return func(c *redisCache) {
+		c.idle = make(chan *conn, size)
+	}
+}
+
+func WithCodec(codec Codec) func(*redisCache) {
+	return func(c *redisCache) {
+		c.codec = codec
+	}
+}
+
+func WithErrorHandler(handler func(err error)) func(*redisCache) {
+	return func(c *redisCache) {
+		c.onError = handler
+	}
+}
+
+func (c *redisCache) Get(key string) (interface{}, bool) {
+	reply, err := c.do("GET", c.keyPrefix+key)
+	if err != nil {
+		c.onError(err)
+		return nil, false
+	}
+	if reply.Null {
+		return nil, false
+	}
+
+	value, err := c.codec.Unmarshal([]byte(reply.Str))
+	if err != nil {
+		c.onError(err)
+		return nil, false
+	}
+
+	return value, true
+}
+
+// GetMulti reads every key with one MGET. Missing keys are absent from the
+// result.
+func (c *redisCache) GetMulti(keys []string) map[string]interface{} {
+	values := make(map[string]interface{}, len(keys))
+	if len(keys) == 0 {
+		return values
+	}
+
+	args := make([]string, 0, len(keys)+1)
+	args = append(args, "MGET")
+	for _, key := range keys {
+		args = append(args, c.keyPrefix+key)
+	}
+	reply, err := c.do(args...)
+	if err == nil && len(reply.Array) != len(keys) {
+		err = fmt.Errorf("%w: MGET returned %d values for %d keys", resp.ErrProtocol, len(reply.Array), len(keys))
+	}
+	if err != nil {
+		c.onError(err)
+		return values
+	}
+
+	for i, element := range reply.Array {
+		if element.Null {
+			continue
+		}
+		value, err := c.codec.Unmarshal([]byte(element.Str))
+		if err != nil {
+			c.onError(err)
+			continue
+		}
+		values[keys[i]] = value
+	}
+
+	return values
+}
+
+// Set stores value with SET ... PX, so the value and its expiration are
+// written atomically. A non-positive interval stores the key without
+// expiration.
+func (c *redisCache) Set(key string, value interface{}, expiredInterval time.Duration) {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		c.onError(err)
+		return
+	}
+
+	if _, err := c.do(c.setArgs(key, data, expiredInterval)...); err != nil {
+		c.onError(err)
+	}
+}
+
+// SetMulti pipelines a SET ... PX per item: all of them are sent before the
+// first reply is read.
+func (c *redisCache) SetMulti(items map[string]interface{}, expiredInterval time.Duration) {
+	commands := make([][]string, 0, len(items))
+	for key, value := range items {
+		data, err := c.codec.Marshal(value)
+		if err != nil {
+			c.onError(err)
+			continue
+		}
+		commands = append(commands, c.setArgs(key, data, expiredInterval))
+	}
+	if len(commands) == 0 {
+		return
+	}
+
+	replies, err := c.pipeline(commands)
+	if err != nil {
+		c.onError(err)
+		return
+	}
+	for _, reply := range replies {
+		if err := reply.Err(); err != nil {
+			c.onError(err)
+		}
+	}
+}
+
+func (c *redisCache) Delete(key string) {
+	if _, err := c.do("DEL", c.keyPrefix+key); err != nil {
+		c.onError(err)
+	}
+}
+
+// DeleteMulti removes every key with one DEL.
+func (c *redisCache) DeleteMulti(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	args := make([]string, 0, len(keys)+1)
+	args = append(args, "DEL")
+	for _, key := range keys {
+		args = append(args, c.keyPrefix+key)
+	}
+	if _, err := c.do(args...); err != nil {
+		c.onError(err)
+	}
+}
+
+func (c *redisCache) setArgs(key string, data []byte, expiredInterval time.Duration) []string {
+	args := []string{"SET", c.keyPrefix + key, string(data)}
+	if expiredInterval > 0 {
+		milliseconds := expiredInterval.Milliseconds()
+		if milliseconds == 0 {
+			milliseconds = 1
+		}
+		args = append(args, "PX", strconv.FormatInt(milliseconds, 10))
+	}
+
+	return args
+}
+
+func (c *redisCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.idle)
+	for cn := range c.idle {
+		cn.netConn.Close()
+	}
+
+	return nil
+}
+
+func (c *redisCache) do(args ...string) (resp.Value, error) {
+	cn, err := c.get()
+	if err != nil {
+		return resp.Value{}, err
+	}
+
+	reply, err := cn.roundTrip(c.ioTimeout, args...)
+	if err != nil {
+		cn.netConn.Close()
+		return resp.Value{}, err
+	}
+	c.put(cn)
+
+	return reply, reply.Err()
+}
+
+// pipeline sends commands on one connection and returns their replies,
+// which may be errors.
+func (c *redisCache) pipeline(commands [][]string) ([]resp.Value, error) {
+	cn, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := cn.pipeline(c.ioTimeout, commands)
+	if err != nil {
+		cn.netConn.Close()
+		return nil, err
+	}
+	c.put(cn)
+
+	return replies, nil
+}
+
+func (c *redisCache) get() (*conn, error) {
+	select {
+	case cn, ok := <-c.idle:
+		if !ok {
+			return nil, ErrClosed
+		}
+		return cn, nil
+	default:
+	}
+
+	return c.dial()
+}
+
+func (c *redisCache) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		cn.netConn.Close()
+		return
+	}
+	select {
+	case c.idle <- cn:
+	default:
+		cn.netConn.Close()
+	}
+}
+
+func (c *redisCache) dial() (*conn, error) {
+	netConn, err := net.DialTimeout("tcp", c.address, c.dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{netConn: netConn, reader: resp.NewReader(netConn), writer: resp.NewWriter(netConn)}
+
+	var handshake [][]string
+	if c.password != "" {
+		if c.username != "" {
+			handshake = append(handshake, []string{"AUTH", c.username, c.password})
+		} else {
+			handshake = append(handshake, []string{"AUTH", c.password})
+		}
+	}
+	if c.db != 0 {
+		handshake = append(handshake, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	for _, args := range handshake {
+		reply, err := cn.roundTrip(c.ioTimeout, args...)
+		if err == nil {
+			err = reply.Err()
+		}
+		if err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+
+	return cn, nil
+}
+
+func (cn *conn) roundTrip(timeout time.Duration, args ...string) (resp.Value, error) {
+	replies, err := cn.pipeline(timeout, [][]string{args})
+	if err != nil {
+		return resp.Value{}, err
+	}
+
+	return replies[0], nil
+}
+
+func (cn *conn) pipeline(timeout time.Duration, commands [][]string) ([]resp.Value, error) {
+	if timeout > 0 {
+		_ = cn.netConn.SetDeadline(time.Now().Add(timeout))
+	}
+	if err := cn.writer.WriteCommands(commands...); err != nil {
+		return nil, err
+	}
+
+	replies := make([]resp.Value, len(commands))
+	for i := range replies {
+		reply, err := cn.reader.ReadValue()
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+
+	return replies, nil
+}
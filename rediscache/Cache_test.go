@@ -0,0 +1,339 @@
+package rediscache
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abicur/go-sim-cache/internal/resp"
+)
+
+type fakeServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	values   map[string]string
+	expiries map[string]time.Time
+	commands [][]string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	server := &fakeServer{
+		listener: listener,
+		values:   make(map[string]string),
+		expiries: make(map[string]time.Time),
+	}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+
+	return server
+}
+
+func (s *fakeServer) serve() {
+	for {
+		netConn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(netConn)
+	}
+}
+
+func (s *fakeServer) handle(netConn net.Conn) {
+	defer netConn.Close()
+	reader := resp.NewReader(netConn)
+	writer := resp.NewWriter(netConn)
+	for {
+		args, err := reader.ReadCommand()
+		if err != nil {
+			return
+		}
+		s.execute(writer, args)
+		if writer.Flush() != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) execute(writer *resp.Writer, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commands = append(s.commands, args)
+	switch strings.ToUpper(args[0]) {
+	case "AUTH":
+		if args[len(args)-1] != "secret" {
+			writer.WriteError("WRONGPASS invalid password")
+			return
+		}
+		writer.WriteSimpleString("OK")
+	case "SELECT":
+		writer.WriteSimpleString("OK")
+	case "GET":
+		s.writeValue(writer, args[1])
+	case "MGET":
+		writer.WriteArrayHeader(len(args) - 1)
+		for _, key := range args[1:] {
+			s.writeValue(writer, key)
+		}
+	case "SET":
+		s.values[args[1]] = args[2]
+		delete(s.expiries, args[1])
+		if len(args) > 4 {
+			milliseconds, _ := strconv.Atoi(args[4])
+			s.expiries[args[1]] = time.Now().Add(time.Duration(milliseconds) * time.Millisecond)
+		}
+		writer.WriteSimpleString("OK")
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, found := s.values[key]; found {
+				delete(s.values, key)
+				deleted++
+			}
+		}
+		writer.WriteInteger(int64(deleted))
+	default:
+		writer.WriteError("ERR unknown command")
+	}
+}
+
+func (s *fakeServer) writeValue(writer *resp.Writer, key string) {
+	value, found := s.values[key]
+	expiry := s.expiries[key]
+	if !found || !expiry.IsZero() && time.Now().After(expiry) {
+		writer.WriteNull()
+		return
+	}
+	writer.WriteBulkString(value)
+}
+
+func (s *fakeServer) recorded() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([][]string(nil), s.commands...)
+}
+
+func Test_redisCache(t *testing.T) {
+	tests := []struct {
+		name            string
+		value           interface{}
+		expiredInterval time.Duration
+		wait            time.Duration
+		wantValue       interface{}
+		wantFound       bool
+	}{
+		{
+			name:            "Get stored value",
+			value:           42,
+			expiredInterval: time.Second * 10,
+			wantValue:       42,
+			wantFound:       true,
+		},
+		{
+			name:            "Get expired value",
+			value:           42,
+			expiredInterval: time.Millisecond * 5,
+			wait:            time.Millisecond * 20,
+		},
+		{
+			name:            "Set without expiration",
+			value:           42,
+			expiredInterval: 0,
+			wantValue:       42,
+			wantFound:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer(t)
+			c := New(WithAddress(server.listener.Addr().String()))
+			defer c.Close()
+
+			c.Set("test", tt.value, tt.expiredInterval)
+			time.Sleep(tt.wait)
+
+			value, found := c.Get("test")
+			if !reflect.DeepEqual(value, tt.wantValue) || found != tt.wantFound {
+				t.Errorf("Get() = %v, %v, want %v, %v", value, found, tt.wantValue, tt.wantFound)
+			}
+		})
+	}
+}
+
+func Test_redisCache_Delete(t *testing.T) {
+	server := newFakeServer(t)
+	c := New(WithAddress(server.listener.Addr().String()), WithKeyPrefix("app:"))
+	defer c.Close()
+
+	c.Set("test", "value", time.Second*10)
+	c.Delete("test")
+	if _, found := c.Get("test"); found {
+		t.Errorf("Get() found a deleted key")
+	}
+
+	commands := server.recorded()
+	if commands[0][0] != "SET" || commands[0][1] != "app:test" || commands[0][3] != "PX" || commands[0][4] != "10000" {
+		t.Errorf("SET command = %v, want prefixed key with PX 10000", commands[0])
+	}
+}
+
+func Test_redisCache_Multi(t *testing.T) {
+	server := newFakeServer(t)
+	var handledErr error
+	c := New(
+		WithAddress(server.listener.Addr().String()),
+		WithKeyPrefix("app:"),
+		WithCodec(StringCodec{}),
+		WithErrorHandler(func(err error) { handledErr = err }),
+	)
+	defer c.Close()
+
+	items := map[string]interface{}{"a": "1", "b": "2", "c": "3"}
+	c.SetMulti(items, time.Second*10)
+	if got := c.GetMulti([]string{"a", "missing", "b", "c"}); !reflect.DeepEqual(got, items) {
+		t.Errorf("GetMulti() = %v, want %v", got, items)
+	}
+	c.DeleteMulti([]string{"a", "b"})
+	if got := c.GetMulti([]string{"a", "b", "c"}); !reflect.DeepEqual(got, map[string]interface{}{"c": "3"}) {
+		t.Errorf("GetMulti() after DeleteMulti() = %v, want only c", got)
+	}
+	if handledErr != nil {
+		t.Errorf("batch error: %v", handledErr)
+	}
+
+	var names []string
+	for _, command := range server.recorded() {
+		names = append(names, command[0])
+	}
+	want := []string{"SET", "SET", "SET", "MGET", "DEL", "MGET"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("commands = %v, want %v", names, want)
+	}
+	for _, command := range server.recorded()[:3] {
+		if len(command) != 5 || !strings.HasPrefix(command[1], "app:") || command[3] != "PX" || command[4] != "10000" {
+			t.Errorf("SET command = %v, want prefixed key with PX 10000", command)
+		}
+	}
+}
+
+func Test_redisCache_Multi_Errors(t *testing.T) {
+	server := newFakeServer(t)
+	var handledErrs []error
+	c := New(
+		WithAddress(server.listener.Addr().String()),
+		WithCodec(StringCodec{}),
+		WithErrorHandler(func(err error) { handledErrs = append(handledErrs, err) }),
+	)
+	defer c.Close()
+
+	c.SetMulti(map[string]interface{}{"valid": "1", "invalid": 2}, time.Second)
+	if len(handledErrs) != 1 || !errors.Is(handledErrs[0], ErrUnsupportedValue) {
+		t.Errorf("SetMulti() errors = %v, want %v", handledErrs, ErrUnsupportedValue)
+	}
+	if got := c.GetMulti([]string{"valid", "invalid"}); !reflect.DeepEqual(got, map[string]interface{}{"valid": "1"}) {
+		t.Errorf("GetMulti() = %v, want only the encodable value", got)
+	}
+	c.GetMulti(nil)
+	c.DeleteMulti(nil)
+	if commands := server.recorded(); len(commands) != 2 {
+		t.Errorf("commands = %v, want none for empty batches", commands)
+	}
+}
+
+func Test_redisCache_Handshake(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   []func(*redisCache)
+		wantFirst []string
+		wantErr   bool
+	}{
+		{
+			name:      "Password and database",
+			options:   []func(*redisCache){WithCredentials("", "secret"), WithDB(2)},
+			wantFirst: []string{"AUTH", "secret"},
+		},
+		{
+			name:      "Username and password",
+			options:   []func(*redisCache){WithCredentials("user", "secret")},
+			wantFirst: []string{"AUTH", "user", "secret"},
+		},
+		{
+			name:      "Wrong password",
+			options:   []func(*redisCache){WithCredentials("", "wrong")},
+			wantFirst: []string{"AUTH", "wrong"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer(t)
+			var handledErr error
+			options := append([]func(*redisCache){
+				WithAddress(server.listener.Addr().String()),
+				WithErrorHandler(func(err error) { handledErr = err }),
+			}, tt.options...)
+			c := New(options...)
+			defer c.Close()
+
+			c.Set("test", "value", time.Second)
+			if (handledErr != nil) != tt.wantErr {
+				t.Fatalf("Set() error = %v, wantErr %v", handledErr, tt.wantErr)
+			}
+			if got := server.recorded()[0]; !reflect.DeepEqual(got, tt.wantFirst) {
+				t.Errorf("first command = %v, want %v", got, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func Test_redisCache_Codec(t *testing.T) {
+	server := newFakeServer(t)
+	var handledErr error
+	c := New(
+		WithAddress(server.listener.Addr().String()),
+		WithCodec(StringCodec{}),
+		WithErrorHandler(func(err error) { handledErr = err }),
+	)
+	defer c.Close()
+
+	c.Set("test", []byte("raw"), time.Second)
+	if got := server.recorded()[0][2]; got != "raw" {
+		t.Errorf("stored value = %q, want %q", got, "raw")
+	}
+	if value, _ := c.Get("test"); value != "raw" {
+		t.Errorf("Get() = %v, want %q", value, "raw")
+	}
+
+	c.Set("test", 42, time.Second)
+	if !errors.Is(handledErr, ErrUnsupportedValue) {
+		t.Errorf("Set() error = %v, want %v", handledErr, ErrUnsupportedValue)
+	}
+}
+
+func Test_redisCache_Close(t *testing.T) {
+	server := newFakeServer(t)
+	var handledErr error
+	c := New(
+		WithAddress(server.listener.Addr().String()),
+		WithErrorHandler(func(err error) { handledErr = err }),
+	)
+	c.Set("test", "value", time.Second)
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	c.Get("test")
+	if !errors.Is(handledErr, ErrClosed) {
+		t.Errorf("Get() after Close() error = %v, want %v", handledErr, ErrClosed)
+	}
+}
@@ -0,0 +1,29 @@
+package rediscache
+
+import cache "github.com/abicur/go-sim-cache"
+
+// Codec converts cache values to and from the bytes stored in Redis.
+type Codec = cache.Codec
+
+// GobCodec is the default codec. Concrete types other than the gob builtins
+// must be registered with gob.Register.
+type GobCodec = cache.GobCodec
+
+// StringCodec stores strings and byte slices verbatim, which keeps the values
+// readable by other Redis clients. Get always returns a string.
+type StringCodec struct{}
+
+func (StringCodec) Marshal(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	default:
+		return nil, ErrUnsupportedValue
+	}
+}
+
+func (StringCodec) Unmarshal(data []byte) (interface{}, error) {
+	return string(data), nil
+}
@@ -0,0 +1,210 @@
+package sessionstore
+
+import (
+	"encoding/base32"
+	"encoding/gob"
+	"errors"
+	"net/http"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+const (
+	defaultKeyPrefix = "session:"
+	defaultMaxAge    = 86400 * 30
+)
+
+var ErrCorruptSession = errors.New("sessionstore: corrupt session values")
+
+var idEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func init() {
+	gob.Register(map[interface{}]interface{}{})
+}
+
+type store struct {
+	cache     cache.Cache
+	keyPrefix string
+	codecs    []securecookie.Codec
+	codec     cache.Codec
+	options   sessions.Options
+}
+
+// New returns a sessions.Store keeping session values in c for the MaxAge of
+// the session options. The cookie holds only the session ID, signed and
+// optionally encrypted with the WithKeyPairs keys, or as is without them.
+// Sessions saved with a non-positive MaxAge are deleted.
+func New(c cache.Cache, options ...func(*store)) sessions.Store {
+	s := &store{
+		cache:     c,
+		keyPrefix: defaultKeyPrefix,
+		options:   sessions.Options{Path: "/", MaxAge: defaultMaxAge},
+	}
+	for _, optionFn := range options {
+		optionFn(s)
+	}
+
+	return s
+}
+
+// WithKeyPairs signs and encrypts the session ID cookie like
+// sessions.NewCookieStore does with its key pairs.
+func WithKeyPairs(keyPairs ...[]byte) func(*store) {
+	return func(s *store) {
+		s.codecs = securecookie.CodecsFromPairs(keyPairs...)
+	}
+}
+
+// WithCookieOptions sets the options of new sessions, which default to path
+// "/" and a MaxAge of 30 days.
+func WithCookieOptions(options sessions.Options) func(*store) {
+	return func(s *store) {
+		s.options = options
+	}
+}
+
+// WithCodec stores session values encoded by codec rather than as maps, for
+// caches that keep values outside the process. codec must round-trip the
+// map[interface{}]interface{} of session values, as GobCodec does for types
+// registered with gob.Register.
+func WithCodec(codec cache.Codec) func(*store) {
+	return func(s *store) {
+		s.codec = codec
+	}
+}
+
+// WithKeyPrefix sets the prefix of the cache keys of sessions, "session:" by
+// default.
+func WithKeyPrefix(prefix string) func(*store) {
+	return func(s *store) {
+		s.keyPrefix = prefix
+	}
+}
+
+func (s *store) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns the session named name of the request, or a new session if the
+// request has none or its session expired.
+func (s *store) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	options := s.options
+	session.Options = &options
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	if err := s.decodeID(name, cookie.Value, &session.ID); err != nil {
+		session.ID = ""
+		return session, err
+	}
+	found, err := s.load(session)
+	if !found {
+		// Never adopt an ID the cache does not know, so that clients
+		// cannot choose the ID of their next session.
+		session.ID = ""
+	}
+	session.IsNew = !found
+
+	return session, err
+}
+
+func (s *store) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge <= 0 {
+		if session.ID != "" {
+			s.cache.Delete(s.keyPrefix + session.ID)
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = idEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+	}
+	if err := s.save(session); err != nil {
+		return err
+	}
+	encoded, err := s.encodeID(session.Name(), session.ID)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+
+	return nil
+}
+
+func (s *store) encodeID(name, id string) (string, error) {
+	if len(s.codecs) == 0 {
+		return id, nil
+	}
+
+	return securecookie.EncodeMulti(name, id, s.codecs...)
+}
+
+func (s *store) decodeID(name, value string, id *string) error {
+	if len(s.codecs) == 0 {
+		*id = value
+		return nil
+	}
+
+	return securecookie.DecodeMulti(name, value, id, s.codecs...)
+}
+
+func (s *store) save(session *sessions.Session) error {
+	var value interface{} = copyValues(session.Values)
+	if s.codec != nil {
+		data, err := s.codec.Marshal(session.Values)
+		if err != nil {
+			return err
+		}
+		value = data
+	}
+	s.cache.Set(s.keyPrefix+session.ID, value, time.Duration(session.Options.MaxAge)*time.Second)
+
+	return nil
+}
+
+// load reads the values of session from the cache and reports whether they
+// were there.
+func (s *store) load(session *sessions.Session) (bool, error) {
+	value, found := s.cache.Get(s.keyPrefix + session.ID)
+	if !found {
+		return false, nil
+	}
+
+	if s.codec != nil {
+		data, ok := value.([]byte)
+		if !ok {
+			return false, ErrCorruptSession
+		}
+		decoded, err := s.codec.Unmarshal(data)
+		if err != nil {
+			return false, err
+		}
+		value = decoded
+	}
+	values, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return false, ErrCorruptSession
+	}
+	session.Values = copyValues(values)
+
+	return true, nil
+}
+
+// copyValues keeps changes to a session from reaching the cache before the
+// session is saved.
+func copyValues(values map[interface{}]interface{}) map[interface{}]interface{} {
+	copied := make(map[interface{}]interface{}, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+
+	return copied
+}
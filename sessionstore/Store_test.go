@@ -0,0 +1,142 @@
+package sessionstore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"github.com/gorilla/sessions"
+)
+
+// request runs a request carrying cookie through store, lets update change
+// the session named "test" and returns it with the cookie set by Save.
+func request(t *testing.T, store sessions.Store, cookie *http.Cookie, update func(s *sessions.Session)) (*sessions.Session, *http.Cookie) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	session, err := store.Get(req, "test")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	update(session)
+
+	recorder := httptest.NewRecorder()
+	if err := session.Save(req, recorder); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Save() set %d cookies, want 1", len(cookies))
+	}
+
+	return session, cookies[0]
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*store)
+	}{
+		{
+			name: "Plain ID",
+		},
+		{
+			name:    "Signed ID",
+			options: []func(*store){WithKeyPairs([]byte("0123456789abcdef0123456789abcdef"))},
+		},
+		{
+			name:    "Encoded values",
+			options: []func(*store){WithCodec(cache.GobCodec{})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			store := New(cache.NewInMemoryCache(ctx), tt.options...)
+
+			first, cookie := request(t, store, nil, func(s *sessions.Session) {
+				if !s.IsNew {
+					t.Errorf("session without cookie is not new")
+				}
+				s.Values["user"] = "ada"
+			})
+			if cookie.MaxAge != defaultMaxAge {
+				t.Errorf("cookie MaxAge = %d, want %d", cookie.MaxAge, defaultMaxAge)
+			}
+
+			second, _ := request(t, store, cookie, func(s *sessions.Session) {
+				if s.IsNew {
+					t.Errorf("session with a cookie is new")
+				}
+				if s.Values["user"] != "ada" {
+					t.Errorf("Values[user] = %v, want %q", s.Values["user"], "ada")
+				}
+				s.Options.MaxAge = -1
+			})
+			if second.ID != first.ID {
+				t.Errorf("session ID changed from %q to %q", first.ID, second.ID)
+			}
+
+			request(t, store, cookie, func(s *sessions.Session) {
+				if !s.IsNew || len(s.Values) != 0 {
+					t.Errorf("deleted session came back with %v", s.Values)
+				}
+			})
+		})
+	}
+}
+
+func TestNew_Expiry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := New(cache.NewInMemoryCache(ctx), WithCookieOptions(sessions.Options{Path: "/", MaxAge: 1}))
+
+	_, cookie := request(t, store, nil, func(s *sessions.Session) {
+		s.Values["user"] = "ada"
+	})
+	time.Sleep(time.Second + time.Millisecond*100)
+
+	request(t, store, cookie, func(s *sessions.Session) {
+		if !s.IsNew || len(s.Values) != 0 {
+			t.Errorf("expired session came back with %v", s.Values)
+		}
+	})
+}
+
+func TestNew_UnknownID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := New(cache.NewInMemoryCache(ctx))
+
+	session, _ := request(t, store, &http.Cookie{Name: "test", Value: "CHOSEN"}, func(s *sessions.Session) {
+		if !s.IsNew {
+			t.Errorf("session with an unknown ID is not new")
+		}
+	})
+	if session.ID == "CHOSEN" {
+		t.Errorf("Save() adopted the ID chosen by the client")
+	}
+}
+
+func TestNew_Tampered(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := New(cache.NewInMemoryCache(ctx), WithKeyPairs([]byte("0123456789abcdef0123456789abcdef")))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "test", Value: "forged"})
+	session, err := store.New(req, "test")
+	if err == nil {
+		t.Errorf("New() accepted a forged cookie")
+	}
+	if session == nil || !session.IsNew || session.ID != "" {
+		t.Errorf("New() = %+v, want a new session without ID", session)
+	}
+}
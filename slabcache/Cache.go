@@ -0,0 +1,275 @@
+package slabcache
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+const (
+	defaultShards    = 16
+	defaultShardSize = 1 << 20
+
+	// An entry is the expiration time, the key length, the key and the value.
+	entryHeaderSize = 8 + 2
+	maxKeyLength    = 1<<16 - 1
+)
+
+var (
+	ErrEntryTooLarge   = errors.New("slabcache: entry does not fit in a shard")
+	ErrKeyTooLong      = errors.New("slabcache: key is too long")
+	ErrMmapUnsupported = errors.New("slabcache: mmap is not supported on this platform")
+)
+
+// Cache is a cache.Cache that keeps encoded values in a few large
+// preallocated byte slices, so millions of entries cost the garbage collector
+// a handful of pointers instead of millions. Each shard is a ring: when it is
+// full the oldest entries are dropped, whether they expired or not. Deleted
+// and overwritten entries keep their space until the ring reaches them.
+//
+// Keys are indexed by a 64-bit hash; a key whose hash collides with another
+// replaces it. Values go through a Codec, GobCodec by default, and encoding
+// errors are passed to the handler set with WithErrorHandler.
+type Cache interface {
+	cache.Cache
+	Close() error
+}
+
+type slabCache struct {
+	shardCount int
+	shardSize  int
+	codec      Codec
+	onError    func(err error)
+	now        func() time.Time
+	useMmap    bool
+	shards     []*shard
+}
+
+type shard struct {
+	mu      sync.RWMutex
+	index   map[uint64]uint32
+	entries *byteQueue
+	mapped  bool
+}
+
+func New(options ...func(*slabCache)) Cache {
+	c := &slabCache{
+		shardCount: defaultShards,
+		shardSize:  defaultShardSize,
+		codec:      GobCodec{},
+		onError:    func(error) {},
+		now:        time.Now,
+	}
+	for _, optionFn := range options {
+		optionFn(c)
+	}
+
+	count := 1
+	for count < c.shardCount {
+		count <<= 1
+	}
+	c.shards = make([]*shard, count)
+	for i := range c.shards {
+		c.shards[i] = c.newShard()
+	}
+
+	return c
+}
+
+// newShard maps the slab of a shard when WithMmap is set and falls back to
+// the heap if that fails.
+func (c *slabCache) newShard() *shard {
+	s := &shard{index: make(map[uint64]uint32)}
+	if c.useMmap {
+		data, err := mmap(c.shardSize)
+		if err == nil {
+			s.entries, s.mapped = newByteQueue(data), true
+			return s
+		}
+		c.onError(err)
+	}
+	s.entries = newByteQueue(make([]byte, c.shardSize))
+
+	return s
+}
+
+// WithShards sets the number of shards, rounded up to a power of two. More
+// shards mean less lock contention.
+func WithShards(shards int) func(*slabCache) {
+	return func(c *slabCache) {
+		c.shardCount = shards
+	}
+}
+
+// WithShardSize sets the bytes preallocated per shard, which bounds both the
+// memory of the cache and the size of a single entry. It must be below 4 GiB.
+func WithShardSize(bytes int) func(*slabCache) {
+	return func(c *slabCache) {
+		c.shardSize = bytes
+	}
+}
+
+// WithMmap allocates the slabs with mmap, outside the Go heap, so caches of
+// tens of gigabytes neither count towards GOGC nor get scanned. The index
+// stays on the heap. Close must be called to release the memory. Where mmap
+// is unavailable the error is passed to the error handler and the slabs are
+// allocated on the heap.
+func WithMmap() func(*slabCache) {
+	return func(c *slabCache) {
+		c.useMmap = true
+	}
+}
+
+func WithCodec(codec Codec) func(*slabCache) {
+	return func(c *slabCache) {
+		c.codec = codec
+	}
+}
+
+func WithErrorHandler(handler func(err error)) func(*slabCache) {
+	return func(c *slabCache) {
+		c.onError = handler
+	}
+}
+
+func (c *slabCache) Get(key string) (interface{}, bool) {
+	hash := fnv64a(key)
+	s := c.shard(hash)
+
+	s.mu.RLock()
+	offset, found := s.index[hash]
+	var data []byte
+	if found && s.entries != nil {
+		entry := s.entries.get(int(offset))
+		if string(entryKey(entry)) == key && !expired(entry, c.now()) {
+			data = append([]byte(nil), entryValue(entry)...)
+		}
+	}
+	s.mu.RUnlock()
+	if data == nil {
+		return nil, false
+	}
+
+	value, err := c.codec.Unmarshal(data)
+	if err != nil {
+		c.onError(err)
+		return nil, false
+	}
+
+	return value, true
+}
+
+func (c *slabCache) Set(key string, value interface{}, expiredInterval time.Duration) {
+	if len(key) > maxKeyLength {
+		c.onError(ErrKeyTooLong)
+		return
+	}
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		c.onError(err)
+		return
+	}
+
+	var expiresAt int64
+	if expiredInterval > 0 {
+		expiresAt = c.now().Add(expiredInterval).UnixNano()
+	}
+	entry := make([]byte, entryHeaderSize+len(key)+len(data))
+	binary.BigEndian.PutUint64(entry, uint64(expiresAt))
+	binary.BigEndian.PutUint16(entry[8:], uint16(len(key)))
+	copy(entry[entryHeaderSize:], key)
+	copy(entry[entryHeaderSize+len(key):], data)
+
+	hash := fnv64a(key)
+	s := c.shard(hash)
+	if headerSize+len(entry) > c.shardSize {
+		c.onError(ErrEntryTooLarge)
+		c.Delete(key)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries == nil {
+		return
+	}
+	offset := s.entries.push(entry, func(offset int, evicted []byte) {
+		evictedHash := fnv64a(string(entryKey(evicted)))
+		if s.index[evictedHash] == uint32(offset) {
+			delete(s.index, evictedHash)
+		}
+	})
+	s.index[hash] = uint32(offset)
+}
+
+func (c *slabCache) Delete(key string) {
+	hash := fnv64a(key)
+	s := c.shard(hash)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if offset, found := s.index[hash]; found && s.entries != nil && string(entryKey(s.entries.get(int(offset)))) == key {
+		delete(s.index, hash)
+	}
+}
+
+// Close releases the slabs. The cache stays usable but holds nothing.
+func (c *slabCache) Close() error {
+	var err error
+	for _, s := range c.shards {
+		s.mu.Lock()
+		if s.mapped {
+			if unmapErr := munmap(s.entries.data); err == nil {
+				err = unmapErr
+			}
+		}
+		s.entries, s.index, s.mapped = nil, make(map[uint64]uint32), false
+		s.mu.Unlock()
+	}
+
+	return err
+}
+
+func (c *slabCache) shard(hash uint64) *shard {
+	return c.shards[hash&uint64(len(c.shards)-1)]
+}
+
+func entryKey(entry []byte) []byte {
+	keyLength := int(binary.BigEndian.Uint16(entry[8:]))
+
+	return entry[entryHeaderSize : entryHeaderSize+keyLength]
+}
+
+func entryValue(entry []byte) []byte {
+	keyLength := int(binary.BigEndian.Uint16(entry[8:]))
+
+	return entry[entryHeaderSize+keyLength:]
+}
+
+// expired reports false for entries stored without expiration, whose
+// expiration time is zero.
+func expired(entry []byte, now time.Time) bool {
+	expiresAt := int64(binary.BigEndian.Uint64(entry))
+
+	return expiresAt != 0 && now.UnixNano() > expiresAt
+}
+
+func fnv64a(key string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+
+	hash := uint64(offset64)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint64(key[i])
+		hash *= prime64
+	}
+
+	return hash
+}
@@ -0,0 +1,204 @@
+package slabcache
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+	"github.com/abicur/go-sim-cache/cachetest"
+)
+
+func Test_slabCache_GetSetDelete(t *testing.T) {
+	tests := []struct {
+		name              string
+		value             interface{}
+		expiredInterval   time.Duration
+		deleteBeforeGet   bool
+		expectedValue     interface{}
+		expectedExistence bool
+	}{
+		{
+			name:              "Get stored value",
+			value:             []int{1, 2},
+			expiredInterval:   time.Second * 10,
+			expectedValue:     []int{1, 2},
+			expectedExistence: true,
+		},
+		{
+			name:            "Get expired value",
+			value:           "value",
+			expiredInterval: time.Nanosecond,
+		},
+		{
+			name:              "Get value without expiration",
+			value:             "value",
+			expectedValue:     "value",
+			expectedExistence: true,
+		},
+		{
+			name:            "Get deleted value",
+			value:           "value",
+			expiredInterval: time.Second * 10,
+			deleteBeforeGet: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(WithShards(2), WithShardSize(1024))
+			defer c.Close()
+
+			c.Set("test", tt.value, tt.expiredInterval)
+			if tt.deleteBeforeGet {
+				c.Delete("test")
+			}
+			time.Sleep(time.Millisecond)
+
+			value, found := c.Get("test")
+			if !reflect.DeepEqual(value, tt.expectedValue) || found != tt.expectedExistence {
+				t.Errorf("Get() = %v, %v, want %v, %v", value, found, tt.expectedValue, tt.expectedExistence)
+			}
+		})
+	}
+}
+
+func Test_slabCache_Overwrite(t *testing.T) {
+	c := New(WithShards(1), WithShardSize(256))
+	for i := 0; i < 100; i++ {
+		c.Set("test", i, time.Minute)
+	}
+
+	if value, found := c.Get("test"); !found || value != 99 {
+		t.Errorf("Get() = %v, %v, want %v, true", value, found, 99)
+	}
+}
+
+func Test_slabCache_RingEviction(t *testing.T) {
+	c := New(WithShards(1), WithShardSize(4096))
+	for i := 0; i < 1000; i++ {
+		c.Set(fmt.Sprintf("key%d", i), i, time.Minute)
+	}
+
+	if value, found := c.Get("key999"); !found || value != 999 {
+		t.Errorf("Get(key999) = %v, %v, want %v, true", value, found, 999)
+	}
+	if _, found := c.Get("key0"); found {
+		t.Errorf("Get(key0) found an entry the ring should have dropped")
+	}
+	if entries := len(c.(*slabCache).shards[0].index); entries >= 1000 || entries == 0 {
+		t.Errorf("index holds %d entries, want only those still in the ring", entries)
+	}
+}
+
+func Test_slabCache_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   interface{}
+		wantErr error
+	}{
+		{
+			name:    "Entry larger than a shard",
+			key:     "test",
+			value:   strings.Repeat("x", 2048),
+			wantErr: ErrEntryTooLarge,
+		},
+		{
+			name:    "Key too long",
+			key:     strings.Repeat("k", maxKeyLength+1),
+			value:   1,
+			wantErr: ErrKeyTooLong,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotErr error
+			c := New(WithShardSize(1024), WithErrorHandler(func(err error) { gotErr = err }))
+
+			c.Set(tt.key, tt.value, time.Minute)
+			if !errors.Is(gotErr, tt.wantErr) {
+				t.Errorf("error handler got %v, want %v", gotErr, tt.wantErr)
+			}
+			if _, found := c.Get(tt.key); found {
+				t.Errorf("Get() found an entry that could not be stored")
+			}
+		})
+	}
+}
+
+type stringCodec struct{}
+
+func (stringCodec) Marshal(value interface{}) ([]byte, error) {
+	return []byte(value.(string)), nil
+}
+
+func (stringCodec) Unmarshal(data []byte) (interface{}, error) {
+	return string(data), nil
+}
+
+func TestWithCodec(t *testing.T) {
+	c := New(WithCodec(stringCodec{}))
+	c.Set("test", "value", time.Minute)
+
+	if value, found := c.Get("test"); !found || value != "value" {
+		t.Errorf("Get() = %v, %v, want %q, true", value, found, "value")
+	}
+}
+
+func Test_slabCache_Concurrent(t *testing.T) {
+	c := New(WithShards(4), WithShardSize(16*1024))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				key := fmt.Sprintf("key%d", j%20)
+				c.Set(key, i, time.Minute)
+				c.Get(key)
+				if j%7 == 0 {
+					c.Delete(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestWithMmap(t *testing.T) {
+	var mmapErr error
+	c := New(WithMmap(), WithShards(2), WithShardSize(4096), WithErrorHandler(func(err error) { mmapErr = err }))
+	if mmapErr != nil {
+		t.Skipf("mmap unavailable: %v", mmapErr)
+	}
+	if !c.(*slabCache).shards[0].mapped {
+		t.Fatalf("WithMmap() allocated the slabs on the heap")
+	}
+
+	for i := 0; i < 500; i++ {
+		c.Set(fmt.Sprintf("key%d", i), i, time.Minute)
+	}
+	if value, found := c.Get("key499"); !found || value != 499 {
+		t.Errorf("Get() = %v, %v, want %v, true", value, found, 499)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if _, found := c.Get("key499"); found {
+		t.Errorf("Get() after Close() found an entry")
+	}
+	c.Set("test", 1, time.Minute)
+	c.Delete("test")
+}
+
+func Test_slabCache_Conformance(t *testing.T) {
+	cachetest.RunConformanceTests(t, func() cache.Cache {
+		return New()
+	})
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package slabcache
+
+import "syscall"
+
+// mmap allocates size bytes of anonymous memory outside the Go heap.
+func mmap(size int) ([]byte, error) {
+	return syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+}
+
+func munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package slabcache
+
+func mmap(size int) ([]byte, error) {
+	return nil, ErrMmapUnsupported
+}
+
+func munmap(data []byte) error {
+	return nil
+}
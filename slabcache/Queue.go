@@ -0,0 +1,75 @@
+package slabcache
+
+import "encoding/binary"
+
+const headerSize = 4
+
+// byteQueue is a FIFO of byte entries in one preallocated slice, used as a
+// ring: when the end of the slice is reached writing continues at the start,
+// dropping the oldest entries to make room. Entries are addressed by the
+// offset returned from push.
+//
+// While not wrapped the entries occupy [head, tail); once wrapped they occupy
+// [head, wrapAt) followed by [0, tail).
+type byteQueue struct {
+	data    []byte
+	head    int
+	tail    int
+	wrapAt  int
+	wrapped bool
+	count   int
+}
+
+func newByteQueue(data []byte) *byteQueue {
+	return &byteQueue{data: data}
+}
+
+// push appends entry, calling evict with the offset and bytes of every entry
+// dropped to make room, and returns the offset of entry. The entry must fit.
+func (q *byteQueue) push(entry []byte, evict func(offset int, entry []byte)) int {
+	size := headerSize + len(entry)
+	for {
+		if q.count == 0 {
+			q.head, q.tail, q.wrapped = 0, 0, false
+		}
+
+		if !q.wrapped {
+			if len(q.data)-q.tail >= size {
+				break
+			}
+			q.wrapped, q.wrapAt, q.tail = true, q.tail, 0
+			continue
+		}
+		if q.head-q.tail >= size {
+			break
+		}
+		q.pop(evict)
+	}
+
+	offset := q.tail
+	binary.BigEndian.PutUint32(q.data[offset:], uint32(len(entry)))
+	copy(q.data[offset+headerSize:], entry)
+	q.tail += size
+	q.count++
+
+	return offset
+}
+
+func (q *byteQueue) pop(evict func(offset int, entry []byte)) {
+	offset := q.head
+	entry := q.get(offset)
+	q.head += headerSize + len(entry)
+	q.count--
+	if q.wrapped && q.head >= q.wrapAt {
+		q.head, q.wrapped = 0, false
+	}
+	evict(offset, entry)
+}
+
+// get returns the entry at offset. The slice aliases the queue and is only
+// valid until the next push.
+func (q *byteQueue) get(offset int) []byte {
+	size := int(binary.BigEndian.Uint32(q.data[offset:]))
+
+	return q.data[offset+headerSize : offset+headerSize+size]
+}
@@ -0,0 +1,97 @@
+package slabcache
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func Test_byteQueue(t *testing.T) {
+	tests := []struct {
+		name        string
+		size        int
+		entries     []string
+		wantEvicted []string
+		wantLive    []string
+	}{
+		{
+			name:     "Entries fit",
+			size:     64,
+			entries:  []string{"a", "bb", "ccc"},
+			wantLive: []string{"a", "bb", "ccc"},
+		},
+		{
+			name:        "Oldest entries make room",
+			size:        20,
+			entries:     []string{"aaaa", "bbbb", "cccc"},
+			wantEvicted: []string{"aaaa"},
+			wantLive:    []string{"bbbb", "cccc"},
+		},
+		{
+			name:        "Writing wraps to the start",
+			size:        24,
+			entries:     []string{"aaaa", "bbbb", "cccc", "dddd", "eeee"},
+			wantEvicted: []string{"aaaa", "bbbb"},
+			wantLive:    []string{"cccc", "dddd", "eeee"},
+		},
+		{
+			name:        "Entry filling the queue",
+			size:        12,
+			entries:     []string{"aa", "bb", "cccccccc"},
+			wantEvicted: []string{"aa", "bb"},
+			wantLive:    []string{"cccccccc"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := newByteQueue(make([]byte, tt.size))
+			offsets := make(map[string]int)
+			var evicted []string
+			for _, entry := range tt.entries {
+				offsets[entry] = queue.push([]byte(entry), func(offset int, entry []byte) {
+					if offsets[string(entry)] != offset {
+						t.Errorf("evicted %q at offset %d, stored at %d", entry, offset, offsets[string(entry)])
+					}
+					evicted = append(evicted, string(entry))
+				})
+			}
+
+			if fmt.Sprint(evicted) != fmt.Sprint(tt.wantEvicted) {
+				t.Errorf("evicted %v, want %v", evicted, tt.wantEvicted)
+			}
+			for _, entry := range tt.wantLive {
+				if got := queue.get(offsets[entry]); !bytes.Equal(got, []byte(entry)) {
+					t.Errorf("get() = %q, want %q", got, entry)
+				}
+			}
+			if queue.count != len(tt.wantLive) {
+				t.Errorf("count = %d, want %d", queue.count, len(tt.wantLive))
+			}
+		})
+	}
+}
+
+func Test_byteQueue_Churn(t *testing.T) {
+	queue := newByteQueue(make([]byte, 100))
+	offsets := make(map[string]int)
+	live := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		entry := fmt.Sprintf("%d-%s", i, bytes.Repeat([]byte("x"), i%13))
+		offsets[entry] = queue.push([]byte(entry), func(offset int, entry []byte) {
+			if !live[string(entry)] || offsets[string(entry)] != offset {
+				t.Fatalf("evicted unknown entry %q at offset %d", entry, offset)
+			}
+			delete(live, string(entry))
+		})
+		live[entry] = true
+	}
+
+	if len(live) != queue.count {
+		t.Errorf("%d live entries, queue counts %d", len(live), queue.count)
+	}
+	for entry := range live {
+		if got := queue.get(offsets[entry]); string(got) != entry {
+			t.Errorf("get() = %q, want %q", got, entry)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package sqlcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+// Query is a statement and the tables it reads or writes. Cached results of a
+// query are dropped when one of its tables is invalidated.
+type Query struct {
+	SQL    string
+	Tables []string
+	// TTL is how long results are cached. Non-positive TTLs leave them
+	// uncached.
+	TTL time.Duration
+}
+
+// DB caches the result sets of queries on a *sql.DB in a cache.Cache.
+// Invalidation is tracked by this DB: results cached by other processes
+// sharing the cache are only dropped by their own invalidations or expiry.
+type DB interface {
+	// Query returns the rows of q, from the cache if they are there.
+	Query(ctx context.Context, q Query, args ...interface{}) (*Result, error)
+	// Exec runs q and invalidates its tables if it succeeds.
+	Exec(ctx context.Context, q Query, args ...interface{}) (sql.Result, error)
+	// Invalidate drops the cached results of queries on tables.
+	Invalidate(tables ...string)
+	// DB returns the wrapped database.
+	DB() *sql.DB
+}
+
+type cachedDB struct {
+	db           *sql.DB
+	cache        cache.Cache
+	onInvalidate []func(tables []string)
+	mu           sync.Mutex
+	generations  map[string]uint64
+}
+
+func New(db *sql.DB, c cache.Cache, options ...func(*cachedDB)) DB {
+	d := &cachedDB{db: db, cache: c, generations: make(map[string]uint64)}
+	for _, optionFn := range options {
+		optionFn(d)
+	}
+
+	return d
+}
+
+// WithInvalidationHook calls hook with the tables of every invalidation, for
+// example to pass it on to other processes. The option may be given several
+// times.
+func WithInvalidationHook(hook func(tables []string)) func(*cachedDB) {
+	return func(d *cachedDB) {
+		d.onInvalidate = append(d.onInvalidate, hook)
+	}
+}
+
+func (d *cachedDB) Query(ctx context.Context, q Query, args ...interface{}) (*Result, error) {
+	if q.TTL <= 0 {
+		return query(ctx, d.db, q.SQL, args)
+	}
+
+	key := d.key(q, args)
+	if value, found := d.cache.Get(key); found {
+		if result, ok := value.(*Result); ok {
+			return result, nil
+		}
+		if result, ok := value.(Result); ok {
+			return &result, nil
+		}
+	}
+
+	result, err := query(ctx, d.db, q.SQL, args)
+	if err != nil {
+		return nil, err
+	}
+	if tagger, ok := d.cache.(cache.Tagger); ok {
+		tagger.SetWithTags(key, result, q.TTL, tableTags(q.Tables)...)
+	} else {
+		d.cache.Set(key, result, q.TTL)
+	}
+
+	return result, nil
+}
+
+func (d *cachedDB) Exec(ctx context.Context, q Query, args ...interface{}) (sql.Result, error) {
+	result, err := d.db.ExecContext(ctx, q.SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	d.Invalidate(q.Tables...)
+
+	return result, nil
+}
+
+func (d *cachedDB) Invalidate(tables ...string) {
+	if len(tables) == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	for _, table := range tables {
+		d.generations[table]++
+	}
+	d.mu.Unlock()
+
+	if tagger, ok := d.cache.(cache.Tagger); ok {
+		for _, tag := range tableTags(tables) {
+			tagger.InvalidateTag(tag)
+		}
+	}
+	for _, hook := range d.onInvalidate {
+		hook(tables)
+	}
+}
+
+func (d *cachedDB) DB() *sql.DB {
+	return d.db
+}
+
+// key identifies the results of q for args at the current generation of its
+// tables, so that results of a query that raced with an invalidation are
+// cached under a key that is no longer read.
+func (d *cachedDB) key(q Query, args []interface{}) string {
+	var identity strings.Builder
+	identity.WriteString(q.SQL)
+
+	d.mu.Lock()
+	for _, table := range q.Tables {
+		identity.WriteString("\x00" + table + "@" + strconv.FormatUint(d.generations[table], 10))
+	}
+	d.mu.Unlock()
+
+	for _, arg := range args {
+		fmt.Fprintf(&identity, "\x00%T:%v", arg, arg)
+	}
+	sum := sha256.Sum256([]byte(identity.String()))
+
+	return "sql:" + hex.EncodeToString(sum[:])
+}
+
+func tableTags(tables []string) []string {
+	tags := make([]string, len(tables))
+	for i, table := range tables {
+		tags[i] = "sql:" + table
+	}
+
+	return tags
+}
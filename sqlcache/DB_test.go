@@ -0,0 +1,272 @@
+package sqlcache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+const (
+	selectPopulation = "SELECT code, population FROM countries WHERE code = ?"
+	updatePopulation = "UPDATE countries SET population = ? WHERE code = ?"
+)
+
+// countries is a driver for a single countries table that understands
+// selectPopulation and updatePopulation and counts the queries it runs.
+type countries struct {
+	mu         sync.Mutex
+	population map[string]int64
+	queries    int
+}
+
+func (c *countries) Open(name string) (driver.Conn, error) {
+	return countriesConn{c}, nil
+}
+
+func (c *countries) Queries() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.queries
+}
+
+type countriesConn struct {
+	db *countries
+}
+
+func (c countriesConn) Prepare(query string) (driver.Stmt, error) {
+	return countriesStmt{db: c.db, query: query}, nil
+}
+
+func (c countriesConn) Close() error {
+	return nil
+}
+
+func (c countriesConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type countriesStmt struct {
+	db    *countries
+	query string
+}
+
+func (s countriesStmt) Close() error {
+	return nil
+}
+
+func (s countriesStmt) NumInput() int {
+	return -1
+}
+
+func (s countriesStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.query != updatePopulation {
+		return nil, errors.New("unsupported statement")
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.population[args[1].(string)] = args[0].(int64)
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s countriesStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.query != selectPopulation {
+		return nil, errors.New("unsupported query")
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.queries++
+	code := args[0].(string)
+	rows := &countriesRows{}
+	if population, found := s.db.population[code]; found {
+		rows.values = [][]driver.Value{{[]byte(code), population}}
+	}
+
+	return rows, nil
+}
+
+type countriesRows struct {
+	values [][]driver.Value
+}
+
+func (r *countriesRows) Columns() []string {
+	return []string{"code", "population"}
+}
+
+func (r *countriesRows) Close() error {
+	return nil
+}
+
+func (r *countriesRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+
+	return nil
+}
+
+var registerOnce sync.Once
+
+func newCountries(t *testing.T) (*countries, *sql.DB) {
+	t.Helper()
+
+	registerOnce.Do(func() {
+		sql.Register("sqlcache-countries", &driverSwitch{})
+	})
+	db := &countries{population: map[string]int64{"fr": 68, "de": 84}}
+	name := t.Name()
+	drivers.Store(name, db)
+	t.Cleanup(func() { drivers.Delete(name) })
+
+	conn, err := sql.Open("sqlcache-countries", name)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return db, conn
+}
+
+// driverSwitch opens the countries database registered under the data source
+// name, so that every test gets its own.
+type driverSwitch struct{}
+
+var drivers sync.Map
+
+func (driverSwitch) Open(name string) (driver.Conn, error) {
+	db, found := drivers.Load(name)
+	if !found {
+		return nil, errors.New("unknown database")
+	}
+
+	return db.(*countries).Open(name)
+}
+
+func population(t *testing.T, db DB, q Query, code string) int64 {
+	t.Helper()
+
+	result, err := db.Query(context.Background(), q, code)
+	if err != nil {
+		t.Fatalf("Query() error: %v", err)
+	}
+	var gotCode string
+	var population int64
+	if err := result.Scan(0, &gotCode, &population); err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if gotCode != code {
+		t.Errorf("Scan() code = %q, want %q", gotCode, code)
+	}
+
+	return population
+}
+
+func TestDB_Query(t *testing.T) {
+	tests := []struct {
+		name        string
+		newCache    func(ctx context.Context) cache.Cache
+		ttl         time.Duration
+		wantQueries int
+	}{
+		{
+			name:        "Cached",
+			newCache:    func(ctx context.Context) cache.Cache { return cache.NewInMemoryCache(ctx) },
+			ttl:         time.Minute,
+			wantQueries: 1,
+		},
+		{
+			name: "Encoded values",
+			newCache: func(ctx context.Context) cache.Cache {
+				return cache.NewInMemoryCache(ctx, cache.WithCodec(cache.GobCodec{}))
+			},
+			ttl:         time.Minute,
+			wantQueries: 1,
+		},
+		{
+			name:        "Uncached",
+			newCache:    func(ctx context.Context) cache.Cache { return cache.NewInMemoryCache(ctx) },
+			wantQueries: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			countries, conn := newCountries(t)
+			db := New(conn, tt.newCache(ctx))
+			q := Query{SQL: selectPopulation, Tables: []string{"countries"}, TTL: tt.ttl}
+
+			for i := 0; i < 3; i++ {
+				if got := population(t, db, q, "fr"); got != 68 {
+					t.Errorf("population #%d = %d, want 68", i, got)
+				}
+			}
+			if got := countries.Queries(); got != tt.wantQueries {
+				t.Errorf("database ran %d queries, want %d", got, tt.wantQueries)
+			}
+		})
+	}
+}
+
+func TestDB_Invalidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		newCache func(ctx context.Context) cache.Cache
+	}{
+		{
+			name:     "Tagger",
+			newCache: func(ctx context.Context) cache.Cache { return cache.NewInMemoryCache(ctx) },
+		},
+		{
+			name:     "Cache",
+			newCache: func(ctx context.Context) cache.Cache { return struct{ cache.Cache }{cache.NewInMemoryCache(ctx)} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			_, conn := newCountries(t)
+			var invalidated [][]string
+			db := New(conn, tt.newCache(ctx), WithInvalidationHook(func(tables []string) {
+				invalidated = append(invalidated, tables)
+			}))
+			read := Query{SQL: selectPopulation, Tables: []string{"countries"}, TTL: time.Minute}
+			write := Query{SQL: updatePopulation, Tables: []string{"countries"}}
+
+			population(t, db, read, "fr")
+			if _, err := db.Exec(context.Background(), write, int64(69), "fr"); err != nil {
+				t.Fatalf("Exec() error: %v", err)
+			}
+			if got := population(t, db, read, "fr"); got != 69 {
+				t.Errorf("population after Exec = %d, want 69", got)
+			}
+
+			db.DB().Exec(updatePopulation, int64(70), "fr")
+			if got := population(t, db, read, "fr"); got != 69 {
+				t.Errorf("population after uncached write = %d, want the cached 69", got)
+			}
+			db.Invalidate("countries")
+			if got := population(t, db, read, "fr"); got != 70 {
+				t.Errorf("population after Invalidate = %d, want 70", got)
+			}
+
+			if want := [][]string{{"countries"}, {"countries"}}; !reflect.DeepEqual(invalidated, want) {
+				t.Errorf("invalidation hook got %v, want %v", invalidated, want)
+			}
+		})
+	}
+}
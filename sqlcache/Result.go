@@ -0,0 +1,129 @@
+package sqlcache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var ErrRowOutOfRange = errors.New("sqlcache: row out of range")
+
+// Result is a scanned result set. Rows hold the values returned by the
+// driver: int64, float64, bool, []byte, string, time.Time or nil. Results
+// may be shared with other callers and must not be modified.
+type Result struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+func init() {
+	gob.Register(Result{})
+	gob.Register(time.Time{})
+}
+
+func query(ctx context.Context, db *sql.DB, statement string, args []interface{}) (*Result, error) {
+	rows, err := db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Columns: columns}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Len returns the number of rows.
+func (r *Result) Len() int {
+	return len(r.Rows)
+}
+
+// Scan copies the columns of row into dest like sql.Rows.Scan. Each dest must
+// be a pointer to a type the column value converts to, a *interface{} or a
+// sql.Scanner.
+func (r *Result) Scan(row int, dest ...interface{}) error {
+	if row < 0 || row >= len(r.Rows) {
+		return ErrRowOutOfRange
+	}
+	values := r.Rows[row]
+	if len(dest) != len(values) {
+		return fmt.Errorf("sqlcache: expected %d destination arguments in Scan, not %d", len(values), len(dest))
+	}
+
+	for i, value := range values {
+		if err := assign(dest[i], value); err != nil {
+			return fmt.Errorf("sqlcache: Scan error on column %q: %w", r.Columns[i], err)
+		}
+	}
+
+	return nil
+}
+
+func assign(dest, value interface{}) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("destination not a pointer")
+	}
+	target = target.Elem()
+	if value == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+
+	if data, ok := value.([]byte); ok {
+		switch target.Kind() {
+		case reflect.String:
+			target.SetString(string(data))
+			return nil
+		case reflect.Slice:
+			if target.Type().Elem().Kind() == reflect.Uint8 {
+				target.SetBytes(append([]byte(nil), data...))
+				return nil
+			}
+		}
+	}
+
+	source := reflect.ValueOf(value)
+	switch {
+	case source.Type().AssignableTo(target.Type()):
+		target.Set(source)
+	case isNumber(source.Kind()) && isNumber(target.Kind()):
+		target.Set(source.Convert(target.Type()))
+	case source.Kind() == reflect.String && target.Kind() == reflect.String:
+		target.SetString(source.String())
+	default:
+		return fmt.Errorf("converting %T to %s is unsupported", value, target.Type())
+	}
+
+	return nil
+}
+
+func isNumber(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
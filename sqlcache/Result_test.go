@@ -0,0 +1,36 @@
+package sqlcache
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestResult_Scan(t *testing.T) {
+	result := &Result{
+		Columns: []string{"name", "count", "ratio", "note"},
+		Rows:    [][]interface{}{{[]byte("fr"), int64(3), float64(0.5), nil}},
+	}
+
+	var name string
+	var count int
+	var ratio float32
+	var note sql.NullString
+	if err := result.Scan(0, &name, &count, &ratio, &note); err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if name != "fr" || count != 3 || ratio != 0.5 || note.Valid {
+		t.Errorf("Scan() = %q, %d, %v, %v, want %q, 3, 0.5, invalid", name, count, ratio, note, "fr")
+	}
+
+	if err := result.Scan(1, &name, &count, &ratio, &note); !errors.Is(err, ErrRowOutOfRange) {
+		t.Errorf("Scan() of a missing row error = %v, want %v", err, ErrRowOutOfRange)
+	}
+	if err := result.Scan(0, &name); err == nil {
+		t.Errorf("Scan() with too few destinations succeeded")
+	}
+	var flag bool
+	if err := result.Scan(0, &name, &flag, &ratio, &note); err == nil {
+		t.Errorf("Scan() of an int64 into a bool succeeded")
+	}
+}
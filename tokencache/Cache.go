@@ -0,0 +1,188 @@
+package tokencache
+
+import (
+	"context"
+	"encoding/gob"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+const (
+	defaultSafetyMargin = time.Second * 30
+	defaultRefreshAhead = time.Minute
+)
+
+// Token is an OAuth2 access token response.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	// ExpiresIn is the lifetime of the token in seconds. Zero means the
+	// server did not say.
+	ExpiresIn int64 `json:"expires_in,omitempty"`
+}
+
+// RefreshFunc obtains a new token for clientID with scopes.
+type RefreshFunc func(ctx context.Context, clientID string, scopes []string) (Token, error)
+
+// Cache stores access tokens keyed by client and scopes.
+type Cache interface {
+	// Token returns a cached token of clientID with scopes, or obtains a new
+	// one from the refresh func.
+	Token(ctx context.Context, clientID string, scopes ...string) (Token, error)
+	// Invalidate drops the cached token of clientID with scopes, for example
+	// after the token was rejected.
+	Invalidate(clientID string, scopes ...string)
+}
+
+// entry is a cached token and the time it must be refreshed by.
+type entry struct {
+	Token     Token
+	ExpiresAt time.Time
+}
+
+func init() {
+	gob.Register(entry{})
+}
+
+type refresh struct {
+	done  chan struct{}
+	token Token
+	err   error
+}
+
+type tokenCache struct {
+	cache        cache.Cache
+	refreshFn    RefreshFunc
+	safetyMargin time.Duration
+	refreshAhead time.Duration
+	defaultTTL   time.Duration
+	onError      func(clientID string, err error)
+	mu           sync.Mutex
+	refreshes    map[string]*refresh
+}
+
+// New returns a Cache keeping tokens in c and obtaining them with refreshFn.
+// A token is cached until its expires_in minus a safety margin of 30 seconds,
+// and is refreshed in the background when it is used within a minute of that.
+// Concurrent refreshes of a token share one call of refreshFn.
+func New(c cache.Cache, refreshFn RefreshFunc, options ...func(*tokenCache)) Cache {
+	t := &tokenCache{
+		cache:        c,
+		refreshFn:    refreshFn,
+		safetyMargin: defaultSafetyMargin,
+		refreshAhead: defaultRefreshAhead,
+		onError:      func(string, error) {},
+		refreshes:    make(map[string]*refresh),
+	}
+	for _, optionFn := range options {
+		optionFn(t)
+	}
+
+	return t
+}
+
+// WithSafetyMargin sets how long before the server expires a token it stops
+// being served.
+func WithSafetyMargin(margin time.Duration) func(*tokenCache) {
+	return func(t *tokenCache) {
+		t.safetyMargin = margin
+	}
+}
+
+// WithRefreshAhead sets how long before a cached token expires a use of it
+// refreshes it in the background. Zero disables background refreshes.
+func WithRefreshAhead(window time.Duration) func(*tokenCache) {
+	return func(t *tokenCache) {
+		t.refreshAhead = window
+	}
+}
+
+// WithDefaultTTL caches tokens without expires_in for ttl. By default they are
+// not cached.
+func WithDefaultTTL(ttl time.Duration) func(*tokenCache) {
+	return func(t *tokenCache) {
+		t.defaultTTL = ttl
+	}
+}
+
+// WithErrorHandler receives the errors of background refreshes.
+func WithErrorHandler(handler func(clientID string, err error)) func(*tokenCache) {
+	return func(t *tokenCache) {
+		t.onError = handler
+	}
+}
+
+func (t *tokenCache) Token(ctx context.Context, clientID string, scopes ...string) (Token, error) {
+	key := cacheKey(clientID, scopes)
+	if value, found := t.cache.Get(key); found {
+		if e, ok := value.(entry); ok && time.Now().Before(e.ExpiresAt) {
+			if t.refreshAhead > 0 && time.Until(e.ExpiresAt) < t.refreshAhead {
+				go t.refreshInBackground(key, clientID, scopes)
+			}
+			return e.Token, nil
+		}
+	}
+
+	return t.refresh(ctx, key, clientID, scopes)
+}
+
+func (t *tokenCache) Invalidate(clientID string, scopes ...string) {
+	t.cache.Delete(cacheKey(clientID, scopes))
+}
+
+func (t *tokenCache) refreshInBackground(key, clientID string, scopes []string) {
+	if _, err := t.refresh(context.Background(), key, clientID, scopes); err != nil {
+		t.onError(clientID, err)
+	}
+}
+
+// refresh obtains a new token and caches it. Concurrent refreshes of a key
+// share the call of the first, which runs with its context.
+func (t *tokenCache) refresh(ctx context.Context, key, clientID string, scopes []string) (Token, error) {
+	t.mu.Lock()
+	if r, found := t.refreshes[key]; found {
+		t.mu.Unlock()
+		<-r.done
+		return r.token, r.err
+	}
+	r := &refresh{done: make(chan struct{})}
+	t.refreshes[key] = r
+	t.mu.Unlock()
+
+	r.token, r.err = t.refreshFn(ctx, clientID, scopes)
+	if r.err == nil {
+		if ttl := t.ttl(r.token); ttl > 0 {
+			t.cache.Set(key, entry{Token: r.token, ExpiresAt: time.Now().Add(ttl)}, ttl)
+		}
+	}
+
+	t.mu.Lock()
+	delete(t.refreshes, key)
+	t.mu.Unlock()
+	close(r.done)
+
+	return r.token, r.err
+}
+
+// ttl returns how long token is cached, or 0 if it is not.
+func (t *tokenCache) ttl(token Token) time.Duration {
+	if token.ExpiresIn <= 0 {
+		return t.defaultTTL
+	}
+
+	return time.Duration(token.ExpiresIn)*time.Second - t.safetyMargin
+}
+
+// cacheKey identifies the token of clientID with scopes, in any order.
+func cacheKey(clientID string, scopes []string) string {
+	sorted := append([]string(nil), scopes...)
+	sort.Strings(sorted)
+
+	return "token:" + clientID + ":" + strings.Join(sorted, " ")
+}
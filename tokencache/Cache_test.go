@@ -0,0 +1,180 @@
+package tokencache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/abicur/go-sim-cache"
+)
+
+// issuer hands out numbered tokens that expire after expiresIn seconds.
+type issuer struct {
+	mu        sync.Mutex
+	expiresIn int64
+	err       error
+	delay     time.Duration
+	issued    int
+}
+
+func (i *issuer) refresh(ctx context.Context, clientID string, scopes []string) (Token, error) {
+	time.Sleep(i.delay)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.err != nil {
+		return Token{}, i.err
+	}
+	i.issued++
+	return Token{AccessToken: fmt.Sprintf("%s-%d", clientID, i.issued), ExpiresIn: i.expiresIn}, nil
+}
+
+func (i *issuer) Issued() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.issued
+}
+
+func newTestCache(t *testing.T) cache.Cache {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return cache.NewInMemoryCache(ctx)
+}
+
+func TestCache_Token(t *testing.T) {
+	i := &issuer{expiresIn: 3600}
+	tokens := New(newTestCache(t), i.refresh)
+
+	first, err := tokens.Token(context.Background(), "client", "read", "write")
+	if err != nil {
+		t.Fatalf("Token() error: %v", err)
+	}
+	second, _ := tokens.Token(context.Background(), "client", "write", "read")
+	if second != first {
+		t.Errorf("Token() with reordered scopes = %v, want %v", second, first)
+	}
+	other, _ := tokens.Token(context.Background(), "client", "read")
+	if other == first {
+		t.Errorf("Token() with other scopes returned the cached token")
+	}
+
+	tokens.Invalidate("client", "read", "write")
+	if third, _ := tokens.Token(context.Background(), "client", "read", "write"); third == first {
+		t.Errorf("Token() after Invalidate() returned the invalidated token")
+	}
+	if got := i.Issued(); got != 3 {
+		t.Errorf("issued %d tokens, want 3", got)
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	tests := []struct {
+		name       string
+		expiresIn  int64
+		options    []func(*tokenCache)
+		wantCached bool
+	}{
+		{
+			name:       "Longer than the margin",
+			expiresIn:  60,
+			wantCached: true,
+		},
+		{
+			name:      "Within the margin",
+			expiresIn: 30,
+		},
+		{
+			name: "Without expires_in",
+		},
+		{
+			name:       "Without expires_in with default TTL",
+			options:    []func(*tokenCache){WithDefaultTTL(time.Minute)},
+			wantCached: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &issuer{expiresIn: tt.expiresIn}
+			tokens := New(newTestCache(t), i.refresh, tt.options...)
+
+			tokens.Token(context.Background(), "client")
+			tokens.Token(context.Background(), "client")
+
+			if cached := i.Issued() == 1; cached != tt.wantCached {
+				t.Errorf("token cached = %v, want %v", cached, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestCache_RefreshAhead(t *testing.T) {
+	i := &issuer{expiresIn: 2}
+	tokens := New(newTestCache(t), i.refresh, WithSafetyMargin(time.Second), WithRefreshAhead(time.Millisecond*500))
+
+	first, _ := tokens.Token(context.Background(), "client")
+	if got, _ := tokens.Token(context.Background(), "client"); got != first || i.Issued() != 1 {
+		t.Errorf("Token() outside the refresh window = %v after %d issues, want %v", got, i.Issued(), first)
+	}
+
+	// Within the window the cached token is served while a new one is issued.
+	time.Sleep(time.Millisecond * 600)
+	if got, _ := tokens.Token(context.Background(), "client"); got != first {
+		t.Errorf("Token() within the refresh window = %v, want %v", got, first)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		got, _ := tokens.Token(context.Background(), "client")
+		if got != first {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("token not refreshed before expiry")
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+}
+
+func TestCache_Singleflight(t *testing.T) {
+	i := &issuer{expiresIn: 3600, delay: time.Millisecond * 20}
+	tokens := New(newTestCache(t), i.refresh)
+
+	var wg sync.WaitGroup
+	for n := 0; n < 10; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := tokens.Token(context.Background(), "client"); err != nil {
+				t.Errorf("Token() error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := i.Issued(); got != 1 {
+		t.Errorf("issued %d tokens, want 1", got)
+	}
+}
+
+func TestCache_RefreshError(t *testing.T) {
+	errDenied := errors.New("denied")
+	i := &issuer{expiresIn: 3600, err: errDenied}
+	tokens := New(newTestCache(t), i.refresh)
+
+	if _, err := tokens.Token(context.Background(), "client"); !errors.Is(err, errDenied) {
+		t.Errorf("Token() error = %v, want %v", err, errDenied)
+	}
+	i.mu.Lock()
+	i.err = nil
+	i.mu.Unlock()
+	if _, err := tokens.Token(context.Background(), "client"); err != nil {
+		t.Errorf("Token() after a failed refresh error: %v", err)
+	}
+}